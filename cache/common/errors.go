@@ -8,9 +8,47 @@ import (
 // ErrNotExceptedTypeFromCache ...
 var ErrNotExceptedTypeFromCache = errors.New("not expected type from cache")
 
+// ErrKeyNotFound is returned for the keys missing in the result of a batch retrieve function,
+// a retrieve function returns it for a missing key so that BatchGet omits the key instead of failing
+var ErrKeyNotFound = errors.New("key not found")
+
 const EmptyCacheExpiration = 5 * time.Second
 
 // EmptyCache is a placeholder for the missing key
 type EmptyCache struct {
 	Err error
 }
+
+// EmptyCacheError is the error of an EmptyCache read from a remote cache, e.g. redis.
+// the original error is produced by other process, only the message and whether it is ErrKeyNotFound are kept
+type EmptyCacheError struct {
+	Msg string
+	// NotFound 原始错误为 ErrKeyNotFound
+	NotFound bool
+}
+
+// NewEmptyCacheError restores the error of an EmptyCache from the message and the not found flag
+func NewEmptyCacheError(msg string, notFound bool) *EmptyCacheError {
+	return &EmptyCacheError{Msg: msg, NotFound: notFound}
+}
+
+// Error implements the error interface
+func (e *EmptyCacheError) Error() string {
+	return e.Msg
+}
+
+// Is reports whether the original error is ErrKeyNotFound,
+// so that errors.Is behaves the same for the EmptyCache read from memory and from a remote cache
+func (e *EmptyCacheError) Is(target error) bool {
+	return e.NotFound && target == ErrKeyNotFound
+}
+
+// RefreshableValue is a cached value with soft and hard expiration, used by stale-while-revalidate.
+// 在 FreshUntil 之前为新鲜数据，在 ExpireAt 之前仍然可以作为过期数据返回，同时在后台刷新
+type RefreshableValue struct {
+	Value interface{}
+	// 软过期时间，到期前的一段时间窗口内触发后台刷新
+	FreshUntil time.Time
+	// 硬过期时间，超过后不再返回该值
+	ExpireAt time.Time
+}
@@ -28,3 +28,8 @@ type RandomExtraExpirationDurationFunc func() time.Duration
 // it retrieves the value from database, redis, apis, etc.
 // 禁用缓存时根据 key 获取 value 的函数
 type RetrieveFunc func(ctx context.Context, key Key) (interface{}, error)
+
+// BatchRetrieveFunc is the type of the batch retrieve function.
+// it retrieves the values of multiple keys in one call, the result is keyed by Key.Key(),
+// the keys missing in the result are treated as not found
+type BatchRetrieveFunc func(ctx context.Context, keys []Key) (map[string]interface{}, error)
@@ -0,0 +1,133 @@
+package layered
+
+import (
+	"errors"
+	"time"
+
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	"github.com/fengzhongzhu1621/xgo/cache/redis"
+)
+
+// NewValueFunc 返回一个指针，L2 中的数据将反序列化到该指针指向的对象中
+// e.g. func() interface{} { return new(User) }
+type NewValueFunc = redis.NewValueFunc
+
+// TwoTierBackend 是一个两级缓存后端，先读 L1（进程内存），再读 L2（redis）
+// 实现了 backend.Backend 接口，可以直接交给 memory.NewBaseCache 使用，从而复用 singleflight 和 EmptyCache 机制
+type TwoTierBackend struct {
+	// 一级缓存，通常为 backend.MemoryBackend
+	l1 backend.Backend
+	// 二级缓存，多个副本共享
+	l2 *redis.Backend
+}
+
+var (
+	_ backend.BatchBackend = (*TwoTierBackend)(nil)
+	_ backend.StatsBackend = (*TwoTierBackend)(nil)
+)
+
+// NewTwoTierBackend create a two tier backend
+// - l1: 进程内缓存
+// - l2: redis 缓存
+// - newValue: 为 nil 时，L2 中的数据反序列化为 interface{}，数值类型可能与写入时不一致
+func NewTwoTierBackend(l1 backend.Backend, l2 *redis.Cache, newValue NewValueFunc) *TwoTierBackend {
+	return &TwoTierBackend{
+		l1: l1,
+		l2: redis.NewBackend(l2, newValue),
+	}
+}
+
+// Set sets value to both L1 and L2, duration 0 means the default expiration of each tier
+func (b *TwoTierBackend) Set(key string, value interface{}, duration time.Duration) {
+	b.l1.Set(key, value, duration)
+	b.l2.Set(key, value, duration)
+}
+
+// Get gets value from L1 first, then from L2, the value got from L2 will be set to L1
+func (b *TwoTierBackend) Get(key string) (interface{}, bool) {
+	if value, ok := b.l1.Get(key); ok {
+		return value, true
+	}
+
+	value, ok := b.l2.Get(key)
+	if !ok {
+		return nil, false
+	}
+	b.fillL1(key, value)
+	return value, true
+}
+
+// BatchGet gets values from L1 first, then the misses from L2 in one round trip
+func (b *TwoTierBackend) BatchGet(keys []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(keys))
+	misses := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if value, ok := b.l1.Get(key); ok {
+			values[key] = value
+		} else {
+			misses = append(misses, key)
+		}
+	}
+	if len(misses) == 0 {
+		return values
+	}
+
+	for key, value := range b.l2.BatchGet(misses) {
+		b.fillL1(key, value)
+		values[key] = value
+	}
+	return values
+}
+
+// BatchSet sets values to L1 one by one, and to L2 in one round trip
+func (b *TwoTierBackend) BatchSet(values map[string]interface{}, duration time.Duration) {
+	for key, value := range values {
+		b.l1.Set(key, value, duration)
+	}
+	b.l2.BatchSet(values, duration)
+}
+
+// Delete deletes value from both L1 and L2
+func (b *TwoTierBackend) Delete(key string) error {
+	err := b.l1.Delete(key)
+	if l2Err := b.l2.Delete(key); l2Err != nil {
+		err = errors.Join(err, l2Err)
+	}
+	return err
+}
+
+// DeleteLocal deletes value from L1 only, used when receiving invalidation from other nodes
+func (b *TwoTierBackend) DeleteLocal(key string) error {
+	return b.l1.Delete(key)
+}
+
+// Len returns the number of items in L1, -1 if L1 does not report it
+func (b *TwoTierBackend) Len() int {
+	if l1, ok := b.l1.(backend.StatsBackend); ok {
+		return l1.Len()
+	}
+	return -1
+}
+
+// Evictions returns the number of items deleted or expired from L1
+func (b *TwoTierBackend) Evictions() uint64 {
+	if l1, ok := b.l1.(backend.StatsBackend); ok {
+		return l1.Evictions()
+	}
+	return 0
+}
+
+// fillL1 回填 L1，空缓存标记只保留较短的时间，stale-while-revalidate 的数据保留到硬过期
+func (b *TwoTierBackend) fillL1(key string, value interface{}) {
+	switch v := value.(type) {
+	case cache.EmptyCache:
+		b.l1.Set(key, value, cache.EmptyCacheExpiration)
+	case cache.RefreshableValue:
+		if ttl := time.Until(v.ExpireAt); ttl > 0 {
+			b.l1.Set(key, value, ttl)
+		}
+	default:
+		b.l1.Set(key, value, 0)
+	}
+}
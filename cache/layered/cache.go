@@ -0,0 +1,158 @@
+package layered
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/memory"
+	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	"github.com/fengzhongzhu1621/xgo/cache/redis"
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
+	log "github.com/sirupsen/logrus"
+)
+
+// Cache is a two tier cache, reads L1 (memory), then L2 (redis), then the retrieveFunc.
+// Set and Delete will broadcast invalidation so that the L1 copy on every node is evicted.
+type Cache struct {
+	// 复用 BaseCache 的 singleflight、EmptyCache 以及各种类型转换方法
+	memory.Cache
+
+	name        string
+	backend     *TwoTierBackend
+	invalidator Invalidator
+	cancel      context.CancelFunc
+}
+
+var (
+	_ memory.Cache    = (*Cache)(nil)
+	_ stats.Inspector = (*Cache)(nil)
+)
+
+// options 两级缓存的可选参数
+type options struct {
+	newValue     NewValueFunc
+	cacheOptions []memory.Option
+}
+
+// Option is the option of the two tier cache
+type Option func(*options)
+
+// WithNewValueFunc set the function which returns a pointer to decode the value from L2
+func WithNewValueFunc(fn NewValueFunc) Option {
+	return func(o *options) {
+		o.newValue = fn
+	}
+}
+
+// WithEmptyCache will set the key EmptyCache in both tiers if retrieve fail from retrieveFunc
+func WithEmptyCache(timeout time.Duration) Option {
+	return func(o *options) {
+		o.cacheOptions = append(o.cacheOptions, memory.WithEmptyCache(timeout))
+	}
+}
+
+// WithCacheOptions pass the options to the underlying memory.BaseCache, e.g. memory.WithRefreshAhead
+func WithCacheOptions(cacheOptions ...memory.Option) Option {
+	return func(o *options) {
+		o.cacheOptions = append(o.cacheOptions, cacheOptions...)
+	}
+}
+
+// NewCache create a two tier cache and start to receive invalidation from other nodes
+// - l1: 进程内缓存，e.g. backend.NewMemoryBackend
+// - l2: 多个副本共享的 redis 缓存
+// - invalidator: 失效广播，为 nil 时不在节点之间广播
+// the cache is registered to cache/stats by the name of l2 until Close
+func NewCache(
+	disabled bool,
+	retrieveFunc cache.RetrieveFunc,
+	l1 backend.Backend,
+	l2 *redis.Cache,
+	invalidator Invalidator,
+	opts ...Option,
+) (*Cache, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	b := NewTwoTierBackend(l1, l2, o.newValue)
+	c := &Cache{
+		Cache:       memory.NewBaseCache(disabled, retrieveFunc, b, o.cacheOptions...),
+		name:        l2.Name(),
+		backend:     b,
+		invalidator: invalidator,
+		cancel:      func() {},
+	}
+
+	if invalidator != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		err := invalidator.Subscribe(ctx, func(key string) {
+			_ = b.DeleteLocal(key)
+		})
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		c.cancel = cancel
+	}
+
+	stats.Register(c.name, c)
+	return c, nil
+}
+
+// Set will set key-value into both tiers, and evict the L1 copy on other nodes
+func (c *Cache) Set(ctx context.Context, key cache.Key, data interface{}) {
+	c.Cache.Set(ctx, key, data)
+
+	if err := c.publish(ctx, key.Key()); err != nil {
+		log.Errorf("layered cache publish invalidation fail, key=%s, err=%s", key.Key(), err)
+	}
+}
+
+// Delete deletes the value from both tiers, and evict the L1 copy on other nodes
+func (c *Cache) Delete(ctx context.Context, key cache.Key) error {
+	err := c.Cache.Delete(ctx, key)
+	if pubErr := c.publish(ctx, key.Key()); pubErr != nil {
+		err = errors.Join(err, pubErr)
+	}
+	return err
+}
+
+// DeleteLocal deletes the value from L1 of current node only
+func (c *Cache) DeleteLocal(ctx context.Context, key cache.Key) error {
+	return c.backend.DeleteLocal(key.Key())
+}
+
+// Len returns the number of items in L1 of current node
+func (c *Cache) Len() int {
+	return c.backend.Len()
+}
+
+// Inspect returns the cached value of the key from L1 or L2, without calling the retrieveFunc
+func (c *Cache) Inspect(ctx context.Context, key string) (interface{}, bool) {
+	return c.DirectGet(ctx, cache.NewStringKey(key))
+}
+
+// Evict deletes the key from both tiers, and evict the L1 copy on other nodes
+func (c *Cache) Evict(ctx context.Context, key string) error {
+	return c.Delete(ctx, cache.NewStringKey(key))
+}
+
+// Close stops receiving invalidation from other nodes, and unregisters the cache from cache/stats
+func (c *Cache) Close() {
+	c.cancel()
+	if registered, ok := stats.Get(c.name); ok && registered == stats.Inspector(c) {
+		stats.Unregister(c.name)
+	}
+}
+
+// publish 广播失效的 key
+func (c *Cache) publish(ctx context.Context, keys ...string) error {
+	if c.invalidator == nil {
+		return nil
+	}
+	return c.invalidator.Publish(ctx, keys...)
+}
@@ -0,0 +1,257 @@
+package layered
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/memory"
+	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	"github.com/fengzhongzhu1621/xgo/cache/redis"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID   int64
+	Name string
+}
+
+// replica 模拟一个服务副本，每个副本有自己的 L1，共享同一个 redis
+type replica struct {
+	cache *Cache
+	l1    *backend.MemoryBackend
+}
+
+func newReplica(
+	t *testing.T,
+	mr *miniredis.Miniredis,
+	nodeID string,
+	retrieveFunc cache.RetrieveFunc,
+	opts ...Option,
+) *replica {
+	cli := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cli.Close() })
+
+	l1 := backend.NewMemoryBackend("test", time.Minute, nil)
+	l2 := redis.NewCacheWithClient("test", cli, time.Minute)
+	c, err := NewCache(false, retrieveFunc, l1, l2, NewRedisInvalidator(cli, "test", nodeID), opts...)
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+
+	return &replica{cache: c, l1: l1}
+}
+
+func TestCacheReadThroughTiers(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	var count int32
+	retrieveFunc := func(ctx context.Context, key cache.Key) (interface{}, error) {
+		atomic.AddInt32(&count, 1)
+		return user{ID: 1, Name: "alice"}, nil
+	}
+	opt := WithNewValueFunc(func() interface{} { return new(user) })
+	a := newReplica(t, mr, "a", retrieveFunc, opt)
+	b := newReplica(t, mr, "b", retrieveFunc, opt)
+
+	ctx := context.Background()
+	key := cache.NewStringKey("1")
+
+	// a 未命中，回源并写入两级缓存
+	value, err := a.cache.Get(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, user{ID: 1, Name: "alice"}, value)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	// b 的 L1 未命中，从 L2 读取，不会回源
+	_, ok := b.l1.Get("1")
+	assert.False(t, ok)
+	value, err = b.cache.Get(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, user{ID: 1, Name: "alice"}, value)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	// 从 L2 读取后回填 b 的 L1
+	_, ok = b.l1.Get("1")
+	assert.True(t, ok)
+}
+
+func TestCacheDeleteInvalidatesAllReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	var version int32
+	retrieveFunc := func(ctx context.Context, key cache.Key) (interface{}, error) {
+		return int64(atomic.LoadInt32(&version)), nil
+	}
+	opt := WithNewValueFunc(func() interface{} { return new(int64) })
+	a := newReplica(t, mr, "a", retrieveFunc, opt)
+	b := newReplica(t, mr, "b", retrieveFunc, opt)
+
+	ctx := context.Background()
+	key := cache.NewStringKey("version")
+
+	v, err := a.cache.GetInt64(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), v)
+	v, err = b.cache.GetInt64(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), v)
+
+	// 数据源变更后，a 删除缓存，b 的 L1 也会被清除
+	atomic.StoreInt32(&version, 1)
+	assert.NoError(t, a.cache.Delete(ctx, key))
+	assert.Eventually(t, func() bool {
+		_, ok := b.l1.Get("version")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+
+	v, err = b.cache.GetInt64(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), v)
+	v, err = a.cache.GetInt64(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), v)
+}
+
+func TestCacheSetInvalidatesOtherReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	retrieveFunc := func(ctx context.Context, key cache.Key) (interface{}, error) {
+		return "origin", nil
+	}
+	a := newReplica(t, mr, "a", retrieveFunc)
+	b := newReplica(t, mr, "b", retrieveFunc)
+
+	ctx := context.Background()
+	key := cache.NewStringKey("name")
+
+	s, err := b.cache.GetString(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, "origin", s)
+
+	a.cache.Set(ctx, key, "updated")
+	assert.Eventually(t, func() bool {
+		s, err := b.cache.GetString(ctx, key)
+		return err == nil && s == "updated"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCacheEmptyCacheAcrossTiers(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	errNotFound := errors.New("not found")
+	var count int32
+	retrieveFunc := func(ctx context.Context, key cache.Key) (interface{}, error) {
+		atomic.AddInt32(&count, 1)
+		return nil, errNotFound
+	}
+	a := newReplica(t, mr, "a", retrieveFunc, WithEmptyCache(time.Minute))
+	b := newReplica(t, mr, "b", retrieveFunc, WithEmptyCache(time.Minute))
+
+	ctx := context.Background()
+	key := cache.NewStringKey("missing")
+
+	_, err := a.cache.Get(ctx, key)
+	assert.ErrorIs(t, err, errNotFound)
+	// 本节点 L1 中保留原始错误
+	_, err = a.cache.Get(ctx, key)
+	assert.ErrorIs(t, err, errNotFound)
+
+	// 其它节点从 L2 读到空缓存标记，不会回源
+	_, err = b.cache.Get(ctx, key)
+	var emptyErr *cache.EmptyCacheError
+	assert.ErrorAs(t, err, &emptyErr)
+	assert.Equal(t, errNotFound.Error(), err.Error())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestCacheSingleflight(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	var count int32
+	retrieveFunc := func(ctx context.Context, key cache.Key) (interface{}, error) {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(50 * time.Millisecond)
+		return "value", nil
+	}
+	a := newReplica(t, mr, "a", retrieveFunc)
+
+	ctx := context.Background()
+	key := cache.NewStringKey("hot")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := a.cache.GetString(ctx, key)
+			assert.NoError(t, err)
+			assert.Equal(t, "value", s)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestCacheDeleteLocal(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	retrieveFunc := func(ctx context.Context, key cache.Key) (interface{}, error) {
+		return "value", nil
+	}
+	a := newReplica(t, mr, "a", retrieveFunc)
+
+	ctx := context.Background()
+	key := cache.NewStringKey("k")
+	a.cache.Set(ctx, key, "value")
+
+	assert.NoError(t, a.cache.DeleteLocal(ctx, key))
+	_, ok := a.l1.Get("k")
+	assert.False(t, ok)
+	// L2 中仍然存在
+	assert.True(t, a.cache.Exists(ctx, key))
+}
+
+func TestCacheRefreshAheadAcrossTiers(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	var count int32
+	retrieveFunc := func(ctx context.Context, key cache.Key) (interface{}, error) {
+		return int64(atomic.AddInt32(&count, 1)), nil
+	}
+	cli := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	l1 := backend.NewMemoryBackend("test", time.Minute, nil)
+	l2 := redis.NewCacheWithClient("test", cli, time.Minute)
+	b := NewTwoTierBackend(l1, l2, func() interface{} { return new(int64) })
+	c := memory.NewBaseCache(false, retrieveFunc, b,
+		memory.WithRefreshAhead(time.Minute, time.Second),
+		memory.WithMaxStaleAge(time.Hour),
+	)
+
+	ctx := context.Background()
+	key := cache.NewStringKey("k")
+	_, err := c.Get(ctx, key)
+	assert.NoError(t, err)
+
+	// 从 L2 读取时保留软过期和硬过期时间
+	assert.NoError(t, l1.Delete("k"))
+	value, ok := b.Get("k")
+	assert.True(t, ok)
+	refreshable, ok := value.(cache.RefreshableValue)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), refreshable.Value)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), refreshable.FreshUntil, time.Second)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), refreshable.ExpireAt, time.Second)
+
+	v, err := c.GetInt64(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), v)
+}
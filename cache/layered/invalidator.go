@@ -0,0 +1,114 @@
+package layered
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+)
+
+// Invalidator 用于在多个副本之间广播缓存失效消息
+type Invalidator interface {
+	// Publish 广播失效的 key
+	Publish(ctx context.Context, keys ...string) error
+	// Subscribe 订阅其它节点广播的失效 key，直到 ctx 结束
+	Subscribe(ctx context.Context, fn func(key string)) error
+	// NodeID 返回当前节点的唯一标识
+	NodeID() string
+}
+
+// invalidateMessage 是在 redis channel 上传递的失效消息
+type invalidateMessage struct {
+	// 发送消息的节点
+	Node string `json:"node"`
+	// 失效的 key
+	Keys []string `json:"keys"`
+}
+
+// RedisInvalidator 基于 redis pub/sub 实现的失效广播
+type RedisInvalidator struct {
+	cli     *redis.Client
+	channel string
+	nodeID  string
+}
+
+var _ Invalidator = (*RedisInvalidator)(nil)
+
+// NewRedisInvalidator create a redis pub/sub invalidator
+// - cli: redis 客户端，所有副本需要连接到同一个 redis
+// - name: 缓存名称，同名缓存的副本之间相互广播
+// - nodeID: 当前节点的唯一标识，用于忽略自己发出的消息
+func NewRedisInvalidator(cli *redis.Client, name string, nodeID string) *RedisInvalidator {
+	return &RedisInvalidator{
+		cli:     cli,
+		channel: fmt.Sprintf("xgo:cache:invalidate:%s", name),
+		nodeID:  nodeID,
+	}
+}
+
+// NodeID returns the id of current node
+func (r *RedisInvalidator) NodeID() string {
+	return r.nodeID
+}
+
+// Publish broadcasts the keys to all nodes
+func (r *RedisInvalidator) Publish(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(invalidateMessage{Node: r.nodeID, Keys: keys})
+	if err != nil {
+		return err
+	}
+	return r.cli.Publish(ctx, r.channel, payload).Err()
+}
+
+// Subscribe receives the keys from other nodes in a new goroutine until ctx is done,
+// it returns after the subscription is confirmed by redis.
+// go-redis will reconnect automatically if the connection is broken
+func (r *RedisInvalidator) Subscribe(ctx context.Context, fn func(key string)) error {
+	pubsub := r.cli.Subscribe(ctx, r.channel)
+	// 等待订阅生效，避免启动阶段丢失消息
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+
+	go func() {
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				r.dispatch(msg.Payload, fn)
+			}
+		}
+	}()
+	return nil
+}
+
+// dispatch 解析失效消息，并对其它节点发出的 key 执行回调
+func (r *RedisInvalidator) dispatch(payload string, fn func(key string)) {
+	var m invalidateMessage
+	if err := json.Unmarshal([]byte(payload), &m); err != nil {
+		log.Errorf("layered cache invalid message, channel=%s, payload=%s, err=%s", r.channel, payload, err)
+		return
+	}
+
+	// 本节点发出的消息在发送前已经处理过了
+	if m.Node == r.nodeID {
+		return
+	}
+	for _, key := range m.Keys {
+		fn(key)
+	}
+}
@@ -1,6 +1,7 @@
 package backend
 
 import (
+	"sync/atomic"
 	"time"
 
 	cache "github.com/fengzhongzhu1621/xgo/cache/common"
@@ -16,8 +17,13 @@ type MemoryBackend struct {
 	defaultExpiration time.Duration
 	// 生成随机过期时间偏移量的函数，用于打散过期时间
 	randomExtraExpirationFunc cache.RandomExtraExpirationDurationFunc
+
+	// 被删除或过期清理的数量
+	evictions atomic.Uint64
 }
 
+var _ StatsBackend = (*MemoryBackend)(nil)
+
 // NewMemoryBackend create memory backend
 // - name: the name of the backend
 // - expiration: the expiration duration of the cache 缓存项的过期时间。过了这个时间，缓存项将被视为过期并可能被清理。
@@ -29,12 +35,17 @@ func NewMemoryBackend(
 ) *MemoryBackend {
 	cleanupInterval := expiration + (5 * time.Minute)
 
-	return &MemoryBackend{
+	b := &MemoryBackend{
 		name:                      name,
 		cache:                     cache.NewTTLCache(expiration, cleanupInterval),
 		defaultExpiration:         expiration,
 		randomExtraExpirationFunc: randomExtraExpirationFunc,
 	}
+	// 删除和过期清理时都会回调
+	b.cache.OnEvicted(func(string, interface{}) {
+		b.evictions.Add(1)
+	})
+	return b
 }
 
 // Set sets value to cache with key and expiration
@@ -61,3 +72,13 @@ func (c *MemoryBackend) Delete(key string) error {
 	c.cache.Delete(key)
 	return nil
 }
+
+// Len returns the number of items in the cache, including the expired items not cleaned up yet
+func (c *MemoryBackend) Len() int {
+	return c.cache.ItemCount()
+}
+
+// Evictions returns the number of items deleted or expired
+func (c *MemoryBackend) Evictions() uint64 {
+	return c.evictions.Load()
+}
@@ -8,3 +8,20 @@ type Backend interface {
 	Get(key string) (interface{}, bool)
 	Delete(key string) error
 }
+
+// StatsBackend is the optional interface of a backend which reports its size and evictions.
+type StatsBackend interface {
+	// Len returns the number of items, including the expired items not cleaned up yet
+	Len() int
+	// Evictions returns the number of items deleted or expired
+	Evictions() uint64
+}
+
+// BatchBackend is the optional interface of a backend which can get or set multiple keys in one round trip.
+type BatchBackend interface {
+	Backend
+	// BatchGet returns the values of the keys, the missing keys are omitted
+	BatchGet(keys []string) map[string]interface{}
+	// BatchSet sets the values with the same expiration
+	BatchSet(values map[string]interface{}, duration time.Duration)
+}
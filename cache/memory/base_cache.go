@@ -2,14 +2,21 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	cache "github.com/fengzhongzhu1621/xgo/cache/common"
 	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/sync/singleflight"
 )
 
+// DefaultRefreshBackoff 后台刷新失败后，再次刷新同一个 key 的默认最小间隔
+const DefaultRefreshBackoff = time.Second
+
 // BaseCache is a cache which retrieves data from the backend and stores it in the cache.
 type BaseCache struct {
 	// 缓存的后端存储，可以是任何实现了 backend.Backend 接口的第三方缓存库
@@ -25,12 +32,38 @@ type BaseCache struct {
 	withEmptyCache bool
 	// 空缓存的过期时间
 	emptyCacheExpireDuration time.Duration
+
+	// 是否启用 stale-while-revalidate
+	withRefresh bool
+	// 数据的新鲜时间，超过后视为过期数据
+	freshDuration time.Duration
+	// 在新鲜时间结束前的这段时间窗口内触发后台刷新
+	refreshWindow time.Duration
+	// 数据的最大存活时间，刷新失败时最多返回这么久的过期数据
+	maxStaleAge time.Duration
+	// 刷新失败后，在这段时间内不再刷新同一个 key，避免按请求频率访问故障的数据源
+	refreshBackoff time.Duration
+	// 每个 key 最近一次刷新失败的时间，key -> time.Time
+	refreshFailures sync.Map
+
+	// 批量获取函数，未命中的 key 在一个时间窗口内合并为一次调用
+	batchRetrieveFunc cache.BatchRetrieveFunc
+	// 收集未命中 key 的时间窗口
+	batchWait time.Duration
+	// 一次批量获取的最大 key 数量
+	maxBatchSize int
+	// 合并未命中 key 的加载器，为 nil 时使用 retrieveFunc
+	loader *batchLoader
+
+	// 命中率、回源次数和耗时等统计
+	stats *stats.Collector
 }
 
+var _ stats.Inspector = (*BaseCache)(nil)
+
 // Exists returns true if the cache has a value for the given key.
 func (c *BaseCache) Exists(ctx context.Context, key cache.Key) bool {
-	k := key.Key()
-	_, ok := c.backend.Get(k)
+	_, ok := c.DirectGet(ctx, key)
 	return ok
 }
 
@@ -39,7 +72,7 @@ func (c *BaseCache) Get(ctx context.Context, key cache.Key) (interface{}, error)
 	// 1. if cache is disabled, fetch and return
 	if c.disabled {
 		// 缓存禁用后，使用指定方法获取 value
-		value, err := c.retrieveFunc(ctx, key)
+		value, err := c.retrieve(ctx, key)
 		if err != nil {
 			return nil, err
 		}
@@ -55,10 +88,17 @@ func (c *BaseCache) Get(ctx context.Context, key cache.Key) (interface{}, error)
 		// EmptyCache 可能是一个自定义的类型，用于表示缓存中曾经存在但现已无效的条目。这种设计允许缓存系统在不删除键的情况下标记某些条目为无效，
 		// 这在某些复杂的缓存策略中可能很有用。
 		if emptyCache, isEmptyCache := value.(cache.EmptyCache); isEmptyCache {
+			c.stats.Hit()
 			return nil, emptyCache.Err
 		}
-		return value, nil
+
+		// 2.1 stale-while-revalidate, 在硬过期前返回旧值，并在后台刷新
+		if value, ok = c.unwrapValue(ctx, key, value); ok {
+			c.stats.Hit()
+			return value, nil
+		}
 	}
+	c.stats.Miss()
 
 	// 3. if not exists in cache, retrieve it
 	// 如果键既不在缓存中，也不是无效的 EmptyCache 类型，则调用 doRetrieve 方法来从数据源（可能是数据库、API 等）获取该键对应的值，并将其添加到缓存中。最后返回新获取到的值。
@@ -67,15 +107,24 @@ func (c *BaseCache) Get(ctx context.Context, key cache.Key) (interface{}, error)
 
 // doRetrieve 缓存不存在时获取值，并缓存执行结果 will retrieve the real data from database, redis, apis, etc.
 func (c *BaseCache) doRetrieve(ctx context.Context, k cache.Key) (interface{}, error) {
+	// 3.1 batch mode, 与其它未命中的 key 合并获取，结果在批次完成时写入缓存
+	if c.loader != nil {
+		return c.loader.load(ctx, k)
+	}
+
 	key := k.Key()
 
 	// 3.2 fetch
 	// Do(key string, fn func() (interface{}, error)) (interface{}, error)：执行给定的函数 fn，并返回其结果。
 	// 如果有多个 goroutine 同时调用此方法并使用相同的 key，则只有一个 goroutine 会执行 fn，
 	// 其他 goroutine 会等待结果并共享相同的返回值。
+	// 共享结果的调用方不会执行 fn，所有调用方返回的 shared 都为 true，因此通过 executed 区分
+	start, executed := time.Now(), false
 	value, err, _ := c.g.Do(key, func() (interface{}, error) {
+		executed = true
 		return c.retrieveFunc(ctx, k)
 	})
+	c.stats.ObserveRetrieve(time.Since(start), err, !executed)
 
 	if err != nil {
 		if c.withEmptyCache {
@@ -89,27 +138,273 @@ func (c *BaseCache) doRetrieve(ctx context.Context, k cache.Key) (interface{}, e
 
 	// 4. set value to cache, use default expiration
 	// 如果成功获取到了数据，方法会将这个值存入缓存。这里使用 0 作为过期时间，表示使用缓存的默认过期策略。
-	c.backend.Set(key, value, 0)
+	c.setValue(key, value)
 
 	return value, nil
 }
 
+// refresh 在后台通过 retrieveFunc 刷新数据，与 doRetrieve 共用 singleflight，同一个 key 同时只有一个刷新
+// 刷新失败时保留旧值，直到硬过期
+func (c *BaseCache) refresh(ctx context.Context, k cache.Key) {
+	key := k.Key()
+	if failedAt, ok := c.refreshFailures.Load(key); ok && time.Since(failedAt.(time.Time)) < c.refreshBackoff {
+		return
+	}
+	// 请求结束后 ctx 可能被取消，刷新不应受其影响
+	ctx = context.WithoutCancel(ctx)
+
+	// DoChan 不会阻塞调用方，返回的 channel 带有缓冲，不读取也不会泄露 goroutine
+	c.g.DoChan(key, func() (interface{}, error) {
+		start := time.Now()
+		value, err := c.retrieve(ctx, k)
+		// 批量模式下由加载器统计
+		if c.loader == nil || c.retrieveFunc != nil {
+			c.stats.ObserveRetrieve(time.Since(start), err, false)
+		}
+		if err != nil {
+			log.Errorf("refresh cache fail, keep serving stale value, key=%s, err=%s", key, err)
+			c.refreshFailures.Store(key, time.Now())
+			return nil, err
+		}
+		c.setValue(key, value)
+		return value, nil
+	})
+}
+
+// retrieve 不经过缓存直接获取数据，仅配置了批量获取函数时通过加载器获取
+func (c *BaseCache) retrieve(ctx context.Context, k cache.Key) (interface{}, error) {
+	if c.retrieveFunc == nil && c.loader != nil {
+		return c.loader.load(ctx, k)
+	}
+	return c.retrieveFunc(ctx, k)
+}
+
+// setValue 将数据存入缓存，启用 stale-while-revalidate 时记录软过期和硬过期时间
+func (c *BaseCache) setValue(key string, value interface{}) {
+	c.backend.Set(key, c.wrapValue(value), c.valueDuration())
+	if c.withRefresh {
+		c.refreshFailures.Delete(key)
+	}
+}
+
+// wrapValue 启用 stale-while-revalidate 时，为数据附加软过期和硬过期时间
+func (c *BaseCache) wrapValue(value interface{}) interface{} {
+	if !c.withRefresh {
+		return value
+	}
+
+	now := time.Now()
+	return cache.RefreshableValue{
+		Value:      value,
+		FreshUntil: now.Add(c.freshDuration),
+		ExpireAt:   now.Add(c.maxStaleAge),
+	}
+}
+
+// valueDuration 数据在后端的过期时间，0 表示使用后端的默认过期时间
+func (c *BaseCache) valueDuration() time.Duration {
+	if c.withRefresh {
+		return c.maxStaleAge
+	}
+	return 0
+}
+
+// unwrapValue 处理后端中的值，返回实际数据，ok 为 false 表示需要重新获取
+func (c *BaseCache) unwrapValue(ctx context.Context, key cache.Key, value interface{}) (interface{}, bool) {
+	refreshable, isRefreshable := value.(cache.RefreshableValue)
+	if !isRefreshable {
+		return value, true
+	}
+
+	now := time.Now()
+	if !now.Before(refreshable.ExpireAt) {
+		return nil, false
+	}
+	if !now.Before(refreshable.FreshUntil.Add(-c.refreshWindow)) {
+		c.refresh(ctx, key)
+	}
+	return refreshable.Value, true
+}
+
+// BatchGet gets the values of the keys, the misses are retrieved in one call if the batch retrieve function is set,
+// the keys not found or cached as EmptyCache are omitted from the result,
+// the retrieve function should return ErrKeyNotFound for the keys not found
+func (c *BaseCache) BatchGet(ctx context.Context, keys []cache.Key) (map[cache.Key]interface{}, error) {
+	result := make(map[cache.Key]interface{}, len(keys))
+
+	// 1. get from cache, use one round trip if the backend supports
+	misses := keys
+	if !c.disabled {
+		misses = make([]cache.Key, 0, len(keys))
+		values := c.batchGetFromBackend(keys)
+		for _, key := range keys {
+			value, ok := values[key.Key()]
+			if !ok {
+				misses = append(misses, key)
+				continue
+			}
+			if _, isEmptyCache := value.(cache.EmptyCache); isEmptyCache {
+				c.stats.Hit()
+				continue
+			}
+			if value, ok = c.unwrapValue(ctx, key, value); ok {
+				c.stats.Hit()
+				result[key] = value
+			} else {
+				misses = append(misses, key)
+			}
+		}
+	}
+	if len(misses) == 0 {
+		return result, nil
+	}
+
+	// 2. retrieve the misses one by one, Get counts the misses itself
+	// the keys not found are omitted as the batch retrieve function does
+	if c.loader == nil {
+		for _, key := range misses {
+			value, err := c.Get(ctx, key)
+			if errors.Is(err, cache.ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			result[key] = value
+		}
+		return result, nil
+	}
+
+	// 3. retrieve the misses in batches, shared with concurrent callers
+	for range misses {
+		c.stats.Miss()
+	}
+	calls, err := c.loader.loadMany(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	for _, call := range calls {
+		if call.err == nil {
+			result[call.key] = call.value
+		} else if !errors.Is(call.err, cache.ErrKeyNotFound) {
+			return nil, call.err
+		}
+	}
+	return result, nil
+}
+
+// batchGetFromBackend 从后端批量获取
+func (c *BaseCache) batchGetFromBackend(keys []cache.Key) map[string]interface{} {
+	ks := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ks = append(ks, key.Key())
+	}
+
+	if b, ok := c.backend.(backend.BatchBackend); ok {
+		return b.BatchGet(ks)
+	}
+
+	values := make(map[string]interface{}, len(ks))
+	for _, k := range ks {
+		if value, ok := c.backend.Get(k); ok {
+			values[k] = value
+		}
+	}
+	return values
+}
+
+// batchSetToBackend 批量写入后端
+func (c *BaseCache) batchSetToBackend(values map[string]interface{}, duration time.Duration) {
+	if len(values) == 0 {
+		return
+	}
+
+	if b, ok := c.backend.(backend.BatchBackend); ok {
+		b.BatchSet(values, duration)
+		return
+	}
+	for k, value := range values {
+		c.backend.Set(k, value, duration)
+	}
+}
+
+// fillBatch 批量获取完成后写入缓存，不存在的 key 写入 EmptyCache
+func (c *BaseCache) fillBatch(calls []*batchCall) {
+	if c.disabled {
+		return
+	}
+
+	values := make(map[string]interface{}, len(calls))
+	empties := make(map[string]interface{})
+	for _, call := range calls {
+		if call.err == nil {
+			values[call.key.Key()] = c.wrapValue(call.value)
+		} else if c.withEmptyCache {
+			empties[call.key.Key()] = cache.EmptyCache{Err: call.err}
+		}
+	}
+
+	c.batchSetToBackend(values, c.valueDuration())
+	c.batchSetToBackend(empties, c.emptyCacheExpireDuration)
+}
+
 // Set will set key-value into cache.
 func (c *BaseCache) Set(ctx context.Context, key cache.Key, data interface{}) {
-	k := key.Key()
-	c.backend.Set(k, data, 0)
+	c.setValue(key.Key(), data)
 }
 
 // Delete deletes the value from the cache for the given key.
 func (c *BaseCache) Delete(ctx context.Context, key cache.Key) error {
 	k := key.Key()
+	// 后端自己统计时不重复计数
+	if _, ok := c.backend.(backend.StatsBackend); !ok {
+		c.stats.Evict()
+	}
 	return c.backend.Delete(k)
 }
 
 // DirectGet will get key from cache, without calling the retrieveFunc
 func (c *BaseCache) DirectGet(ctx context.Context, key cache.Key) (interface{}, bool) {
 	k := key.Key()
-	return c.backend.Get(k)
+	value, ok := c.backend.Get(k)
+	if !ok {
+		return nil, false
+	}
+
+	if refreshable, isRefreshable := value.(cache.RefreshableValue); isRefreshable {
+		if !time.Now().Before(refreshable.ExpireAt) {
+			return nil, false
+		}
+		return refreshable.Value, true
+	}
+	return value, true
+}
+
+// Stats returns the statistics of the cache, the evictions include the expired items if the backend reports them
+func (c *BaseCache) Stats() stats.Stats {
+	s := c.stats.Snapshot()
+	if b, ok := c.backend.(backend.StatsBackend); ok {
+		s.Evictions += b.Evictions()
+	}
+	return s
+}
+
+// Len returns the number of items in the backend, -1 if the backend does not report it
+func (c *BaseCache) Len() int {
+	if b, ok := c.backend.(backend.StatsBackend); ok {
+		return b.Len()
+	}
+	return -1
+}
+
+// Inspect returns the cached value of the key without calling the retrieveFunc
+func (c *BaseCache) Inspect(ctx context.Context, key string) (interface{}, bool) {
+	return c.DirectGet(ctx, cache.NewStringKey(key))
+}
+
+// Evict deletes the key from the cache
+func (c *BaseCache) Evict(ctx context.Context, key string) error {
+	return c.Delete(ctx, cache.NewStringKey(key))
 }
 
 // Disabled returns true if the cache is disabled.
@@ -369,6 +664,46 @@ func WithEmptyCache(timeout time.Duration) Option {
 	}
 }
 
+// WithRefreshAhead enables stale-while-revalidate.
+// the value is fresh for freshDuration, and will be refreshed in background
+// when get within the window before it becomes stale, callers keep getting the old value meanwhile.
+// if refresh fail, the stale value will be served until the max stale age, see WithMaxStaleAge
+func WithRefreshAhead(freshDuration time.Duration, window time.Duration) Option {
+	return func(baseCache *BaseCache) {
+		baseCache.withRefresh = true
+		baseCache.freshDuration = freshDuration
+		baseCache.refreshWindow = window
+	}
+}
+
+// WithRefreshBackoff set the minimum interval between the background refreshes of a key after a refresh fails,
+// the stale value is served meanwhile. backoff <= 0 means DefaultRefreshBackoff
+func WithRefreshBackoff(backoff time.Duration) Option {
+	return func(baseCache *BaseCache) {
+		baseCache.refreshBackoff = backoff
+	}
+}
+
+// WithMaxStaleAge set the hard maximum age of the value when stale-while-revalidate is enabled,
+// default is the freshDuration of WithRefreshAhead
+func WithMaxStaleAge(maxAge time.Duration) Option {
+	return func(baseCache *BaseCache) {
+		baseCache.maxStaleAge = maxAge
+	}
+}
+
+// WithBatchRetrieve enables the batch mode, the misses are collected within the wait window or
+// until maxBatchSize keys, then retrieved by batchRetrieveFunc in one call.
+// the keys missing in the result are cached as EmptyCache if WithEmptyCache is set.
+// wait <= 0 means DefaultBatchWait, maxBatchSize <= 0 means DefaultMaxBatchSize
+func WithBatchRetrieve(batchRetrieveFunc cache.BatchRetrieveFunc, wait time.Duration, maxBatchSize int) Option {
+	return func(baseCache *BaseCache) {
+		baseCache.batchRetrieveFunc = batchRetrieveFunc
+		baseCache.batchWait = wait
+		baseCache.maxBatchSize = maxBatchSize
+	}
+}
+
 func NewBaseCache(
 	disabled bool,
 	retrieveFunc cache.RetrieveFunc,
@@ -379,10 +714,21 @@ func NewBaseCache(
 		backend:      backend,
 		disabled:     disabled,
 		retrieveFunc: retrieveFunc,
+		stats:        stats.NewCollector(),
 	}
 	// 自定义参数
 	for _, o := range options {
 		o(c)
 	}
+	// 最大存活时间不能小于新鲜时间
+	if c.withRefresh && c.maxStaleAge < c.freshDuration {
+		c.maxStaleAge = c.freshDuration
+	}
+	if c.refreshBackoff <= 0 {
+		c.refreshBackoff = DefaultRefreshBackoff
+	}
+	if c.batchRetrieveFunc != nil {
+		c.loader = newBatchLoader(c.batchRetrieveFunc, c.batchWait, c.maxBatchSize, c.fillBatch, c.stats)
+	}
 	return c
 }
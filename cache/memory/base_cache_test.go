@@ -0,0 +1,270 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+// mapBackend 是一个没有过期机制的 backend.Backend 实现，用于验证与具体后端无关
+type mapBackend struct {
+	m sync.Map
+}
+
+func (b *mapBackend) Set(key string, value interface{}, duration time.Duration) {
+	b.m.Store(key, value)
+}
+
+func (b *mapBackend) Get(key string) (interface{}, bool) {
+	return b.m.Load(key)
+}
+
+func (b *mapBackend) Delete(key string) error {
+	b.m.Delete(key)
+	return nil
+}
+
+// versionSource 模拟数据源，每次回源返回递增的版本号
+type versionSource struct {
+	count    int32
+	attempts int32
+	fail     atomic.Bool
+	latency  time.Duration
+}
+
+func (s *versionSource) retrieve(ctx context.Context, key cache.Key) (interface{}, error) {
+	atomic.AddInt32(&s.attempts, 1)
+	time.Sleep(s.latency)
+	if s.fail.Load() {
+		return nil, errors.New("source unavailable")
+	}
+	return int(atomic.AddInt32(&s.count, 1)), nil
+}
+
+func (s *versionSource) calls() int32 {
+	return atomic.LoadInt32(&s.count)
+}
+
+func TestBaseCacheRefreshAhead(t *testing.T) {
+	backends := map[string]backend.Backend{
+		"memory": backend.NewMemoryBackend("test", time.Minute, nil),
+		"map":    &mapBackend{},
+	}
+
+	for name, b := range backends {
+		t.Run(name, func(t *testing.T) {
+			source := &versionSource{}
+			c := NewBaseCache(false, source.retrieve, b,
+				WithRefreshAhead(100*time.Millisecond, 50*time.Millisecond),
+				WithMaxStaleAge(time.Second),
+			)
+			ctx := context.Background()
+			key := cache.NewStringKey("k")
+
+			v, err := c.GetInt(ctx, key)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, v)
+
+			// 新鲜数据不触发刷新
+			v, err = c.GetInt(ctx, key)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, v)
+			assert.Equal(t, int32(1), source.calls())
+
+			// 进入刷新窗口后仍然返回旧值，同时在后台刷新
+			time.Sleep(60 * time.Millisecond)
+			v, err = c.GetInt(ctx, key)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, v)
+			assert.Eventually(t, func() bool {
+				v, err := c.GetInt(ctx, key)
+				return err == nil && v == 2
+			}, time.Second, 5*time.Millisecond)
+		})
+	}
+}
+
+func TestBaseCacheRefreshSingleflight(t *testing.T) {
+	source := &versionSource{latency: 50 * time.Millisecond}
+	c := NewBaseCache(false, source.retrieve, &mapBackend{},
+		WithRefreshAhead(10*time.Millisecond, 10*time.Millisecond),
+		WithMaxStaleAge(time.Minute),
+	)
+	ctx := context.Background()
+	key := cache.NewStringKey("k")
+
+	_, err := c.Get(ctx, key)
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	// 窗口内的并发请求不会被阻塞，且只触发一次刷新
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			v, err := c.GetInt(ctx, key)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, v)
+			assert.Less(t, time.Since(start), source.latency)
+		}()
+	}
+	wg.Wait()
+
+	assert.Eventually(t, func() bool {
+		return source.calls() == 2
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(source.latency)
+	assert.Equal(t, int32(2), source.calls())
+}
+
+func TestBaseCacheRefreshFailServeStale(t *testing.T) {
+	source := &versionSource{}
+	c := NewBaseCache(false, source.retrieve, &mapBackend{},
+		WithRefreshAhead(20*time.Millisecond, 10*time.Millisecond),
+		WithMaxStaleAge(200*time.Millisecond),
+		WithEmptyCache(time.Minute),
+	)
+	ctx := context.Background()
+	key := cache.NewStringKey("k")
+
+	v, err := c.GetInt(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	// 刷新失败时，在硬过期前一直返回旧值
+	source.fail.Store(true)
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		v, err = c.GetInt(ctx, key)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v)
+	}
+	assert.True(t, c.Exists(ctx, key))
+
+	// 超过硬过期时间后，阻塞回源并返回错误
+	time.Sleep(200 * time.Millisecond)
+	assert.False(t, c.Exists(ctx, key))
+	_, err = c.GetInt(ctx, key)
+	assert.Error(t, err)
+}
+
+func TestBaseCacheRefreshFailBackoff(t *testing.T) {
+	source := &versionSource{}
+	c := NewBaseCache(false, source.retrieve, &mapBackend{},
+		WithRefreshAhead(10*time.Millisecond, 10*time.Millisecond),
+		WithMaxStaleAge(time.Minute),
+		WithRefreshBackoff(100*time.Millisecond),
+	)
+	ctx := context.Background()
+	key := cache.NewStringKey("k")
+
+	_, err := c.GetInt(ctx, key)
+	assert.NoError(t, err)
+
+	// 刷新失败后，退避时间内的请求不再触发刷新
+	source.fail.Store(true)
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		v, err := c.GetInt(ctx, key)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v)
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&source.attempts))
+
+	// 退避时间结束后再次刷新，成功后恢复
+	source.fail.Store(false)
+	time.Sleep(100 * time.Millisecond)
+	_, _ = c.GetInt(ctx, key)
+	assert.Eventually(t, func() bool {
+		v, _ := c.GetInt(ctx, key)
+		return v == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestBaseCacheRefreshDirectGet(t *testing.T) {
+	source := &versionSource{}
+	c := NewBaseCache(false, source.retrieve, &mapBackend{},
+		WithRefreshAhead(time.Minute, time.Second),
+	)
+	ctx := context.Background()
+	key := cache.NewStringKey("k")
+
+	c.Set(ctx, key, 100)
+	value, ok := c.DirectGet(ctx, key)
+	assert.True(t, ok)
+	assert.Equal(t, 100, value)
+	assert.Equal(t, int32(0), source.calls())
+}
+
+func TestBaseCacheStats(t *testing.T) {
+	source := &versionSource{latency: 20 * time.Millisecond}
+	c := NewCache("test-stats", false, source.retrieve, time.Minute, nil)
+	ctx := context.Background()
+
+	// 并发未命中共享一次回源
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Get(ctx, cache.NewStringKey("k"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	_, _ = c.Get(ctx, cache.NewStringKey("k"))
+	_ = c.Delete(ctx, cache.NewStringKey("k"))
+
+	s := c.Stats()
+	assert.Equal(t, uint64(1), s.Hits)
+	assert.Equal(t, uint64(5), s.Misses)
+	assert.Equal(t, uint64(1), s.Retrieves)
+	assert.Equal(t, uint64(4), s.SharedRetrieves)
+	assert.Equal(t, uint64(1), s.Evictions)
+	assert.GreaterOrEqual(t, s.RetrieveDuration, 20*time.Millisecond)
+
+	// 按名称注册，可以查看和删除单个 key
+	inspector, ok := stats.Get("test-stats")
+	assert.True(t, ok)
+	c.Set(ctx, cache.NewStringKey("k"), 100)
+	assert.Equal(t, 1, inspector.Len())
+	value, ok := inspector.Inspect(ctx, "k")
+	assert.True(t, ok)
+	assert.Equal(t, 100, value)
+	assert.NoError(t, inspector.Evict(ctx, "k"))
+	_, ok = inspector.Inspect(ctx, "k")
+	assert.False(t, ok)
+	assert.Equal(t, uint64(2), c.Stats().Evictions)
+}
+
+func TestBatchRetrieveStats(t *testing.T) {
+	source := &userSource{}
+	c := NewBaseCache(false, nil, &mapBackend{},
+		WithBatchRetrieve(source.batchRetrieve, time.Millisecond, 100),
+	)
+	ctx := context.Background()
+
+	_, err := c.BatchGet(ctx, keysOf(1, 3, 5))
+	assert.NoError(t, err)
+	_, err = c.BatchGet(ctx, keysOf(1, 3))
+	assert.NoError(t, err)
+
+	s := c.Stats()
+	assert.Equal(t, uint64(2), s.Hits)
+	assert.Equal(t, uint64(3), s.Misses)
+	// 一个批次只计一次回源
+	assert.Equal(t, uint64(1), s.Retrieves)
+	// 不报告数量的后端返回 -1
+	assert.Equal(t, -1, c.(*BaseCache).Len())
+}
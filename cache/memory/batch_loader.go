@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBatchWait 收集未命中 key 的默认时间窗口
+	DefaultBatchWait = 2 * time.Millisecond
+	// DefaultMaxBatchSize 一次批量获取的默认最大 key 数量
+	DefaultMaxBatchSize = 100
+)
+
+// batchCall 是一个 key 的获取结果，同一个 key 的并发调用方共享同一个 batchCall
+type batchCall struct {
+	key   cache.Key
+	value interface{}
+	err   error
+	// 获取完成后关闭
+	done chan struct{}
+}
+
+// batch 是一个时间窗口内收集到的未命中 key
+type batch struct {
+	ctx   context.Context
+	calls []*batchCall
+	timer *time.Timer
+}
+
+// batchResultFunc 批量获取完成后的回调，用于写入缓存
+type batchResultFunc func(calls []*batchCall)
+
+// batchLoader 在一个较短的时间窗口内收集未命中的 key，然后通过 BatchRetrieveFunc 一次性获取 (dataloader)
+type batchLoader struct {
+	batchRetrieveFunc cache.BatchRetrieveFunc
+	// 收集 key 的时间窗口
+	wait time.Duration
+	// 达到该数量后立即获取，不再等待
+	maxBatchSize int
+	// 获取完成后的回调
+	onResult batchResultFunc
+	// 统计回源次数和耗时，可以为 nil
+	stats *stats.Collector
+
+	mu sync.Mutex
+	// 正在收集 key 的批次
+	pending *batch
+	// 已经在批次中但还没有获取完成的 key
+	inflight map[string]*batchCall
+}
+
+func newBatchLoader(
+	batchRetrieveFunc cache.BatchRetrieveFunc,
+	wait time.Duration,
+	maxBatchSize int,
+	onResult batchResultFunc,
+	collector *stats.Collector,
+) *batchLoader {
+	if wait <= 0 {
+		wait = DefaultBatchWait
+	}
+	if maxBatchSize <= 0 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
+
+	return &batchLoader{
+		batchRetrieveFunc: batchRetrieveFunc,
+		wait:              wait,
+		maxBatchSize:      maxBatchSize,
+		onResult:          onResult,
+		stats:             collector,
+		inflight:          make(map[string]*batchCall),
+	}
+}
+
+// load 获取一个 key，阻塞直到所在的批次获取完成或 ctx 结束
+func (l *batchLoader) load(ctx context.Context, key cache.Key) (interface{}, error) {
+	call := l.enqueue(ctx, []cache.Key{key})[0]
+	return l.waitCall(ctx, call)
+}
+
+// loadMany 获取多个 key，阻塞直到所有 key 获取完成或 ctx 结束
+func (l *batchLoader) loadMany(ctx context.Context, keys []cache.Key) ([]*batchCall, error) {
+	calls := l.enqueue(ctx, keys)
+	for _, call := range calls {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return calls, nil
+}
+
+// waitCall 等待一个 key 获取完成
+func (l *batchLoader) waitCall(ctx context.Context, call *batchCall) (interface{}, error) {
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// enqueue 将 key 加入正在收集的批次，已经在获取中的 key 直接复用
+func (l *batchLoader) enqueue(ctx context.Context, keys []cache.Key) []*batchCall {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	calls := make([]*batchCall, 0, len(keys))
+	for _, key := range keys {
+		k := key.Key()
+		if call, ok := l.inflight[k]; ok {
+			if l.stats != nil {
+				l.stats.ObserveRetrieve(0, nil, true)
+			}
+			calls = append(calls, call)
+			continue
+		}
+
+		call := &batchCall{key: key, done: make(chan struct{})}
+		l.inflight[k] = call
+		calls = append(calls, call)
+
+		if l.pending == nil {
+			// 调用方的请求结束后 ctx 可能被取消，批量获取不应受其影响
+			b := &batch{ctx: context.WithoutCancel(ctx)}
+			b.timer = time.AfterFunc(l.wait, func() {
+				l.mu.Lock()
+				defer l.mu.Unlock()
+				l.dispatchLocked(b)
+			})
+			l.pending = b
+		}
+		l.pending.calls = append(l.pending.calls, call)
+
+		if len(l.pending.calls) >= l.maxBatchSize {
+			l.pending.timer.Stop()
+			l.dispatchLocked(l.pending)
+		}
+	}
+	return calls
+}
+
+// dispatchLocked 开始获取一个批次，调用方需要持有锁
+func (l *batchLoader) dispatchLocked(b *batch) {
+	// 批次已经因为数量达到上限被获取
+	if l.pending != b {
+		return
+	}
+	l.pending = nil
+	go l.run(b)
+}
+
+// run 执行批量获取，写入缓存后唤醒所有等待的调用方
+func (l *batchLoader) run(b *batch) {
+	keys := make([]cache.Key, 0, len(b.calls))
+	for _, call := range b.calls {
+		keys = append(keys, call.key)
+	}
+
+	start := time.Now()
+	values, err := l.retrieve(b.ctx, keys)
+	if l.stats != nil {
+		l.stats.ObserveRetrieve(time.Since(start), err, false)
+	}
+	for _, call := range b.calls {
+		if err != nil {
+			call.err = err
+			continue
+		}
+		value, ok := values[call.key.Key()]
+		if !ok {
+			call.err = cache.ErrKeyNotFound
+			continue
+		}
+		call.value = value
+	}
+
+	// 先写入缓存，再从 inflight 中移除，保证之后的调用方可以命中缓存
+	if l.onResult != nil {
+		l.onResult(b.calls)
+	}
+
+	l.mu.Lock()
+	for _, call := range b.calls {
+		delete(l.inflight, call.key.Key())
+	}
+	l.mu.Unlock()
+
+	for _, call := range b.calls {
+		close(call.done)
+	}
+}
+
+// retrieve 调用批量获取函数，panic 时转换为错误，由批次中所有等待的调用方返回，避免调用方一直阻塞
+func (l *batchLoader) retrieve(ctx context.Context, keys []cache.Key) (values map[string]interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("batch retrieve panic, keys=%d, err=%v\n%s", len(keys), r, debug.Stack())
+			err = fmt.Errorf("cache: batch retrieve panic: %v", r)
+		}
+	}()
+	return l.batchRetrieveFunc(ctx, keys)
+}
@@ -0,0 +1,294 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	"github.com/fengzhongzhu1621/xgo/cache/redis"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// userSource 模拟数据库，id 为偶数的用户不存在
+type userSource struct {
+	mu      sync.Mutex
+	batches [][]string
+	latency time.Duration
+	err     error
+}
+
+func (s *userSource) batchRetrieve(ctx context.Context, keys []cache.Key) (map[string]interface{}, error) {
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, key.Key())
+	}
+	sort.Strings(ids)
+
+	s.mu.Lock()
+	s.batches = append(s.batches, ids)
+	s.mu.Unlock()
+
+	time.Sleep(s.latency)
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	result := make(map[string]interface{}, len(ids))
+	for _, id := range ids {
+		if n, _ := strconv.Atoi(id); n%2 == 1 {
+			result[id] = "user-" + id
+		}
+	}
+	return result, nil
+}
+
+func (s *userSource) calls() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string(nil), s.batches...)
+}
+
+func keysOf(ids ...int) []cache.Key {
+	keys := make([]cache.Key, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, cache.NewIntKey(id))
+	}
+	return keys
+}
+
+func TestBatchRetrieveCoalesceGets(t *testing.T) {
+	source := &userSource{}
+	c := NewBaseCache(false, nil, backend.NewMemoryBackend("test", time.Minute, nil),
+		WithBatchRetrieve(source.batchRetrieve, 20*time.Millisecond, 100),
+		WithEmptyCache(time.Minute),
+	)
+	ctx := context.Background()
+
+	// 并发的单 key 请求合并为一次批量获取
+	var wg sync.WaitGroup
+	for _, key := range keysOf(1, 2, 3, 4, 5) {
+		wg.Add(1)
+		go func(key cache.Key) {
+			defer wg.Done()
+			value, err := c.Get(ctx, key)
+			if n, _ := strconv.Atoi(key.Key()); n%2 == 1 {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-"+key.Key(), value)
+			} else {
+				assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	assert.Equal(t, [][]string{{"1", "2", "3", "4", "5"}}, source.calls())
+
+	// 不存在的 id 被缓存为 EmptyCache，不会再次获取
+	_, err := c.Get(ctx, cache.NewIntKey(2))
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	s, err := c.GetString(ctx, cache.NewIntKey(3))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-3", s)
+	assert.Len(t, source.calls(), 1)
+}
+
+func TestBatchRetrieveMaxBatchSize(t *testing.T) {
+	source := &userSource{}
+	c := NewBaseCache(false, nil, backend.NewMemoryBackend("test", time.Minute, nil),
+		WithBatchRetrieve(source.batchRetrieve, time.Hour, 4),
+	)
+
+	result, err := c.BatchGet(context.Background(), keysOf(1, 3, 5, 7, 9, 11, 13, 15))
+	assert.NoError(t, err)
+	assert.Len(t, result, 8)
+	// 达到上限立即获取，不需要等待时间窗口
+	assert.Len(t, source.calls(), 2)
+}
+
+func TestBatchRetrieveShareInflight(t *testing.T) {
+	source := &userSource{latency: 50 * time.Millisecond}
+	c := NewBaseCache(false, nil, backend.NewMemoryBackend("test", time.Minute, nil),
+		WithBatchRetrieve(source.batchRetrieve, 5*time.Millisecond, 100),
+	)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.Get(ctx, cache.NewIntKey(1))
+			assert.NoError(t, err)
+			assert.Equal(t, "user-1", value)
+		}()
+	}
+	// 在第一个批次获取期间加入的请求复用正在进行的获取
+	time.Sleep(20 * time.Millisecond)
+	result, err := c.BatchGet(ctx, keysOf(1, 3))
+	wg.Wait()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-3", result[cache.NewIntKey(3)])
+	assert.Equal(t, [][]string{{"1"}, {"3"}}, source.calls())
+}
+
+func TestBatchGetPartialHit(t *testing.T) {
+	source := &userSource{}
+	c := NewBaseCache(false, nil, backend.NewMemoryBackend("test", time.Minute, nil),
+		WithBatchRetrieve(source.batchRetrieve, time.Millisecond, 100),
+		WithEmptyCache(time.Minute),
+	)
+	ctx := context.Background()
+	c.Set(ctx, cache.NewIntKey(1), "cached")
+
+	result, err := c.BatchGet(ctx, keysOf(1, 2, 3))
+	assert.NoError(t, err)
+	assert.Equal(t, map[cache.Key]interface{}{
+		cache.NewIntKey(1): "cached",
+		cache.NewIntKey(3): "user-3",
+	}, result)
+	assert.Equal(t, [][]string{{"2", "3"}}, source.calls())
+
+	// EmptyCache 的 key 不会出现在结果中，也不会再次获取
+	result, err = c.BatchGet(ctx, keysOf(1, 2, 3))
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Len(t, source.calls(), 1)
+}
+
+func TestBatchGetWithoutBatchRetrieve(t *testing.T) {
+	errUnavailable := errors.New("db unavailable")
+	retrieve := func(ctx context.Context, key cache.Key) (interface{}, error) {
+		switch key.Key() {
+		case "2":
+			return nil, cache.ErrKeyNotFound
+		case "4":
+			return nil, errUnavailable
+		}
+		return "user-" + key.Key(), nil
+	}
+	c := NewBaseCache(false, retrieve, backend.NewMemoryBackend("test", time.Minute, nil))
+	ctx := context.Background()
+
+	// 逐个获取时不存在的 key 同样不出现在结果中
+	result, err := c.BatchGet(ctx, keysOf(1, 2, 3))
+	assert.NoError(t, err)
+	assert.Equal(t, map[cache.Key]interface{}{
+		cache.NewIntKey(1): "user-1",
+		cache.NewIntKey(3): "user-3",
+	}, result)
+
+	_, err = c.BatchGet(ctx, keysOf(1, 4))
+	assert.ErrorIs(t, err, errUnavailable)
+}
+
+func TestBatchRetrieveError(t *testing.T) {
+	errUnavailable := errors.New("db unavailable")
+	source := &userSource{err: errUnavailable}
+	c := NewBaseCache(false, nil, backend.NewMemoryBackend("test", time.Minute, nil),
+		WithBatchRetrieve(source.batchRetrieve, time.Millisecond, 100),
+	)
+
+	_, err := c.BatchGet(context.Background(), keysOf(1, 2))
+	assert.ErrorIs(t, err, errUnavailable)
+	_, err = c.Get(context.Background(), cache.NewIntKey(1))
+	assert.ErrorIs(t, err, errUnavailable)
+}
+
+func TestBatchRetrievePanic(t *testing.T) {
+	var panicked atomic.Bool
+	c := NewBaseCache(false, nil, backend.NewMemoryBackend("test", time.Minute, nil),
+		WithBatchRetrieve(func(ctx context.Context, keys []cache.Key) (map[string]interface{}, error) {
+			if !panicked.Swap(true) {
+				panic("boom")
+			}
+			return map[string]interface{}{"1": "user-1"}, nil
+		}, 50*time.Millisecond, 100),
+	)
+	ctx := context.Background()
+
+	// panic 转换为错误返回给批次中所有的调用方
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = c.Get(ctx, cache.NewIntKey(i+1))
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.ErrorContains(t, err, "batch retrieve panic: boom")
+	}
+
+	// 之后的批次正常获取
+	v, err := c.Get(ctx, cache.NewIntKey(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", v)
+}
+
+func TestBatchRetrieveContextCanceled(t *testing.T) {
+	source := &userSource{latency: 100 * time.Millisecond}
+	c := NewBaseCache(false, nil, backend.NewMemoryBackend("test", time.Minute, nil),
+		WithBatchRetrieve(source.batchRetrieve, time.Millisecond, 100),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Get(ctx, cache.NewIntKey(1))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 调用方取消不影响批量获取，结果仍然写入缓存
+	assert.Eventually(t, func() bool {
+		return c.Exists(context.Background(), cache.NewIntKey(1))
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestBatchRetrieveRedisBackend(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	newCache := func(source *userSource) Cache {
+		b := redis.NewBackend(redis.NewCacheWithClient("test", cli, time.Minute), func() interface{} {
+			return new(string)
+		})
+		return NewBaseCache(false, nil, b,
+			WithBatchRetrieve(source.batchRetrieve, time.Millisecond, 100),
+			WithEmptyCache(time.Minute),
+		)
+	}
+	ctx := context.Background()
+
+	source := &userSource{}
+	result, err := newCache(source).BatchGet(ctx, keysOf(1, 2, 3))
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Len(t, source.calls(), 1)
+
+	// 另一个副本直接从 redis 批量读取，包括 EmptyCache
+	other := &userSource{}
+	c := newCache(other)
+	result, err = c.BatchGet(ctx, keysOf(1, 2, 3))
+	assert.NoError(t, err)
+	assert.Equal(t, map[cache.Key]interface{}{
+		cache.NewIntKey(1): "user-1",
+		cache.NewIntKey(3): "user-3",
+	}, result)
+	_, err = c.Get(ctx, cache.NewIntKey(2))
+	var emptyErr *cache.EmptyCacheError
+	assert.ErrorAs(t, err, &emptyErr)
+	// 从 redis 读取的空缓存和进程内的空缓存一样可以判断为不存在
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	assert.Empty(t, other.calls())
+}
@@ -5,8 +5,10 @@ import (
 
 	cache "github.com/fengzhongzhu1621/xgo/cache/common"
 	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
 )
 
+// NewCache create a memory cache, the cache is registered to cache/stats by name
 func NewCache(
 	name string,
 	disabled bool,
@@ -16,5 +18,7 @@ func NewCache(
 	options ...Option,
 ) Cache {
 	cacheBackend := backend.NewMemoryBackend(name, expiration, randomExtraExpirationFunc)
-	return NewBaseCache(disabled, retrieveFunc, cacheBackend, options...)
+	c := NewBaseCache(disabled, retrieveFunc, cacheBackend, options...)
+	stats.Register(name, c.(stats.Inspector))
+	return c
 }
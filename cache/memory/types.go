@@ -5,6 +5,7 @@ import (
 	"time"
 
 	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
 )
 
 // Cache is the interface for the cache.
@@ -32,6 +33,10 @@ type Cache interface {
 	Exists(ctx context.Context, key cache.Key) bool
 
 	DirectGet(ctx context.Context, key cache.Key) (interface{}, bool)
+	BatchGet(ctx context.Context, keys []cache.Key) (map[cache.Key]interface{}, error)
 
 	Disabled() bool
+
+	// Stats returns the statistics of the cache
+	Stats() stats.Stats
 }
@@ -0,0 +1,201 @@
+package redis
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	gopkgcache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	log "github.com/sirupsen/logrus"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// NewValueFunc 返回一个指针，redis 中的数据将反序列化到该指针指向的对象中
+// e.g. func() interface{} { return new(User) }
+type NewValueFunc func() interface{}
+
+// entry 是 Backend 写入 redis 的数据格式，用于区分正常值和空缓存标记
+type entry struct {
+	// 是否为空缓存标记
+	Empty bool `msgpack:"e,omitempty"`
+	// 空缓存标记携带的错误信息
+	Err string `msgpack:"r,omitempty"`
+	// 空缓存标记的错误是否为 ErrKeyNotFound
+	NotFound bool `msgpack:"n,omitempty"`
+	// 正常值的 msgpack 编码
+	Value msgpack.RawMessage `msgpack:"v,omitempty"`
+	// stale-while-revalidate 的软过期和硬过期时间，unix 纳秒
+	FreshUntil int64 `msgpack:"f,omitempty"`
+	ExpireAt   int64 `msgpack:"x,omitempty"`
+}
+
+// Backend adapts the Cache to backend.Backend, so that it can be used by memory.BaseCache,
+// EmptyCache and RefreshableValue are kept across processes.
+type Backend struct {
+	cache *Cache
+	// 反序列化的目标对象
+	newValue NewValueFunc
+}
+
+var _ backend.BatchBackend = (*Backend)(nil)
+
+// NewBackend create a redis backend
+// - newValue: 为 nil 时，数据反序列化为 interface{}，数值类型可能与写入时不一致
+func NewBackend(c *Cache, newValue NewValueFunc) *Backend {
+	return &Backend{
+		cache:    c,
+		newValue: newValue,
+	}
+}
+
+// Set sets value to redis, duration 0 means the default expiration
+func (b *Backend) Set(key string, value interface{}, duration time.Duration) {
+	e, err := b.encode(value)
+	if err != nil {
+		log.Errorf("redis backend encode value fail, key=%s, err=%s", key, err)
+		return
+	}
+	if err = b.cache.Set(gopkgcache.NewStringKey(key), e, duration); err != nil {
+		log.Errorf("redis backend set fail, key=%s, err=%s", key, err)
+	}
+}
+
+// Get gets value by key from redis
+func (b *Backend) Get(key string) (interface{}, bool) {
+	var e entry
+	if err := b.cache.Get(gopkgcache.NewStringKey(key), &e); err != nil {
+		return nil, false
+	}
+
+	value, err := b.decode(&e)
+	if err != nil {
+		log.Errorf("redis backend decode value fail, key=%s, err=%s", key, err)
+		return nil, false
+	}
+	return value, true
+}
+
+// Delete deletes value by key from redis
+func (b *Backend) Delete(key string) error {
+	return b.cache.Delete(gopkgcache.NewStringKey(key))
+}
+
+// BatchGet gets the values of the keys with pipeline, the missing keys are omitted
+func (b *Backend) BatchGet(keys []string) map[string]interface{} {
+	cacheKeys := make([]gopkgcache.Key, 0, len(keys))
+	for _, key := range keys {
+		cacheKeys = append(cacheKeys, gopkgcache.NewStringKey(key))
+	}
+
+	values := make(map[string]interface{}, len(keys))
+	raws, err := b.cache.BatchGet(cacheKeys)
+	if err != nil {
+		log.Errorf("redis backend batch get fail, keys=%v, err=%s", keys, err)
+		return values
+	}
+
+	for k, raw := range raws {
+		var e entry
+		if err := b.cache.Unmarshal([]byte(raw), &e); err != nil {
+			log.Errorf("redis backend unmarshal fail, key=%s, err=%s", k.Key(), err)
+			continue
+		}
+		value, err := b.decode(&e)
+		if err != nil {
+			log.Errorf("redis backend decode value fail, key=%s, err=%s", k.Key(), err)
+			continue
+		}
+		values[k.Key()] = value
+	}
+	return values
+}
+
+// BatchSet sets the values with tx pipeline, duration 0 means the default expiration
+func (b *Backend) BatchSet(values map[string]interface{}, duration time.Duration) {
+	kvs := make([]KV, 0, len(values))
+	for key, value := range values {
+		e, err := b.encode(value)
+		if err != nil {
+			log.Errorf("redis backend encode value fail, key=%s, err=%s", key, err)
+			continue
+		}
+		data, err := b.cache.Marshal(e)
+		if err != nil {
+			log.Errorf("redis backend marshal fail, key=%s, err=%s", key, err)
+			continue
+		}
+		kvs = append(kvs, KV{Key: key, Value: string(data)})
+	}
+	if len(kvs) == 0 {
+		return
+	}
+
+	if err := b.cache.BatchSetWithTx(kvs, duration); err != nil {
+		log.Errorf("redis backend batch set fail, count=%d, err=%s", len(kvs), err)
+	}
+}
+
+// encode 将值转换为 redis 中的存储格式
+func (b *Backend) encode(value interface{}) (*entry, error) {
+	if emptyCache, ok := value.(gopkgcache.EmptyCache); ok {
+		e := &entry{Empty: true}
+		if emptyCache.Err != nil {
+			e.Err = emptyCache.Err.Error()
+			e.NotFound = errors.Is(emptyCache.Err, gopkgcache.ErrKeyNotFound)
+		}
+		return e, nil
+	}
+
+	if refreshable, ok := value.(gopkgcache.RefreshableValue); ok {
+		e, err := b.encode(refreshable.Value)
+		if err != nil {
+			return nil, err
+		}
+		e.FreshUntil = refreshable.FreshUntil.UnixNano()
+		e.ExpireAt = refreshable.ExpireAt.UnixNano()
+		return e, nil
+	}
+
+	data, err := msgpack.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &entry{Value: data}, nil
+}
+
+// decode 将 redis 中的存储格式还原为值
+func (b *Backend) decode(e *entry) (interface{}, error) {
+	if e.Empty {
+		return gopkgcache.EmptyCache{Err: gopkgcache.NewEmptyCacheError(e.Err, e.NotFound)}, nil
+	}
+
+	if e.ExpireAt != 0 {
+		value, err := b.decodeValue(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		return gopkgcache.RefreshableValue{
+			Value:      value,
+			FreshUntil: time.Unix(0, e.FreshUntil),
+			ExpireAt:   time.Unix(0, e.ExpireAt),
+		}, nil
+	}
+	return b.decodeValue(e.Value)
+}
+
+// decodeValue 反序列化正常值
+func (b *Backend) decodeValue(data msgpack.RawMessage) (interface{}, error) {
+	if b.newValue == nil {
+		var value interface{}
+		err := msgpack.Unmarshal(data, &value)
+		return value, err
+	}
+
+	ptr := b.newValue()
+	if err := msgpack.Unmarshal(data, ptr); err != nil {
+		return nil, err
+	}
+	// 返回指针指向的值，与进程内缓存中保存的类型保持一致
+	return reflect.ValueOf(ptr).Elem().Interface(), nil
+}
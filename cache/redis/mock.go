@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
 	redisClient "github.com/fengzhongzhu1621/xgo/db/redis/client"
 	"github.com/go-redis/cache/v8"
 )
@@ -31,5 +32,6 @@ func NewMockCache(name string, expiration time.Duration) *Cache {
 		codec:             codec,      // 缓存编解码器
 		cli:               cli,        // Redis 客户端实例
 		defaultExpiration: expiration, // 缓存项的默认过期时间
+		stats:             stats.NewCollector(),
 	}
 }
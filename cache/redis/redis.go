@@ -7,6 +7,7 @@ import (
 	"time"
 
 	gopkgcache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
 	redisClient "github.com/fengzhongzhu1621/xgo/db/redis/client"
 	"github.com/go-redis/cache/v8"
 	"github.com/go-redis/redis/v8"
@@ -44,12 +45,19 @@ type Cache struct {
 	defaultExpiration time.Duration
 	// 用于防止缓存击穿攻击。它可以确保在高并发情况下，对于同一缓存键的多次访问只会触发一次实际的数据加载操作。
 	G singleflight.Group
+	// 命中率、回源次数和耗时等统计，需要通过 stats.Register 注册后才会被导出
+	stats *stats.Collector
 }
 
+var _ stats.Inspector = (*Cache)(nil)
+
 // NewCache create a cache instance
 func NewCache(name string, expiration time.Duration) *Cache {
-	cli := redisClient.GetDefaultRedisClient()
+	return NewCacheWithClient(name, redisClient.GetDefaultRedisClient(), expiration)
+}
 
+// NewCacheWithClient create a cache instance with the given redis client
+func NewCacheWithClient(name string, cli *redis.Client, expiration time.Duration) *Cache {
 	// key format = xgo:{version}:{cache_name}:{real_key}
 	keyPrefix := fmt.Sprintf("xgo:%s:%s", CacheVersion, name)
 
@@ -65,6 +73,7 @@ func NewCache(name string, expiration time.Duration) *Cache {
 		codec:             codec,      // 缓存编解码器
 		cli:               cli,        // Redis 客户端实例
 		defaultExpiration: expiration, // 缓存项的默认过期时间
+		stats:             stats.NewCollector(),
 	}
 }
 
@@ -129,15 +138,20 @@ func (c *Cache) GetInto(
 	err = c.Get(key, obj)
 	if err == nil {
 		// 返回正常
+		c.stats.Hit()
 		return nil
 	}
+	c.stats.Miss()
 
 	// 如果缓存未命中，调用 retrieveFunc 从数据源获取数据
 	// 确保在多个并发请求同时尝试获取同一个不存在的键时，只会执行一次数据获取操作。
+	start, executed := time.Now(), false
 	data, err, _ := c.G.Do(key.Key(), func() (interface{}, error) {
+		executed = true
 		ctx := context.TODO()
 		return retrieveFunc(ctx, key)
 	})
+	c.stats.ObserveRetrieve(time.Since(start), err, !executed)
 	if err != nil {
 		return
 	}
@@ -158,7 +172,9 @@ func (c *Cache) Delete(key gopkgcache.Key) (err error) {
 
 	ctx := context.TODO()
 
-	_, err = c.cli.Del(ctx, k).Result()
+	var n int64
+	n, err = c.cli.Del(ctx, k).Result()
+	c.observeEvictions(n)
 	return err
 }
 
@@ -184,7 +200,9 @@ func (c *Cache) BatchDelete(keys []gopkgcache.Key) error {
 	var err error
 	if len(newKeys) < PipelineSizeThreshold {
 		// 直接使用 Del 方法批量删除这些键
-		_, err = c.cli.Del(ctx, newKeys...).Result()
+		var n int64
+		n, err = c.cli.Del(ctx, newKeys...).Result()
+		c.observeEvictions(n)
 	} else {
 		// 使用 Redis 的管道（pipeline）功能来批量删除这些键。这样可以提高删除操作的效率，减少网络往返次数
 		pipe := c.cli.Pipeline()
@@ -193,7 +211,13 @@ func (c *Cache) BatchDelete(keys []gopkgcache.Key) error {
 			pipe.Del(ctx, key)
 		}
 
-		_, err = pipe.Exec(ctx)
+		var cmds []redis.Cmder
+		cmds, err = pipe.Exec(ctx)
+		for _, cmd := range cmds {
+			if intCmd, ok := cmd.(*redis.IntCmd); ok {
+				c.observeEvictions(intCmd.Val())
+			}
+		}
 	}
 	return err
 }
@@ -441,3 +465,39 @@ func (c *Cache) Unmarshal(b []byte, value interface{}) error {
 func (c *Cache) Marshal(value interface{}) ([]byte, error) {
 	return c.codec.Marshal(value)
 }
+
+// observeEvictions 记录删除的 key 数量
+func (c *Cache) observeEvictions(n int64) {
+	for i := int64(0); i < n; i++ {
+		c.stats.Evict()
+	}
+}
+
+// Name returns the name of the cache
+func (c *Cache) Name() string {
+	return c.name
+}
+
+// Stats returns the statistics of GetInto and Delete, the expired keys are not counted as evictions
+func (c *Cache) Stats() stats.Stats {
+	return c.stats.Snapshot()
+}
+
+// Len always returns -1, counting the keys with the prefix requires scanning the whole redis
+func (c *Cache) Len() int {
+	return -1
+}
+
+// Inspect returns the cached value of the key, the value is decoded into interface{}
+func (c *Cache) Inspect(ctx context.Context, key string) (interface{}, bool) {
+	var value interface{}
+	if err := c.codec.Get(ctx, c.genKey(key), &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// Evict deletes the key from the cache
+func (c *Cache) Evict(ctx context.Context, key string) error {
+	return c.Delete(gopkgcache.NewStringKey(key))
+}
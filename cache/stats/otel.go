@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meterName is the instrumentation name of the cache meter
+const meterName = "github.com/fengzhongzhu1621/xgo/cache"
+
+// RegisterOtelMeter registers observable instruments of all registered caches to the meter,
+// if meter is nil, the global meter provider is used.
+// the returned Registration can be used to unregister the callback
+func RegisterOtelMeter(meter metric.Meter) (metric.Registration, error) {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter(meterName)
+	}
+
+	hits, err := meter.Int64ObservableCounter("cache.hits",
+		metric.WithDescription("number of cache hits"))
+	if err != nil {
+		return nil, err
+	}
+	misses, err := meter.Int64ObservableCounter("cache.misses",
+		metric.WithDescription("number of cache misses"))
+	if err != nil {
+		return nil, err
+	}
+	retrieves, err := meter.Int64ObservableCounter("cache.retrieves",
+		metric.WithDescription("number of calls to the retrieve function"))
+	if err != nil {
+		return nil, err
+	}
+	retrieveErrors, err := meter.Int64ObservableCounter("cache.retrieve.errors",
+		metric.WithDescription("number of failed calls to the retrieve function"))
+	if err != nil {
+		return nil, err
+	}
+	retrieveDuration, err := meter.Float64ObservableCounter("cache.retrieve.duration",
+		metric.WithDescription("total duration of the retrieve function"), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	shared, err := meter.Int64ObservableCounter("cache.retrieve.shared",
+		metric.WithDescription("number of retrieves shared by singleflight"))
+	if err != nil {
+		return nil, err
+	}
+	evictions, err := meter.Int64ObservableCounter("cache.evictions",
+		metric.WithDescription("number of evicted items"))
+	if err != nil {
+		return nil, err
+	}
+	items, err := meter.Int64ObservableGauge("cache.items",
+		metric.WithDescription("number of items in the cache"))
+	if err != nil {
+		return nil, err
+	}
+	hitRatio, err := meter.Float64ObservableGauge("cache.hit_ratio",
+		metric.WithDescription("hits / (hits + misses)"))
+	if err != nil {
+		return nil, err
+	}
+
+	return meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		Each(func(name string, c Inspector) {
+			attrs := metric.WithAttributes(attribute.String("cache", name))
+			s := c.Stats()
+
+			o.ObserveInt64(hits, int64(s.Hits), attrs)
+			o.ObserveInt64(misses, int64(s.Misses), attrs)
+			o.ObserveInt64(retrieves, int64(s.Retrieves), attrs)
+			o.ObserveInt64(retrieveErrors, int64(s.RetrieveErrors), attrs)
+			o.ObserveFloat64(retrieveDuration, s.RetrieveDuration.Seconds(), attrs)
+			o.ObserveInt64(shared, int64(s.SharedRetrieves), attrs)
+			o.ObserveInt64(evictions, int64(s.Evictions), attrs)
+			o.ObserveFloat64(hitRatio, s.HitRatio(), attrs)
+			if n := c.Len(); n >= 0 {
+				o.ObserveInt64(items, int64(n), attrs)
+			}
+		})
+		return nil
+	}, hits, misses, retrieves, retrieveErrors, retrieveDuration, shared, evictions, items, hitRatio)
+}
@@ -0,0 +1,68 @@
+package stats
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Inspector is implemented by the caches which can be inspected by operators
+type Inspector interface {
+	// Stats returns the statistics of the cache
+	Stats() Stats
+	// Len returns the number of items in the cache, -1 if unknown
+	Len() int
+	// Inspect returns the cached value of the key without calling the retrieve function
+	Inspect(ctx context.Context, key string) (interface{}, bool)
+	// Evict deletes the key from the cache
+	Evict(ctx context.Context, key string) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Inspector{}
+)
+
+// Register registers a named cache, the cache with the same name will be replaced
+func Register(name string, c Inspector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = c
+}
+
+// Unregister removes a named cache
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, name)
+}
+
+// Get returns the named cache
+func Get(name string) (Inspector, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := registry[name]
+	return c, ok
+}
+
+// Names returns the sorted names of the registered caches
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Each calls fn for every registered cache in name order
+func Each(fn func(name string, c Inspector)) {
+	for _, name := range Names() {
+		if c, ok := Get(name); ok {
+			fn(name, c)
+		}
+	}
+}
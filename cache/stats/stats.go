@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the statistics of a cache
+type Stats struct {
+	// 命中次数，包括命中空缓存
+	Hits uint64 `json:"hits"`
+	// 未命中次数
+	Misses uint64 `json:"misses"`
+	// 回源次数，singleflight 共享的调用只计一次
+	Retrieves uint64 `json:"retrieves"`
+	// 回源失败次数
+	RetrieveErrors uint64 `json:"retrieve_errors"`
+	// 回源总耗时
+	RetrieveDuration time.Duration `json:"retrieve_duration"`
+	// 通过 singleflight 共享其它调用回源结果的次数
+	SharedRetrieves uint64 `json:"shared_retrieves"`
+	// 被删除或过期淘汰的次数
+	Evictions uint64 `json:"evictions"`
+}
+
+// HitRatio returns hits / (hits + misses), 0 if no request
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// AvgRetrieveDuration returns the average duration of the retrieves
+func (s Stats) AvgRetrieveDuration() time.Duration {
+	if s.Retrieves == 0 {
+		return 0
+	}
+	return s.RetrieveDuration / time.Duration(s.Retrieves)
+}
+
+// Collector collects the statistics of a cache, it is safe for concurrent use
+type Collector struct {
+	hits             atomic.Uint64
+	misses           atomic.Uint64
+	retrieves        atomic.Uint64
+	retrieveErrors   atomic.Uint64
+	retrieveDuration atomic.Int64
+	sharedRetrieves  atomic.Uint64
+	evictions        atomic.Uint64
+}
+
+// NewCollector create a collector
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Hit records a cache hit
+func (c *Collector) Hit() {
+	c.hits.Add(1)
+}
+
+// Miss records a cache miss
+func (c *Collector) Miss() {
+	c.misses.Add(1)
+}
+
+// ObserveRetrieve records a retrieve, shared means the result is shared from other caller by singleflight
+func (c *Collector) ObserveRetrieve(duration time.Duration, err error, shared bool) {
+	if shared {
+		c.sharedRetrieves.Add(1)
+		return
+	}
+
+	c.retrieves.Add(1)
+	c.retrieveDuration.Add(int64(duration))
+	if err != nil {
+		c.retrieveErrors.Add(1)
+	}
+}
+
+// Evict records an eviction
+func (c *Collector) Evict() {
+	c.evictions.Add(1)
+}
+
+// Snapshot returns the current statistics
+func (c *Collector) Snapshot() Stats {
+	return Stats{
+		Hits:             c.hits.Load(),
+		Misses:           c.misses.Load(),
+		Retrieves:        c.retrieves.Load(),
+		RetrieveErrors:   c.retrieveErrors.Load(),
+		RetrieveDuration: time.Duration(c.retrieveDuration.Load()),
+		SharedRetrieves:  c.sharedRetrieves.Load(),
+		Evictions:        c.evictions.Load(),
+	}
+}
@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	assert.Equal(t, float64(0), c.Snapshot().HitRatio())
+
+	c.Hit()
+	c.Hit()
+	c.Hit()
+	c.Miss()
+	c.ObserveRetrieve(10*time.Millisecond, nil, false)
+	c.ObserveRetrieve(30*time.Millisecond, errors.New("db error"), false)
+	c.ObserveRetrieve(0, nil, true)
+	c.Evict()
+
+	s := c.Snapshot()
+	assert.Equal(t, Stats{
+		Hits:             3,
+		Misses:           1,
+		Retrieves:        2,
+		RetrieveErrors:   1,
+		RetrieveDuration: 40 * time.Millisecond,
+		SharedRetrieves:  1,
+		Evictions:        1,
+	}, s)
+	assert.Equal(t, 0.75, s.HitRatio())
+	assert.Equal(t, 20*time.Millisecond, s.AvgRetrieveDuration())
+}
+
+type fakeInspector struct {
+	Collector
+}
+
+func (f *fakeInspector) Stats() Stats { return f.Snapshot() }
+
+func (f *fakeInspector) Len() int { return -1 }
+
+func (f *fakeInspector) Inspect(ctx context.Context, key string) (interface{}, bool) {
+	return nil, false
+}
+
+func (f *fakeInspector) Evict(ctx context.Context, key string) error { return nil }
+
+func TestRegistry(t *testing.T) {
+	a, b := &fakeInspector{}, &fakeInspector{}
+	Register("test-stats-b", b)
+	Register("test-stats-a", a)
+	defer Unregister("test-stats-a")
+	defer Unregister("test-stats-b")
+
+	got, ok := Get("test-stats-a")
+	assert.True(t, ok)
+	assert.Same(t, a, got)
+
+	var names []string
+	Each(func(name string, c Inspector) {
+		if name == "test-stats-a" || name == "test-stats-b" {
+			names = append(names, name)
+		}
+	})
+	assert.Equal(t, []string{"test-stats-a", "test-stats-b"}, names)
+
+	Unregister("test-stats-a")
+	_, ok = Get("test-stats-a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,346 @@
+package typed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	"github.com/fengzhongzhu1621/xgo/cache/redis"
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrNotFound is the error cached for the keys missing in the result of BatchRetrieveFunc,
+// RetrieveFunc returns it for a missing key so that GetMany omits the key instead of failing
+var ErrNotFound = cache.ErrKeyNotFound
+
+// RetrieveFunc retrieves the value of the key from database, redis, apis, etc.
+type RetrieveFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// BatchRetrieveFunc retrieves the values of the keys in one call,
+// the keys missing in the result will be treated as not found
+type BatchRetrieveFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Cache is a type-safe cache, reads from the store, if missing, calls the retrieveFunc
+type Cache[K comparable, V any] struct {
+	store Store[V]
+
+	// 一个布尔值，用于指示缓存是否被禁用。
+	disabled bool
+	// 根据 key 获取 value 的函数
+	retrieveFunc RetrieveFunc[K, V]
+	// 根据多个 key 批量获取 value 的函数，为 nil 时逐个调用 retrieveFunc
+	batchRetrieveFunc BatchRetrieveFunc[K, V]
+	// 用于防止缓存击穿
+	g singleflight.Group
+	// 用于指示是否启用空缓存机制
+	withEmptyCache bool
+	// 空缓存的过期时间
+	emptyCacheExpireDuration time.Duration
+	// 正常值的过期时间，0 表示使用 store 的默认过期时间
+	expiration time.Duration
+	// 命中率、回源次数和耗时等统计
+	stats *stats.Collector
+}
+
+var _ stats.Inspector = (*Cache[string, int])(nil)
+
+// options 可选参数，与类型参数无关，调用方不需要显式指定 K 和 V
+type options struct {
+	disabled                 bool
+	withEmptyCache           bool
+	emptyCacheExpireDuration time.Duration
+	expiration               time.Duration
+}
+
+// Option is the option of the typed cache
+type Option func(*options)
+
+// WithNoCache disables the cache, always calls the retrieveFunc
+func WithNoCache() Option {
+	return func(o *options) {
+		o.disabled = true
+	}
+}
+
+// WithEmptyCache will set the key a negative cache if retrieve fail from retrieveFunc
+func WithEmptyCache(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout == 0 {
+			timeout = cache.EmptyCacheExpiration
+		}
+		o.withEmptyCache = true
+		o.emptyCacheExpireDuration = timeout
+	}
+}
+
+// WithExpiration set the expiration of the value, default is the expiration of the store
+func WithExpiration(expiration time.Duration) Option {
+	return func(o *options) {
+		o.expiration = expiration
+	}
+}
+
+// New create a typed cache
+func New[K comparable, V any](store Store[V], retrieveFunc RetrieveFunc[K, V], opts ...Option) *Cache[K, V] {
+	return NewBatch(store, retrieveFunc, nil, opts...)
+}
+
+// NewBatch create a typed cache with a batch retrieve function used by GetMany
+func NewBatch[K comparable, V any](
+	store Store[V],
+	retrieveFunc RetrieveFunc[K, V],
+	batchRetrieveFunc BatchRetrieveFunc[K, V],
+	opts ...Option,
+) *Cache[K, V] {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Cache[K, V]{
+		store:                    store,
+		disabled:                 o.disabled,
+		retrieveFunc:             retrieveFunc,
+		batchRetrieveFunc:        batchRetrieveFunc,
+		withEmptyCache:           o.withEmptyCache,
+		emptyCacheExpireDuration: o.emptyCacheExpireDuration,
+		expiration:               o.expiration,
+		stats:                    stats.NewCollector(),
+	}
+}
+
+// NewMemoryCache create a typed cache with go-cache memory backend, the cache is registered to cache/stats by name
+func NewMemoryCache[K comparable, V any](
+	name string,
+	retrieveFunc RetrieveFunc[K, V],
+	expiration time.Duration,
+	randomExtraExpirationFunc cache.RandomExtraExpirationDurationFunc,
+	opts ...Option,
+) *Cache[K, V] {
+	b := backend.NewMemoryBackend(name, expiration, randomExtraExpirationFunc)
+	c := New(NewMemoryStore[V](b), retrieveFunc, opts...)
+	stats.Register(name, c)
+	return c
+}
+
+// NewRedisCache create a typed cache with the default redis client, the cache is registered to cache/stats by name
+func NewRedisCache[K comparable, V any](
+	name string,
+	retrieveFunc RetrieveFunc[K, V],
+	expiration time.Duration,
+	opts ...Option,
+) *Cache[K, V] {
+	c := New(NewRedisStore[V](redis.NewCache(name, expiration)), retrieveFunc, opts...)
+	stats.Register(name, c)
+	return c
+}
+
+// genKey 将 K 转换为 store 中的 key
+func (c *Cache[K, V]) genKey(key K) string {
+	switch k := any(key).(type) {
+	case string:
+		return k
+	case cache.Key:
+		return k.Key()
+	default:
+		return fmt.Sprint(key)
+	}
+}
+
+// Get will get the key from cache, if missing, will call the retrieveFunc to get the data, add to cache, then return
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	if c.disabled {
+		return c.retrieveFunc(ctx, key)
+	}
+
+	k := c.genKey(key)
+	if entry, ok := c.store.Get(ctx, k); ok {
+		c.stats.Hit()
+		if entry.Empty {
+			var zero V
+			return zero, entry.Err
+		}
+		return entry.Value, nil
+	}
+	c.stats.Miss()
+
+	return c.doRetrieve(ctx, key, k)
+}
+
+// doRetrieve 缓存不存在时获取值，并缓存执行结果
+func (c *Cache[K, V]) doRetrieve(ctx context.Context, key K, k string) (V, error) {
+	start, executed := time.Now(), false
+	value, err, _ := c.g.Do(k, func() (interface{}, error) {
+		executed = true
+		return c.retrieveFunc(ctx, key)
+	})
+	c.stats.ObserveRetrieve(time.Since(start), err, !executed)
+	if err != nil {
+		if c.withEmptyCache {
+			c.setEmpty(ctx, k, err)
+		}
+		var zero V
+		return zero, err
+	}
+
+	// V 为接口类型时 retrieveFunc 可能返回 nil，此时断言失败，使用零值
+	v, _ := value.(V)
+	c.set(ctx, k, v)
+	return v, nil
+}
+
+// GetMany gets the values of the keys, the misses will be retrieved by the batchRetrieveFunc in one call,
+// the keys not found or cached as empty are omitted from the result
+func (c *Cache[K, V]) GetMany(ctx context.Context, keys []K) (map[K]V, error) {
+	result := make(map[K]V, len(keys))
+
+	misses := keys
+	if !c.disabled {
+		misses = make([]K, 0, len(keys))
+		for _, key := range keys {
+			entry, ok := c.store.Get(ctx, c.genKey(key))
+			if !ok {
+				misses = append(misses, key)
+				continue
+			}
+			c.stats.Hit()
+			if !entry.Empty {
+				result[key] = entry.Value
+			}
+		}
+	}
+	if len(misses) == 0 {
+		return result, nil
+	}
+
+	// 没有批量获取函数时逐个获取，不存在的 key 和批量获取一样不出现在结果中
+	if c.batchRetrieveFunc == nil {
+		for _, key := range misses {
+			value, err := c.Get(ctx, key)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			result[key] = value
+		}
+		return result, nil
+	}
+
+	for range misses {
+		c.stats.Miss()
+	}
+	start := time.Now()
+	values, err := c.batchRetrieveFunc(ctx, misses)
+	c.stats.ObserveRetrieve(time.Since(start), err, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range misses {
+		value, ok := values[key]
+		if !ok {
+			if c.withEmptyCache && !c.disabled {
+				c.setEmpty(ctx, c.genKey(key), ErrNotFound)
+			}
+			continue
+		}
+
+		result[key] = value
+		if !c.disabled {
+			c.set(ctx, c.genKey(key), value)
+		}
+	}
+	return result, nil
+}
+
+// Set will set key-value into cache.
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V) error {
+	return c.store.Set(ctx, c.genKey(key), Entry[V]{Value: value}, c.expiration)
+}
+
+// Delete deletes the value from the cache for the given key.
+func (c *Cache[K, V]) Delete(ctx context.Context, key K) error {
+	// store 自己统计时不重复计数
+	if _, ok := c.store.(backend.StatsBackend); !ok {
+		c.stats.Evict()
+	}
+	return c.store.Delete(ctx, c.genKey(key))
+}
+
+// Exists returns true if the cache has a value for the given key, including the empty cache.
+func (c *Cache[K, V]) Exists(ctx context.Context, key K) bool {
+	_, ok := c.store.Get(ctx, c.genKey(key))
+	return ok
+}
+
+// DirectGet will get key from cache, without calling the retrieveFunc
+func (c *Cache[K, V]) DirectGet(ctx context.Context, key K) (V, bool) {
+	entry, ok := c.store.Get(ctx, c.genKey(key))
+	if !ok || entry.Empty {
+		var zero V
+		return zero, false
+	}
+	return entry.Value, true
+}
+
+// Disabled returns true if the cache is disabled.
+func (c *Cache[K, V]) Disabled() bool {
+	return c.disabled
+}
+
+// Stats returns the statistics of the cache, the evictions include the expired items if the store reports them
+func (c *Cache[K, V]) Stats() stats.Stats {
+	s := c.stats.Snapshot()
+	if b, ok := c.store.(backend.StatsBackend); ok {
+		s.Evictions += b.Evictions()
+	}
+	return s
+}
+
+// Len returns the number of items in the store, -1 if the store does not report it
+func (c *Cache[K, V]) Len() int {
+	if b, ok := c.store.(backend.StatsBackend); ok {
+		return b.Len()
+	}
+	return -1
+}
+
+// Inspect returns the cached value of the store key without calling the retrieveFunc
+func (c *Cache[K, V]) Inspect(ctx context.Context, key string) (interface{}, bool) {
+	entry, ok := c.store.Get(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	if entry.Empty {
+		return cache.EmptyCache{Err: entry.Err}, true
+	}
+	return entry.Value, true
+}
+
+// Evict deletes the store key from the cache
+func (c *Cache[K, V]) Evict(ctx context.Context, key string) error {
+	if _, ok := c.store.(backend.StatsBackend); !ok {
+		c.stats.Evict()
+	}
+	return c.store.Delete(ctx, key)
+}
+
+// set 写入缓存，写入失败不影响调用方
+func (c *Cache[K, V]) set(ctx context.Context, k string, value V) {
+	if err := c.store.Set(ctx, k, Entry[V]{Value: value}, c.expiration); err != nil {
+		log.Errorf("typed cache set fail, key=%s, err=%s", k, err)
+	}
+}
+
+// setEmpty 写入空缓存，使用较短的过期时间
+func (c *Cache[K, V]) setEmpty(ctx context.Context, k string, err error) {
+	if setErr := c.store.Set(ctx, k, Entry[V]{Empty: true, Err: err}, c.emptyCacheExpireDuration); setErr != nil {
+		log.Errorf("typed cache set empty cache fail, key=%s, err=%s", k, setErr)
+	}
+}
@@ -0,0 +1,297 @@
+package typed
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	"github.com/fengzhongzhu1621/xgo/cache/redis"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID   int64
+	Name string
+	Tags []string
+}
+
+type userKey struct {
+	ID int64
+}
+
+func (k userKey) Key() string {
+	return "user:" + strconv.FormatInt(k.ID, 10)
+}
+
+// stores 返回需要覆盖的所有存储实现
+func stores(t *testing.T) map[string]func() Store[user] {
+	mr := miniredis.RunT(t)
+	cli := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cli.Close() })
+
+	return map[string]func() Store[user]{
+		"memory": func() Store[user] {
+			return NewMemoryStore[user](backend.NewMemoryBackend("test", time.Minute, nil))
+		},
+		"redis": func() Store[user] {
+			mr.FlushAll()
+			return NewRedisStore[user](redis.NewCacheWithClient("test", cli, time.Minute))
+		},
+	}
+}
+
+func TestCacheGet(t *testing.T) {
+	for name, newStore := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			var count int32
+			c := New(newStore(), func(ctx context.Context, key int64) (user, error) {
+				atomic.AddInt32(&count, 1)
+				return user{ID: key, Name: "alice", Tags: []string{"a"}}, nil
+			})
+			ctx := context.Background()
+
+			u, err := c.Get(ctx, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, user{ID: 1, Name: "alice", Tags: []string{"a"}}, u)
+
+			u, err = c.Get(ctx, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, "alice", u.Name)
+			assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+			cached, ok := c.DirectGet(ctx, 1)
+			assert.True(t, ok)
+			assert.Equal(t, int64(1), cached.ID)
+
+			assert.NoError(t, c.Delete(ctx, 1))
+			assert.False(t, c.Exists(ctx, 1))
+
+			assert.NoError(t, c.Set(ctx, 2, user{ID: 2, Name: "bob"}))
+			u, err = c.Get(ctx, 2)
+			assert.NoError(t, err)
+			assert.Equal(t, "bob", u.Name)
+			assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+		})
+	}
+}
+
+func TestCacheEmptyCache(t *testing.T) {
+	errNotFound := errors.New("user not found")
+
+	for name, newStore := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			var count int32
+			c := New(newStore(), func(ctx context.Context, key userKey) (user, error) {
+				atomic.AddInt32(&count, 1)
+				return user{}, errNotFound
+			}, WithEmptyCache(time.Minute))
+			ctx := context.Background()
+
+			_, err := c.Get(ctx, userKey{ID: 1})
+			assert.ErrorIs(t, err, errNotFound)
+
+			_, err = c.Get(ctx, userKey{ID: 1})
+			assert.Error(t, err)
+			assert.Equal(t, errNotFound.Error(), err.Error())
+			assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+			assert.True(t, c.Exists(ctx, userKey{ID: 1}))
+			_, ok := c.DirectGet(ctx, userKey{ID: 1})
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestCacheGetMany(t *testing.T) {
+	for name, newStore := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			var calls [][]int64
+			var mu sync.Mutex
+			c := NewBatch(newStore(),
+				func(ctx context.Context, key int64) (user, error) {
+					return user{ID: key}, nil
+				},
+				func(ctx context.Context, keys []int64) (map[int64]user, error) {
+					mu.Lock()
+					calls = append(calls, keys)
+					mu.Unlock()
+
+					result := make(map[int64]user)
+					for _, key := range keys {
+						// 偶数 id 不存在
+						if key%2 == 1 {
+							result[key] = user{ID: key}
+						}
+					}
+					return result, nil
+				},
+				WithEmptyCache(time.Minute),
+			)
+			ctx := context.Background()
+
+			assert.NoError(t, c.Set(ctx, 1, user{ID: 1, Name: "cached"}))
+
+			result, err := c.GetMany(ctx, []int64{1, 2, 3, 4, 5})
+			assert.NoError(t, err)
+			assert.Len(t, result, 3)
+			assert.Equal(t, "cached", result[1].Name)
+			assert.Equal(t, int64(5), result[5].ID)
+
+			// 命中的 key 不会回源，只有一次批量回源
+			assert.Len(t, calls, 1)
+			keys := calls[0]
+			sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+			assert.Equal(t, []int64{2, 3, 4, 5}, keys)
+
+			// 不存在的 key 被负缓存
+			_, err = c.Get(ctx, 2)
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			result, err = c.GetMany(ctx, []int64{1, 2, 3, 4, 5})
+			assert.NoError(t, err)
+			assert.Len(t, result, 3)
+			assert.Len(t, calls, 1)
+		})
+	}
+}
+
+func TestCacheGetManyWithoutBatchRetrieve(t *testing.T) {
+	errUnavailable := errors.New("db unavailable")
+	for name, newStore := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := New(newStore(), func(ctx context.Context, key int64) (user, error) {
+				switch key {
+				case 2:
+					return user{}, ErrNotFound
+				case 4:
+					return user{}, errUnavailable
+				}
+				return user{ID: key}, nil
+			})
+			ctx := context.Background()
+
+			// 逐个获取时不存在的 key 同样不出现在结果中
+			result, err := c.GetMany(ctx, []int64{1, 2, 3})
+			assert.NoError(t, err)
+			assert.Equal(t, map[int64]user{1: {ID: 1}, 3: {ID: 3}}, result)
+
+			_, err = c.GetMany(ctx, []int64{1, 4})
+			assert.ErrorIs(t, err, errUnavailable)
+		})
+	}
+}
+
+func TestRedisStoreEmptyRoundTrip(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cli.Close() })
+	ctx := context.Background()
+
+	errUnavailable := errors.New("db unavailable")
+	writer := NewRedisStore[user](redis.NewCacheWithClient("test", cli, time.Minute))
+	assert.NoError(t, writer.Set(ctx, "missing", Entry[user]{Empty: true, Err: ErrNotFound}, time.Minute))
+	assert.NoError(t, writer.Set(ctx, "failed", Entry[user]{Empty: true, Err: errUnavailable}, time.Minute))
+
+	// 其他进程读到的空缓存保留是否为 ErrNotFound
+	reader := NewRedisStore[user](redis.NewCacheWithClient("test", cli, time.Minute))
+	entry, ok := reader.Get(ctx, "missing")
+	assert.True(t, ok)
+	assert.True(t, entry.Empty)
+	assert.ErrorIs(t, entry.Err, ErrNotFound)
+	assert.ErrorIs(t, entry.Err, cache.ErrKeyNotFound)
+
+	entry, ok = reader.Get(ctx, "failed")
+	assert.True(t, ok)
+	assert.NotErrorIs(t, entry.Err, ErrNotFound)
+	assert.Equal(t, errUnavailable.Error(), entry.Err.Error())
+}
+
+func TestCacheDisabled(t *testing.T) {
+	var count int32
+	c := New(NewMemoryStore[string](backend.NewMemoryBackend("test", time.Minute, nil)),
+		func(ctx context.Context, key string) (string, error) {
+			atomic.AddInt32(&count, 1)
+			return key + "-value", nil
+		}, WithNoCache())
+	ctx := context.Background()
+
+	assert.True(t, c.Disabled())
+	for i := 0; i < 3; i++ {
+		v, err := c.Get(ctx, "k")
+		assert.NoError(t, err)
+		assert.Equal(t, "k-value", v)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+	assert.False(t, c.Exists(ctx, "k"))
+}
+
+func TestMemoryStoreTypeMismatch(t *testing.T) {
+	b := backend.NewMemoryBackend("test", time.Minute, nil)
+	b.Set("k", "not an int", 0)
+
+	c := New(NewMemoryStore[int](b), func(ctx context.Context, key string) (int, error) {
+		return 100, nil
+	})
+
+	// 类型不一致时回源并覆盖
+	v, err := c.Get(context.Background(), "k")
+	assert.NoError(t, err)
+	assert.Equal(t, 100, v)
+
+	value, ok := b.Get("k")
+	assert.True(t, ok)
+	assert.Equal(t, 100, value)
+}
+
+type named interface {
+	Name() string
+}
+
+func TestCacheNilInterfaceValue(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cli.Close() })
+
+	for name, store := range map[string]Store[named]{
+		"memory": NewMemoryStore[named](backend.NewMemoryBackend("test", time.Minute, nil)),
+		"redis":  NewRedisStore[named](redis.NewCacheWithClient("test", cli, time.Minute)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var count int32
+			c := New(store, func(ctx context.Context, key string) (named, error) {
+				atomic.AddInt32(&count, 1)
+				return nil, nil
+			})
+			ctx := context.Background()
+
+			// 接口类型的 nil 值是合法的获取结果，同样被缓存
+			for i := 0; i < 2; i++ {
+				v, err := c.Get(ctx, "nil")
+				assert.NoError(t, err)
+				assert.Nil(t, v)
+			}
+			assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+		})
+	}
+}
+
+func TestCacheKeyConversion(t *testing.T) {
+	b := backend.NewMemoryBackend("test", time.Minute, nil)
+	c := New(NewMemoryStore[int](b), func(ctx context.Context, key cache.StringKey) (int, error) {
+		return 1, nil
+	})
+
+	_, err := c.Get(context.Background(), cache.NewStringKey("k"))
+	assert.NoError(t, err)
+	_, ok := b.Get("k")
+	assert.True(t, ok)
+}
@@ -0,0 +1,154 @@
+package typed
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/memory/backend"
+	"github.com/fengzhongzhu1621/xgo/cache/redis"
+	gocache "github.com/go-redis/cache/v8"
+	goredis "github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+)
+
+// Entry is a typed cache entry, Empty means the entry is a negative cache of Err
+type Entry[V any] struct {
+	Value V
+	// 是否为空缓存（负缓存）
+	Empty bool
+	// 空缓存对应的错误
+	Err error
+}
+
+// Store is the typed storage of the cache
+type Store[V any] interface {
+	// Get returns the entry of the key, the bool is false if the key is missing
+	Get(ctx context.Context, key string) (Entry[V], bool)
+	// Set sets the entry with the ttl, 0 means the default expiration of the store
+	Set(ctx context.Context, key string, entry Entry[V], ttl time.Duration) error
+	// Delete deletes the key
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryStore is a typed store on top of backend.Backend
+type MemoryStore[V any] struct {
+	backend backend.Backend
+}
+
+var (
+	_ Store[int]           = (*MemoryStore[int])(nil)
+	_ backend.StatsBackend = (*MemoryStore[int])(nil)
+)
+
+// NewMemoryStore create a typed store with memory backend, e.g. backend.NewMemoryBackend
+func NewMemoryStore[V any](b backend.Backend) *MemoryStore[V] {
+	return &MemoryStore[V]{backend: b}
+}
+
+// Get returns the entry of the key
+func (s *MemoryStore[V]) Get(ctx context.Context, key string) (Entry[V], bool) {
+	value, ok := s.backend.Get(key)
+	if !ok {
+		return Entry[V]{}, false
+	}
+
+	// 先判断空缓存，V 为接口类型时也能正确区分
+	switch v := value.(type) {
+	case cache.EmptyCache:
+		return Entry[V]{Empty: true, Err: v.Err}, true
+	case V:
+		return Entry[V]{Value: v}, true
+	case nil:
+		// V 为接口类型时缓存的 nil 值
+		return Entry[V]{}, true
+	default:
+		// 类型不一致时视为未命中，由调用方回源后覆盖
+		log.Errorf("typed cache got unexpected type from backend, key=%s, value=%T", key, value)
+		return Entry[V]{}, false
+	}
+}
+
+// Set sets the entry with the ttl
+func (s *MemoryStore[V]) Set(ctx context.Context, key string, entry Entry[V], ttl time.Duration) error {
+	if entry.Empty {
+		s.backend.Set(key, cache.EmptyCache{Err: entry.Err}, ttl)
+	} else {
+		s.backend.Set(key, entry.Value, ttl)
+	}
+	return nil
+}
+
+// Len returns the number of items in the backend, -1 if the backend does not report it
+func (s *MemoryStore[V]) Len() int {
+	if b, ok := s.backend.(backend.StatsBackend); ok {
+		return b.Len()
+	}
+	return -1
+}
+
+// Evictions returns the number of items deleted or expired from the backend
+func (s *MemoryStore[V]) Evictions() uint64 {
+	if b, ok := s.backend.(backend.StatsBackend); ok {
+		return b.Evictions()
+	}
+	return 0
+}
+
+// Delete deletes the key
+func (s *MemoryStore[V]) Delete(ctx context.Context, key string) error {
+	return s.backend.Delete(key)
+}
+
+// redisEntry 是写入 redis 的数据格式，直接反序列化为 V，不需要反射复制
+type redisEntry[V any] struct {
+	Value V      `msgpack:"v,omitempty"`
+	Empty bool   `msgpack:"e,omitempty"`
+	Err   string `msgpack:"r,omitempty"`
+	// 空缓存的错误是否为 ErrNotFound
+	NotFound bool `msgpack:"n,omitempty"`
+}
+
+// RedisStore is a typed store on top of redis.Cache
+type RedisStore[V any] struct {
+	cache *redis.Cache
+}
+
+var _ Store[int] = (*RedisStore[int])(nil)
+
+// NewRedisStore create a typed store with redis cache
+func NewRedisStore[V any](c *redis.Cache) *RedisStore[V] {
+	return &RedisStore[V]{cache: c}
+}
+
+// Get returns the entry of the key
+func (s *RedisStore[V]) Get(ctx context.Context, key string) (Entry[V], bool) {
+	var entry redisEntry[V]
+	if err := s.cache.Get(cache.NewStringKey(key), &entry); err != nil {
+		if !errors.Is(err, gocache.ErrCacheMiss) && !errors.Is(err, goredis.Nil) {
+			log.Errorf("typed cache get from redis fail, key=%s, err=%s", key, err)
+		}
+		return Entry[V]{}, false
+	}
+
+	if entry.Empty {
+		return Entry[V]{Empty: true, Err: cache.NewEmptyCacheError(entry.Err, entry.NotFound)}, true
+	}
+	return Entry[V]{Value: entry.Value}, true
+}
+
+// Set sets the entry with the ttl
+func (s *RedisStore[V]) Set(ctx context.Context, key string, entry Entry[V], ttl time.Duration) error {
+	value := redisEntry[V]{Value: entry.Value, Empty: entry.Empty}
+	if entry.Empty && entry.Err != nil {
+		value.Err = entry.Err.Error()
+		value.NotFound = errors.Is(entry.Err, ErrNotFound)
+	}
+	return s.cache.Set(cache.NewStringKey(key), &value, ttl)
+}
+
+// Delete deletes the key
+func (s *RedisStore[V]) Delete(ctx context.Context, key string) error {
+	return s.cache.Delete(cache.NewStringKey(key))
+}
@@ -0,0 +1,183 @@
+package concurrency
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// ILimit 并发上限算法，根据请求的耗时和结果调整并发上限
+type ILimit interface {
+	// Limit returns the current concurrency limit.
+	Limit() int
+	// Update adjusts the limit by a sample, inflight is the number of in-flight requests
+	// when the sample request started, dropped reports whether the request was dropped or timed out.
+	Update(rtt time.Duration, inflight int, dropped bool)
+}
+
+var (
+	_ ILimit = (*AIMDLimit)(nil)
+	_ ILimit = (*GradientLimit)(nil)
+	_ ILimit = FixedLimit(0)
+)
+
+// FixedLimit 固定的并发上限
+type FixedLimit int
+
+// Limit implements ILimit.
+func (l FixedLimit) Limit() int {
+	return int(l)
+}
+
+// Update implements ILimit.
+func (l FixedLimit) Update(time.Duration, int, bool) {}
+
+// AIMDLimit 加性增乘性减算法
+// 请求成功且并发上限被充分使用时每个样本增加 1/limit，即每个耗时周期上限加一，请求丢弃或者超时时上限乘以 BackoffRatio，
+// 上次缩减之前开始的请求已经反映在缩减中，它们的丢弃不再缩减上限，避免一次过载引起上限连续缩减
+type AIMDLimit struct {
+	opts *LimitOptions
+
+	mu           sync.Mutex
+	limit        float64
+	lastDecrease time.Time
+}
+
+// NewAIMDLimit creates an AIMD limit.
+func NewAIMDLimit(opt ...LimitOption) *AIMDLimit {
+	opts := defaultLimitOptions()
+	for _, o := range opt {
+		o(opts)
+	}
+	return &AIMDLimit{opts: opts, limit: opts.clamp(float64(opts.InitialLimit))}
+}
+
+// Limit implements ILimit.
+func (l *AIMDLimit) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Update implements ILimit.
+func (l *AIMDLimit) Update(rtt time.Duration, inflight int, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.opts.now()
+	if dropped || (l.opts.Timeout > 0 && rtt > l.opts.Timeout) {
+		if now.Add(-rtt).Before(l.lastDecrease) {
+			return
+		}
+		l.lastDecrease = now
+		l.limit = l.opts.clamp(math.Floor(l.limit * l.opts.BackoffRatio))
+		return
+	}
+	// 并发上限没有被充分使用时不增加，避免上限无限增长
+	if float64(inflight)*2 >= l.limit {
+		l.limit = l.opts.clamp(l.limit + 1/l.limit)
+	}
+}
+
+// GradientLimit 基于耗时梯度的算法
+// 样本按窗口聚合，每个窗口至少包含 MinSamples 个样本并且持续一个平均耗时，窗口的平均耗时为短期耗时，
+// 短期耗时的指数移动平均为长期耗时，长期耗时反映没有排队时的耗时，两者的比值即梯度：
+// 梯度小于 1 说明请求在排队，按梯度缩减并发上限；否则在当前上限的基础上增加 sqrt(limit) 的排队空间。
+// 上次更新之前开始的请求反映的是旧的并发上限，不计入新的窗口
+type GradientLimit struct {
+	opts *LimitOptions
+
+	mu      sync.Mutex
+	limit   float64
+	longRTT float64
+
+	windowStart    time.Time
+	windowRTT      time.Duration
+	windowSamples  int
+	windowInflight int
+	windowDropped  bool
+}
+
+// NewGradientLimit creates a gradient limit.
+func NewGradientLimit(opt ...LimitOption) *GradientLimit {
+	opts := defaultLimitOptions()
+	for _, o := range opt {
+		o(opts)
+	}
+	return &GradientLimit{
+		opts:        opts,
+		limit:       opts.clamp(float64(opts.InitialLimit)),
+		windowStart: opts.now(),
+	}
+}
+
+// Limit implements ILimit.
+func (l *GradientLimit) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Update implements ILimit.
+func (l *GradientLimit) Update(rtt time.Duration, inflight int, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.opts.now()
+	if now.Add(-rtt).Before(l.windowStart) {
+		return
+	}
+	l.windowRTT += rtt
+	l.windowSamples++
+	if inflight > l.windowInflight {
+		l.windowInflight = inflight
+	}
+	l.windowDropped = l.windowDropped || dropped
+
+	shortRTT := float64(l.windowRTT) / float64(l.windowSamples)
+	if l.windowSamples < l.opts.MinSamples || float64(now.Sub(l.windowStart)) < shortRTT {
+		return
+	}
+	l.update(math.Max(shortRTT, 1), l.windowInflight, l.windowDropped)
+
+	l.windowStart = now
+	l.windowRTT = 0
+	l.windowSamples = 0
+	l.windowInflight = 0
+	l.windowDropped = false
+}
+
+// update 根据一个窗口的短期耗时更新并发上限
+func (l *GradientLimit) update(shortRTT float64, inflight int, dropped bool) {
+	if l.longRTT == 0 {
+		l.longRTT = shortRTT
+	} else {
+		l.longRTT = ema(l.longRTT, shortRTT, l.opts.LongWindow)
+	}
+	// 长期耗时明显大于短期耗时说明负载已经下降，快速恢复长期耗时
+	if l.longRTT/shortRTT > 2 {
+		l.longRTT *= 0.95
+	}
+
+	gradient := 0.5
+	if !dropped {
+		gradient = math.Max(0.5, math.Min(1, l.opts.Tolerance*l.longRTT/shortRTT))
+	}
+	// 并发上限没有被充分使用时不增加
+	if gradient >= 1 && float64(inflight)*2 < l.limit {
+		return
+	}
+
+	newLimit := l.limit*gradient + math.Sqrt(l.limit)
+	newLimit = l.limit*(1-l.opts.Smoothing) + newLimit*l.opts.Smoothing
+	l.limit = l.opts.clamp(newLimit)
+}
+
+// ema 计算窗口为 window 的指数移动平均
+func ema(avg, sample float64, window int) float64 {
+	if window <= 1 {
+		return sample
+	}
+	factor := 2 / float64(window+1)
+	return avg*(1-factor) + sample*factor
+}
@@ -0,0 +1,140 @@
+package concurrency
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clock 模拟时钟
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func withClock(c *clock) LimitOption {
+	return func(o *LimitOptions) {
+		o.now = c.Now
+	}
+}
+
+// completion 模拟的请求
+type completion struct {
+	start, finish time.Time
+	inflight      int
+}
+
+// simulate 模拟一个可以同时处理 capacity 个请求的服务，超过的请求排队，耗时随请求开始时的并发数线性增加，
+// 客户端始终有 demand 个请求等待发送，返回每个请求结束时的并发上限
+func simulate(limit ILimit, c *clock, capacity, demand, requests int) []int {
+	const serviceTime = 10 * time.Millisecond
+
+	var (
+		pending  []completion
+		inflight int
+		limits   = make([]int, 0, requests)
+	)
+	for len(limits) < requests {
+		// 在并发上限内发送请求
+		for inflight < demand && inflight < limit.Limit() {
+			inflight++
+			rtt := serviceTime
+			if inflight > capacity {
+				rtt = serviceTime * time.Duration(inflight) / time.Duration(capacity)
+			}
+			pending = append(pending, completion{start: c.now, finish: c.now.Add(rtt), inflight: inflight})
+		}
+
+		// 最早结束的请求
+		sort.Slice(pending, func(i, j int) bool { return pending[i].finish.Before(pending[j].finish) })
+		done := pending[0]
+		pending = pending[1:]
+		inflight--
+		c.now = done.finish
+		limit.Update(done.finish.Sub(done.start), done.inflight, false)
+		limits = append(limits, limit.Limit())
+	}
+	return limits
+}
+
+func assertConverge(t *testing.T, limits []int, min, max int) {
+	// 忽略前半段的收敛过程，后半段的上限稳定在区间内
+	lo, hi := limits[len(limits)/2], limits[len(limits)/2]
+	for _, l := range limits[len(limits)/2:] {
+		if l < lo {
+			lo = l
+		}
+		if l > hi {
+			hi = l
+		}
+	}
+	assert.GreaterOrEqual(t, lo, min)
+	assert.LessOrEqual(t, hi, max)
+}
+
+func TestAIMDLimitConverge(t *testing.T) {
+	const capacity = 50
+
+	// 耗时超过 2 倍服务时间时视为超时
+	c := &clock{now: time.Now()}
+	limit := NewAIMDLimit(WithInitialLimit(10), WithTimeout(20*time.Millisecond), withClock(c))
+	limits := simulate(limit, c, capacity, 500, 20000)
+	assertConverge(t, limits, capacity, 2*capacity+5)
+
+	// 从过高的上限开始同样收敛
+	limit = NewAIMDLimit(WithInitialLimit(1000), WithTimeout(20*time.Millisecond), withClock(c))
+	limits = simulate(limit, c, capacity, 500, 20000)
+	assertConverge(t, limits, capacity, 2*capacity+5)
+}
+
+func TestGradientLimitConverge(t *testing.T) {
+	const capacity = 50
+
+	c := &clock{now: time.Now()}
+	limit := NewGradientLimit(WithInitialLimit(10), withClock(c))
+	limits := simulate(limit, c, capacity, 500, 20000)
+	assertConverge(t, limits, capacity, 3*capacity)
+
+	limit = NewGradientLimit(WithInitialLimit(1000), withClock(c))
+	limits = simulate(limit, c, capacity, 500, 20000)
+	assertConverge(t, limits, capacity, 3*capacity)
+}
+
+func TestLimitAppLimited(t *testing.T) {
+	// 请求量很小时不增加并发上限
+	c := &clock{now: time.Now()}
+	aimd := NewAIMDLimit(WithInitialLimit(20), withClock(c))
+	simulate(aimd, c, 50, 5, 1000)
+	assert.Equal(t, 20, aimd.Limit())
+
+	gradient := NewGradientLimit(WithInitialLimit(20), withClock(c))
+	simulate(gradient, c, 50, 5, 1000)
+	assert.Equal(t, 20, gradient.Limit())
+}
+
+func TestLimitDropped(t *testing.T) {
+	c := &clock{now: time.Now()}
+	aimd := NewAIMDLimit(WithInitialLimit(100), WithBackoffRatio(0.5), WithMinLimit(10), withClock(c))
+	aimd.Update(time.Millisecond, 100, true)
+	assert.Equal(t, 50, aimd.Limit())
+	// 上次缩减之前开始的请求不再缩减上限
+	aimd.Update(time.Millisecond, 100, true)
+	assert.Equal(t, 50, aimd.Limit())
+	for i := 0; i < 10; i++ {
+		c.now = c.now.Add(time.Second)
+		aimd.Update(time.Millisecond, 100, true)
+	}
+	assert.Equal(t, 10, aimd.Limit())
+
+	gradient := NewGradientLimit(WithInitialLimit(100), WithMaxLimit(100), WithMinSamples(1), withClock(c))
+	c.now = c.now.Add(time.Second)
+	gradient.Update(time.Millisecond, 100, true)
+	assert.Less(t, gradient.Limit(), 100)
+
+	assert.Equal(t, 8, FixedLimit(8).Limit())
+}
@@ -0,0 +1,106 @@
+package concurrency
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ILimiter 并发限制器，server transport 和 client transport 在处理请求前获取许可
+type ILimiter interface {
+	// Acquire returns a token if the number of in-flight requests does not exceed the limit,
+	// one of the methods of the token must be called when the request is finished.
+	Acquire() (IToken, bool)
+}
+
+// IToken 请求的执行许可，请求结束后调用其中一个方法释放许可
+type IToken interface {
+	// OnSuccess releases the token and records the latency of the request.
+	OnSuccess()
+	// OnDropped releases the token and reports the request was dropped or timed out.
+	OnDropped()
+	// OnIgnore releases the token without affecting the limit, such as the request failed fast.
+	OnIgnore()
+}
+
+// Stats 限制器的状态
+type Stats struct {
+	Name     string `json:"name"`
+	Limit    int    `json:"limit"`
+	Inflight int    `json:"inflight"`
+	Rejected uint64 `json:"rejected"`
+}
+
+var _ ILimiter = (*Limiter)(nil)
+
+// Limiter 自适应并发限制器，正在处理的请求数量超过 ILimit 给出的上限时拒绝请求
+type Limiter struct {
+	name     string
+	limit    ILimit
+	inflight atomic.Int64
+	rejected atomic.Uint64
+}
+
+// NewLimiter creates a limiter with the limit algorithm.
+func NewLimiter(name string, limit ILimit) *Limiter {
+	return &Limiter{name: name, limit: limit}
+}
+
+// Name returns the name of the limiter.
+func (l *Limiter) Name() string {
+	return l.name
+}
+
+// Acquire implements ILimiter.
+func (l *Limiter) Acquire() (IToken, bool) {
+	inflight := l.inflight.Add(1)
+	if inflight > int64(l.limit.Limit()) {
+		l.inflight.Add(-1)
+		l.rejected.Add(1)
+		return nil, false
+	}
+	return &token{l: l, start: time.Now(), inflight: int(inflight)}, true
+}
+
+// Stats returns the current state of the limiter.
+func (l *Limiter) Stats() Stats {
+	return Stats{
+		Name:     l.name,
+		Limit:    l.limit.Limit(),
+		Inflight: int(l.inflight.Load()),
+		Rejected: l.rejected.Load(),
+	}
+}
+
+// token 记录请求开始的时间和当时正在处理的请求数量
+type token struct {
+	l        *Limiter
+	start    time.Time
+	inflight int
+	once     sync.Once
+}
+
+// OnSuccess implements IToken.
+func (t *token) OnSuccess() {
+	t.release(func() { t.l.limit.Update(time.Since(t.start), t.inflight, false) })
+}
+
+// OnDropped implements IToken.
+func (t *token) OnDropped() {
+	t.release(func() { t.l.limit.Update(time.Since(t.start), t.inflight, true) })
+}
+
+// OnIgnore implements IToken.
+func (t *token) OnIgnore() {
+	t.release(nil)
+}
+
+// release 只释放一次许可
+func (t *token) release(update func()) {
+	t.once.Do(func() {
+		if update != nil {
+			update()
+		}
+		t.l.inflight.Add(-1)
+	})
+}
@@ -0,0 +1,68 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter("test", FixedLimit(2))
+
+	t1, ok := l.Acquire()
+	require.True(t, ok)
+	t2, ok := l.Acquire()
+	require.True(t, ok)
+	_, ok = l.Acquire()
+	assert.False(t, ok)
+	assert.Equal(t, Stats{Name: "test", Limit: 2, Inflight: 2, Rejected: 1}, l.Stats())
+
+	// 许可只释放一次
+	t1.OnSuccess()
+	t1.OnDropped()
+	assert.Equal(t, 1, l.Stats().Inflight)
+	t2.OnIgnore()
+	assert.Equal(t, 0, l.Stats().Inflight)
+
+	_, ok = l.Acquire()
+	assert.True(t, ok)
+}
+
+func TestLimiterUpdate(t *testing.T) {
+	limit := NewAIMDLimit(WithInitialLimit(10), WithTimeout(time.Hour))
+	l := NewLimiter("aimd", limit)
+
+	token, ok := l.Acquire()
+	require.True(t, ok)
+	token.OnDropped()
+	assert.Equal(t, 9, l.Stats().Limit)
+
+	// 忽略的请求不影响上限
+	token, ok = l.Acquire()
+	require.True(t, ok)
+	token.OnIgnore()
+	assert.Equal(t, 9, l.Stats().Limit)
+}
+
+func TestRegistry(t *testing.T) {
+	a := NewLimiter("a", FixedLimit(1))
+	b := NewLimiter("b", FixedLimit(2))
+	Register(b)
+	Register(a)
+	defer Unregister("a")
+	defer Unregister("b")
+
+	got, ok := Get("a")
+	assert.True(t, ok)
+	assert.Same(t, a, got)
+
+	var names []string
+	Each(func(l *Limiter) { names = append(names, l.Name()) })
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	Unregister("a")
+	_, ok = Get("a")
+	assert.False(t, ok)
+}
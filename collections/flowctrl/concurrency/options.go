@@ -0,0 +1,125 @@
+// Package concurrency implements adaptive concurrency limiting driven by the observed latency.
+package concurrency
+
+import "time"
+
+const (
+	defaultInitialLimit = 20
+	defaultMinLimit     = 1
+	defaultMaxLimit     = 1000
+	defaultBackoffRatio = 0.9
+	defaultTolerance    = 1.5
+	defaultSmoothing    = 0.2
+	defaultLongWindow   = 600
+	defaultMinSamples   = 10
+)
+
+// LimitOptions 并发上限算法的配置选项
+type LimitOptions struct {
+	InitialLimit int // 初始并发上限
+	MinLimit     int // 并发上限的最小值
+	MaxLimit     int // 并发上限的最大值
+
+	// AIMD
+	BackoffRatio float64       // 请求丢弃或超时时并发上限的缩减比例，取值 (0, 1)
+	Timeout      time.Duration // 请求耗时超过 Timeout 时视为丢弃，为 0 时不检查
+
+	// Gradient
+	Tolerance  float64 // 允许的排队耗时比例，短期耗时不超过长期耗时的 Tolerance 倍时不缩减并发上限
+	Smoothing  float64 // 新的并发上限的平滑系数，取值 (0, 1]
+	LongWindow int     // 长期耗时的指数移动平均窗口（更新次数）
+	MinSamples int     // 每次更新并发上限至少需要的样本数
+
+	now func() time.Time // 时钟，测试时替换
+}
+
+// LimitOption modifies the LimitOptions.
+type LimitOption func(*LimitOptions)
+
+func defaultLimitOptions() *LimitOptions {
+	return &LimitOptions{
+		InitialLimit: defaultInitialLimit,
+		MinLimit:     defaultMinLimit,
+		MaxLimit:     defaultMaxLimit,
+		BackoffRatio: defaultBackoffRatio,
+		Tolerance:    defaultTolerance,
+		Smoothing:    defaultSmoothing,
+		LongWindow:   defaultLongWindow,
+		MinSamples:   defaultMinSamples,
+		now:          time.Now,
+	}
+}
+
+// WithInitialLimit returns a LimitOption which sets the initial limit.
+func WithInitialLimit(limit int) LimitOption {
+	return func(o *LimitOptions) {
+		o.InitialLimit = limit
+	}
+}
+
+// WithMinLimit returns a LimitOption which sets the minimum limit.
+func WithMinLimit(limit int) LimitOption {
+	return func(o *LimitOptions) {
+		o.MinLimit = limit
+	}
+}
+
+// WithMaxLimit returns a LimitOption which sets the maximum limit.
+func WithMaxLimit(limit int) LimitOption {
+	return func(o *LimitOptions) {
+		o.MaxLimit = limit
+	}
+}
+
+// WithBackoffRatio returns a LimitOption which sets the ratio of decreasing the limit of AIMD.
+func WithBackoffRatio(ratio float64) LimitOption {
+	return func(o *LimitOptions) {
+		o.BackoffRatio = ratio
+	}
+}
+
+// WithTimeout returns a LimitOption which sets the latency treated as dropped of AIMD.
+func WithTimeout(timeout time.Duration) LimitOption {
+	return func(o *LimitOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// WithTolerance returns a LimitOption which sets the tolerance of the latency gradient.
+func WithTolerance(tolerance float64) LimitOption {
+	return func(o *LimitOptions) {
+		o.Tolerance = tolerance
+	}
+}
+
+// WithSmoothing returns a LimitOption which sets the smoothing factor of the gradient limit.
+func WithSmoothing(smoothing float64) LimitOption {
+	return func(o *LimitOptions) {
+		o.Smoothing = smoothing
+	}
+}
+
+// WithLongWindow returns a LimitOption which sets the window of the long-term latency of the gradient limit.
+func WithLongWindow(window int) LimitOption {
+	return func(o *LimitOptions) {
+		o.LongWindow = window
+	}
+}
+
+// WithMinSamples returns a LimitOption which sets the minimum samples of each update of the gradient limit.
+func WithMinSamples(n int) LimitOption {
+	return func(o *LimitOptions) {
+		o.MinSamples = n
+	}
+}
+
+// clamp 将并发上限限制在 [MinLimit, MaxLimit] 之间
+func (o *LimitOptions) clamp(limit float64) float64 {
+	if limit < float64(o.MinLimit) {
+		return float64(o.MinLimit)
+	}
+	if limit > float64(o.MaxLimit) {
+		return float64(o.MaxLimit)
+	}
+	return limit
+}
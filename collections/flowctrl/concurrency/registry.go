@@ -0,0 +1,51 @@
+package concurrency
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]*Limiter{}
+)
+
+// Register registers a limiter by its name so that its state can be exported as metrics,
+// the limiter with the same name will be replaced
+func Register(l *Limiter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[l.Name()] = l
+}
+
+// Unregister removes a named limiter
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, name)
+}
+
+// Get returns the named limiter
+func Get(name string) (*Limiter, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	l, ok := registry[name]
+	return l, ok
+}
+
+// Each calls fn for every registered limiter in name order
+func Each(fn func(l *Limiter)) {
+	registryMu.RLock()
+	limiters := make([]*Limiter, 0, len(registry))
+	for _, l := range registry {
+		limiters = append(limiters, l)
+	}
+	registryMu.RUnlock()
+
+	sort.Slice(limiters, func(i, j int) bool {
+		return limiters[i].Name() < limiters[j].Name()
+	})
+	for _, l := range limiters {
+		fn(l)
+	}
+}
@@ -10,6 +10,8 @@ import (
 // * 简单高效：实现简单，计算量小
 // * 并发安全：通过互斥锁保证多线程环境下的正确性
 // * 边界问题：在窗口切换瞬间可能出现突发流量(例如窗口末尾和开始瞬间允许的请求数可能超过限制)
+//
+// Deprecated: 使用 NewLocalFixedWindow 代替，它实现了 Limiter 接口，可以按 key 限流，也可以替换为 redis 实现。
 type FixedWindowCounter struct {
 	mu           sync.Mutex    // 互斥锁，保证并发安全
 	requestCount int           // 当前窗口内的请求计数
@@ -20,6 +22,8 @@ type FixedWindowCounter struct {
 
 // NewFixedWindowCounter 创建一个固定窗口计数器限流器
 // counter := NewFixedWindowCounter(100, time.Second) // 每秒最多100个请求
+//
+// Deprecated: 使用 NewLocalFixedWindow 代替。
 func NewFixedWindowCounter(limit int, window time.Duration) *FixedWindowCounter {
 	return &FixedWindowCounter{
 		limit:     limit,  // 窗口内允许的最大请求数
@@ -0,0 +1,132 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrExceedsLimit 请求的许可数量超过了限流器的容量，永远无法获取
+var ErrExceedsLimit = errors.New("ratelimiter: n exceeds limit")
+
+// Limiter 按 key 限流的统一接口，本地和 redis 实现的令牌桶、固定窗口、滑动窗口算法都实现了该接口
+type Limiter interface {
+	// Allow 获取一个许可，获取失败时返回 false
+	Allow(ctx context.Context, key string) bool
+	// AllowN 获取 n 个许可，获取失败时返回 false
+	AllowN(ctx context.Context, key string, n int) bool
+	// Reserve 获取一个许可，获取成功时 Delay 为 0；否则不占用许可，Delay 为可以重试的等待时间
+	Reserve(ctx context.Context, key string) Reservation
+	// Wait 阻塞直到获取一个许可或者 ctx 结束
+	Wait(ctx context.Context, key string) error
+}
+
+// Reservation 获取许可的结果
+type Reservation struct {
+	ok    bool
+	delay time.Duration
+}
+
+// OK 返回是否可以获取许可，请求的数量超过限流器容量时为 false
+func (r Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay 返回需要等待的时间，为 0 时已经获取了许可
+func (r Reservation) Delay() time.Duration {
+	return r.delay
+}
+
+// reserver 尝试获取 n 个许可
+type reserver interface {
+	reserveN(ctx context.Context, key string, n int) Reservation
+}
+
+// limiter 基于 reserveN 实现 Limiter 接口
+type limiter struct {
+	reserver
+}
+
+// Allow implements Limiter.
+func (l limiter) Allow(ctx context.Context, key string) bool {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN implements Limiter.
+func (l limiter) AllowN(ctx context.Context, key string, n int) bool {
+	r := l.reserveN(ctx, key, n)
+	return r.ok && r.delay == 0
+}
+
+// Reserve implements Limiter.
+func (l limiter) Reserve(ctx context.Context, key string) Reservation {
+	return l.reserveN(ctx, key, 1)
+}
+
+// Wait implements Limiter.
+func (l limiter) Wait(ctx context.Context, key string) error {
+	for {
+		r := l.reserveN(ctx, key, 1)
+		if !r.ok {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return ErrExceedsLimit
+		}
+		if r.delay == 0 {
+			return nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < r.delay {
+			return fmt.Errorf("ratelimiter: wait %v exceeds context deadline", r.delay)
+		}
+
+		timer := time.NewTimer(r.delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+const (
+	defaultKeyPrefix        = "ratelimit:"
+	defaultFallbackCooldown = time.Second
+)
+
+// Options 限流器的配置选项
+type Options struct {
+	// KeyPrefix redis key 的前缀
+	KeyPrefix string
+	// FallbackCooldown redis 访问失败后使用本地限流的时间，期间不再访问 redis
+	FallbackCooldown time.Duration
+
+	now func() time.Time // 时钟，测试时替换
+}
+
+// Option modifies the Options.
+type Option func(*Options)
+
+func defaultOptions() *Options {
+	return &Options{
+		KeyPrefix:        defaultKeyPrefix,
+		FallbackCooldown: defaultFallbackCooldown,
+		now:              time.Now,
+	}
+}
+
+// WithKeyPrefix returns an Option which sets the prefix of the redis keys.
+func WithKeyPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.KeyPrefix = prefix
+	}
+}
+
+// WithFallbackCooldown returns an Option which sets how long the local limiter is used after redis fails.
+func WithFallbackCooldown(d time.Duration) Option {
+	return func(o *Options) {
+		o.FallbackCooldown = d
+	}
+}
@@ -0,0 +1,149 @@
+package ratelimiter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// localState 单个 key 的限流状态
+type localState struct {
+	tokens float64     // 令牌桶：当前令牌数
+	last   time.Time   // 令牌桶：上次填充时间；固定窗口：窗口开始时间
+	count  int         // 固定窗口：窗口内的请求数
+	events []time.Time // 滑动窗口：窗口内的请求时间
+	access time.Time   // 最近一次访问时间，用于清理过期的 key
+}
+
+// takeFunc 在 key 的状态上获取 n 个许可，limit 为令牌桶容量或者窗口内允许的请求数，
+// period 为生成一个令牌的时间或者窗口长度
+type takeFunc func(s *localState, limit int, period time.Duration, now time.Time, n int) Reservation
+
+// localLimiter 按 key 保存状态的本地限流器，逻辑与 redis 脚本相同，也作为 redis 不可用时的降级实现
+type localLimiter struct {
+	limiter
+
+	take     takeFunc
+	period   time.Duration
+	ttl      time.Duration
+	limitFor func(key string) int
+	now      func() time.Time
+
+	mu        sync.Mutex
+	states    map[string]*localState
+	lastSweep time.Time
+}
+
+func newLocalLimiter(take takeFunc, limitFor func(string) int, period, ttl time.Duration,
+	now func() time.Time) *localLimiter {
+	l := &localLimiter{
+		take:      take,
+		period:    period,
+		ttl:       ttl,
+		limitFor:  limitFor,
+		now:       now,
+		states:    make(map[string]*localState),
+		lastSweep: now(),
+	}
+	l.limiter = limiter{l}
+	return l
+}
+
+// NewLocalTokenBucket 创建按 key 限流的本地令牌桶，每 rate 时间生成一个令牌，最多保存 capacity 个令牌
+func NewLocalTokenBucket(capacity int, rate time.Duration) Limiter {
+	return newLocalLimiter(takeTokenBucket, fixedLimit(capacity), rate, time.Duration(capacity)*rate, time.Now)
+}
+
+// NewLocalFixedWindow 创建按 key 限流的本地固定窗口计数器，每个 window 内最多允许 limit 个请求
+func NewLocalFixedWindow(limit int, window time.Duration) Limiter {
+	return newLocalLimiter(takeFixedWindow, fixedLimit(limit), window, window, time.Now)
+}
+
+// NewLocalSlidingWindow 创建按 key 限流的本地滑动窗口计数器，任意 window 时间内最多允许 limit 个请求
+func NewLocalSlidingWindow(limit int, window time.Duration) Limiter {
+	return newLocalLimiter(takeSlidingWindow, fixedLimit(limit), window, window, time.Now)
+}
+
+func fixedLimit(limit int) func(string) int {
+	return func(string) int { return limit }
+}
+
+// reserveN implements reserver.
+func (l *localLimiter) reserveN(_ context.Context, key string, n int) Reservation {
+	limit := l.limitFor(key)
+	if n > limit {
+		return Reservation{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	s, ok := l.states[key]
+	if !ok {
+		s = &localState{tokens: float64(limit)}
+		l.states[key] = s
+	}
+	s.access = now
+	return l.take(s, limit, l.period, now, n)
+}
+
+// sweep 清理超过 ttl 没有访问的 key，每个 ttl 最多清理一次
+func (l *localLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.ttl {
+		return
+	}
+	l.lastSweep = now
+	for key, s := range l.states {
+		if now.Sub(s.access) >= l.ttl {
+			delete(l.states, key)
+		}
+	}
+}
+
+// takeTokenBucket 令牌桶：按时间填充令牌，令牌足够时扣除，否则返回令牌足够的等待时间
+func takeTokenBucket(s *localState, capacity int, rate time.Duration, now time.Time, n int) Reservation {
+	if now.After(s.last) {
+		s.tokens = math.Min(float64(capacity), s.tokens+float64(now.Sub(s.last))/float64(rate))
+		s.last = now
+	}
+	if s.tokens >= float64(n) {
+		s.tokens -= float64(n)
+		return Reservation{ok: true}
+	}
+	return Reservation{ok: true, delay: time.Duration(math.Ceil((float64(n) - s.tokens) * float64(rate)))}
+}
+
+// takeFixedWindow 固定窗口：窗口切换时计数清零，计数未超过限制时增加，否则返回下一个窗口开始的等待时间
+func takeFixedWindow(s *localState, limit int, window time.Duration, now time.Time, n int) Reservation {
+	start := now.Truncate(window)
+	if start.After(s.last) {
+		s.last = start
+		s.count = 0
+	}
+	if s.count+n <= limit {
+		s.count += n
+		return Reservation{ok: true}
+	}
+	return Reservation{ok: true, delay: s.last.Add(window).Sub(now)}
+}
+
+// takeSlidingWindow 滑动窗口：清理窗口外的请求，窗口内的请求数未超过限制时记录，否则返回足够的请求移出窗口的等待时间
+func takeSlidingWindow(s *localState, limit int, window time.Duration, now time.Time, n int) Reservation {
+	expired := 0
+	for expired < len(s.events) && s.events[expired].Add(window).Before(now) {
+		expired++
+	}
+	s.events = s.events[expired:]
+
+	if len(s.events)+n <= limit {
+		for i := 0; i < n; i++ {
+			s.events = append(s.events, now)
+		}
+		return Reservation{ok: true}
+	}
+	oldest := s.events[len(s.events)+n-limit-1]
+	return Reservation{ok: true, delay: oldest.Add(window).Sub(now) + time.Nanosecond}
+}
@@ -0,0 +1,221 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/logging"
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/xid"
+)
+
+// 脚本的时间单位均为毫秒，返回 {allowed, delay}，allowed 为 1 时获取成功，为 0 时 delay 为需要等待的时间
+var (
+	// tokenBucketScript ARGV: capacity, rate（生成一个令牌的毫秒数，可以是小数）, now, n
+	tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1]) or capacity
+local last = tonumber(state[2]) or now
+if now > last then
+	tokens = math.min(capacity, tokens + (now - last) / rate)
+	last = now
+end
+
+local allowed, delay = 0, 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+else
+	delay = math.ceil((n - tokens) * rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', tostring(last))
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity * rate) + 1000)
+return {allowed, delay}
+`)
+
+	// fixedWindowScript ARGV: limit, window, now, n
+	fixedWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+
+local start = now - now % window
+local count = 0
+local state = redis.call('HMGET', KEYS[1], 'start', 'count')
+local stored = tonumber(state[1])
+if stored ~= nil and stored >= start then
+	start = stored
+	count = tonumber(state[2])
+end
+
+if count + n <= limit then
+	redis.call('HSET', KEYS[1], 'start', tostring(start), 'count', count + n)
+	redis.call('PEXPIRE', KEYS[1], start + window - now + 1000)
+	return {1, 0}
+end
+return {0, start + window - now}
+`)
+
+	// slidingWindowScript ARGV: limit, window, now, n, member
+	slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', '(' .. (now - window))
+local count = redis.call('ZCARD', KEYS[1])
+if count + n <= limit then
+	for i = 1, n do
+		redis.call('ZADD', KEYS[1], now, ARGV[5] .. ':' .. i)
+	end
+	redis.call('PEXPIRE', KEYS[1], window + 1000)
+	return {1, 0}
+end
+local index = count + n - limit - 1
+local oldest = redis.call('ZRANGE', KEYS[1], index, index, 'WITHSCORES')
+return {0, tonumber(oldest[2]) + window - now + 1}
+`)
+)
+
+// redisLimiter 使用 lua 脚本原子地更新 redis 中的限流状态，多个副本共享同一个限流额度，
+// redis 访问失败时在 FallbackCooldown 内降级为本地限流，ctx 结束导致的失败不降级，直接拒绝请求
+type redisLimiter struct {
+	limiter
+
+	cli      redis.Scripter
+	script   *redis.Script
+	unique   bool // 脚本需要唯一的请求标识
+	period   time.Duration
+	limitFor func(key string) int
+	opts     *Options
+	fallback *localLimiter
+
+	// unhealthyUntil redis 访问失败后降级到的时间点（UnixNano）
+	unhealthyUntil atomic.Int64
+}
+
+func newRedisLimiter(cli redis.Scripter, script *redis.Script, take takeFunc, limitFor func(string) int,
+	period, ttl time.Duration, opt ...Option) *redisLimiter {
+	opts := defaultOptions()
+	for _, o := range opt {
+		o(opts)
+	}
+	l := &redisLimiter{
+		cli:      cli,
+		script:   script,
+		period:   period,
+		limitFor: limitFor,
+		opts:     opts,
+		fallback: newLocalLimiter(take, limitFor, period, ttl, opts.now),
+	}
+	l.limiter = limiter{l}
+	return l
+}
+
+// NewRedisTokenBucket 创建基于 redis 的令牌桶，每 rate 时间生成一个令牌，最多保存 capacity 个令牌
+func NewRedisTokenBucket(cli redis.Scripter, capacity int, rate time.Duration, opt ...Option) Limiter {
+	return newRedisLimiter(cli, tokenBucketScript, takeTokenBucket, fixedLimit(capacity),
+		rate, time.Duration(capacity)*rate, opt...)
+}
+
+// NewRedisFixedWindow 创建基于 redis 的固定窗口计数器，每个 window 内最多允许 limit 个请求
+func NewRedisFixedWindow(cli redis.Scripter, limit int, window time.Duration, opt ...Option) Limiter {
+	return newRedisLimiter(cli, fixedWindowScript, takeFixedWindow, fixedLimit(limit), window, window, opt...)
+}
+
+// NewRedisSlidingWindow 创建基于 redis 的滑动窗口计数器，任意 window 时间内最多允许 limit 个请求
+func NewRedisSlidingWindow(cli redis.Scripter, limit int, window time.Duration, opt ...Option) Limiter {
+	l := newRedisLimiter(cli, slidingWindowScript, takeSlidingWindow, fixedLimit(limit), window, window, opt...)
+	l.unique = true
+	return l
+}
+
+// reserveN implements reserver.
+func (l *redisLimiter) reserveN(ctx context.Context, key string, n int) Reservation {
+	limit := l.limitFor(key)
+	if n > limit {
+		return Reservation{}
+	}
+
+	now := l.opts.now()
+	if now.UnixNano() < l.unhealthyUntil.Load() {
+		return l.fallback.reserveN(ctx, key, n)
+	}
+
+	r, err := l.eval(ctx, key, limit, n, now)
+	if err != nil && ctx.Err() != nil {
+		// 调用方取消或者超时不代表 redis 不可用，拒绝本次请求而不降级
+		return Reservation{}
+	}
+	if err != nil {
+		logging.Errorf("ratelimiter: eval redis script of key %s fail, fallback to local limiter: %v", key, err)
+		l.unhealthyUntil.Store(now.Add(l.opts.FallbackCooldown).UnixNano())
+		return l.fallback.reserveN(ctx, key, n)
+	}
+	return r
+}
+
+// eval 执行限流脚本
+func (l *redisLimiter) eval(ctx context.Context, key string, limit, n int, now time.Time) (Reservation, error) {
+	ms := float64(time.Millisecond)
+	args := []interface{}{limit, float64(l.period) / ms, now.UnixMilli(), n}
+	if l.unique {
+		args = append(args, xid.New().String())
+	}
+
+	res, err := l.script.Run(ctx, l.cli, []string{l.opts.KeyPrefix + key}, args...).Int64Slice()
+	if err != nil {
+		return Reservation{}, err
+	}
+	if len(res) != 2 {
+		return Reservation{}, fmt.Errorf("unexpected script result %v", res)
+	}
+	if res[0] == 1 {
+		return Reservation{ok: true}, nil
+	}
+	return Reservation{ok: true, delay: time.Duration(math.Max(float64(res[1]), 1)) * time.Millisecond}, nil
+}
+
+// RedisUserRateLimiter 基于 redis 的用户限流器，每个用户在固定窗口内最多允许 limit 个请求，可以为用户单独设置限制
+type RedisUserRateLimiter struct {
+	*redisLimiter
+
+	limit      int
+	mu         sync.RWMutex
+	userLimits map[string]int
+}
+
+// NewRedisUserRateLimiter 创建基于 redis 的用户限流器，key 为用户 ID
+func NewRedisUserRateLimiter(cli redis.Scripter, limit int, window time.Duration, opt ...Option) *RedisUserRateLimiter {
+	l := &RedisUserRateLimiter{limit: limit, userLimits: make(map[string]int)}
+	l.redisLimiter = newRedisLimiter(cli, fixedWindowScript, takeFixedWindow, l.userLimit, window, window, opt...)
+	return l
+}
+
+// SetLimit 设置指定用户的速率限制阈值
+func (l *RedisUserRateLimiter) SetLimit(userID string, limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.userLimits[userID] = limit
+}
+
+// userLimit 返回用户的速率限制阈值，没有单独设置时使用全局阈值
+func (l *RedisUserRateLimiter) userLimit(userID string) int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if limit, ok := l.userLimits[userID]; ok {
+		return limit
+	}
+	return l.limit
+}
@@ -0,0 +1,207 @@
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock 模拟时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func withClock(c *fakeClock) Option {
+	return func(o *Options) {
+		o.now = c.Now
+	}
+}
+
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cli.Close() })
+	return mr, cli
+}
+
+func TestRedisTokenBucket(t *testing.T) {
+	_, cli := newTestClient(t)
+	ctx := context.Background()
+	clock := &fakeClock{now: time.UnixMilli(1700000000000)}
+
+	// 两个副本共享同一个令牌桶
+	a := NewRedisTokenBucket(cli, 3, 100*time.Millisecond, withClock(clock))
+	b := NewRedisTokenBucket(cli, 3, 100*time.Millisecond, withClock(clock))
+	assert.True(t, a.AllowN(ctx, "k", 2))
+	assert.True(t, b.Allow(ctx, "k"))
+	assert.False(t, a.Allow(ctx, "k"))
+
+	r := b.Reserve(ctx, "k")
+	assert.True(t, r.OK())
+	assert.Equal(t, 100*time.Millisecond, r.Delay())
+
+	// 令牌按时间填充，不超过容量
+	clock.Add(250 * time.Millisecond)
+	assert.True(t, a.AllowN(ctx, "k", 2))
+	assert.False(t, b.Allow(ctx, "k"))
+	clock.Add(time.Hour)
+	assert.True(t, a.AllowN(ctx, "k", 3))
+
+	// 其他 key 不受影响
+	assert.True(t, a.Allow(ctx, "other"))
+
+	// 超过容量永远无法获取
+	assert.False(t, a.AllowN(ctx, "k", 4))
+	assert.False(t, NewRedisTokenBucket(cli, 0, time.Second).Reserve(ctx, "k").OK())
+}
+
+func TestRedisFixedWindow(t *testing.T) {
+	_, cli := newTestClient(t)
+	ctx := context.Background()
+	clock := &fakeClock{now: time.UnixMilli(1700000000200)}
+
+	a := NewRedisFixedWindow(cli, 2, time.Second, withClock(clock))
+	b := NewRedisFixedWindow(cli, 2, time.Second, withClock(clock))
+	assert.True(t, a.Allow(ctx, "k"))
+	assert.True(t, b.Allow(ctx, "k"))
+	assert.False(t, a.Allow(ctx, "k"))
+	assert.Equal(t, 800*time.Millisecond, b.Reserve(ctx, "k").Delay())
+
+	// 下一个窗口重新计数
+	clock.Add(800 * time.Millisecond)
+	assert.True(t, a.AllowN(ctx, "k", 2))
+	assert.False(t, b.Allow(ctx, "k"))
+}
+
+func TestRedisSlidingWindow(t *testing.T) {
+	_, cli := newTestClient(t)
+	ctx := context.Background()
+	clock := &fakeClock{now: time.UnixMilli(1700000000000)}
+
+	a := NewRedisSlidingWindow(cli, 2, time.Second, withClock(clock))
+	b := NewRedisSlidingWindow(cli, 2, time.Second, withClock(clock))
+	assert.True(t, a.Allow(ctx, "k"))
+	clock.Add(600 * time.Millisecond)
+	assert.True(t, b.Allow(ctx, "k"))
+	assert.False(t, a.Allow(ctx, "k"))
+
+	// 第一个请求移出窗口后可以获取
+	r := b.Reserve(ctx, "k")
+	assert.Equal(t, 401*time.Millisecond, r.Delay())
+	clock.Add(r.Delay())
+	assert.True(t, a.Allow(ctx, "k"))
+	assert.False(t, b.Allow(ctx, "k"))
+}
+
+func TestRedisUserRateLimiter(t *testing.T) {
+	_, cli := newTestClient(t)
+	ctx := context.Background()
+	clock := &fakeClock{now: time.UnixMilli(1700000000000)}
+
+	l := NewRedisUserRateLimiter(cli, 1, time.Minute, withClock(clock))
+	l.SetLimit("vip", 3)
+	assert.True(t, l.Allow(ctx, "user"))
+	assert.False(t, l.Allow(ctx, "user"))
+	assert.True(t, l.AllowN(ctx, "vip", 3))
+	assert.False(t, l.Allow(ctx, "vip"))
+}
+
+func TestRedisFallback(t *testing.T) {
+	mr, cli := newTestClient(t)
+	ctx := context.Background()
+	clock := &fakeClock{now: time.UnixMilli(1700000000000)}
+
+	l := NewRedisFixedWindow(cli, 2, time.Minute, withClock(clock), WithFallbackCooldown(time.Second),
+		WithKeyPrefix("test:"))
+	assert.True(t, l.Allow(ctx, "k"))
+	assert.True(t, mr.Exists("test:k"))
+
+	// redis 不可用时使用本地限流
+	mr.Close()
+	assert.True(t, l.AllowN(ctx, "k", 2))
+	assert.False(t, l.Allow(ctx, "k"))
+
+	// 冷却时间后重新访问 redis
+	require.NoError(t, mr.Restart())
+	clock.Add(time.Second)
+	assert.True(t, l.Allow(ctx, "k"))
+	assert.False(t, l.Allow(ctx, "k"))
+}
+
+func TestRedisCanceledContext(t *testing.T) {
+	_, cli := newTestClient(t)
+	l := NewRedisFixedWindow(cli, 1, time.Minute, WithKeyPrefix("test:"))
+
+	// 调用方取消的请求被拒绝，不会降级为本地限流
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, l.Allow(ctx, "k"))
+	assert.ErrorIs(t, l.Wait(ctx, "k"), context.Canceled)
+	assert.Zero(t, l.(*redisLimiter).unhealthyUntil.Load())
+
+	assert.True(t, l.Allow(context.Background(), "k"))
+	assert.False(t, l.Allow(context.Background(), "k"))
+}
+
+func TestLocalLimiter(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+
+	tb := newLocalLimiter(takeTokenBucket, fixedLimit(2), 100*time.Millisecond, 200*time.Millisecond, clock.Now)
+	assert.True(t, tb.AllowN(ctx, "k", 2))
+	assert.Equal(t, 100*time.Millisecond, tb.Reserve(ctx, "k").Delay())
+	clock.Add(100 * time.Millisecond)
+	assert.True(t, tb.Allow(ctx, "k"))
+
+	fw := newLocalLimiter(takeFixedWindow, fixedLimit(1), time.Second, time.Second, clock.Now)
+	assert.True(t, fw.Allow(ctx, "k"))
+	assert.Equal(t, 900*time.Millisecond, fw.Reserve(ctx, "k").Delay())
+	clock.Add(900 * time.Millisecond)
+	assert.True(t, fw.Allow(ctx, "k"))
+
+	sw := newLocalLimiter(takeSlidingWindow, fixedLimit(1), time.Second, time.Second, clock.Now)
+	assert.True(t, sw.Allow(ctx, "k"))
+	clock.Add(500 * time.Millisecond)
+	r := sw.Reserve(ctx, "k")
+	assert.Equal(t, 500*time.Millisecond+time.Nanosecond, r.Delay())
+	clock.Add(r.Delay())
+	assert.True(t, sw.Allow(ctx, "k"))
+
+	// 过期的 key 被清理
+	clock.Add(time.Hour)
+	sw.Allow(ctx, "other")
+	assert.Len(t, sw.states, 1)
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := NewLocalFixedWindow(1, 50*time.Millisecond)
+	ctx := context.Background()
+	require.NoError(t, l.Wait(ctx, "k"))
+
+	start := time.Now()
+	require.NoError(t, l.Wait(ctx, "k"))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// 等待时间超过 ctx 的截止时间
+	l = NewLocalSlidingWindow(1, time.Minute)
+	require.NoError(t, l.Wait(ctx, "k"))
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.Error(t, l.Wait(ctx, "k"))
+
+	// 超过容量
+	assert.ErrorIs(t, NewLocalTokenBucket(0, time.Second).Wait(context.Background(), "k"), ErrExceedsLimit)
+}
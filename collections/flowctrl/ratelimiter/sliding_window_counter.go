@@ -12,6 +12,8 @@ import (
 // * 精确限流：比固定窗口更精确地控制单位时间内的请求数量
 // * 并发安全：通过互斥锁保证多线程环境下的正确性
 // * 内存消耗：需要存储每个请求的时间戳，可能消耗较多内存(在高并发场景下)
+//
+// Deprecated: 使用 NewLocalSlidingWindow 代替，它实现了 Limiter 接口，可以按 key 限流，也可以替换为 redis 实现。
 type SlidingWindowCounter struct {
 	mu     sync.Mutex    // 互斥锁，保证并发安全
 	events *list.List    // 双向链表，存储请求时间戳，链表长度等于当前窗口内的请求数，链表的值是请求时间
@@ -19,6 +21,9 @@ type SlidingWindowCounter struct {
 	window time.Duration // 滑动时间窗口长度
 }
 
+// NewSlidingWindowCounter 创建滑动窗口计数器，任意 window 时间内最多允许 limit 个请求
+//
+// Deprecated: 使用 NewLocalSlidingWindow 代替。
 func NewSlidingWindowCounter(limit int, window time.Duration) *SlidingWindowCounter {
 	return &SlidingWindowCounter{
 		events: list.New(), // 初始化空链表
@@ -6,6 +6,8 @@ import (
 )
 
 // TokenBucket 实现了一个令牌桶(Token Bucket)限流算法，用于控制请求的处理速率。
+//
+// Deprecated: 使用 NewLocalTokenBucket 代替，它实现了 Limiter 接口，可以按 key 限流，也可以替换为 redis 实现。
 type TokenBucket struct {
 	mu       sync.Mutex    // 互斥锁，保证并发安全
 	capacity int           // 桶的容量，即最大令牌数
@@ -14,6 +16,9 @@ type TokenBucket struct {
 	lastTime time.Time     // 上次填充令牌的时间
 }
 
+// NewTokenBucket 创建令牌桶，每 rate 时间生成一个令牌，最多保存 capacity 个令牌
+//
+// Deprecated: 使用 NewLocalTokenBucket 代替。
 func NewTokenBucket(capacity int, rate time.Duration) *TokenBucket {
 	return &TokenBucket{
 		capacity: capacity,
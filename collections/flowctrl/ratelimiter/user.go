@@ -6,6 +6,8 @@ import (
 )
 
 // UserRateLimiter 用户速率限制器
+//
+// Deprecated: 使用 NewLocalFixedWindow 代替，以用户 ID 作为 key；需要为用户单独设置限制时使用 NewRedisUserRateLimiter。
 type UserRateLimiter struct {
 	mu         sync.Mutex           // 互斥锁，保证并发安全
 	userLimits map[string]int       // 每个用户的速率限制阈值
@@ -23,6 +25,8 @@ type UserRateLimiter struct {
 //
 // 返回值:
 //   - *UserRateLimiter: 初始化后的用户速率限制器实例
+//
+// Deprecated: 使用 NewLocalFixedWindow 或者 NewRedisUserRateLimiter 代替。
 func NewUserRateLimiter(limit int, window time.Duration) *UserRateLimiter {
 	return &UserRateLimiter{
 		userLimits: make(map[string]int),
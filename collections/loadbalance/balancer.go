@@ -0,0 +1,171 @@
+package loadbalance
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/logging"
+)
+
+// ErrNoAvailableNode 没有可用的节点
+var ErrNoAvailableNode = errors.New("no nodes available")
+
+// DoneFunc 调用结束时回调，err 为调用结果，用于统计节点的并发数、耗时和连续失败次数。
+// 每次 Pick 成功后必须且只能调用一次
+type DoneFunc func(err error)
+
+// Balancer 负载均衡器，每次调用选择一个节点，并根据调用结果被动摘除故障节点
+type Balancer interface {
+	// Pick 选择一个节点，key 为一致性哈希等策略使用的请求标识，其他策略忽略
+	Pick(key string) (string, DoneFunc, error)
+	// Update 更新节点列表，已经存在的节点保留调用统计
+	Update(nodes []Node)
+	// Nodes 返回当前的节点列表
+	Nodes() []Node
+}
+
+// Picker 负载均衡策略
+type Picker interface {
+	// Build 节点列表变化时调用，有状态的策略可以在这里重建内部数据结构
+	Build(nodes []*NodeState)
+	// Pick 从可用的节点中选择一个，available 不为空
+	Pick(available []*NodeState, key string) *NodeState
+}
+
+// balancer 实现了节点管理和被动摘除，选择节点由 Picker 完成
+type balancer struct {
+	picker Picker
+	opts   *Options
+
+	mu    sync.RWMutex
+	nodes []*NodeState
+}
+
+// NewBalancer 使用指定的策略创建负载均衡器
+func NewBalancer(picker Picker, opt ...Option) Balancer {
+	opts := defaultOptions()
+	for _, o := range opt {
+		o(opts)
+	}
+	return &balancer{picker: picker, opts: opts}
+}
+
+// Pick implements Balancer.
+func (b *balancer) Pick(key string) (string, DoneFunc, error) {
+	b.mu.RLock()
+	nodes := b.nodes
+	b.mu.RUnlock()
+	if len(nodes) == 0 {
+		return "", nil, ErrNoAvailableNode
+	}
+
+	start := b.opts.now()
+	n := b.picker.Pick(available(nodes, start), key)
+	if n == nil {
+		return "", nil, ErrNoAvailableNode
+	}
+	n.inflight.Add(1)
+	return n.Address(), func(err error) { b.done(n, start, err) }, nil
+}
+
+// available 返回没有被摘除的节点，所有节点都被摘除时返回全部节点
+func available(nodes []*NodeState, now time.Time) []*NodeState {
+	var res []*NodeState
+	for i, n := range nodes {
+		if n.Ejected(now) {
+			// 出现第一个被摘除的节点时才复制
+			if res == nil {
+				res = append(make([]*NodeState, 0, len(nodes)), nodes[:i]...)
+			}
+			continue
+		}
+		if res != nil {
+			res = append(res, n)
+		}
+	}
+	if len(res) == 0 {
+		return nodes
+	}
+	return res
+}
+
+// done 更新节点的调用统计，连续失败次数达到阈值时摘除节点
+func (b *balancer) done(n *NodeState, start time.Time, err error) {
+	n.inflight.Add(-1)
+	if isIgnored(err) {
+		return
+	}
+
+	now := b.opts.now()
+	n.observe(now.Sub(start), now, b.opts.DecayTime)
+	if !b.opts.IsFailure(err) {
+		n.succeed(now)
+		return
+	}
+	if n.fail(b.opts.ConsecutiveFailures) {
+		b.eject(n, now)
+	}
+}
+
+// eject 摘除节点，被摘除的节点不超过 MaxEjectionPercent
+func (b *balancer) eject(n *NodeState, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n.Ejected(now) {
+		return
+	}
+	ejected := 0
+	for _, node := range b.nodes {
+		if node.Ejected(now) {
+			ejected++
+		}
+	}
+	if (ejected+1)*100 > b.opts.MaxEjectionPercent*len(b.nodes) {
+		return
+	}
+	d := n.eject(now, b.opts.BaseEjectionTime, b.opts.MaxEjectionTime)
+	logging.Warnf("loadbalance: eject node %s for %v after consecutive failures", n.Address(), d)
+}
+
+// Update implements Balancer.
+func (b *balancer) Update(nodes []Node) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	old := make(map[string]*NodeState, len(b.nodes))
+	for _, n := range b.nodes {
+		old[n.Address()] = n
+	}
+	states := make([]*NodeState, 0, len(nodes))
+	seen := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		if _, ok := seen[node.Address]; ok {
+			continue
+		}
+		seen[node.Address] = struct{}{}
+
+		n, ok := old[node.Address]
+		if ok {
+			n.setWeight(node.Weight)
+		} else {
+			n = newNodeState(node)
+		}
+		states = append(states, n)
+	}
+	b.picker.Build(states)
+	b.nodes = states
+}
+
+// Nodes implements Balancer.
+func (b *balancer) Nodes() []Node {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	nodes := make([]Node, 0, len(b.nodes))
+	for _, n := range b.nodes {
+		nodes = append(nodes, Node{Address: n.Address(), Weight: n.Weight()})
+	}
+	return nodes
+}
@@ -0,0 +1,226 @@
+package loadbalance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNode = errors.New("connection refused")
+
+// fakeClock 模拟时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestBalancer(t *testing.T, picker Picker, clock *fakeClock, opt ...Option) *balancer {
+	opt = append(opt, func(o *Options) { o.now = clock.Now })
+	b, ok := NewBalancer(picker, opt...).(*balancer)
+	require.True(t, ok)
+	b.Update([]Node{{Address: "a"}, {Address: "b"}, {Address: "c"}})
+	return b
+}
+
+func pickN(t *testing.T, b Balancer, key string, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		addr, done, err := b.Pick(key)
+		require.NoError(t, err)
+		done(nil)
+		counts[addr]++
+	}
+	return counts
+}
+
+func state(b *balancer, addr string) *NodeState {
+	for _, n := range b.nodes {
+		if n.Address() == addr {
+			return n
+		}
+	}
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{RoundRobin, WeightedRoundRobin, Random, ConsistentHash, P2C, LeastRequest, EWMA} {
+		b, err := New(name)
+		require.NoError(t, err)
+		_, _, err = b.Pick("k")
+		assert.ErrorIs(t, err, ErrNoAvailableNode)
+
+		b.Update([]Node{{Address: "a", Weight: 2}, {Address: "b"}})
+		addr, done, err := b.Pick("k")
+		require.NoError(t, err, name)
+		assert.Contains(t, []string{"a", "b"}, addr)
+		done(nil)
+	}
+
+	_, err := New("unknown")
+	assert.Error(t, err)
+}
+
+func TestWeightedPickers(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	b := newTestBalancer(t, NewRoundRobinPicker(), clock)
+	assert.Equal(t, map[string]int{"a": 2, "b": 2, "c": 2}, pickN(t, b, "", 6))
+
+	b = newTestBalancer(t, NewWeightedRoundRobinPicker(), clock)
+	b.Update([]Node{{Address: "a", Weight: 3}, {Address: "b", Weight: 1}})
+	assert.Equal(t, map[string]int{"a": 6, "b": 2}, pickN(t, b, "", 8))
+	assert.Equal(t, []Node{{Address: "a", Weight: 3}, {Address: "b", Weight: 1}}, b.Nodes())
+
+	b = newTestBalancer(t, NewRandomPicker(), clock)
+	b.Update([]Node{{Address: "a", Weight: 100}, {Address: "b", Weight: 0}})
+	counts := pickN(t, b, "", 1000)
+	assert.Greater(t, counts["a"], 900)
+}
+
+func TestLeastRequestPickers(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+
+	// 正在处理的请求最少的节点被选中
+	b := newTestBalancer(t, NewLeastRequestPicker(), clock)
+	var dones []DoneFunc
+	for i := 0; i < 3; i++ {
+		_, done, err := b.Pick("")
+		require.NoError(t, err)
+		dones = append(dones, done)
+	}
+	assert.Equal(t, []int64{1, 1, 1}, []int64{
+		state(b, "a").Inflight(), state(b, "b").Inflight(), state(b, "c").Inflight(),
+	})
+	state(b, "b").inflight.Add(-1)
+	addr, done, err := b.Pick("")
+	require.NoError(t, err)
+	assert.Equal(t, "b", addr)
+	done(nil)
+
+	// 两个节点时 p2c 总是选择负载较低的节点
+	b = newTestBalancer(t, NewP2CPicker(), clock)
+	b.Update([]Node{{Address: "a"}, {Address: "b"}})
+	state(b, "a").inflight.Add(5)
+	assert.Equal(t, map[string]int{"b": 10}, pickN(t, b, "", 10))
+
+	// 按权重归一化
+	b.Update([]Node{{Address: "a", Weight: 10}, {Address: "b"}})
+	state(b, "b").inflight.Add(1)
+	assert.Equal(t, map[string]int{"a": 10}, pickN(t, b, "", 10))
+}
+
+func TestEWMAPicker(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	b := newTestBalancer(t, NewEWMAPicker(), clock, WithDecayTime(time.Second))
+	b.Update([]Node{{Address: "a"}, {Address: "b"}})
+
+	addr, done, err := b.Pick("")
+	require.NoError(t, err)
+	clock.Add(100 * time.Millisecond)
+	done(nil)
+	assert.Equal(t, 100*time.Millisecond, state(b, addr).Latency())
+
+	other := "a"
+	if addr == "a" {
+		other = "b"
+	}
+	state(b, other).observe(10*time.Millisecond, clock.Now(), time.Second)
+	assert.Equal(t, map[string]int{other: 10}, pickN(t, b, "", 10))
+
+	// 耗时按时间衰减
+	state(b, other).observe(time.Second, clock.now.Add(time.Second), time.Second)
+	latency := state(b, other).Latency()
+	assert.Greater(t, latency, 600*time.Millisecond)
+	assert.Less(t, latency, 700*time.Millisecond)
+	assert.Equal(t, map[string]int{addr: 10}, pickN(t, b, "", 10))
+}
+
+func TestOutlierEjection(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	b := newTestBalancer(t, NewConsistentHashPicker(10, nil), clock,
+		WithConsecutiveFailures(2), WithEjectionTime(10*time.Second, 15*time.Second))
+
+	fail := func(key string) string {
+		addr, done, err := b.Pick(key)
+		require.NoError(t, err)
+		done(errNode)
+		return addr
+	}
+	target := fail("k")
+	assert.Equal(t, target, fail("k"))
+	assert.True(t, state(b, target).Ejected(clock.Now()))
+
+	// 被摘除的节点不再被选中，一致性哈希选择下一个节点
+	counts := pickN(t, b, "k", 10)
+	assert.Len(t, counts, 1)
+	assert.Zero(t, counts[target])
+	next := fail("k")
+	assert.NotEqual(t, target, next)
+
+	// 被摘除的节点不超过一半
+	fail("k")
+	assert.False(t, state(b, next).Ejected(clock.Now()))
+
+	// 摘除时间结束后恢复，再次被摘除时时长增加
+	clock.Add(10 * time.Second)
+	assert.False(t, state(b, target).Ejected(clock.Now()))
+	assert.Equal(t, target, fail("k"))
+	assert.Equal(t, target, fail("k"))
+	clock.Add(14 * time.Second)
+	assert.True(t, state(b, target).Ejected(clock.Now()))
+	clock.Add(time.Second)
+	assert.False(t, state(b, target).Ejected(clock.Now()))
+
+	// 成功后清空连续失败次数
+	assert.Equal(t, target, fail("k"))
+	pickN(t, b, "k", 1)
+	assert.Equal(t, target, fail("k"))
+	assert.False(t, state(b, target).Ejected(clock.Now()))
+}
+
+func TestBalancerDone(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	b := newTestBalancer(t, NewRoundRobinPicker(), clock,
+		WithConsecutiveFailures(1), WithMaxEjectionPercent(100),
+		WithFailurePredicate(func(err error) bool { return errors.Is(err, errNode) }))
+
+	// 取消的请求和业务错误不影响节点状态
+	addr, done, err := b.Pick("")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, state(b, addr).Inflight())
+	clock.Add(time.Second)
+	done(context.Canceled)
+	assert.Zero(t, state(b, addr).Inflight())
+	assert.Zero(t, state(b, addr).Latency())
+
+	addr, done, err = b.Pick("")
+	require.NoError(t, err)
+	done(errors.New("business error"))
+	assert.False(t, state(b, addr).Ejected(clock.Now()))
+
+	// 节点列表更新时保留已有节点的状态
+	addr, done, err = b.Pick("")
+	require.NoError(t, err)
+	done(errNode)
+	ejected := state(b, addr)
+	assert.True(t, ejected.Ejected(clock.Now()))
+	b.Update([]Node{{Address: "a"}, {Address: "b"}, {Address: "c"}, {Address: "d"}, {Address: "a"}})
+	assert.Len(t, b.Nodes(), 4)
+	assert.Same(t, ejected, state(b, addr))
+
+	// 所有节点都被摘除时使用全部节点
+	for _, n := range b.nodes {
+		n.eject(clock.Now(), time.Minute, time.Minute)
+	}
+	assert.Len(t, pickN(t, b, "", 4), 4)
+}
@@ -19,6 +19,8 @@ func (s UInt32Slice) Less(i, j int) bool { return s[i] < s[j] }
 func (s UInt32Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
 // ConsistentHashBalance 一致性哈希负载均衡器
+//
+// Deprecated: 没有节点的健康检查和调用反馈，使用 New(ConsistentHash) 或者 NewBalancer(NewConsistentHashPicker(replicas, fn)) 创建的 Balancer 代替。
 type ConsistentHashBalance struct {
 	mux      sync.RWMutex      // 读写锁，保护内部数据结构
 	hash     Hash              // 哈希函数，默认使用crc32
@@ -30,6 +32,8 @@ type ConsistentHashBalance struct {
 // NewConsistentHashBalance 创建一致性哈希负载均衡器实例
 // replicas: 每个节点的虚拟节点数（权重体现，值越大权重越高）
 // fn: 自定义哈希函数，如果为nil则使用crc32.ChecksumIEEE
+//
+// Deprecated: 使用 NewBalancer(NewConsistentHashPicker(replicas, fn)) 代替。
 func NewConsistentHashBalance(replicas int, fn Hash) *ConsistentHashBalance {
 	m := &ConsistentHashBalance{
 		replicas: replicas,
@@ -0,0 +1,119 @@
+package loadbalance
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Node 服务节点
+type Node struct {
+	Address string // 节点地址，如 ip:port
+	Weight  int    // 节点权重，小于等于 0 时为 1
+}
+
+// NodeState 节点及其调用统计，供 Picker 选择节点
+type NodeState struct {
+	address string
+
+	weight       atomic.Int64
+	inflight     atomic.Int64
+	ejectedUntil atomic.Int64 // 摘除到的时间点（UnixNano）
+
+	mu         sync.Mutex
+	latency    float64   // 耗时的 EWMA（纳秒）
+	lastUpdate time.Time // 上次更新耗时的时间
+	failures   int       // 连续失败次数
+	ejections  int       // 连续被摘除的次数
+}
+
+func newNodeState(node Node) *NodeState {
+	n := &NodeState{address: node.Address}
+	n.setWeight(node.Weight)
+	return n
+}
+
+// Address 返回节点地址
+func (n *NodeState) Address() string {
+	return n.address
+}
+
+// Weight 返回节点权重
+func (n *NodeState) Weight() int {
+	return int(n.weight.Load())
+}
+
+func (n *NodeState) setWeight(weight int) {
+	if weight <= 0 {
+		weight = 1
+	}
+	n.weight.Store(int64(weight))
+}
+
+// Inflight 返回节点正在处理的请求数
+func (n *NodeState) Inflight() int64 {
+	return n.inflight.Load()
+}
+
+// Latency 返回节点耗时的 EWMA，没有完成过请求时为 0
+func (n *NodeState) Latency() time.Duration {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return time.Duration(n.latency)
+}
+
+// Ejected 返回节点在 now 时是否处于摘除状态
+func (n *NodeState) Ejected(now time.Time) bool {
+	return now.UnixNano() < n.ejectedUntil.Load()
+}
+
+// observe 记录一次调用的耗时，按照距离上次更新的时间衰减历史耗时
+func (n *NodeState) observe(rtt time.Duration, now time.Time, decay time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.lastUpdate.IsZero() || decay <= 0 {
+		n.latency = float64(rtt)
+	} else {
+		elapsed := math.Max(float64(now.Sub(n.lastUpdate)), 0)
+		w := math.Exp(-elapsed / float64(decay))
+		n.latency = n.latency*w + float64(rtt)*(1-w)
+	}
+	n.lastUpdate = now
+}
+
+// succeed 清空连续失败和摘除次数
+func (n *NodeState) succeed(now time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.failures = 0
+	if !n.Ejected(now) {
+		n.ejections = 0
+	}
+}
+
+// fail 记录一次失败，返回连续失败次数是否达到阈值
+func (n *NodeState) fail(threshold int) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.failures++
+	return threshold > 0 && n.failures >= threshold
+}
+
+// eject 摘除节点，每次连续摘除的时长增加 base，最长为 max
+func (n *NodeState) eject(now time.Time, base, max time.Duration) time.Duration {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.failures = 0
+	n.ejections++
+	d := base * time.Duration(n.ejections)
+	if d > max || d <= 0 {
+		d = max
+	}
+	n.ejectedUntil.Store(now.Add(d).UnixNano())
+	return d
+}
@@ -0,0 +1,86 @@
+package loadbalance
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Options 负载均衡器的配置
+type Options struct {
+	// 连续失败多少次后摘除节点，小于等于 0 时不摘除
+	ConsecutiveFailures int
+	// 第一次摘除的时长，节点每次被摘除时长增加 BaseEjectionTime
+	BaseEjectionTime time.Duration
+	// 摘除的最大时长
+	MaxEjectionTime time.Duration
+	// 最多摘除的节点百分比，避免故障扩散时所有节点都被摘除
+	MaxEjectionPercent int
+	// 耗时 EWMA 的衰减时间，越小越关注最近的耗时
+	DecayTime time.Duration
+	// 判断调用结果是否为节点故障
+	IsFailure func(err error) bool
+
+	now func() time.Time
+}
+
+// Option 设置负载均衡器的配置
+type Option func(*Options)
+
+func defaultOptions() *Options {
+	return &Options{
+		ConsecutiveFailures: 5,
+		BaseEjectionTime:    30 * time.Second,
+		MaxEjectionTime:     5 * time.Minute,
+		MaxEjectionPercent:  50,
+		DecayTime:           10 * time.Second,
+		IsFailure:           isFailure,
+		now:                 time.Now,
+	}
+}
+
+// isFailure 默认所有错误都是节点故障
+func isFailure(err error) bool {
+	return err != nil
+}
+
+// isIgnored 调用方取消的请求与节点无关，不统计耗时和失败
+func isIgnored(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// WithConsecutiveFailures 设置连续失败多少次后摘除节点，小于等于 0 时关闭被动摘除
+func WithConsecutiveFailures(n int) Option {
+	return func(o *Options) {
+		o.ConsecutiveFailures = n
+	}
+}
+
+// WithEjectionTime 设置节点的基础摘除时长和最大摘除时长
+func WithEjectionTime(base, max time.Duration) Option {
+	return func(o *Options) {
+		o.BaseEjectionTime = base
+		o.MaxEjectionTime = max
+	}
+}
+
+// WithMaxEjectionPercent 设置最多摘除的节点百分比
+func WithMaxEjectionPercent(percent int) Option {
+	return func(o *Options) {
+		o.MaxEjectionPercent = percent
+	}
+}
+
+// WithDecayTime 设置耗时 EWMA 的衰减时间
+func WithDecayTime(d time.Duration) Option {
+	return func(o *Options) {
+		o.DecayTime = d
+	}
+}
+
+// WithFailurePredicate 设置判断调用结果是否为节点故障的函数，例如业务错误不应该摘除节点
+func WithFailurePredicate(fn func(err error) bool) Option {
+	return func(o *Options) {
+		o.IsFailure = fn
+	}
+}
@@ -0,0 +1,303 @@
+package loadbalance
+
+import (
+	"fmt"
+	"hash/crc32"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 负载均衡策略的名称
+const (
+	RoundRobin         = "round_robin"
+	WeightedRoundRobin = "weighted_round_robin"
+	Random             = "random"
+	ConsistentHash     = "consistent_hash"
+	P2C                = "p2c"
+	LeastRequest       = "least_request"
+	EWMA               = "ewma"
+)
+
+var pickerBuilders = map[string]func() Picker{
+	RoundRobin:         func() Picker { return NewRoundRobinPicker() },
+	WeightedRoundRobin: func() Picker { return NewWeightedRoundRobinPicker() },
+	Random:             func() Picker { return NewRandomPicker() },
+	ConsistentHash:     func() Picker { return NewConsistentHashPicker(100, nil) },
+	P2C:                func() Picker { return NewP2CPicker() },
+	LeastRequest:       func() Picker { return NewLeastRequestPicker() },
+	EWMA:               func() Picker { return NewEWMAPicker() },
+}
+
+// New 根据策略名称创建负载均衡器，便于通过配置选择策略
+func New(name string, opt ...Option) (Balancer, error) {
+	builder, ok := pickerBuilders[name]
+	if !ok {
+		return nil, fmt.Errorf("loadbalance: unknown strategy %q", name)
+	}
+	return NewBalancer(builder(), opt...), nil
+}
+
+// roundRobinPicker 轮询
+type roundRobinPicker struct {
+	next atomic.Uint64
+}
+
+// NewRoundRobinPicker 创建轮询策略
+func NewRoundRobinPicker() Picker {
+	return &roundRobinPicker{}
+}
+
+// Build implements Picker.
+func (p *roundRobinPicker) Build([]*NodeState) {}
+
+// Pick implements Picker.
+func (p *roundRobinPicker) Pick(available []*NodeState, _ string) *NodeState {
+	return available[(p.next.Add(1)-1)%uint64(len(available))]
+}
+
+// weightedRoundRobinPicker 平滑加权轮询
+type weightedRoundRobinPicker struct {
+	mu      sync.Mutex
+	current map[*NodeState]int // 节点的当前临时权重
+}
+
+// NewWeightedRoundRobinPicker 创建平滑加权轮询策略
+func NewWeightedRoundRobinPicker() Picker {
+	return &weightedRoundRobinPicker{current: make(map[*NodeState]int)}
+}
+
+// Build implements Picker.
+func (p *weightedRoundRobinPicker) Build(nodes []*NodeState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := make(map[*NodeState]int, len(nodes))
+	for _, n := range nodes {
+		current[n] = p.current[n]
+	}
+	p.current = current
+}
+
+// Pick implements Picker.
+func (p *weightedRoundRobinPicker) Pick(available []*NodeState, _ string) *NodeState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var best *NodeState
+	total := 0
+	for _, n := range available {
+		w := n.Weight()
+		total += w
+		p.current[n] += w
+		if best == nil || p.current[n] > p.current[best] {
+			best = n
+		}
+	}
+	p.current[best] -= total
+	return best
+}
+
+// randomPicker 按权重随机
+type randomPicker struct{}
+
+// NewRandomPicker 创建按权重随机的策略
+func NewRandomPicker() Picker {
+	return randomPicker{}
+}
+
+// Build implements Picker.
+func (randomPicker) Build([]*NodeState) {}
+
+// Pick implements Picker.
+func (randomPicker) Pick(available []*NodeState, _ string) *NodeState {
+	total := 0
+	for _, n := range available {
+		total += n.Weight()
+	}
+	r := rand.IntN(total)
+	for _, n := range available {
+		if r -= n.Weight(); r < 0 {
+			return n
+		}
+	}
+	return available[len(available)-1]
+}
+
+// hashRing 一致性哈希环
+type hashRing struct {
+	keys  UInt32Slice
+	nodes map[uint32]*NodeState
+	size  int // 真实节点数
+}
+
+// consistentHashPicker 一致性哈希，key 对应的节点被摘除时顺时针选择下一个可用的节点
+type consistentHashPicker struct {
+	replicas int
+	hash     Hash
+	ring     atomic.Pointer[hashRing]
+}
+
+// NewConsistentHashPicker 创建一致性哈希策略，replicas 为每个节点的虚拟节点数，fn 为空时使用 crc32
+func NewConsistentHashPicker(replicas int, fn Hash) Picker {
+	if fn == nil {
+		fn = crc32.ChecksumIEEE
+	}
+	if replicas <= 0 {
+		replicas = 1
+	}
+	return &consistentHashPicker{replicas: replicas, hash: fn}
+}
+
+// Build implements Picker.
+func (p *consistentHashPicker) Build(nodes []*NodeState) {
+	ring := &hashRing{nodes: make(map[uint32]*NodeState, len(nodes)*p.replicas), size: len(nodes)}
+	for _, n := range nodes {
+		for i := 0; i < p.replicas; i++ {
+			hash := p.hash([]byte(strconv.Itoa(i) + n.Address()))
+			if _, ok := ring.nodes[hash]; ok {
+				continue
+			}
+			ring.keys = append(ring.keys, hash)
+			ring.nodes[hash] = n
+		}
+	}
+	sort.Sort(ring.keys)
+	p.ring.Store(ring)
+}
+
+// Pick implements Picker.
+func (p *consistentHashPicker) Pick(available []*NodeState, key string) *NodeState {
+	ring := p.ring.Load()
+	if ring == nil || len(ring.keys) == 0 {
+		return available[0]
+	}
+
+	hash := p.hash([]byte(key))
+	idx := sort.Search(len(ring.keys), func(i int) bool { return ring.keys[i] >= hash })
+	// 没有节点被摘除
+	if len(available) == ring.size {
+		return ring.nodes[ring.keys[idx%len(ring.keys)]]
+	}
+	usable := make(map[*NodeState]struct{}, len(available))
+	for _, n := range available {
+		usable[n] = struct{}{}
+	}
+	for i := 0; i < len(ring.keys); i++ {
+		n := ring.nodes[ring.keys[(idx+i)%len(ring.keys)]]
+		if _, ok := usable[n]; ok {
+			return n
+		}
+	}
+	return available[0]
+}
+
+// load 节点按权重归一化的负载
+func load(n *NodeState) float64 {
+	return float64(n.Inflight()+1) / float64(n.Weight())
+}
+
+// p2cPicker 随机选择两个节点，使用正在处理的请求数较少的节点
+type p2cPicker struct{}
+
+// NewP2CPicker 创建 power of two choices 策略，比较按权重归一化的正在处理的请求数
+func NewP2CPicker() Picker {
+	return p2cPicker{}
+}
+
+// Build implements Picker.
+func (p2cPicker) Build([]*NodeState) {}
+
+// Pick implements Picker.
+func (p2cPicker) Pick(available []*NodeState, _ string) *NodeState {
+	if len(available) == 1 {
+		return available[0]
+	}
+	i := rand.IntN(len(available))
+	j := rand.IntN(len(available) - 1)
+	if j >= i {
+		j++
+	}
+	a, b := available[i], available[j]
+	if load(b) < load(a) {
+		return b
+	}
+	return a
+}
+
+// leastRequestPicker 选择正在处理的请求数最少的节点
+type leastRequestPicker struct{}
+
+// NewLeastRequestPicker 创建最少请求策略，比较按权重归一化的正在处理的请求数，相同时随机选择
+func NewLeastRequestPicker() Picker {
+	return leastRequestPicker{}
+}
+
+// Build implements Picker.
+func (leastRequestPicker) Build([]*NodeState) {}
+
+// Pick implements Picker.
+func (leastRequestPicker) Pick(available []*NodeState, _ string) *NodeState {
+	return pickMin(available, load)
+}
+
+// ewmaPicker 选择耗时 EWMA 与正在处理的请求数乘积最小的节点
+type ewmaPicker struct{}
+
+// NewEWMAPicker 创建 EWMA 耗时策略，没有耗时数据的节点使用其他节点的平均耗时
+func NewEWMAPicker() Picker {
+	return ewmaPicker{}
+}
+
+// Build implements Picker.
+func (ewmaPicker) Build([]*NodeState) {}
+
+// Pick implements Picker.
+func (ewmaPicker) Pick(available []*NodeState, _ string) *NodeState {
+	latencies := make(map[*NodeState]time.Duration, len(available))
+	var sum time.Duration
+	measured := 0
+	for _, n := range available {
+		l := n.Latency()
+		latencies[n] = l
+		if l > 0 {
+			sum += l
+			measured++
+		}
+	}
+	var avg time.Duration
+	if measured > 0 {
+		avg = sum / time.Duration(measured)
+	}
+
+	return pickMin(available, func(n *NodeState) float64 {
+		l := latencies[n]
+		if l == 0 {
+			l = avg
+		}
+		return float64(l+1) * load(n)
+	})
+}
+
+// pickMin 选择 score 最小的节点，相同时随机选择
+func pickMin(available []*NodeState, score func(*NodeState) float64) *NodeState {
+	var best *NodeState
+	var bestScore float64
+	ties := 0
+	for _, n := range available {
+		s := score(n)
+		switch {
+		case best == nil || s < bestScore:
+			best, bestScore, ties = n, s, 1
+		case s == bestScore:
+			ties++
+			if rand.IntN(ties) == 0 {
+				best = n
+			}
+		}
+	}
+	return best
+}
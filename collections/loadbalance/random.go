@@ -8,6 +8,8 @@ import (
 )
 
 // RandomBalance 随机负载均衡器
+//
+// Deprecated: 没有节点的健康检查和调用反馈，使用 New(Random) 创建的 Balancer 代替。
 type RandomBalance struct {
 	rss []string
 	mu  sync.Mutex
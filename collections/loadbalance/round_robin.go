@@ -6,6 +6,8 @@ import (
 )
 
 // RoundRobinBalance 轮询负载均衡器
+//
+// Deprecated: 没有节点的健康检查和调用反馈，使用 New(RoundRobin) 创建的 Balancer 代替。
 type RoundRobinBalance struct {
 	curIndex int      // 当前索引
 	rss      []string // 服务器地址列表
@@ -17,6 +17,8 @@ import (
 // 这样长期来看，厉害的厨师做的菜最多（符合权重比例），但每一轮的选择是动态的。
 
 // WeightNode 加权节点
+//
+// Deprecated: 使用 Node 代替，权重通过 Node.Weight 设置。
 type WeightNode struct {
 	addr            string // 节点地址
 	Weight          int    // 初始权重
@@ -25,6 +27,8 @@ type WeightNode struct {
 }
 
 // WeightRoundRobinBalance 加权轮询负载均衡器
+//
+// Deprecated: 没有节点的健康检查和调用反馈，使用 New(WeightedRoundRobin) 创建的 Balancer 代替。
 type WeightRoundRobinBalance struct {
 	mu     sync.Mutex // 互斥锁，保证并发安全
 	curIdx int        // 当前索引（虽然加权轮询不需要，但保留以兼容接口）
@@ -33,6 +37,8 @@ type WeightRoundRobinBalance struct {
 }
 
 // NewWeightRoundRobinBalance 创建加权轮询负载均衡器实例
+//
+// Deprecated: 使用 New(WeightedRoundRobin) 代替。
 func NewWeightRoundRobinBalance() *WeightRoundRobinBalance {
 	return &WeightRoundRobinBalance{
 		rss: make([]*WeightNode, 0),
@@ -0,0 +1,29 @@
+package dsl
+
+import "fmt"
+
+// Position 查询语句中的位置，行号和列号都从 1 开始，列号按字符计算
+type Position struct {
+	Line   int
+	Column int
+}
+
+// String returns the position like "1:10"
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Error 解析或校验查询语句失败时返回的错误，包含出错的位置
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+func newError(pos Position, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,321 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/rule"
+)
+
+var (
+	identRegexp = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_]*(\.[\p{L}_][\p{L}\p{N}_]*)*$`)
+	// ruleTimeRegexp 不带时区的时间，见 validator.IsTime
+	ruleTimeRegexp = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2}$`)
+	// zonedTimeRegexp 带 +hh:mm 时区的时间，见 validator.IsTime
+	zonedTimeRegexp = regexp.MustCompile(
+		`^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?\+[0-9]{2}:[0-9]{2}$`)
+)
+
+// compareSymbols 使用符号表示的操作符
+var compareSymbols = map[operator.OpType]string{
+	operator.Equal:                  "=",
+	operator.NotEqual:               "!=",
+	operator.Less:                   "<",
+	operator.LessOrEqual:            "<=",
+	operator.Greater:                ">",
+	operator.GreaterOrEqual:         ">=",
+	operator.DatetimeLess:           "<",
+	operator.DatetimeLessOrEqual:    "<=",
+	operator.DatetimeGreater:        ">",
+	operator.DatetimeGreaterOrEqual: ">=",
+}
+
+// keywordOps 使用关键字表示的操作符
+var keywordOps = map[operator.OpType]string{
+	operator.In:         "in",
+	operator.NotIn:      "not in",
+	operator.IsNull:     "is null",
+	operator.IsNotNull:  "is not null",
+	operator.IsEmpty:    "is empty",
+	operator.IsNotEmpty: "is not empty",
+	operator.Exist:      "exists",
+	operator.NotExist:   "not exists",
+}
+
+// Format 将过滤规则转换为单行的查询语句，转换结果可以使用 Parse 解析为相同的规则
+func Format(r operator.IRuleFactory) (string, error) {
+	return FormatIndent(r, "")
+}
+
+// FormatIndent 将过滤规则转换为查询语句，indent 不为空时组合规则的每个子规则单独一行，并按层级缩进
+func FormatIndent(r operator.IRuleFactory, indent string) (string, error) {
+	f := &formatter{indent: indent}
+	if err := f.rule(r, 0, false); err != nil {
+		return "", err
+	}
+	return f.b.String(), nil
+}
+
+type formatter struct {
+	b      strings.Builder
+	indent string
+}
+
+// newline 换行并缩进，没有设置缩进时输出一个空格
+func (f *formatter) newline(level int) {
+	if f.indent == "" {
+		f.b.WriteByte(' ')
+		return
+	}
+	f.b.WriteByte('\n')
+	f.b.WriteString(strings.Repeat(f.indent, level))
+}
+
+// rule 输出规则，nested 表示规则是组合规则的子规则，嵌套的组合规则总是使用括号包裹，保证解析后的结构不变
+func (f *formatter) rule(r operator.IRuleFactory, level int, nested bool) error {
+	switch v := r.(type) {
+	case *rule.CombinedRule:
+		return f.combined(v, level, nested)
+	case *rule.AtomRule:
+		return f.atom(v, level)
+	case nil:
+		return fmt.Errorf("rule is nil")
+	default:
+		return fmt.Errorf("unsupported rule type %T", r)
+	}
+}
+
+func (f *formatter) combined(cr *rule.CombinedRule, level int, nested bool) error {
+	if err := cr.Condition.Validate(); err != nil {
+		return err
+	}
+	if len(cr.Rules) == 0 {
+		return fmt.Errorf("combined rules shouldn't be empty")
+	}
+
+	inner := level
+	if nested {
+		f.b.WriteByte('(')
+		inner++
+		if f.indent != "" {
+			f.newline(inner)
+		}
+	}
+
+	keyword := strings.ToLower(string(cr.Condition))
+	for idx, child := range cr.Rules {
+		if idx > 0 {
+			f.newline(inner)
+			f.b.WriteString(keyword)
+			f.b.WriteByte(' ')
+		}
+		if err := f.rule(child, inner, true); err != nil {
+			return err
+		}
+	}
+
+	if nested {
+		if f.indent != "" {
+			f.newline(level)
+		}
+		f.b.WriteByte(')')
+	}
+	return nil
+}
+
+func (f *formatter) atom(ar *rule.AtomRule, level int) error {
+	if len(ar.Field) == 0 {
+		return fmt.Errorf("field is empty")
+	}
+	if err := ar.Operator.Validate(); err != nil {
+		return err
+	}
+
+	f.b.WriteString(formatField(ar.Field))
+	f.b.WriteByte(' ')
+
+	if symbol, ok := compareSymbols[ar.Operator]; ok {
+		if s, ok := formatCompareValue(ar.Operator, ar.Value); ok {
+			f.b.WriteString(symbol + " " + s)
+			return nil
+		}
+	}
+
+	if keyword, ok := keywordOps[ar.Operator]; ok {
+		f.b.WriteString(keyword)
+	} else {
+		f.b.WriteString(string(ar.Operator))
+	}
+
+	switch ar.Operator {
+	case operator.IsNull, operator.IsNotNull, operator.IsEmpty, operator.IsNotEmpty, operator.Exist, operator.NotExist:
+		return nil
+	case operator.Object, operator.Array:
+		sub, ok := ar.Value.(operator.IRuleFactory)
+		if !ok {
+			return fmt.Errorf("%s operator's value(%+v) is not a rule type", ar.Operator, ar.Value)
+		}
+		f.b.WriteString(" (")
+		if f.indent != "" {
+			f.newline(level + 1)
+		}
+		if err := f.rule(sub, level+1, false); err != nil {
+			return err
+		}
+		if f.indent != "" {
+			f.newline(level)
+		}
+		f.b.WriteByte(')')
+		return nil
+	}
+
+	s, err := formatValue(ar.Value)
+	if err != nil {
+		return fmt.Errorf("%s: %v", ar.Field, err)
+	}
+	f.b.WriteString(" " + s)
+	return nil
+}
+
+// formatCompareValue 返回可以使用比较符号表示的值，datetime 操作符的值需要能表示为日期时间字面量
+func formatCompareValue(op operator.OpType, value interface{}) (string, bool) {
+	if !isDatetimeOp(op) {
+		s, err := formatScalar(value)
+		return s, err == nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		switch {
+		case ruleTimeRegexp.MatchString(v):
+			t, err := time.Parse(ruleTimeLayout, v)
+			if err != nil {
+				return "", false
+			}
+			return formatTime(t), true
+		case zonedTimeRegexp.MatchString(v):
+			return v, true
+		}
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	}
+	return "", false
+}
+
+// formatTime 格式化不带时区的时间，零点只保留日期
+func formatTime(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format(dateLayout)
+	}
+	return t.Format(datetimeLayout)
+}
+
+// formatField 字段名不是合法的标识符或与关键字冲突时使用反引号包裹
+func formatField(field string) string {
+	if identRegexp.MatchString(field) && !isReserved(field) {
+		return field
+	}
+	return "`" + strings.ReplaceAll(field, "`", "``") + "`"
+}
+
+func isReserved(field string) bool {
+	switch strings.ToLower(field) {
+	case keywordAnd, keywordOr, keywordNot, keywordIn, keywordIs, keywordNull, keywordEmpty, keywordExists,
+		keywordTrue, keywordFalse:
+		return true
+	}
+	return false
+}
+
+// formatValue 格式化原子规则的值，值可以是基础类型或基础类型的数组
+func formatValue(value interface{}) (string, error) {
+	if value == nil {
+		return "", fmt.Errorf("value is nil")
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return formatScalar(value)
+	}
+
+	items := make([]string, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		s, err := formatScalar(v.Index(i).Interface())
+		if err != nil {
+			return "", err
+		}
+		items[i] = s
+	}
+	return "[" + strings.Join(items, ", ") + "]", nil
+}
+
+// formatScalar 格式化基础类型的值，浮点数总是包含小数点，保证解析后的类型不变
+func formatScalar(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return quote(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int8, int16, int32, int64:
+		return strconv.FormatInt(reflect.ValueOf(v).Int(), 10), nil
+	case uint, uint8, uint16, uint32, uint64:
+		return strconv.FormatUint(reflect.ValueOf(v).Uint(), 10), nil
+	case float32:
+		return formatFloat(float64(v), 32)
+	case float64:
+		return formatFloat(v, 64)
+	case json.Number:
+		if _, err := parseNumber(v.String()); err != nil {
+			return "", fmt.Errorf("invalid number %s", v)
+		}
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported value(%+v) of type %T", value, value)
+	}
+}
+
+func formatFloat(f float64, bitSize int) (string, error) {
+	s := strconv.FormatFloat(f, 'g', -1, bitSize)
+	switch {
+	case strings.ContainsAny(s, "IN"):
+		return "", fmt.Errorf("unsupported number %s", s)
+	case !strings.ContainsAny(s, ".e"):
+		s += ".0"
+	}
+	return s, nil
+}
+
+// quote 使用双引号包裹字符串，只使用 lexer 支持的转义字符
+func quote(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04x`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,128 @@
+package dsl
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/rule"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{
+			`status = "running" and (cpu > 80 or tags contains "hot") and created_at >= 2024-01-01`,
+			`status = "running" and (cpu > 80 or tags contains "hot") and created_at >= 2024-01-01`,
+		},
+		{`a = 1 or b = 2 and c = 3`, `a = 1 or (b = 2 and c = 3)`},
+		{`a == 'x' AND b <> 1.0`, `a = "x" and b != 1.0`},
+		{`a IN ("x", 1) and b not in []`, `a in ["x", 1] and b not in []`},
+		{`a is not null and b is empty and c not exists`, `a is not null and b is empty and c not exists`},
+		{`a not contains_i "x" and b size 1`, `a not_contains_i "x" and b size 1`},
+		{`a < 2024-01-02T03:04:05 and b > 2024-01-02T03:04:05+08:00`,
+			`a < 2024-01-02T03:04:05 and b > 2024-01-02T03:04:05+08:00`},
+		{`a datetime_less 1700000000`, `a datetime_less 1700000000`},
+		{"`and` = \"\\\"\\t\" and `a b` = true", "`and` = \"\\\"\\t\" and `a b` = true"},
+		{`labels filter_object (env = "prod" or env = "test")`, `labels filter_object (env = "prod" or env = "test")`},
+		{`tags filter_array (element = "hot")`, `tags filter_array (element = "hot")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r, err := Parse(tt.query)
+			assert.NoError(t, err)
+
+			s, err := Format(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, s)
+
+			// 格式化的结果可以解析为相同的规则
+			again, err := Parse(s)
+			assert.NoError(t, err)
+			assert.Equal(t, r, again)
+		})
+	}
+}
+
+func TestFormatIndent(t *testing.T) {
+	r, err := Parse(`status = "running" and (cpu > 80 or host filter_object (name = "a" and weight > 1)) and tags is empty`)
+	assert.NoError(t, err)
+
+	s, err := FormatIndent(r, "  ")
+	assert.NoError(t, err)
+	assert.Equal(t, `status = "running"
+and (
+  cpu > 80
+  or host filter_object (
+    name = "a"
+    and weight > 1
+  )
+)
+and tags is empty`, s)
+
+	again, err := Parse(s)
+	assert.NoError(t, err)
+	assert.Equal(t, r, again)
+}
+
+func TestFormatJsonRule(t *testing.T) {
+	r, err := rule.ParseJsonRule([]byte(`{
+		"condition": "OR",
+		"rules": [
+			{"field": "age", "operator": "greater_or_equal", "value": 18},
+			{"field": "score", "operator": "less", "value": 0.5},
+			{"field": "tag", "operator": "in", "value": ["a", "b"]},
+			{"field": "created_at", "operator": "datetime_less", "value": "2024-01-01 08:30:00"},
+			{"field": "updated_at", "operator": "datetime_less", "value": "yesterday"}
+		]
+	}`))
+	assert.NoError(t, err)
+
+	s, err := Format(r)
+	assert.NoError(t, err)
+	assert.Equal(t, `age >= 18.0 or score < 0.5 or tag in ["a", "b"] or created_at < 2024-01-01T08:30:00 or `+
+		`updated_at datetime_less "yesterday"`, s)
+
+	// 浮点数的类型保持不变
+	again, err := Parse(s)
+	assert.NoError(t, err)
+	assert.Equal(t, r, again)
+}
+
+func TestFormatValues(t *testing.T) {
+	s, err := Format(&rule.AtomRule{
+		Field: "a", Operator: operator.In, Value: []int{1, 2},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `a in [1, 2]`, s)
+
+	s, err = Format(&rule.AtomRule{Field: "a", Operator: operator.Equal, Value: json.Number("12")})
+	assert.NoError(t, err)
+	assert.Equal(t, `a = 12`, s)
+
+	s, err = Format(&rule.AtomRule{
+		Field: "a", Operator: operator.DatetimeGreater, Value: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `a > 2024-01-02T03:04:05Z`, s)
+
+	_, err = Format(&rule.AtomRule{Field: "a", Operator: operator.Equal, Value: map[string]interface{}{}})
+	assert.Error(t, err)
+
+	_, err = Format(&rule.AtomRule{Field: "a", Operator: operator.Equal})
+	assert.Error(t, err)
+
+	_, err = Format(&rule.AtomRule{Field: "a", Operator: "like", Value: "x"})
+	assert.Error(t, err)
+
+	_, err = Format(&rule.CombinedRule{Condition: operator.And})
+	assert.Error(t, err)
+
+	_, err = Format(&rule.AtomRule{Field: "a", Operator: operator.Object, Value: "x"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,375 @@
+package dsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind 词法单元的类型
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenDatetime
+	tokenCompare
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+// String 返回词法单元类型的描述，用于错误信息
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "end of input"
+	case tokenIdent:
+		return "identifier"
+	case tokenString:
+		return "string"
+	case tokenNumber:
+		return "number"
+	case tokenDatetime:
+		return "datetime"
+	case tokenCompare:
+		return "comparison operator"
+	case tokenLParen:
+		return "'('"
+	case tokenRParen:
+		return "')'"
+	case tokenLBracket:
+		return "'['"
+	case tokenRBracket:
+		return "']'"
+	case tokenComma:
+		return "','"
+	default:
+		return "unknown token"
+	}
+}
+
+// token 词法单元
+type token struct {
+	kind tokenKind
+	// text 原始文本，字符串和反引号字段为解码后的内容
+	text string
+	// quoted 标识符是否使用反引号包裹，包裹的标识符不会被识别为关键字
+	quoted bool
+	pos    Position
+}
+
+// describe 返回词法单元的描述，用于错误信息
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return t.kind.String()
+	}
+	return strconv.Quote(t.text)
+}
+
+// lexer 将输入的查询语句拆分为词法单元
+type lexer struct {
+	input  string
+	offset int
+	line   int
+	column int
+}
+
+func newLexer(input string) *lexer {
+	return &lexer{input: input, line: 1, column: 1}
+}
+
+// peekRune 返回当前位置之后第 n 个字符，不移动位置
+func (l *lexer) peekRune(n int) rune {
+	offset := l.offset
+	for i := 0; ; i++ {
+		if offset >= len(l.input) {
+			return utf8.RuneError
+		}
+		r, size := utf8.DecodeRuneInString(l.input[offset:])
+		if i == n {
+			return r
+		}
+		offset += size
+	}
+}
+
+// next 读取一个字符并移动位置
+func (l *lexer) next() rune {
+	if l.offset >= len(l.input) {
+		return utf8.RuneError
+	}
+	r, size := utf8.DecodeRuneInString(l.input[l.offset:])
+	l.offset += size
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+	return r
+}
+
+func (l *lexer) eof() bool {
+	return l.offset >= len(l.input)
+}
+
+func (l *lexer) pos() Position {
+	return Position{Line: l.line, Column: l.column}
+}
+
+// tokens 返回所有的词法单元，最后一个总是 tokenEOF
+func (l *lexer) tokens() ([]token, error) {
+	result := make([]token, 0)
+	for {
+		tok, err := l.scan()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, tok)
+		if tok.kind == tokenEOF {
+			return result, nil
+		}
+	}
+}
+
+// scan 读取下一个词法单元
+func (l *lexer) scan() (token, error) {
+	for !l.eof() && unicode.IsSpace(l.peekRune(0)) {
+		l.next()
+	}
+
+	start := l.pos()
+	if l.eof() {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	r := l.peekRune(0)
+	switch {
+	case r == '(':
+		l.next()
+		return token{kind: tokenLParen, text: "(", pos: start}, nil
+	case r == ')':
+		l.next()
+		return token{kind: tokenRParen, text: ")", pos: start}, nil
+	case r == '[':
+		l.next()
+		return token{kind: tokenLBracket, text: "[", pos: start}, nil
+	case r == ']':
+		l.next()
+		return token{kind: tokenRBracket, text: "]", pos: start}, nil
+	case r == ',':
+		l.next()
+		return token{kind: tokenComma, text: ",", pos: start}, nil
+	case r == '"' || r == '\'':
+		return l.scanString(start)
+	case r == '`':
+		return l.scanQuotedIdent(start)
+	case r == '=' || r == '!' || r == '<' || r == '>':
+		return l.scanCompare(start)
+	case isDigit(r) || (r == '-' || r == '+') && isDigit(l.peekRune(1)):
+		return l.scanNumber(start)
+	case isIdentStart(r):
+		return l.scanIdent(start), nil
+	default:
+		return token{}, newError(start, "unexpected character %q", r)
+	}
+}
+
+// scanCompare 读取比较操作符: =, ==, !=, <>, <, <=, >, >=
+func (l *lexer) scanCompare(start Position) (token, error) {
+	first := l.next()
+	second := l.peekRune(0)
+
+	text := string(first)
+	switch {
+	case first == '=' && second == '=',
+		first == '!' && second == '=',
+		first == '<' && (second == '=' || second == '>'),
+		first == '>' && second == '=':
+		l.next()
+		text += string(second)
+	case first == '!':
+		return token{}, newError(start, "unexpected character '!', do you mean '!='")
+	}
+
+	return token{kind: tokenCompare, text: text, pos: start}, nil
+}
+
+// scanString 读取单引号或双引号包裹的字符串，支持 go 语言的转义字符
+func (l *lexer) scanString(start Position) (token, error) {
+	quote := l.next()
+	var b strings.Builder
+	for {
+		if l.eof() {
+			return token{}, newError(start, "unterminated string")
+		}
+
+		r := l.next()
+		switch r {
+		case quote:
+			return token{kind: tokenString, text: b.String(), pos: start}, nil
+		case '\n':
+			return token{}, newError(start, "unterminated string")
+		case '\\':
+			escPos := l.pos()
+			value, err := l.scanEscape(quote)
+			if err != nil {
+				return token{}, newError(escPos, "%v", err)
+			}
+			b.WriteString(value)
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+// scanEscape 读取转义字符，反斜杠已经被读取
+func (l *lexer) scanEscape(quote rune) (string, error) {
+	if l.eof() {
+		return "", fmt.Errorf("unterminated escape sequence")
+	}
+
+	r := l.next()
+	switch r {
+	case 'n':
+		return "\n", nil
+	case 't':
+		return "\t", nil
+	case 'r':
+		return "\r", nil
+	case '\\':
+		return `\`, nil
+	case quote:
+		return string(quote), nil
+	case 'u':
+		hex := make([]rune, 0, 4)
+		for i := 0; i < 4; i++ {
+			h := l.peekRune(0)
+			if !isHex(h) {
+				return "", fmt.Errorf("invalid unicode escape sequence")
+			}
+			hex = append(hex, l.next())
+		}
+		code, _ := strconv.ParseUint(string(hex), 16, 32)
+		return string(rune(code)), nil
+	default:
+		return "", fmt.Errorf("unknown escape sequence '\\%c'", r)
+	}
+}
+
+// scanQuotedIdent 读取反引号包裹的字段名，用于包含特殊字符或与关键字同名的字段
+func (l *lexer) scanQuotedIdent(start Position) (token, error) {
+	l.next()
+	var b strings.Builder
+	for {
+		if l.eof() {
+			return token{}, newError(start, "unterminated quoted identifier")
+		}
+
+		r := l.next()
+		if r == '`' {
+			// 两个连续的反引号表示一个反引号
+			if l.peekRune(0) == '`' {
+				l.next()
+				b.WriteRune('`')
+				continue
+			}
+			if b.Len() == 0 {
+				return token{}, newError(start, "empty quoted identifier")
+			}
+			return token{kind: tokenIdent, text: b.String(), quoted: true, pos: start}, nil
+		}
+		if r == '\n' {
+			return token{}, newError(start, "unterminated quoted identifier")
+		}
+		b.WriteRune(r)
+	}
+}
+
+// scanIdent 读取标识符，标识符可以包含 . 用于表示嵌套字段
+func (l *lexer) scanIdent(start Position) token {
+	begin := l.offset
+	for !l.eof() {
+		r := l.peekRune(0)
+		if !isIdentStart(r) && !isDigit(r) && r != '.' {
+			break
+		}
+		l.next()
+	}
+	return token{kind: tokenIdent, text: l.input[begin:l.offset], pos: start}
+}
+
+// scanNumber 读取数字，形如 yyyy-mm-dd 开头的数字作为日期时间读取
+func (l *lexer) scanNumber(start Position) (token, error) {
+	begin := l.offset
+	if r := l.peekRune(0); r == '-' || r == '+' {
+		l.next()
+	}
+	l.skipDigits()
+
+	if l.offset-begin == 4 && l.peekRune(0) == '-' && isDigit(l.peekRune(1)) {
+		return l.scanDatetime(begin, start)
+	}
+
+	if l.peekRune(0) == '.' && isDigit(l.peekRune(1)) {
+		l.next()
+		l.skipDigits()
+	}
+	if r := l.peekRune(0); r == 'e' || r == 'E' {
+		l.next()
+		if r := l.peekRune(0); r == '-' || r == '+' {
+			l.next()
+		}
+		if !isDigit(l.peekRune(0)) {
+			return token{}, newError(start, "invalid number %q", l.input[begin:l.offset])
+		}
+		l.skipDigits()
+	}
+
+	if r := l.peekRune(0); isIdentStart(r) {
+		return token{}, newError(l.pos(), "unexpected character %q after number", r)
+	}
+	return token{kind: tokenNumber, text: l.input[begin:l.offset], pos: start}, nil
+}
+
+// scanDatetime 读取日期时间，支持 2006-01-02, 2006-01-02T15:04:05 和 2006-01-02T15:04:05+08:00 等格式，
+// 格式的合法性在语法分析时校验
+func (l *lexer) scanDatetime(begin int, start Position) (token, error) {
+	for !l.eof() {
+		r := l.peekRune(0)
+		if !isDigit(r) && r != '-' && r != '+' && r != ':' && r != '.' && r != 'T' && r != 'Z' {
+			break
+		}
+		l.next()
+	}
+
+	if r := l.peekRune(0); isIdentStart(r) {
+		return token{}, newError(l.pos(), "unexpected character %q in datetime", r)
+	}
+	return token{kind: tokenDatetime, text: l.input[begin:l.offset], pos: start}, nil
+}
+
+func (l *lexer) skipDigits() {
+	for isDigit(l.peekRune(0)) {
+		l.next()
+	}
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isHex(r rune) bool {
+	return isDigit(r) || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
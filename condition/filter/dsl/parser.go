@@ -0,0 +1,551 @@
+package dsl
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/rule"
+)
+
+// 关键字，不区分大小写
+const (
+	keywordAnd    = "and"
+	keywordOr     = "or"
+	keywordNot    = "not"
+	keywordIn     = "in"
+	keywordIs     = "is"
+	keywordNull   = "null"
+	keywordEmpty  = "empty"
+	keywordExists = "exists"
+	keywordTrue   = "true"
+	keywordFalse  = "false"
+)
+
+// 日期时间的格式
+const (
+	dateLayout     = "2006-01-02"
+	datetimeLayout = "2006-01-02T15:04:05"
+	// ruleTimeLayout 过滤规则中不带时区的时间格式，见 validator.IsTime
+	ruleTimeLayout = "2006-01-02 15:04:05"
+)
+
+// Parse 将查询语句解析为过滤规则，不对规则进行校验，例如
+//
+//	status = "running" and (cpu > 80 or tags contains "hot") and created_at >= 2024-01-01
+//
+// and 的优先级高于 or，同一层级连续的 and/or 合并为一个组合规则，括号总是生成一个新的组合规则。
+func Parse(query string) (operator.IRuleFactory, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parse()
+}
+
+// ParseWithOption 将查询语句解析为过滤规则，并使用 opt 校验规则，
+// 超出 MaxRulesDepth, MaxRulesLimit, MaxInLimit, MaxNotInLimit 等限制时返回的错误包含出错的位置。
+func ParseWithOption(query string, opt *operator.ExprOption) (operator.IRuleFactory, error) {
+	if opt == nil {
+		return nil, errors.New("validate option must be set")
+	}
+
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.validate(r, opt); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// parser 递归下降的语法分析器
+//
+//	expr      = and { "or" and }
+//	and       = term { "and" term }
+//	term      = "(" expr ")" | condition
+//	condition = field ( compare value | [ "not" ] "in" list | "is" [ "not" ] ( "null" | "empty" )
+//	            | [ "not" ] "exists" | [ "not" ] operator [ value | list | "(" expr ")" ] )
+type parser struct {
+	tokens []token
+	idx    int
+	// positions 记录每个规则在查询语句中的位置，用于报告校验错误
+	positions map[operator.IRuleFactory]Position
+	// valuePositions 记录原子规则的值在查询语句中的位置
+	valuePositions map[operator.IRuleFactory]Position
+}
+
+func newParser(query string) (*parser, error) {
+	tokens, err := newLexer(query).tokens()
+	if err != nil {
+		return nil, err
+	}
+
+	return &parser{
+		tokens:         tokens,
+		positions:      make(map[operator.IRuleFactory]Position),
+		valuePositions: make(map[operator.IRuleFactory]Position),
+	}, nil
+}
+
+func (p *parser) parse() (operator.IRuleFactory, error) {
+	if p.peek().kind == tokenEOF {
+		return nil, newError(p.peek().pos, "query is empty")
+	}
+
+	r, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, newError(tok.pos, "unexpected %s", tok.describe())
+	}
+	return r, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.idx]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.idx]
+	if tok.kind != tokenEOF {
+		p.idx++
+	}
+	return tok
+}
+
+// expect 读取指定类型的词法单元
+func (p *parser) expect(kind tokenKind) (token, error) {
+	tok := p.peek()
+	if tok.kind != kind {
+		return tok, newError(tok.pos, "expected %s, got %s", kind, tok.describe())
+	}
+	return p.advance(), nil
+}
+
+// isKeyword 判断词法单元是否是指定的关键字，反引号包裹的标识符不是关键字
+func isKeyword(tok token, keyword string) bool {
+	return tok.kind == tokenIdent && !tok.quoted && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *parser) parseExpr() (operator.IRuleFactory, error) {
+	return p.parseLogic(operator.Or, keywordOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (operator.IRuleFactory, error) {
+	return p.parseLogic(operator.And, keywordAnd, p.parseTerm)
+}
+
+// parseLogic 解析由 keyword 连接的多个子规则，只有一个子规则时直接返回子规则
+func (p *parser) parseLogic(
+	condition operator.LogicOperator,
+	keyword string,
+	parseChild func() (operator.IRuleFactory, error),
+) (operator.IRuleFactory, error) {
+	start := p.peek().pos
+	first, err := parseChild()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []operator.IRuleFactory{first}
+	for isKeyword(p.peek(), keyword) {
+		p.advance()
+		child, err := parseChild()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, child)
+	}
+
+	if len(rules) == 1 {
+		return first, nil
+	}
+
+	combined := &rule.CombinedRule{Condition: condition, Rules: rules}
+	p.positions[combined] = start
+	return combined, nil
+}
+
+func (p *parser) parseTerm() (operator.IRuleFactory, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenLParen:
+		p.advance()
+		r, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen); err != nil {
+			return nil, err
+		}
+		if _, ok := r.(*rule.CombinedRule); ok {
+			p.positions[r] = tok.pos
+		}
+		return r, nil
+	case isKeyword(tok, keywordAnd), isKeyword(tok, keywordOr), isKeyword(tok, keywordNot):
+		return nil, newError(tok.pos, "unexpected keyword %s, expected field or '('", tok.describe())
+	case tok.kind == tokenIdent:
+		return p.parseCondition()
+	default:
+		return nil, newError(tok.pos, "expected field or '(', got %s", tok.describe())
+	}
+}
+
+// parseCondition 解析原子规则
+func (p *parser) parseCondition() (operator.IRuleFactory, error) {
+	field := p.advance()
+	atom := &rule.AtomRule{Field: field.text}
+	p.positions[atom] = field.pos
+
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenCompare:
+		return atom, p.parseCompare(atom)
+	case isKeyword(tok, keywordIn):
+		p.advance()
+		return atom, p.parseOpValue(atom, operator.In)
+	case isKeyword(tok, keywordIs):
+		p.advance()
+		return atom, p.parseIs(atom)
+	case isKeyword(tok, keywordExists):
+		p.advance()
+		atom.Operator = operator.Exist
+		return atom, nil
+	case isKeyword(tok, keywordNot):
+		p.advance()
+		return atom, p.parseNot(atom, tok)
+	case tok.kind == tokenIdent && !tok.quoted:
+		op := operator.OpType(strings.ToLower(tok.text))
+		if op.Validate() != nil {
+			return nil, newError(tok.pos, "unknown operator %s", tok.describe())
+		}
+		p.advance()
+		return atom, p.parseOpValue(atom, op)
+	default:
+		return nil, newError(tok.pos, "expected operator after field %q, got %s", field.text, tok.describe())
+	}
+}
+
+// parseCompare 解析比较操作符，值为日期时间时使用 datetime 操作符
+func (p *parser) parseCompare(atom *rule.AtomRule) error {
+	opTok := p.advance()
+	valueTok := p.peek()
+	value, isDatetime, err := p.parseScalar()
+	if err != nil {
+		return err
+	}
+	atom.Value = value
+	p.valuePositions[atom] = valueTok.pos
+
+	switch opTok.text {
+	case "=", "==":
+		atom.Operator = operator.Equal
+	case "!=", "<>":
+		atom.Operator = operator.NotEqual
+	case "<":
+		atom.Operator = pickOp(isDatetime, operator.DatetimeLess, operator.Less)
+	case "<=":
+		atom.Operator = pickOp(isDatetime, operator.DatetimeLessOrEqual, operator.LessOrEqual)
+	case ">":
+		atom.Operator = pickOp(isDatetime, operator.DatetimeGreater, operator.Greater)
+	case ">=":
+		atom.Operator = pickOp(isDatetime, operator.DatetimeGreaterOrEqual, operator.GreaterOrEqual)
+	}
+
+	if isDatetime && !isDatetimeOp(atom.Operator) {
+		return newError(valueTok.pos, "datetime value is only supported by <, <=, > and >=")
+	}
+	return nil
+}
+
+func pickOp(isDatetime bool, datetimeOp, op operator.OpType) operator.OpType {
+	if isDatetime {
+		return datetimeOp
+	}
+	return op
+}
+
+// parseIs 解析 is [not] null 和 is [not] empty
+func (p *parser) parseIs(atom *rule.AtomRule) error {
+	not := false
+	if isKeyword(p.peek(), keywordNot) {
+		p.advance()
+		not = true
+	}
+
+	tok := p.advance()
+	switch {
+	case isKeyword(tok, keywordNull):
+		atom.Operator = pickOp(not, operator.IsNotNull, operator.IsNull)
+	case isKeyword(tok, keywordEmpty):
+		atom.Operator = pickOp(not, operator.IsNotEmpty, operator.IsEmpty)
+	default:
+		return newError(tok.pos, "expected null or empty, got %s", tok.describe())
+	}
+	return nil
+}
+
+// parseNot 解析 not in, not exists 和 not contains 等取反的操作符
+func (p *parser) parseNot(atom *rule.AtomRule, notTok token) error {
+	tok := p.peek()
+	switch {
+	case isKeyword(tok, keywordIn):
+		p.advance()
+		return p.parseOpValue(atom, operator.NotIn)
+	case isKeyword(tok, keywordExists):
+		p.advance()
+		atom.Operator = operator.NotExist
+		return nil
+	case tok.kind == tokenIdent && !tok.quoted:
+		op := operator.OpType(keywordNot + "_" + strings.ToLower(tok.text))
+		if op.Validate() != nil {
+			return newError(notTok.pos, "unknown operator \"not %s\"", tok.text)
+		}
+		p.advance()
+		return p.parseOpValue(atom, op)
+	default:
+		return newError(tok.pos, "expected operator after not, got %s", tok.describe())
+	}
+}
+
+// parseOpValue 根据操作符解析值
+func (p *parser) parseOpValue(atom *rule.AtomRule, op operator.OpType) error {
+	atom.Operator = op
+	valueTok := p.peek()
+	p.valuePositions[atom] = valueTok.pos
+
+	switch op {
+	case operator.IsEmpty, operator.IsNotEmpty, operator.IsNull, operator.IsNotNull, operator.Exist, operator.NotExist:
+		return nil
+	case operator.Object, operator.Array:
+		if _, err := p.expect(tokenLParen); err != nil {
+			return err
+		}
+		sub, err := p.parseExpr()
+		if err != nil {
+			return err
+		}
+		if _, err := p.expect(tokenRParen); err != nil {
+			return err
+		}
+		atom.Value = sub
+		return nil
+	case operator.In, operator.NotIn:
+		list, err := p.parseList()
+		if err != nil {
+			return err
+		}
+		atom.Value = list
+		return nil
+	}
+
+	var value interface{}
+	var isDatetime bool
+	var err error
+	if valueTok.kind == tokenLBracket {
+		value, err = p.parseList()
+	} else {
+		value, isDatetime, err = p.parseScalar()
+	}
+	if err != nil {
+		return err
+	}
+
+	if isDatetime && !isDatetimeOp(op) {
+		return newError(valueTok.pos, "datetime value is not supported by operator %s", op)
+	}
+	atom.Value = value
+	return nil
+}
+
+// parseList 解析 [v1, v2] 或 (v1, v2) 形式的列表
+func (p *parser) parseList() ([]interface{}, error) {
+	open := p.peek()
+	var closeKind tokenKind
+	switch open.kind {
+	case tokenLBracket:
+		closeKind = tokenRBracket
+	case tokenLParen:
+		closeKind = tokenRParen
+	default:
+		return nil, newError(open.pos, "expected list, got %s", open.describe())
+	}
+	p.advance()
+
+	list := make([]interface{}, 0)
+	if p.peek().kind == closeKind {
+		p.advance()
+		return list, nil
+	}
+
+	for {
+		tok := p.peek()
+		value, isDatetime, err := p.parseScalar()
+		if err != nil {
+			return nil, err
+		}
+		if isDatetime {
+			return nil, newError(tok.pos, "datetime value is not supported in list")
+		}
+		list = append(list, value)
+
+		tok = p.advance()
+		switch tok.kind {
+		case tokenComma:
+		case closeKind:
+			return list, nil
+		default:
+			return nil, newError(tok.pos, "expected ',' or %s, got %s", closeKind, tok.describe())
+		}
+	}
+}
+
+// parseScalar 解析字符串、数字、布尔值和日期时间
+func (p *parser) parseScalar() (interface{}, bool, error) {
+	tok := p.advance()
+	switch {
+	case tok.kind == tokenString:
+		return tok.text, false, nil
+	case tok.kind == tokenNumber:
+		value, err := parseNumber(tok.text)
+		if err != nil {
+			return nil, false, newError(tok.pos, "invalid number %s", tok.describe())
+		}
+		return value, false, nil
+	case tok.kind == tokenDatetime:
+		value, err := parseDatetime(tok.text)
+		if err != nil {
+			return nil, false, newError(tok.pos, "%v", err)
+		}
+		return value, true, nil
+	case isKeyword(tok, keywordTrue):
+		return true, false, nil
+	case isKeyword(tok, keywordFalse):
+		return false, false, nil
+	default:
+		return nil, false, newError(tok.pos, "expected value, got %s", tok.describe())
+	}
+}
+
+// parseNumber 整数解析为 int64，其它解析为 float64
+func parseNumber(text string) (interface{}, error) {
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i, nil
+	}
+	return strconv.ParseFloat(text, 64)
+}
+
+// parseDatetime 将日期时间转换为过滤规则支持的值：
+// 不带时区的时间转换为 2006-01-02 15:04:05 格式的字符串，带 +hh:mm 时区的时间保持原样，
+// 其它时区的时间转换为 time.Time
+func parseDatetime(text string) (interface{}, error) {
+	if t, err := time.Parse(dateLayout, text); err == nil {
+		return t.Format(ruleTimeLayout), nil
+	}
+	if t, err := time.Parse(datetimeLayout, text); err == nil {
+		return t.Format(ruleTimeLayout), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return nil, errors.New("invalid datetime " + strconv.Quote(text) +
+			", supported formats are 2006-01-02, 2006-01-02T15:04:05 and 2006-01-02T15:04:05+08:00")
+	}
+	if strings.Contains(text, "+") {
+		return text, nil
+	}
+	return t, nil
+}
+
+func isDatetimeOp(op operator.OpType) bool {
+	switch op {
+	case operator.DatetimeLess, operator.DatetimeLessOrEqual, operator.DatetimeGreater, operator.DatetimeGreaterOrEqual:
+		return true
+	}
+	return false
+}
+
+// validate 校验规则，规则数量、深度和 in 的元素数量等限制的错误包含出错的位置
+func (p *parser) validate(r operator.IRuleFactory, opt *operator.ExprOption) error {
+	if err := p.checkLimits(r, opt, opt.MaxRulesDepth, true); err != nil {
+		return err
+	}
+
+	if err := r.Validate(opt); err != nil {
+		return newError(p.positions[r], "%v", err)
+	}
+	return nil
+}
+
+// checkLimits 与 CombinedRule.Validate 和 ObjectOp.ValidateValue 的深度计算方式保持一致，
+// topLevel 表示规则不在 filter_object 和 filter_array 中，此时可以直接使用 opt 校验原子规则
+func (p *parser) checkLimits(r operator.IRuleFactory, opt *operator.ExprOption, depth uint, topLevel bool) error {
+	pos := p.positions[r]
+	switch v := r.(type) {
+	case *rule.CombinedRule:
+		if uint(len(v.Rules)) > opt.MaxRulesLimit {
+			return newError(pos, "rules elements number exceeds limit: %d", opt.MaxRulesLimit)
+		}
+		if depth <= 1 {
+			return newError(pos, "expression rules depth exceeds maximum: %d", opt.MaxRulesDepth)
+		}
+		for _, child := range v.Rules {
+			if err := p.checkLimits(child, opt, depth-1, topLevel); err != nil {
+				return err
+			}
+		}
+	case *rule.AtomRule:
+		valuePos := p.valuePositions[r]
+		switch v.Operator {
+		case operator.In:
+			if n := listLen(v.Value); n > int(opt.MaxInLimit) {
+				return newError(valuePos, "in operator's value elements number %d exceeds limit: %d", n, opt.MaxInLimit)
+			}
+		case operator.NotIn:
+			if n := listLen(v.Value); n > int(opt.MaxNotInLimit) {
+				return newError(valuePos, "not in operator's value elements number %d exceeds limit: %d",
+					n, opt.MaxNotInLimit)
+			}
+		case operator.Object, operator.Array:
+			if depth <= 1 {
+				return newError(valuePos, "expression rules depth exceeds maximum: %d", opt.MaxRulesDepth)
+			}
+			if sub, ok := v.Value.(operator.IRuleFactory); ok {
+				// 子规则的字段相对于父字段，交给 AtomRule.Validate 校验
+				return p.checkLimits(sub, opt, depth-1, false)
+			}
+		}
+
+		if topLevel {
+			atomOpt := operator.CloneExprOption(opt)
+			atomOpt.MaxRulesDepth = depth
+			if err := v.Validate(atomOpt); err != nil {
+				return newError(pos, "%v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func listLen(value interface{}) int {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return 0
+	}
+	return v.Len()
+}
@@ -0,0 +1,246 @@
+package dsl
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/criteria"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/rule"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	r, err := Parse(`status = "running" and (cpu > 80 or tags contains "hot") and created_at >= 2024-01-01`)
+	assert.NoError(t, err)
+
+	expected := &rule.CombinedRule{
+		Condition: operator.And,
+		Rules: []operator.IRuleFactory{
+			&rule.AtomRule{Field: "status", Operator: operator.Equal, Value: "running"},
+			&rule.CombinedRule{
+				Condition: operator.Or,
+				Rules: []operator.IRuleFactory{
+					&rule.AtomRule{Field: "cpu", Operator: operator.Greater, Value: int64(80)},
+					&rule.AtomRule{Field: "tags", Operator: operator.Contains, Value: "hot"},
+				},
+			},
+			&rule.AtomRule{
+				Field:    "created_at",
+				Operator: operator.DatetimeGreaterOrEqual,
+				Value:    "2024-01-01 00:00:00",
+			},
+		},
+	}
+	assert.Equal(t, expected, r)
+}
+
+func TestParsePrecedence(t *testing.T) {
+	// and 的优先级高于 or
+	r, err := Parse(`a = 1 or b = 2 and c = 3 or d = 4`)
+	assert.NoError(t, err)
+
+	expected := &rule.CombinedRule{
+		Condition: operator.Or,
+		Rules: []operator.IRuleFactory{
+			&rule.AtomRule{Field: "a", Operator: operator.Equal, Value: int64(1)},
+			&rule.CombinedRule{
+				Condition: operator.And,
+				Rules: []operator.IRuleFactory{
+					&rule.AtomRule{Field: "b", Operator: operator.Equal, Value: int64(2)},
+					&rule.AtomRule{Field: "c", Operator: operator.Equal, Value: int64(3)},
+				},
+			},
+			&rule.AtomRule{Field: "d", Operator: operator.Equal, Value: int64(4)},
+		},
+	}
+	assert.Equal(t, expected, r)
+
+	// 冗余的括号不生成组合规则
+	r, err = Parse(`((a = 1))`)
+	assert.NoError(t, err)
+	assert.Equal(t, &rule.AtomRule{Field: "a", Operator: operator.Equal, Value: int64(1)}, r)
+}
+
+func TestParseOperators(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected *rule.AtomRule
+	}{
+		{`a == "x"`, &rule.AtomRule{Field: "a", Operator: operator.Equal, Value: "x"}},
+		{`a != 'x'`, &rule.AtomRule{Field: "a", Operator: operator.NotEqual, Value: "x"}},
+		{`a <> true`, &rule.AtomRule{Field: "a", Operator: operator.NotEqual, Value: true}},
+		{`a < -1.5`, &rule.AtomRule{Field: "a", Operator: operator.Less, Value: -1.5}},
+		{`a <= 1e3`, &rule.AtomRule{Field: "a", Operator: operator.LessOrEqual, Value: float64(1000)}},
+		{`a > 0`, &rule.AtomRule{Field: "a", Operator: operator.Greater, Value: int64(0)}},
+		{`a in [1, "b", false]`, &rule.AtomRule{
+			Field: "a", Operator: operator.In, Value: []interface{}{int64(1), "b", false},
+		}},
+		{`a IN ("x")`, &rule.AtomRule{Field: "a", Operator: operator.In, Value: []interface{}{"x"}}},
+		{`a not in []`, &rule.AtomRule{Field: "a", Operator: operator.NotIn, Value: []interface{}{}}},
+		{`a is null`, &rule.AtomRule{Field: "a", Operator: operator.IsNull}},
+		{`a is not null`, &rule.AtomRule{Field: "a", Operator: operator.IsNotNull}},
+		{`a is empty`, &rule.AtomRule{Field: "a", Operator: operator.IsEmpty}},
+		{`a IS NOT EMPTY`, &rule.AtomRule{Field: "a", Operator: operator.IsNotEmpty}},
+		{`a exists`, &rule.AtomRule{Field: "a", Operator: operator.Exist}},
+		{`a not exists`, &rule.AtomRule{Field: "a", Operator: operator.NotExist}},
+		{`a not_exist`, &rule.AtomRule{Field: "a", Operator: operator.NotExist}},
+		{`a size 3`, &rule.AtomRule{Field: "a", Operator: operator.Size, Value: int64(3)}},
+		{`a contains_s "X"`, &rule.AtomRule{Field: "a", Operator: operator.ContainsSensitive, Value: "X"}},
+		{`a not contains "x"`, &rule.AtomRule{Field: "a", Operator: operator.NotContains, Value: "x"}},
+		{`a not contains_i "x"`, &rule.AtomRule{Field: "a", Operator: operator.NotContainsInsensitive, Value: "x"}},
+		{`a begins_with "x"`, &rule.AtomRule{Field: "a", Operator: operator.BeginsWith, Value: "x"}},
+		{`a not begins_with_i "x"`, &rule.AtomRule{
+			Field: "a", Operator: operator.NotBeginsWithInsensitive, Value: "x",
+		}},
+		{`a ends_with_i "x"`, &rule.AtomRule{Field: "a", Operator: operator.EndsWithInsensitive, Value: "x"}},
+		{`a.b.c = "x\n\"y\"中"`, &rule.AtomRule{Field: "a.b.c", Operator: operator.Equal, Value: "x\n\"y\"中"}},
+		{"`and` = 1", &rule.AtomRule{Field: "and", Operator: operator.Equal, Value: int64(1)}},
+		{"`a b``c` = 1", &rule.AtomRule{Field: "a b`c", Operator: operator.Equal, Value: int64(1)}},
+		{`a < 2024-01-02T03:04:05`, &rule.AtomRule{
+			Field: "a", Operator: operator.DatetimeLess, Value: "2024-01-02 03:04:05",
+		}},
+		{`a > 2024-01-02T03:04:05+08:00`, &rule.AtomRule{
+			Field: "a", Operator: operator.DatetimeGreater, Value: "2024-01-02T03:04:05+08:00",
+		}},
+		{`a <= 2024-01-02T03:04:05Z`, &rule.AtomRule{
+			Field: "a", Operator: operator.DatetimeLessOrEqual, Value: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		}},
+		{`a datetime_greater 1700000000`, &rule.AtomRule{
+			Field: "a", Operator: operator.DatetimeGreater, Value: int64(1700000000),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r, err := Parse(tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, r)
+		})
+	}
+}
+
+func TestParseFilterObject(t *testing.T) {
+	r, err := Parse(`labels filter_object (env = "prod" or env = "test") and tags filter_array (element = "hot")`)
+	assert.NoError(t, err)
+
+	expected := &rule.CombinedRule{
+		Condition: operator.And,
+		Rules: []operator.IRuleFactory{
+			&rule.AtomRule{
+				Field:    "labels",
+				Operator: operator.Object,
+				Value: &rule.CombinedRule{
+					Condition: operator.Or,
+					Rules: []operator.IRuleFactory{
+						&rule.AtomRule{Field: "env", Operator: operator.Equal, Value: "prod"},
+						&rule.AtomRule{Field: "env", Operator: operator.Equal, Value: "test"},
+					},
+				},
+			},
+			&rule.AtomRule{
+				Field:    "tags",
+				Operator: operator.Array,
+				Value:    &rule.AtomRule{Field: operator.ArrayElement, Operator: operator.Equal, Value: "hot"},
+			},
+		},
+	}
+	assert.Equal(t, expected, r)
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		query  string
+		pos    Position
+		substr string
+	}{
+		{``, Position{1, 1}, "query is empty"},
+		{`a = 1 and`, Position{1, 10}, "expected field or '('"},
+		{`a = `, Position{1, 5}, "expected value"},
+		{`a ~ 1`, Position{1, 3}, "unexpected character"},
+		{`a = "abc`, Position{1, 5}, "unterminated string"},
+		{`a = "\q"`, Position{1, 7}, "unknown escape sequence"},
+		{`a like "x"`, Position{1, 3}, "unknown operator"},
+		{`a not like "x"`, Position{1, 3}, "unknown operator \"not like\""},
+		{`a = 1 b = 2`, Position{1, 7}, "unexpected \"b\""},
+		{"a = 1 and\n  (b = 2 or c = 3", Position{2, 18}, "expected ')'"},
+		{`a in 1`, Position{1, 6}, "expected list"},
+		{`a in [1 2]`, Position{1, 9}, "expected ',' or ']'"},
+		{`a is nothing`, Position{1, 6}, "expected null or empty"},
+		{`a = 2024-01-01`, Position{1, 5}, "only supported by <, <=, > and >="},
+		{`a contains 2024-01-01`, Position{1, 12}, "not supported by operator contains"},
+		{`a > 2024-13-01`, Position{1, 5}, "invalid datetime"},
+		{`a > 1x`, Position{1, 6}, "after number"},
+		{`not a = 1`, Position{1, 1}, "unexpected keyword"},
+		{`a filter_object b = 1`, Position{1, 17}, "expected '('"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			var dslErr *Error
+			if !errors.As(err, &dslErr) {
+				t.Fatalf("expected *Error, got %v", err)
+			}
+			assert.Equal(t, tt.pos, dslErr.Pos)
+			assert.Contains(t, dslErr.Msg, tt.substr)
+		})
+	}
+}
+
+func TestParseWithOption(t *testing.T) {
+	opt := operator.NewDefaultExprOpt(map[string]criteria.FieldType{
+		"status":      criteria.String,
+		"cpu":         criteria.Numeric,
+		"tags":        criteria.Array,
+		"created_at":  criteria.Time,
+		"labels":      criteria.MapString,
+		"host.name":   criteria.String,
+		"host":        criteria.Object,
+		"host.weight": criteria.Numeric,
+	})
+	opt.MaxInLimit = 2
+	opt.MaxNotInLimit = 1
+
+	_, err := ParseWithOption(
+		`status = "running" and (cpu > 80 or status contains "run") and created_at >= 2024-01-01`, opt)
+	assert.NoError(t, err)
+
+	_, err = ParseWithOption(`host filter_object (name = "a" and weight > 1)`, opt)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		query  string
+		pos    Position
+		substr string
+	}{
+		{`status in ["a", "b", "c"]`, Position{1, 11}, "exceeds limit: 2"},
+		{`status not in ["a", "b"]`, Position{1, 15}, "exceeds limit: 1"},
+		{`a = 1 and b = 2`, Position{1, 1}, "rule field: a is not exist"},
+		{`status = 1 and cpu = 1`, Position{1, 1}, "invalid status's value"},
+		{`status = "a" and cpu > "x"`, Position{1, 18}, "invalid cpu's value"},
+		{`cpu = 1 and (cpu = 2 or (cpu = 3 and cpu = 4))`, Position{1, 25}, "depth exceeds maximum: 3"},
+		{`cpu = 1 and (cpu = 2 or host filter_object (name = "a"))`, Position{1, 44}, "depth exceeds maximum: 3"},
+		{`host filter_object (name = 1)`, Position{1, 1}, "invalid name's value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseWithOption(tt.query, opt)
+			var dslErr *Error
+			if !errors.As(err, &dslErr) {
+				t.Fatalf("expected *Error, got %v", err)
+			}
+			assert.Equal(t, tt.pos, dslErr.Pos)
+			assert.Contains(t, dslErr.Msg, tt.substr)
+		})
+	}
+
+	opt.MaxRulesLimit = 2
+	_, err = ParseWithOption(`cpu = 1 or cpu = 2 or cpu = 3`, opt)
+	assert.ErrorContains(t, err, "line 1, column 1: rules elements number exceeds limit: 2")
+
+	_, err = ParseWithOption(`cpu = 1`, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package expression
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/google/cel-go/cel"
+)
+
+// NewCELEnv returns the cel environment which declares the data variable used by the expression converted by ToCEL,
+// the data is a map(string, dyn) named operator.CELDataVar.
+func NewCELEnv(opts ...cel.EnvOption) (*cel.Env, error) {
+	envOpts := []cel.EnvOption{
+		cel.Variable(operator.CELDataVar, cel.MapType(cel.StringType, cel.DynType)),
+	}
+	return cel.NewEnv(append(envOpts, opts...)...)
+}
+
+// CELProgram converts the expression to a cel program, the env should be created by NewCELEnv.
+func (exp Expression) CELProgram(env *cel.Env) (cel.Program, error) {
+	if exp.IRuleFactory == nil {
+		return nil, errors.New("expression should not be nil")
+	}
+	if env == nil {
+		return nil, errors.New("cel env must be set")
+	}
+
+	source, err := exp.ToCEL()
+	if err != nil {
+		return nil, err
+	}
+
+	ast, iss := env.Compile(source)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("compile cel expression failed, err: %v", iss.Err())
+	}
+
+	return env.Program(ast)
+}
+
+// MatchCEL checks if the data matches the cel program converted by CELProgram.
+func MatchCEL(prg cel.Program, data map[string]interface{}) (bool, error) {
+	out, _, err := prg.Eval(map[string]interface{}{operator.CELDataVar: data})
+	if err != nil {
+		return false, err
+	}
+
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("cel program result(%v) is not bool type", out)
+	}
+	return matched, nil
+}
@@ -0,0 +1,215 @@
+package expression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/collections/maps/mapstr"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/dsl"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/rule"
+	"github.com/stretchr/testify/assert"
+)
+
+// celFixtureData 覆盖各种类型的数据，包括类型不匹配和字段不存在的情况
+var celFixtureData = []map[string]interface{}{
+	{
+		"name":       "Alpha-Web",
+		"cpu":        85,
+		"mem":        0.5,
+		"tags":       []interface{}{"hot", "web"},
+		"enabled":    true,
+		"created_at": "2024-03-01 10:00:00",
+		"labels":     map[string]interface{}{"env": "prod", "tier": 1},
+		"ports":      []interface{}{80, 443},
+	},
+	{
+		"name":       "beta-db",
+		"cpu":        20.5,
+		"mem":        "0.75",
+		"tags":       []interface{}{},
+		"enabled":    false,
+		"created_at": time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC),
+		"labels":     map[string]interface{}{"env": "test"},
+		"ports":      []interface{}{"x", 5432},
+	},
+	{
+		"name":       42,
+		"cpu":        "90",
+		"tags":       nil,
+		"enabled":    "yes",
+		"created_at": 1700000000,
+		"labels":     12,
+		"ports":      "none",
+	},
+	{},
+	{
+		"name":       "GAMMA",
+		"cpu":        true,
+		"mem":        uint(3),
+		"tags":       []string{"HOT", "cold"},
+		"created_at": "2024-01-01T08:00:00+08:00",
+		"labels":     mapstr.MapStr{"env": nil, "tier": "2"},
+		"ports":      []interface{}{443, "x"},
+	},
+	{
+		"name":       "a.b*c",
+		"cpu":        "abc",
+		"mem":        []interface{}{1},
+		"created_at": "2024-01-01T08:00:00.5+09:00",
+		"ports":      []interface{}{},
+		"enabled":    1,
+	},
+}
+
+// celFixtureRules 使用查询语句书写的规则
+var celFixtureRules = []string{
+	// equal
+	`name = "Alpha-Web"`,
+	`name != "beta-db"`,
+	`name = 42`,
+	`cpu = 85`,
+	`cpu != 85`,
+	`cpu = "90"`,
+	`cpu = true`,
+	`enabled = true`,
+	`enabled != false`,
+	`mem = 0.5`,
+	// numeric compare
+	`cpu > 50`,
+	`cpu >= 20.5`,
+	`cpu < 1`,
+	`cpu <= 90`,
+	`mem > 0.6`,
+	`mem less "1"`,
+	// in
+	`name in ["beta-db", "GAMMA"]`,
+	`name not in ["beta-db"]`,
+	`cpu in [85, "90"]`,
+	`cpu in [1, "x", 85]`,
+	`cpu not in [85, "x", 1]`,
+	`name in []`,
+	`name not in []`,
+	`enabled in [true, 1]`,
+	`enabled not in [false]`,
+	`ports in [80]`,
+	// string
+	`name contains "ALPHA"`,
+	`name contains_s "Alpha"`,
+	`name not contains "web"`,
+	`name not_contains_i "WEB"`,
+	`name begins_with "alpha"`,
+	`name begins_with_i "alpha"`,
+	`name not begins_with "b"`,
+	`name not begins_with_i "B"`,
+	`name ends_with "db"`,
+	`name ends_with_i "WEB"`,
+	`name not ends_with "a"`,
+	`name not ends_with_i "A"`,
+	`name contains "a.b*"`,
+	`name begins_with_i "a.B"`,
+	// datetime
+	`created_at > 2024-01-01`,
+	`created_at < 2024-03-01T10:00:00`,
+	`created_at <= 2024-03-01T10:00:00`,
+	`created_at >= 2023-11-14T22:13:20Z`,
+	`created_at < 2023-11-14T22:13:20.5Z`,
+	`created_at >= 2023-11-14T22:13:20.5Z`,
+	`created_at <= 2024-01-01T08:00:00+08:00`,
+	`created_at > 2000-01-01T00:00:00+08:00`,
+	`created_at datetime_greater 1700000000`,
+	// array
+	`tags is empty`,
+	`tags is not empty`,
+	`tags size 2`,
+	`ports size 0`,
+	// null and existence
+	`name is null`,
+	`name is not null`,
+	`mem exists`,
+	`mem not exists`,
+	// filter object and array
+	`labels filter_object (env = "prod")`,
+	`labels filter_object (env is null or tier >= 1)`,
+	`labels filter_object (env != "prod" and tier exists)`,
+	`tags filter_array (element = "hot")`,
+	`tags filter_array (element contains "HOT")`,
+	`tags filter_array (element = "web" and element = "hot")`,
+	`ports filter_array (element = 443)`,
+	`ports filter_array (element > 100)`,
+	`ports filter_array (element is not null)`,
+	// combined
+	`name begins_with_i "a" and (cpu > 80 or tags contains "hot")`,
+	`cpu > 50 or name = 42 or mem exists`,
+	`cpu > "10" or enabled = true`,
+	`(name = 42 and cpu = "90") or (tags is empty and mem != 0.75)`,
+}
+
+func TestCELMatchEquivalence(t *testing.T) {
+	env, err := NewCELEnv()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	matchedCount := 0
+	for _, query := range celFixtureRules {
+		r, err := dsl.Parse(query)
+		if !assert.NoError(t, err, query) {
+			continue
+		}
+
+		prg, err := Expression{r}.CELProgram(env)
+		if !assert.NoError(t, err, query) {
+			continue
+		}
+
+		for idx, data := range celFixtureData {
+			// Match 返回错误时视为不匹配，与 CombinedRule.Match 对子规则的处理一致
+			expected, err := r.Match(mapstr.MapStr(data))
+			if err != nil {
+				expected = false
+			}
+
+			matched, err := MatchCEL(prg, data)
+			assert.NoError(t, err, "%s, data[%d]", query, idx)
+			assert.Equal(t, expected, matched, "%s, data[%d]", query, idx)
+
+			if matched {
+				matchedCount++
+			}
+		}
+	}
+
+	// 避免规则和数据的组合总是不匹配
+	assert.Greater(t, matchedCount, len(celFixtureRules))
+}
+
+func TestToCEL(t *testing.T) {
+	r := &rule.CombinedRule{
+		Condition: operator.And,
+		Rules: []operator.IRuleFactory{
+			&rule.AtomRule{Field: "name", Operator: operator.ContainsSensitive, Value: "a"},
+			&rule.AtomRule{Field: "tags", Operator: operator.IsNotNull},
+		},
+	}
+
+	expr, err := r.ToCEL()
+	assert.NoError(t, err)
+	assert.Equal(t, `((type(dyn("name" in data ? data["name"] : null)) == string) && `+
+		`(dyn("name" in data ? data["name"] : null).contains("a"))) && `+
+		`(dyn("tags" in data ? data["tags"] : null) != null)`, expr)
+
+	_, err = (&rule.AtomRule{Field: "cpu", Operator: operator.Greater, Value: "x"}).ToCEL()
+	assert.Error(t, err)
+
+	_, err = (&rule.AtomRule{Field: "name", Operator: operator.ContainsSensitive, Value: 1}).ToCEL()
+	assert.Error(t, err)
+
+	_, err = (&rule.AtomRule{Field: "tags", Operator: operator.Array, Value: &rule.AtomRule{
+		Field: "name", Operator: operator.Equal, Value: "a",
+	}}).ToCEL()
+	assert.Error(t, err)
+
+	_, err = Expression{}.CELProgram(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package expression
+
+import (
+	"errors"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+)
+
+// ToWhere validates the expression, then convert it to a parameterized sql condition, the placeholders are always ?,
+// use Dialect.Rebind to convert them if needed.
+// the fields are used as column names, so they are always checked against opt.RuleFields,
+// IgnoreRuleFields is not allowed.
+func (exp Expression) ToWhere(dialect operator.Dialect, opt *operator.ExprOption) (string, []interface{}, error) {
+	if err := dialect.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	if opt == nil {
+		return "", nil, errors.New("expression's validate option must be set")
+	}
+
+	// 字段名会作为列名拼接到 sql 中，必须校验字段白名单
+	if opt.IgnoreRuleFields || len(opt.RuleFields) == 0 {
+		return "", nil, errors.New("rule fields must be set to generate sql")
+	}
+
+	if err := exp.Validate(opt); err != nil {
+		return "", nil, err
+	}
+
+	return exp.IRuleFactory.ToSQL(dialect)
+}
@@ -0,0 +1,50 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/criteria"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/rule"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpressionToWhere(t *testing.T) {
+	opt := operator.NewDefaultExprOpt(map[string]criteria.FieldType{
+		"name": criteria.String,
+		"age":  criteria.Numeric,
+	})
+	exp := Expression{
+		&rule.CombinedRule{
+			Condition: operator.And,
+			Rules: []operator.IRuleFactory{
+				&rule.AtomRule{Field: "name", Operator: operator.Equal, Value: "bob"},
+				&rule.AtomRule{Field: "age", Operator: operator.Less, Value: 18},
+			},
+		},
+	}
+
+	sql, args, err := exp.ToWhere(operator.PostgreSQL, opt)
+	assert.NoError(t, err)
+	assert.Equal(t, `("name" = ?) AND ("age" < ?)`, sql)
+	assert.Equal(t, `("name" = $1) AND ("age" < $2)`, operator.PostgreSQL.Rebind(sql))
+	assert.Equal(t, []interface{}{"bob", 18}, args)
+
+	// 不在白名单中的字段
+	injected := Expression{
+		&rule.AtomRule{Field: "name`; DROP TABLE user; --", Operator: operator.Equal, Value: "bob"},
+	}
+	_, _, err = injected.ToWhere(operator.MySQL, opt)
+	assert.Error(t, err)
+
+	// 字段名会作为列名，不允许忽略白名单
+	_, _, err = exp.ToWhere(operator.MySQL, &operator.ExprOption{IgnoreRuleFields: true, MaxRulesLimit: 50,
+		MaxRulesDepth: MaxRulesDepth})
+	assert.Error(t, err)
+
+	_, _, err = exp.ToWhere(operator.MySQL, nil)
+	assert.Error(t, err)
+
+	_, _, err = exp.ToWhere(operator.Dialect("oracle"), opt)
+	assert.Error(t, err)
+}
@@ -0,0 +1,430 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/cast"
+)
+
+// CELDataVar is the variable name of the matched data in the cel expression, it should be declared as
+// map(string, dyn), e.g. cel.Variable(CELDataVar, cel.MapType(cel.StringType, cel.DynType))
+const CELDataVar = "data"
+
+// CELExpr is the cel expression of an operator, the expression only uses the standard cel functions and macros.
+type CELExpr struct {
+	// Guard is the condition that the operator's Match doesn't return an error, empty means always true
+	Guard string
+	// Cond is the condition that the value matches the operator, it's only evaluated when Guard is true
+	Cond string
+}
+
+// String returns the boolean cel expression, it's false when the operator's Match returns an error,
+// which is the same as CombinedRule.Match that treats the rule failed to match as not matched.
+func (e CELExpr) String() string {
+	switch {
+	case e.Guard == "":
+		return e.Cond
+	case e.Cond == celTrue:
+		return e.Guard
+	case e.Cond == celFalse:
+		return celFalse
+	default:
+		return "(" + e.Guard + ") && (" + e.Cond + ")"
+	}
+}
+
+// negate returns the expression of the not operator, it fails when the original operator fails,
+// e.g. not_equal, not_in and not_contains
+func (e CELExpr) negate() CELExpr {
+	return CELExpr{Guard: e.Guard, Cond: celNot(e.Cond)}
+}
+
+// CELField returns the cel expression to get the field of the data, it's null if the field does not exist,
+// which is the same as MapStr.GetValue. the result is declared as dyn type to be checked at runtime.
+func CELField(data string, field string) string {
+	key := strconv.Quote(field)
+	return fmt.Sprintf("dyn(%s in %s ? %s[%s] : null)", key, data, data, key)
+}
+
+// CELAnyElement returns the cel expression which checks if any element of the list matches the operator's
+// expression elem, the elements are referenced by CELElementVar in elem. it's the same as AtomRule.Match
+// with filter array option, the elements are checked in order, and it fails if the element that is checked
+// first fails to match.
+func CELAnyElement(list string, elem CELExpr) string {
+	if elem.Guard == "" {
+		return fmt.Sprintf("type(%s) == list && %s.exists(%s, %s)", list, list, CELElementVar, elem.Cond)
+	}
+
+	// 1: matched, 0: failed, 2: not matched. the first element which is matched or failed decides the result
+	codes := fmt.Sprintf("%s.map(%s, (%s) ? ((%s) ? 1 : 2) : 0).filter(c, c != 2)",
+		list, CELElementVar, elem.Guard, elem.Cond)
+	return fmt.Sprintf("type(%s) == list && [%s].exists(c, size(c) > 0 && c[0] == 1)", list, codes)
+}
+
+// CELElementVar is the variable name of the array element in the cel expression of filter array operator
+const CELElementVar = "e"
+
+const (
+	celTrue  = "true"
+	celFalse = "false"
+
+	celNumericTypes = "[int, uint, double]"
+)
+
+// celFloatPattern is the format of the string that can be parsed by strconv.ParseFloat,
+// it's used to check if a string value can be cast to a number.
+const celFloatPattern = `^[+-]?((\d+\.?\d*|\.\d+)([eE][+-]?\d+)?|0[xX](_?[0-9a-fA-F])*\.?(_?[0-9a-fA-F])*[pP][+-]?\d+|` +
+	`(?i:inf|infinity|nan))$`
+
+// datetime string formats, see validator.IsTime and cast.Str2Time
+const (
+	celTimeWithoutLocationPattern = `^[0-9]{4}-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2}$`
+	celTimeWithLocationPattern    = `^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?\+[0-9]{2}:[0-9]{2}$`
+	// celTimeParsablePattern is the time with location that cast.Str2Time parses successfully in local time zone,
+	// others are parsed as the zero time.
+	celTimeParsablePattern    = `^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?\+08:00$`
+	celTimeWithLocationLayout = "2006-01-02T15:04:05.999999999+08:00"
+)
+
+func celNot(cond string) string {
+	switch cond {
+	case celTrue:
+		return celFalse
+	case celFalse:
+		return celTrue
+	default:
+		return "!(" + cond + ")"
+	}
+}
+
+// celString returns the cel string literal
+func celString(s string) string {
+	return strconv.Quote(s)
+}
+
+// celDouble returns the cel double literal
+func celDouble(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("number %v is not supported by cel", f)
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s, nil
+}
+
+// celBranch is one of the exclusive branches of a cel expression, the branch is chosen when guard is true
+type celBranch struct {
+	guard string
+	cond  string
+}
+
+// celBranches combines the exclusive branches into a cel expression
+func celBranches(branches []celBranch) CELExpr {
+	valid := make([]celBranch, 0, len(branches))
+	for _, b := range branches {
+		if b.guard != celFalse {
+			valid = append(valid, b)
+		}
+	}
+	if len(valid) == 0 {
+		return CELExpr{Cond: celFalse}
+	}
+
+	guards := make([]string, len(valid))
+	for idx, b := range valid {
+		guards[idx] = b.guard
+	}
+
+	cond := valid[len(valid)-1].cond
+	for idx := len(valid) - 2; idx >= 0; idx-- {
+		cond = fmt.Sprintf("%s ? (%s) : (%s)", valid[idx].guard, valid[idx].cond, cond)
+	}
+
+	return CELExpr{Guard: strings.Join(guards, " || "), Cond: cond}
+}
+
+// celEqual generate the cel expression which is the same as EqualOp.Match
+func celEqual(field string, value interface{}) (CELExpr, error) {
+	if len(field) == 0 {
+		return CELExpr{}, errors.New("field is empty")
+	}
+
+	branches := make([]celBranch, 0)
+	switch v := value.(type) {
+	case string:
+		branches = append(branches, celBranch{
+			guard: fmt.Sprintf("type(%s) == string", field),
+			cond:  fmt.Sprintf("%s == %s", field, celString(v)),
+		})
+	case bool:
+		branches = append(branches, celBranch{
+			guard: fmt.Sprintf("type(%s) == bool", field),
+			cond:  fmt.Sprintf("%s == %t", field, v),
+		})
+	default:
+		if !reflect.ValueOf(value).IsValid() || !isBasicKind(reflect.TypeOf(value).Kind()) {
+			return CELExpr{}, fmt.Errorf("invalid eq value(%+v)", value)
+		}
+	}
+
+	// 输入值为数字时，规则值转换为数字后比较
+	if f, err := cast.ToFloat64E(value); err == nil {
+		d, err := celDouble(f)
+		if err != nil {
+			return CELExpr{}, err
+		}
+		branches = append(branches, celBranch{
+			guard: fmt.Sprintf("type(%s) in %s", field, celNumericTypes),
+			cond:  fmt.Sprintf("double(%s) == %s", field, d),
+		})
+	}
+
+	// 输入值为 null 时不匹配
+	branches = append(branches, celBranch{guard: field + " == null", cond: celFalse})
+	return celBranches(branches), nil
+}
+
+func isBasicKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// celIn generate the cel expression which is the same as InOp.Match, the elements are checked in order,
+// and it fails at the first element whose type not matches the input value.
+func celIn(field string, value interface{}) (CELExpr, error) {
+	if len(field) == 0 {
+		return CELExpr{}, errors.New("field is empty")
+	}
+	if value == nil {
+		return CELExpr{}, errors.New("rule value is nil")
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Array, reflect.Slice:
+	default:
+		return CELExpr{}, fmt.Errorf("rule value(%+v) is not of array type", value)
+	}
+
+	v := reflect.ValueOf(value)
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+
+	numbers, numOK := celInPrefix(items, func(item interface{}) (string, bool) {
+		f, err := cast.ToFloat64E(item)
+		if err != nil {
+			return "", false
+		}
+		d, err := celDouble(f)
+		return d, err == nil
+	})
+	strs, strOK := celInPrefix(items, func(item interface{}) (string, bool) {
+		s, ok := item.(string)
+		return celString(s), ok
+	})
+	bools, boolOK := celInPrefix(items, func(item interface{}) (string, bool) {
+		b, ok := item.(bool)
+		return strconv.FormatBool(b), ok
+	})
+
+	branches := []celBranch{
+		celInBranch(fmt.Sprintf("type(%s) in %s", field, celNumericTypes), "double("+field+")", numbers, numOK),
+		celInBranch(fmt.Sprintf("type(%s) == string", field), field, strs, strOK),
+		celInBranch(fmt.Sprintf("type(%s) == bool", field), field, bools, boolOK),
+		// 输入值为 null 时不匹配
+		{guard: field + " == null", cond: celFalse},
+	}
+	return celBranches(branches), nil
+}
+
+// celInPrefix converts the items to cel literals until the first item that can not be converted,
+// ok is false if any item can not be converted or the items are empty, which fails the in operator.
+func celInPrefix(items []interface{}, convert func(item interface{}) (string, bool)) ([]string, bool) {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := convert(item)
+		if !ok {
+			return result, false
+		}
+		result = append(result, s)
+	}
+	return result, len(items) > 0
+}
+
+// celInBranch generate the branch of the in operator, if not all the items are valid,
+// the input value matches when it's in the valid prefix, otherwise the operator fails.
+func celInBranch(typeGuard string, value string, items []string, ok bool) celBranch {
+	cond := fmt.Sprintf("%s in [%s]", value, strings.Join(items, ", "))
+	switch {
+	case ok:
+		return celBranch{guard: typeGuard, cond: cond}
+	case len(items) == 0:
+		return celBranch{guard: celFalse}
+	default:
+		return celBranch{guard: typeGuard + " && " + cond, cond: celTrue}
+	}
+}
+
+// celCompare generate the cel expression which is the same as the numeric compare operators' Match,
+// the input value is cast to a number like cast.ToFloat64E.
+func celCompare(field string, op string, value interface{}) (CELExpr, error) {
+	if len(field) == 0 {
+		return CELExpr{}, errors.New("field is empty")
+	}
+
+	f, err := cast.ToFloat64E(value)
+	if err != nil {
+		return CELExpr{}, fmt.Errorf("parse rule value(%+v) failed, err: %v", value, err)
+	}
+	d, err := celDouble(f)
+	if err != nil {
+		return CELExpr{}, err
+	}
+
+	return CELExpr{
+		Guard: fmt.Sprintf("type(%s) in [int, uint, double, bool] || type(%s) == string && %s.matches(%s)",
+			field, field, field, celString(celFloatPattern)),
+		Cond: fmt.Sprintf("(type(%s) == bool ? (%s ? 1.0 : 0.0) : double(%s)) %s %s", field, field, field, op, d),
+	}, nil
+}
+
+// celLike generate the cel expression which is the same as the string operators' Match,
+// the case-insensitive match uses the regular expression with i flag.
+func celLike(field string, value interface{}, mode likeMode, insensitive bool) (CELExpr, error) {
+	if len(field) == 0 {
+		return CELExpr{}, errors.New("field is empty")
+	}
+	s, ok := value.(string)
+	if !ok {
+		return CELExpr{}, fmt.Errorf("rule value(%+v) is not string type", value)
+	}
+
+	var cond string
+	switch {
+	case insensitive:
+		pattern := regexp.QuoteMeta(s)
+		switch mode {
+		case likePrefix:
+			pattern = "^" + pattern
+		case likeSuffix:
+			pattern += "$"
+		}
+		cond = fmt.Sprintf("%s.matches(%s)", field, celString("(?i)"+pattern))
+	case mode == likePrefix:
+		cond = fmt.Sprintf("%s.startsWith(%s)", field, celString(s))
+	case mode == likeSuffix:
+		cond = fmt.Sprintf("%s.endsWith(%s)", field, celString(s))
+	default:
+		cond = fmt.Sprintf("%s.contains(%s)", field, celString(s))
+	}
+
+	return CELExpr{Guard: fmt.Sprintf("type(%s) == string", field), Cond: cond}, nil
+}
+
+// celDatetime generate the cel expression which is the same as the datetime operators' Match,
+// the input value is converted to time like cast.ConvToTime.
+func celDatetime(field string, op string, value interface{}) (CELExpr, error) {
+	if len(field) == 0 {
+		return CELExpr{}, errors.New("field is empty")
+	}
+
+	t, err := cast.ConvToTime(value)
+	if err != nil {
+		return CELExpr{}, fmt.Errorf("convert value to time failed, err: %v", err)
+	}
+
+	// 数字和字符串类型的时间精确到秒，规则值有小数部分时调整比较符号
+	secOp := op
+	if t.Nanosecond() > 0 {
+		switch op {
+		case "<":
+			secOp = "<="
+		case ">=":
+			secOp = ">"
+		}
+	}
+
+	local := t.In(time.Local)
+	branches := []celBranch{
+		{
+			guard: fmt.Sprintf("type(%s) == google.protobuf.Timestamp", field),
+			cond:  fmt.Sprintf("%s %s timestamp(%s)", field, op, celString(t.UTC().Format(time.RFC3339Nano))),
+		},
+		{
+			guard: fmt.Sprintf("type(%s) in %s", field, celNumericTypes),
+			cond:  fmt.Sprintf("int(%s) %s %d", field, secOp, t.Unix()),
+		},
+		{
+			// 格式固定的字符串按字典序比较
+			guard: fmt.Sprintf("type(%s) == string && %s.matches(%s)",
+				field, field, celString(celTimeWithoutLocationPattern)),
+			cond: fmt.Sprintf("%s %s %s", field, secOp, celString(local.Format("2006-01-02 15:04:05"))),
+		},
+		{
+			guard: fmt.Sprintf("type(%s) == string && %s.matches(%s)",
+				field, field, celString(celTimeParsablePattern)),
+			cond: fmt.Sprintf("%s %s %s", field, op, celString(local.Format(celTimeWithLocationLayout))),
+		},
+		{
+			// 其它时区的时间被解析为零值
+			guard: fmt.Sprintf("type(%s) == string && %s.matches(%s)",
+				field, field, celString(celTimeWithLocationPattern)),
+			cond: strconv.FormatBool(compareTime(time.Time{}, op, t)),
+		},
+	}
+	return celBranches(branches), nil
+}
+
+func compareTime(t1 time.Time, op string, t2 time.Time) bool {
+	switch op {
+	case "<":
+		return t1.Before(t2)
+	case "<=":
+		return !t1.After(t2)
+	case ">":
+		return t1.After(t2)
+	default:
+		return !t1.Before(t2)
+	}
+}
+
+// celNullCheck generate the cel expression of the null check operators
+func celNullCheck(field string, isNull bool) (CELExpr, error) {
+	if len(field) == 0 {
+		return CELExpr{}, errors.New("field is empty")
+	}
+
+	if isNull {
+		return CELExpr{Cond: field + " == null"}, nil
+	}
+	return CELExpr{Cond: field + " != null"}, nil
+}
+
+// celListSize generate the cel expression which checks the list's size
+func celListSize(field string, op string, size int) (CELExpr, error) {
+	if len(field) == 0 {
+		return CELExpr{}, errors.New("field is empty")
+	}
+
+	return CELExpr{
+		Guard: fmt.Sprintf("type(%s) == list", field),
+		Cond:  fmt.Sprintf("size(%s) %s %d", field, op, size),
+	}, nil
+}
@@ -0,0 +1,160 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/cast"
+)
+
+// elasticsearch query dsl keywords
+const (
+	ESBool               = "bool"
+	ESFilter             = "filter"
+	ESShould             = "should"
+	ESMustNot            = "must_not"
+	ESMinimumShouldMatch = "minimum_should_match"
+	ESTerm               = "term"
+	ESTerms              = "terms"
+	ESRange              = "range"
+	ESExists             = "exists"
+	ESPrefix             = "prefix"
+	ESWildcard           = "wildcard"
+	ESScript             = "script"
+	ESCaseInsensitive    = "case_insensitive"
+	ESLT                 = "lt"
+	ESLTE                = "lte"
+	ESGT                 = "gt"
+	ESGTE                = "gte"
+)
+
+// esWildcardEscaper 转义 wildcard 查询中的通配符
+var esWildcardEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`)
+
+// esTerm generate the elasticsearch term query
+func esTerm(field string, value interface{}) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+
+	return map[string]interface{}{
+		ESTerm: map[string]interface{}{field: value},
+	}, nil
+}
+
+// esTerms generate the elasticsearch terms query, the value must be an array
+func esTerms(field string, value interface{}) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+	if value == nil {
+		return nil, errors.New("rule value is nil")
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Array, reflect.Slice:
+	default:
+		return nil, fmt.Errorf("rule value(%+v) is not of array type", value)
+	}
+
+	return map[string]interface{}{
+		ESTerms: map[string]interface{}{field: value},
+	}, nil
+}
+
+// esRange generate the elasticsearch range query
+func esRange(field string, op string, value interface{}) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+
+	return map[string]interface{}{
+		ESRange: map[string]interface{}{
+			field: map[string]interface{}{op: value},
+		},
+	}, nil
+}
+
+// esDatetimeRange generate the elasticsearch range query of the datetime operators,
+// the value is converted to a RFC3339 time string which is supported by the default date format
+func esDatetimeRange(field string, op string, value interface{}) (map[string]interface{}, error) {
+	v, err := cast.ConvToTime(value)
+	if err != nil {
+		return nil, fmt.Errorf("convert value to time failed, err: %v", err)
+	}
+
+	return esRange(field, op, v.Format(time.RFC3339Nano))
+}
+
+// esExists generate the elasticsearch exists query
+func esExists(field string) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+
+	return map[string]interface{}{
+		ESExists: map[string]interface{}{"field": field},
+	}, nil
+}
+
+// esNot wraps the query with bool must_not
+func esNot(query map[string]interface{}, err error) (map[string]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		ESBool: map[string]interface{}{
+			ESMustNot: []interface{}{query},
+		},
+	}, nil
+}
+
+// esLike generate the elasticsearch query for the string operators
+func esLike(field string, value interface{}, mode likeMode, insensitive bool) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("rule value(%+v) is not string type", value)
+	}
+
+	if mode == likePrefix {
+		cond := map[string]interface{}{"value": s}
+		if insensitive {
+			cond[ESCaseInsensitive] = true
+		}
+		return map[string]interface{}{
+			ESPrefix: map[string]interface{}{field: cond},
+		}, nil
+	}
+
+	cond := map[string]interface{}{"value": wrapPattern(esWildcardEscaper.Replace(s), mode, "*")}
+	if insensitive {
+		cond[ESCaseInsensitive] = true
+	}
+	return map[string]interface{}{
+		ESWildcard: map[string]interface{}{field: cond},
+	}, nil
+}
+
+// esArraySize generate the elasticsearch script query which checks the array field's length,
+// the field must have doc values, e.g. keyword or numeric field
+func esArraySize(field string, size int) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+
+	return map[string]interface{}{
+		ESScript: map[string]interface{}{
+			ESScript: map[string]interface{}{
+				"source": "doc[params.field].size() == params.size",
+				"params": map[string]interface{}{"field": field, "size": size},
+			},
+		},
+	}, nil
+}
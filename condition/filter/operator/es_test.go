@@ -0,0 +1,74 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperatorToES(t *testing.T) {
+	tests := []struct {
+		op       OpType
+		value    interface{}
+		expected map[string]interface{}
+	}{
+		{Equal, "a", map[string]interface{}{ESTerm: map[string]interface{}{"f": "a"}}},
+		{NotEqual, 1, map[string]interface{}{ESBool: map[string]interface{}{ESMustNot: []interface{}{
+			map[string]interface{}{ESTerm: map[string]interface{}{"f": 1}},
+		}}}},
+		{In, []string{"a", "b"}, map[string]interface{}{ESTerms: map[string]interface{}{"f": []string{"a", "b"}}}},
+		{Less, 1, map[string]interface{}{ESRange: map[string]interface{}{"f": map[string]interface{}{ESLT: 1}}}},
+		{GreaterOrEqual, 2.5, map[string]interface{}{
+			ESRange: map[string]interface{}{"f": map[string]interface{}{ESGTE: 2.5}},
+		}},
+		{DatetimeGreater, time.Date(2024, 1, 2, 3, 4, 5, 500, time.UTC), map[string]interface{}{
+			ESRange: map[string]interface{}{"f": map[string]interface{}{ESGT: "2024-01-02T03:04:05.0000005Z"}},
+		}},
+		{Exist, nil, map[string]interface{}{ESExists: map[string]interface{}{"field": "f"}}},
+		{IsNull, nil, map[string]interface{}{ESBool: map[string]interface{}{ESMustNot: []interface{}{
+			map[string]interface{}{ESExists: map[string]interface{}{"field": "f"}},
+		}}}},
+		{BeginsWithInsensitive, "a*", map[string]interface{}{
+			ESPrefix: map[string]interface{}{"f": map[string]interface{}{"value": "a*", ESCaseInsensitive: true}},
+		}},
+		{ContainsSensitive, "a*?", map[string]interface{}{
+			ESWildcard: map[string]interface{}{"f": map[string]interface{}{"value": `*a\*\?*`}},
+		}},
+		{EndsWith, "a", map[string]interface{}{
+			ESWildcard: map[string]interface{}{"f": map[string]interface{}{"value": "*a"}},
+		}},
+		{Size, 2, map[string]interface{}{ESScript: map[string]interface{}{ESScript: map[string]interface{}{
+			"source": "doc[params.field].size() == params.size",
+			"params": map[string]interface{}{"field": "f", "size": 2},
+		}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.op), func(t *testing.T) {
+			query, err := GetOperator(tt.op).ToES("f", tt.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, query)
+		})
+	}
+}
+
+func TestOperatorToESInvalid(t *testing.T) {
+	_, err := GetOperator(Equal).ToES("", 1)
+	assert.Error(t, err)
+
+	_, err = GetOperator(In).ToES("f", 1)
+	assert.Error(t, err)
+
+	_, err = GetOperator(Contains).ToES("f", 1)
+	assert.Error(t, err)
+
+	_, err = GetOperator(DatetimeLess).ToES("f", "x")
+	assert.Error(t, err)
+
+	_, err = GetOperator(Size).ToES("f", "x")
+	assert.Error(t, err)
+
+	_, err = GetOperator(Array).ToES("f", 1)
+	assert.Error(t, err)
+}
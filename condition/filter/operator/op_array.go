@@ -67,6 +67,54 @@ func (o ArrayOp) ToMgo(field string, value interface{}) (map[string]interface{},
 	return subRule.ToMgo(parentOpt)
 }
 
+// ToSQL convert the filter array operator's field and value to a parameterized sql condition.
+func (o ArrayOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return "", nil, errors.New("filter array operator is not supported by sql")
+}
+
+// ToES convert the filter array operator's field and value to an elasticsearch query.
+func (o ArrayOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+
+	subRule, ok := value.(IRuleFactory)
+	if !ok {
+		return nil, fmt.Errorf("filter array operator's value(%+v) is not a rule type", value)
+	}
+
+	parentOpt := &RuleOption{
+		Parent:     field,
+		ParentType: criteria.Array,
+	}
+
+	return subRule.ToES(parentOpt)
+}
+
+// ToCEL convert the filter array operator's value to a cel expression, field is the cel expression of the field's value.
+func (o ArrayOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	if len(field) == 0 {
+		return CELExpr{}, errors.New("field is empty")
+	}
+
+	subRule, ok := value.(IRuleFactory)
+	if !ok {
+		return CELExpr{}, fmt.Errorf("filter array operator's value(%+v) is not a rule type", value)
+	}
+
+	parentOpt := &RuleOption{
+		Parent:     field,
+		ParentType: criteria.Array,
+	}
+
+	cond, err := subRule.ToCEL(parentOpt)
+	if err != nil {
+		return CELExpr{}, err
+	}
+
+	return CELExpr{Guard: field + " != null", Cond: cond}, nil
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o ArrayOp) Match(value1, value2 interface{}) (bool, error) {
 	if value1 == nil {
@@ -40,6 +40,21 @@ func (o BeginsWithOp) ToMgo(field string, value interface{}) (map[string]interfa
 	}, nil
 }
 
+// ToSQL convert the begins with operator's field and value to a parameterized sql condition.
+func (o BeginsWithOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likePrefix, false, false, dialect)
+}
+
+// ToES convert the begins with operator's field and value to an elasticsearch query.
+func (o BeginsWithOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esLike(field, value, likePrefix, false)
+}
+
+// ToCEL convert the begins with operator's value to a cel expression, field is the cel expression of the field's value.
+func (o BeginsWithOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celLike(field, value, likePrefix, false)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o BeginsWithOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
@@ -84,6 +99,21 @@ func (o BeginsWithInsensitiveOp) ToMgo(
 	}, nil
 }
 
+// ToSQL convert the begins with insensitive operator's field and value to a parameterized sql condition.
+func (o BeginsWithInsensitiveOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likePrefix, true, false, dialect)
+}
+
+// ToES convert the begins with insensitive operator's field and value to an elasticsearch query.
+func (o BeginsWithInsensitiveOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esLike(field, value, likePrefix, true)
+}
+
+// ToCEL convert the begins with insensitive operator's value to a cel expression, field is the cel expression of the field's value.
+func (o BeginsWithInsensitiveOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celLike(field, value, likePrefix, true)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o BeginsWithInsensitiveOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
@@ -126,6 +156,25 @@ func (o NotBeginsWithOp) ToMgo(field string, value interface{}) (map[string]inte
 	}, nil
 }
 
+// ToSQL convert the not begins with operator's field and value to a parameterized sql condition.
+func (o NotBeginsWithOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likePrefix, false, true, dialect)
+}
+
+// ToES convert the not begins with operator's field and value to an elasticsearch query.
+func (o NotBeginsWithOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esLike(field, value, likePrefix, false))
+}
+
+// ToCEL convert the not begins with operator's value to a cel expression, field is the cel expression of the field's value.
+func (o NotBeginsWithOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	expr, err := celLike(field, value, likePrefix, false)
+	if err != nil {
+		return CELExpr{}, err
+	}
+	return expr.negate(), nil
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o NotBeginsWithOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
@@ -172,6 +221,25 @@ func (o NotBeginsWithInsensitiveOp) ToMgo(
 	}, nil
 }
 
+// ToSQL convert the not begins with insensitive operator's field and value to a parameterized sql condition.
+func (o NotBeginsWithInsensitiveOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likePrefix, true, true, dialect)
+}
+
+// ToES convert the not begins with insensitive operator's field and value to an elasticsearch query.
+func (o NotBeginsWithInsensitiveOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esLike(field, value, likePrefix, true))
+}
+
+// ToCEL convert the not begins with insensitive operator's value to a cel expression, field is the cel expression of the field's value.
+func (o NotBeginsWithInsensitiveOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	expr, err := celLike(field, value, likePrefix, true)
+	if err != nil {
+		return CELExpr{}, err
+	}
+	return expr.negate(), nil
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o NotBeginsWithInsensitiveOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
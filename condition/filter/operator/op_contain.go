@@ -41,6 +41,21 @@ func (o ContainsOp) ToMgo(field string, value interface{}) (map[string]interface
 	}, nil
 }
 
+// ToSQL convert the contains operator's field and value to a parameterized sql condition.
+func (o ContainsOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likeContains, true, false, dialect)
+}
+
+// ToES convert the contains operator's field and value to an elasticsearch query.
+func (o ContainsOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esLike(field, value, likeContains, true)
+}
+
+// ToCEL convert the contains operator's value to a cel expression, field is the cel expression of the field's value.
+func (o ContainsOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celLike(field, value, likeContains, true)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o ContainsOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
@@ -84,6 +99,21 @@ func (o ContainsSensitiveOp) ToMgo(
 	}, nil
 }
 
+// ToSQL convert the contains sensitive operator's field and value to a parameterized sql condition.
+func (o ContainsSensitiveOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likeContains, false, false, dialect)
+}
+
+// ToES convert the contains sensitive operator's field and value to an elasticsearch query.
+func (o ContainsSensitiveOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esLike(field, value, likeContains, false)
+}
+
+// ToCEL convert the contains sensitive operator's value to a cel expression, field is the cel expression of the field's value.
+func (o ContainsSensitiveOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celLike(field, value, likeContains, false)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o ContainsSensitiveOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
@@ -126,6 +156,25 @@ func (o NotContainsOp) ToMgo(field string, value interface{}) (map[string]interf
 	}, nil
 }
 
+// ToSQL convert the not contains operator's field and value to a parameterized sql condition.
+func (o NotContainsOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likeContains, false, true, dialect)
+}
+
+// ToES convert the not contains operator's field and value to an elasticsearch query.
+func (o NotContainsOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esLike(field, value, likeContains, false))
+}
+
+// ToCEL convert the not contains operator's value to a cel expression, field is the cel expression of the field's value.
+func (o NotContainsOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	expr, err := celLike(field, value, likeContains, false)
+	if err != nil {
+		return CELExpr{}, err
+	}
+	return expr.negate(), nil
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o NotContainsOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
@@ -172,6 +221,25 @@ func (o NotContainsInsensitiveOp) ToMgo(
 	}, nil
 }
 
+// ToSQL convert the not contains insensitive operator's field and value to a parameterized sql condition.
+func (o NotContainsInsensitiveOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likeContains, true, true, dialect)
+}
+
+// ToES convert the not contains insensitive operator's field and value to an elasticsearch query.
+func (o NotContainsInsensitiveOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esLike(field, value, likeContains, true))
+}
+
+// ToCEL convert the not contains insensitive operator's value to a cel expression, field is the cel expression of the field's value.
+func (o NotContainsInsensitiveOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	expr, err := celLike(field, value, likeContains, true)
+	if err != nil {
+		return CELExpr{}, err
+	}
+	return expr.negate(), nil
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o NotContainsInsensitiveOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
@@ -42,6 +42,26 @@ func (o DatetimeLessOp) ToMgo(field string, value interface{}) (map[string]inter
 	}, nil
 }
 
+// ToSQL convert the datetime less than operator's field and value to a parameterized sql condition.
+func (o DatetimeLessOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	v, err := cast.ConvToTime(value)
+	if err != nil {
+		return "", nil, fmt.Errorf("convert value to time failed, err: %v", err)
+	}
+
+	return sqlCompare(field, "<", v, dialect)
+}
+
+// ToES convert the datetime less than operator's field and value to an elasticsearch query.
+func (o DatetimeLessOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esDatetimeRange(field, ESLT, value)
+}
+
+// ToCEL convert the datetime less than operator's value to a cel expression, field is the cel expression of the field's value.
+func (o DatetimeLessOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celDatetime(field, "<", value)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o DatetimeLessOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseTimeValues(value1, value2)
@@ -87,6 +107,26 @@ func (o DatetimeLessOrEqualOp) ToMgo(
 	}, nil
 }
 
+// ToSQL convert the datetime less than or equal operator's field and value to a parameterized sql condition.
+func (o DatetimeLessOrEqualOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	v, err := cast.ConvToTime(value)
+	if err != nil {
+		return "", nil, fmt.Errorf("convert value to time failed, err: %v", err)
+	}
+
+	return sqlCompare(field, "<=", v, dialect)
+}
+
+// ToES convert the datetime less than or equal operator's field and value to an elasticsearch query.
+func (o DatetimeLessOrEqualOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esDatetimeRange(field, ESLTE, value)
+}
+
+// ToCEL convert the datetime less than or equal operator's value to a cel expression, field is the cel expression of the field's value.
+func (o DatetimeLessOrEqualOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celDatetime(field, "<=", value)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o DatetimeLessOrEqualOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseTimeValues(value1, value2)
@@ -130,6 +170,26 @@ func (o DatetimeGreaterOp) ToMgo(field string, value interface{}) (map[string]in
 	}, nil
 }
 
+// ToSQL convert the datetime greater than operator's field and value to a parameterized sql condition.
+func (o DatetimeGreaterOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	v, err := cast.ConvToTime(value)
+	if err != nil {
+		return "", nil, fmt.Errorf("convert value to time failed, err: %v", err)
+	}
+
+	return sqlCompare(field, ">", v, dialect)
+}
+
+// ToES convert the datetime greater than operator's field and value to an elasticsearch query.
+func (o DatetimeGreaterOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esDatetimeRange(field, ESGT, value)
+}
+
+// ToCEL convert the datetime greater than operator's value to a cel expression, field is the cel expression of the field's value.
+func (o DatetimeGreaterOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celDatetime(field, ">", value)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o DatetimeGreaterOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseTimeValues(value1, value2)
@@ -178,6 +238,26 @@ func (o DatetimeGreaterOrEqualOp) ToMgo(
 	}, nil
 }
 
+// ToSQL convert the datetime greater than or equal operator's field and value to a parameterized sql condition.
+func (o DatetimeGreaterOrEqualOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	v, err := cast.ConvToTime(value)
+	if err != nil {
+		return "", nil, fmt.Errorf("convert value to time failed, err: %v", err)
+	}
+
+	return sqlCompare(field, ">=", v, dialect)
+}
+
+// ToES convert the datetime greater than or equal operator's field and value to an elasticsearch query.
+func (o DatetimeGreaterOrEqualOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esDatetimeRange(field, ESGTE, value)
+}
+
+// ToCEL convert the datetime greater than or equal operator's value to a cel expression, field is the cel expression of the field's value.
+func (o DatetimeGreaterOrEqualOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celDatetime(field, ">=", value)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o DatetimeGreaterOrEqualOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseTimeValues(value1, value2)
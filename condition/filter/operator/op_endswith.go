@@ -40,6 +40,21 @@ func (o EndsWithOp) ToMgo(field string, value interface{}) (map[string]interface
 	}, nil
 }
 
+// ToSQL convert the ends with operator's field and value to a parameterized sql condition.
+func (o EndsWithOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likeSuffix, false, false, dialect)
+}
+
+// ToES convert the ends with operator's field and value to an elasticsearch query.
+func (o EndsWithOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esLike(field, value, likeSuffix, false)
+}
+
+// ToCEL convert the ends with operator's value to a cel expression, field is the cel expression of the field's value.
+func (o EndsWithOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celLike(field, value, likeSuffix, false)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o EndsWithOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
@@ -84,6 +99,21 @@ func (o EndsWithInsensitiveOp) ToMgo(
 	}, nil
 }
 
+// ToSQL convert the ends with insensitive operator's field and value to a parameterized sql condition.
+func (o EndsWithInsensitiveOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likeSuffix, true, false, dialect)
+}
+
+// ToES convert the ends with insensitive operator's field and value to an elasticsearch query.
+func (o EndsWithInsensitiveOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esLike(field, value, likeSuffix, true)
+}
+
+// ToCEL convert the ends with insensitive operator's value to a cel expression, field is the cel expression of the field's value.
+func (o EndsWithInsensitiveOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celLike(field, value, likeSuffix, true)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o EndsWithInsensitiveOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
@@ -124,6 +154,25 @@ func (o NotEndsWithOp) ToMgo(field string, value interface{}) (map[string]interf
 	}, nil
 }
 
+// ToSQL convert the not ends with operator's field and value to a parameterized sql condition.
+func (o NotEndsWithOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likeSuffix, false, true, dialect)
+}
+
+// ToES convert the not ends with operator's field and value to an elasticsearch query.
+func (o NotEndsWithOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esLike(field, value, likeSuffix, false))
+}
+
+// ToCEL convert the not ends with operator's value to a cel expression, field is the cel expression of the field's value.
+func (o NotEndsWithOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	expr, err := celLike(field, value, likeSuffix, false)
+	if err != nil {
+		return CELExpr{}, err
+	}
+	return expr.negate(), nil
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o NotEndsWithOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseStringValues(value1, value2)
@@ -178,3 +227,22 @@ func (o NotEndsWithInsensitiveOp) ToMgo(
 		},
 	}, nil
 }
+
+// ToSQL convert the not ends with insensitive operator's field and value to a parameterized sql condition.
+func (o NotEndsWithInsensitiveOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlLike(field, value, likeSuffix, true, true, dialect)
+}
+
+// ToES convert the not ends with insensitive operator's field and value to an elasticsearch query.
+func (o NotEndsWithInsensitiveOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esLike(field, value, likeSuffix, true))
+}
+
+// ToCEL convert the not ends with insensitive operator's value to a cel expression, field is the cel expression of the field's value.
+func (o NotEndsWithInsensitiveOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	expr, err := celLike(field, value, likeSuffix, true)
+	if err != nil {
+		return CELExpr{}, err
+	}
+	return expr.negate(), nil
+}
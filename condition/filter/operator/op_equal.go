@@ -37,6 +37,21 @@ func (o EqualOp) ToMgo(field string, value interface{}) (map[string]interface{},
 	}, nil
 }
 
+// ToSQL convert the equal operator's field and value to a parameterized sql condition.
+func (o EqualOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlCompare(field, "=", value, dialect)
+}
+
+// ToES convert the equal operator's field and value to an elasticsearch query.
+func (o EqualOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esTerm(field, value)
+}
+
+// ToCEL convert the equal operator's value to a cel expression, field is the cel expression of the field's value.
+func (o EqualOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celEqual(field, value)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o EqualOp) Match(value1, value2 interface{}) (bool, error) {
 	switch t := value1.(type) {
@@ -32,6 +32,23 @@ func (o ExistOp) ToMgo(field string, value interface{}) (map[string]interface{},
 	}, nil
 }
 
+// ToSQL convert the 'exist' operator's field and value to a parameterized sql condition.
+func (o ExistOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	// 关系型数据库的字段总是存在，不存在的值以 NULL 存储
+	return sqlNullCheck(field, false, dialect)
+}
+
+// ToES convert the 'exist' operator's field and value to an elasticsearch query.
+func (o ExistOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esExists(field)
+}
+
+// ToCEL convert the 'exist' operator's value to a cel expression, field is the cel expression of the field's value.
+// NOTE: the cel expression keeps the same result with Match, which matches when the field's value is nil.
+func (o ExistOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celNullCheck(field, true)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o ExistOp) Match(value1, value2 interface{}) (bool, error) {
 	return value1 == nil, nil
@@ -63,6 +80,22 @@ func (o NotExistOp) ToMgo(field string, value interface{}) (map[string]interface
 	}, nil
 }
 
+// ToSQL convert the not exist operator's field and value to a parameterized sql condition.
+func (o NotExistOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlNullCheck(field, true, dialect)
+}
+
+// ToES convert the not exist operator's field and value to an elasticsearch query.
+func (o NotExistOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esExists(field))
+}
+
+// ToCEL convert the not exist operator's value to a cel expression, field is the cel expression of the field's value.
+// NOTE: the cel expression keeps the same result with Match, which matches when the field's value is not nil.
+func (o NotExistOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celNullCheck(field, false)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o NotExistOp) Match(value1, value2 interface{}) (bool, error) {
 	return value1 != nil, nil
@@ -34,6 +34,21 @@ func (o GreaterOrEqualOp) ToMgo(field string, value interface{}) (map[string]int
 	}, nil
 }
 
+// ToSQL convert the greater than or equal operator's field and value to a parameterized sql condition.
+func (o GreaterOrEqualOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlCompare(field, ">=", value, dialect)
+}
+
+// ToES convert the greater than or equal operator's field and value to an elasticsearch query.
+func (o GreaterOrEqualOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esRange(field, ESGTE, value)
+}
+
+// ToCEL convert the greater than or equal operator's value to a cel expression, field is the cel expression of the field's value.
+func (o GreaterOrEqualOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celCompare(field, ">=", value)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o GreaterOrEqualOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseNumericValues(value1, value2)
@@ -34,6 +34,21 @@ func (o GreaterOp) ToMgo(field string, value interface{}) (map[string]interface{
 	}, nil
 }
 
+// ToSQL convert the greater than operator's field and value to a parameterized sql condition.
+func (o GreaterOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlCompare(field, ">", value, dialect)
+}
+
+// ToES convert the greater than operator's field and value to an elasticsearch query.
+func (o GreaterOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esRange(field, ESGT, value)
+}
+
+// ToCEL convert the greater than operator's value to a cel expression, field is the cel expression of the field's value.
+func (o GreaterOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celCompare(field, ">", value)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o GreaterOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseNumericValues(value1, value2)
@@ -43,6 +43,21 @@ func (o InOp) ToMgo(field string, value interface{}) (map[string]interface{}, er
 	}, nil
 }
 
+// ToSQL convert the in operator's field and value to a parameterized sql condition.
+func (o InOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlIn(field, value, false, dialect)
+}
+
+// ToES convert the in operator's field and value to an elasticsearch query.
+func (o InOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esTerms(field, value)
+}
+
+// ToCEL convert the in operator's value to a cel expression, field is the cel expression of the field's value.
+func (o InOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celIn(field, value)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o InOp) Match(value1, value2 interface{}) (bool, error) {
 	var itemType string
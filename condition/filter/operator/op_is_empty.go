@@ -34,6 +34,21 @@ func (o IsEmptyOp) ToMgo(field string, value interface{}) (map[string]interface{
 	}, nil
 }
 
+// ToSQL convert the empty operator's field and value to a parameterized sql condition.
+func (o IsEmptyOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlJSONLength(field, "=", 0, dialect)
+}
+
+// ToES convert the empty operator's field and value to an elasticsearch query.
+func (o IsEmptyOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esExists(field))
+}
+
+// ToCEL convert the empty operator's value to a cel expression, field is the cel expression of the field's value.
+func (o IsEmptyOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celListSize(field, "==", 0)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o IsEmptyOp) Match(value1, value2 interface{}) (bool, error) {
 	if value1 == nil {
@@ -76,6 +91,21 @@ func (o IsNotEmptyOp) ToMgo(field string, value interface{}) (map[string]interfa
 	}, nil
 }
 
+// ToSQL convert the not empty operator's field and value to a parameterized sql condition.
+func (o IsNotEmptyOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlJSONLength(field, ">", 0, dialect)
+}
+
+// ToES convert the not empty operator's field and value to an elasticsearch query.
+func (o IsNotEmptyOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esExists(field)
+}
+
+// ToCEL convert the not empty operator's value to a cel expression, field is the cel expression of the field's value.
+func (o IsNotEmptyOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celListSize(field, ">", 0)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o IsNotEmptyOp) Match(value1, value2 interface{}) (bool, error) {
 	if value1 == nil {
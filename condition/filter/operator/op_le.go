@@ -34,6 +34,21 @@ func (o LessOrEqualOp) ToMgo(field string, value interface{}) (map[string]interf
 	}, nil
 }
 
+// ToSQL convert the less than or equal operator's field and value to a parameterized sql condition.
+func (o LessOrEqualOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlCompare(field, "<=", value, dialect)
+}
+
+// ToES convert the less than or equal operator's field and value to an elasticsearch query.
+func (o LessOrEqualOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esRange(field, ESLTE, value)
+}
+
+// ToCEL convert the less than or equal operator's value to a cel expression, field is the cel expression of the field's value.
+func (o LessOrEqualOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celCompare(field, "<=", value)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o LessOrEqualOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseNumericValues(value1, value2)
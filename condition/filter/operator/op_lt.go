@@ -35,6 +35,21 @@ func (o LessOp) ToMgo(field string, value interface{}) (map[string]interface{},
 	}, nil
 }
 
+// ToSQL convert the less than operator's field and value to a parameterized sql condition.
+func (o LessOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlCompare(field, "<", value, dialect)
+}
+
+// ToES convert the less than operator's field and value to an elasticsearch query.
+func (o LessOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esRange(field, ESLT, value)
+}
+
+// ToCEL convert the less than operator's value to a cel expression, field is the cel expression of the field's value.
+func (o LessOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celCompare(field, "<", value)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o LessOp) Match(value1, value2 interface{}) (bool, error) {
 	val1, val2, err := parseNumericValues(value1, value2)
@@ -37,6 +37,31 @@ func (ne NotEqualOp) ToMgo(field string, value interface{}) (map[string]interfac
 	}, nil
 }
 
+// ToSQL convert the not equal operator's field and value to a parameterized sql condition.
+func (ne NotEqualOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	cond, args, err := sqlCompare(field, "<>", value, dialect)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// 与 mongodb 的 $ne 一致，值为 null 的数据也匹配
+	return fmt.Sprintf("(%s OR %s IS NULL)", cond, dialect.QuoteIdent(field)), args, nil
+}
+
+// ToES convert the not equal operator's field and value to an elasticsearch query.
+func (ne NotEqualOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esTerm(field, value))
+}
+
+// ToCEL convert the not equal operator's value to a cel expression, field is the cel expression of the field's value.
+func (ne NotEqualOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	expr, err := celEqual(field, value)
+	if err != nil {
+		return CELExpr{}, err
+	}
+	return expr.negate(), nil
+}
+
 // Match checks if the first data matches the second data by this operator
 func (ne NotEqualOp) Match(value1, value2 interface{}) (bool, error) {
 	matched, err := GetOperator(Equal).Match(value1, value2)
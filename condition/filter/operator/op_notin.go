@@ -43,6 +43,25 @@ func (o NotInOp) ToMgo(field string, value interface{}) (map[string]interface{},
 	}, nil
 }
 
+// ToSQL convert the not in operator's field and value to a parameterized sql condition.
+func (o NotInOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlIn(field, value, true, dialect)
+}
+
+// ToES convert the not in operator's field and value to an elasticsearch query.
+func (o NotInOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esTerms(field, value))
+}
+
+// ToCEL convert the not in operator's value to a cel expression, field is the cel expression of the field's value.
+func (o NotInOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	expr, err := celIn(field, value)
+	if err != nil {
+		return CELExpr{}, err
+	}
+	return expr.negate(), nil
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o NotInOp) Match(value1, value2 interface{}) (bool, error) {
 	matched, err := GetOperator(In).Match(value1, value2)
@@ -32,6 +32,21 @@ func (o IsNullOp) ToMgo(field string, value interface{}) (map[string]interface{}
 	}, nil
 }
 
+// ToSQL convert the null operator's field and value to a parameterized sql condition.
+func (o IsNullOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlNullCheck(field, true, dialect)
+}
+
+// ToES convert the null operator's field and value to an elasticsearch query.
+func (o IsNullOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esNot(esExists(field))
+}
+
+// ToCEL convert the null operator's value to a cel expression, field is the cel expression of the field's value.
+func (o IsNullOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celNullCheck(field, true)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o IsNullOp) Match(value1, value2 interface{}) (bool, error) {
 	return value1 == nil, nil
@@ -63,6 +78,21 @@ func (o IsNotNullOp) ToMgo(field string, value interface{}) (map[string]interfac
 	}, nil
 }
 
+// ToSQL convert the not null operator's field and value to a parameterized sql condition.
+func (o IsNotNullOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlNullCheck(field, false, dialect)
+}
+
+// ToES convert the not null operator's field and value to an elasticsearch query.
+func (o IsNotNullOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	return esExists(field)
+}
+
+// ToCEL convert the not null operator's value to a cel expression, field is the cel expression of the field's value.
+func (o IsNotNullOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	return celNullCheck(field, false)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o IsNotNullOp) Match(value1, value2 interface{}) (bool, error) {
 	return value1 != nil, nil
@@ -63,6 +63,55 @@ func (o ObjectOp) ToMgo(field string, value interface{}) (map[string]interface{}
 	return subRule.ToMgo(parentOpt)
 }
 
+// ToSQL convert the filter object operator's field and value to a parameterized sql condition.
+func (o ObjectOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return "", nil, errors.New("filter object operator is not supported by sql")
+}
+
+// ToES convert the filter object operator's field and value to an elasticsearch query.
+func (o ObjectOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	if len(field) == 0 {
+		return nil, errors.New("field is empty")
+	}
+
+	subRule, ok := value.(IRuleFactory)
+	if !ok {
+		return nil, fmt.Errorf("filter object operator's value(%+v) is not a rule type", value)
+	}
+
+	parentOpt := &RuleOption{
+		Parent:     field,
+		ParentType: criteria.Object,
+	}
+
+	return subRule.ToES(parentOpt)
+}
+
+// ToCEL convert the filter object operator's value to a cel expression, field is the cel expression of the field's value.
+// NOTE: only map value is matched, json string value which is supported by Match is not supported by cel.
+func (o ObjectOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	if len(field) == 0 {
+		return CELExpr{}, errors.New("field is empty")
+	}
+
+	subRule, ok := value.(IRuleFactory)
+	if !ok {
+		return CELExpr{}, fmt.Errorf("filter object operator's value(%+v) is not a rule type", value)
+	}
+
+	parentOpt := &RuleOption{
+		Parent:     field,
+		ParentType: criteria.Object,
+	}
+
+	cond, err := subRule.ToCEL(parentOpt)
+	if err != nil {
+		return CELExpr{}, err
+	}
+
+	return CELExpr{Guard: fmt.Sprintf("type(%s) == map", field), Cond: cond}, nil
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o ObjectOp) Match(value1, value2 interface{}) (bool, error) {
 	subRule, ok := value2.(IRuleFactory)
@@ -43,6 +43,31 @@ func (o SizeOp) ToMgo(field string, value interface{}) (map[string]interface{},
 	}, nil
 }
 
+// ToSQL convert the size operator's field and value to a parameterized sql condition.
+func (o SizeOp) ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error) {
+	return sqlJSONLength(field, "=", value, dialect)
+}
+
+// ToES convert the size operator's field and value to an elasticsearch query.
+func (o SizeOp) ToES(field string, value interface{}) (map[string]interface{}, error) {
+	size, err := cast.ToIntE(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size operator's value, should be a numeric value, err: %v", err)
+	}
+
+	return esArraySize(field, size)
+}
+
+// ToCEL convert the size operator's value to a cel expression, field is the cel expression of the field's value.
+func (o SizeOp) ToCEL(field string, value interface{}) (CELExpr, error) {
+	size, err := cast.ToIntE(value)
+	if err != nil {
+		return CELExpr{}, fmt.Errorf("invalid size operator's value, should be a numeric value, err: %v", err)
+	}
+
+	return celListSize(field, "==", size)
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o SizeOp) Match(value1, value2 interface{}) (bool, error) {
 	if value1 == nil {
@@ -20,6 +20,21 @@ func (o UnknownOp) ToMgo(_ string, _ interface{}) (map[string]interface{}, error
 	return nil, errors.New("unknown operator, can not gen mongo expression")
 }
 
+// ToSQL convert this operator's field and value to a parameterized sql condition.
+func (o UnknownOp) ToSQL(_ string, _ interface{}, _ Dialect) (string, []interface{}, error) {
+	return "", nil, errors.New("unknown operator, can not gen sql expression")
+}
+
+// ToES convert this operator's field and value to an elasticsearch query.
+func (o UnknownOp) ToES(_ string, _ interface{}) (map[string]interface{}, error) {
+	return nil, errors.New("unknown operator, can not convert to elasticsearch query")
+}
+
+// ToCEL convert this operator's value to a cel expression, field is the cel expression of the field's value.
+func (o UnknownOp) ToCEL(_ string, _ interface{}) (CELExpr, error) {
+	return CELExpr{}, errors.New("unknown operator, can not convert to cel expression")
+}
+
 // Match checks if the first data matches the second data by this operator
 func (o UnknownOp) Match(_, _ interface{}) (bool, error) {
 	return false, errors.New("unknown operator, can not check if two value matches this operator")
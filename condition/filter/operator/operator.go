@@ -60,6 +60,14 @@ type IOperator interface {
 	ValidateValue(v interface{}, opt *ExprOption) error
 	// ToMgo generate an operator's mongo condition with its field and value.
 	ToMgo(field string, value interface{}) (map[string]interface{}, error)
+	// ToSQL generate an operator's parameterized sql condition with its field and value,
+	// the placeholders are always ?, use Dialect.Rebind to convert them if needed
+	ToSQL(field string, value interface{}, dialect Dialect) (string, []interface{}, error)
+	// ToES generate an operator's elasticsearch query with its field and value.
+	ToES(field string, value interface{}) (map[string]interface{}, error)
+	// ToCEL generate an operator's cel expression with the cel expression of the field's value and the value,
+	// the expression is evaluated to false when Match returns an error
+	ToCEL(field string, value interface{}) (CELExpr, error)
 	// Match checks if the first data matches the second data by this operator
 	Match(value1, value2 interface{}) (bool, error)
 }
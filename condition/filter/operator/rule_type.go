@@ -170,6 +170,12 @@ type IRuleFactory interface {
 	RuleFields() []string
 	// ToMgo convert this rule to a mongo condition
 	ToMgo(opt ...*RuleOption) (map[string]interface{}, error)
+	// ToSQL convert this rule to a parameterized sql condition
+	ToSQL(dialect Dialect, opt ...*RuleOption) (string, []interface{}, error)
+	// ToES convert this rule to an elasticsearch bool query
+	ToES(opt ...*RuleOption) (map[string]interface{}, error)
+	// ToCEL convert this rule to a cel expression, the data is referenced by CELDataVar
+	ToCEL(opt ...*RuleOption) (string, error)
 	// Match checks if the input data matches this rule
 	Match(data MatchedData, opt ...*RuleOption) (bool, error)
 }
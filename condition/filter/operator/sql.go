@@ -0,0 +1,249 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Dialect defines the sql dialect which the filter is compiled to.
+type Dialect string
+
+const (
+	// MySQL dialect
+	MySQL Dialect = "mysql"
+	// SQLite dialect
+	SQLite Dialect = "sqlite"
+	// PostgreSQL dialect
+	PostgreSQL Dialect = "postgres"
+)
+
+// ParseDialect 根据 gorm 的 Dialector.Name() 或 database/sql 的驱动名称获得方言
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "mysql":
+		return MySQL, nil
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	case "postgres", "postgresql", "pgx":
+		return PostgreSQL, nil
+	default:
+		return "", fmt.Errorf("unsupported sql dialect: %s", name)
+	}
+}
+
+// Validate test the dialect is valid or not.
+func (d Dialect) Validate() error {
+	switch d {
+	case MySQL, SQLite, PostgreSQL:
+	default:
+		return fmt.Errorf("unsupported sql dialect: %s", d)
+	}
+
+	return nil
+}
+
+// QuoteIdent quotes the field name, e.g. `t`.`name` for mysql, "t"."name" for sqlite and postgres
+func (d Dialect) QuoteIdent(field string) string {
+	quote := `"`
+	if d == MySQL {
+		quote = "`"
+	}
+
+	parts := strings.Split(field, ".")
+	for idx, part := range parts {
+		// 字段名中的引号需要转义，防止注入
+		parts[idx] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+// Rebind replaces the ? placeholders with the bind vars of the dialect, e.g. $1, $2 for postgres
+func (d Dialect) Rebind(sql string) string {
+	if d != PostgreSQL {
+		return sql
+	}
+
+	var b strings.Builder
+	b.Grow(len(sql) + 10)
+	n := 0
+	for _, r := range sql {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// jsonLength 返回 json 数组长度的 sql 表达式
+func (d Dialect) jsonLength(field string) string {
+	switch d {
+	case MySQL:
+		return fmt.Sprintf("JSON_LENGTH(%s)", d.QuoteIdent(field))
+	case PostgreSQL:
+		return fmt.Sprintf("jsonb_array_length(%s::jsonb)", d.QuoteIdent(field))
+	default:
+		return fmt.Sprintf("json_array_length(%s)", d.QuoteIdent(field))
+	}
+}
+
+// sqlCompare generate the sql condition like `field op ?`
+func sqlCompare(field string, op string, value interface{}, d Dialect) (string, []interface{}, error) {
+	if len(field) == 0 {
+		return "", nil, errors.New("field is empty")
+	}
+	if err := d.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("%s %s ?", d.QuoteIdent(field), op), []interface{}{value}, nil
+}
+
+// sqlNullCheck generate the sql condition like `field IS NULL`
+func sqlNullCheck(field string, isNull bool, d Dialect) (string, []interface{}, error) {
+	if len(field) == 0 {
+		return "", nil, errors.New("field is null")
+	}
+	if err := d.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	if isNull {
+		return d.QuoteIdent(field) + " IS NULL", nil, nil
+	}
+	return d.QuoteIdent(field) + " IS NOT NULL", nil, nil
+}
+
+// sqlIn generate the sql condition like `field IN (?, ?)`
+func sqlIn(field string, value interface{}, not bool, d Dialect) (string, []interface{}, error) {
+	if len(field) == 0 {
+		return "", nil, errors.New("field is empty")
+	}
+	if err := d.Validate(); err != nil {
+		return "", nil, err
+	}
+	if value == nil {
+		return "", nil, errors.New("rule value is nil")
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Array, reflect.Slice:
+	default:
+		return "", nil, fmt.Errorf("rule value(%+v) is not of array type", value)
+	}
+
+	v := reflect.ValueOf(value)
+	length := v.Len()
+	// 与 mongodb 一致，空集合时 in 不匹配任何数据，not in 匹配所有数据
+	if length == 0 {
+		if not {
+			return "1 = 1", nil, nil
+		}
+		return "1 = 0", nil, nil
+	}
+
+	args := make([]interface{}, length)
+	for i := 0; i < length; i++ {
+		args[i] = v.Index(i).Interface()
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", length), ", ")
+
+	col := d.QuoteIdent(field)
+	if not {
+		// 与 mongodb 的 $nin 一致，值为 null 的数据也匹配
+		return fmt.Sprintf("(%s NOT IN (%s) OR %s IS NULL)", col, placeholders, col), args, nil
+	}
+	return fmt.Sprintf("%s IN (%s)", col, placeholders), args, nil
+}
+
+// likeMode 字符串的匹配方式
+type likeMode int
+
+const (
+	likePrefix likeMode = iota
+	likeSuffix
+	likeContains
+)
+
+// likeEscaper 转义 LIKE 中的通配符，使用 \ 作为转义字符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// globEscaper 转义 sqlite GLOB 中的通配符
+var globEscaper = strings.NewReplacer(`*`, `[*]`, `?`, `[?]`, `[`, `[[]`)
+
+// wrapPattern 根据匹配方式添加通配符
+func wrapPattern(s string, mode likeMode, wildcard string) string {
+	switch mode {
+	case likePrefix:
+		return s + wildcard
+	case likeSuffix:
+		return wildcard + s
+	default:
+		return wildcard + s + wildcard
+	}
+}
+
+// sqlLike generate the sql condition for the string operators, the value is always passed as a parameter
+func sqlLike(
+	field string,
+	value interface{},
+	mode likeMode,
+	insensitive bool,
+	not bool,
+	d Dialect,
+) (string, []interface{}, error) {
+	if len(field) == 0 {
+		return "", nil, errors.New("field is empty")
+	}
+	if err := d.Validate(); err != nil {
+		return "", nil, err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", nil, fmt.Errorf("rule value(%+v) is not string type", value)
+	}
+
+	col := d.QuoteIdent(field)
+	notStr := ""
+	if not {
+		notStr = "NOT "
+	}
+
+	// sqlite 的 LIKE 不区分大小写，区分大小写时使用 GLOB
+	if d == SQLite && !insensitive {
+		return fmt.Sprintf("%s %sGLOB ?", col, notStr), []interface{}{wrapPattern(globEscaper.Replace(s), mode, "*")}, nil
+	}
+
+	pattern := wrapPattern(likeEscaper.Replace(s), mode, "%")
+	switch {
+	case d == PostgreSQL && insensitive:
+		return fmt.Sprintf("%s %sILIKE ?", col, notStr), []interface{}{pattern}, nil
+	case d == PostgreSQL:
+		return fmt.Sprintf("%s %sLIKE ?", col, notStr), []interface{}{pattern}, nil
+	case d == MySQL && !insensitive:
+		// mysql 默认的排序规则不区分大小写，按二进制比较
+		return fmt.Sprintf("%s %sLIKE BINARY ?", col, notStr), []interface{}{pattern}, nil
+	case d == MySQL:
+		return fmt.Sprintf("LOWER(%s) %sLIKE ?", col, notStr), []interface{}{strings.ToLower(pattern)}, nil
+	default:
+		// sqlite 没有默认的转义字符
+		return fmt.Sprintf("LOWER(%s) %sLIKE ? ESCAPE '\\'", col, notStr), []interface{}{strings.ToLower(pattern)}, nil
+	}
+}
+
+// sqlJSONLength generate the sql condition on the length of a json array field
+func sqlJSONLength(field string, op string, value interface{}, d Dialect) (string, []interface{}, error) {
+	if len(field) == 0 {
+		return "", nil, errors.New("field is empty")
+	}
+	if err := d.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("%s %s ?", d.jsonLength(field), op), []interface{}{value}, nil
+}
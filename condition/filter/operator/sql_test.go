@@ -0,0 +1,115 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDialect(t *testing.T) {
+	for name, expected := range map[string]Dialect{
+		"mysql":    MySQL,
+		"sqlite3":  SQLite,
+		"sqlite":   SQLite,
+		"postgres": PostgreSQL,
+		"pgx":      PostgreSQL,
+	} {
+		dialect, err := ParseDialect(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, dialect)
+	}
+
+	_, err := ParseDialect("oracle")
+	assert.Error(t, err)
+}
+
+func TestDialectQuoteIdent(t *testing.T) {
+	assert.Equal(t, "`name`", MySQL.QuoteIdent("name"))
+	assert.Equal(t, "`t`.`name`", MySQL.QuoteIdent("t.name"))
+	assert.Equal(t, "`a``b`", MySQL.QuoteIdent("a`b"))
+	assert.Equal(t, `"t"."name"`, PostgreSQL.QuoteIdent("t.name"))
+	assert.Equal(t, `"a""b"`, SQLite.QuoteIdent(`a"b`))
+}
+
+func TestDialectRebind(t *testing.T) {
+	sql := "`a` = ? AND `b` IN (?, ?)"
+	assert.Equal(t, sql, MySQL.Rebind(sql))
+	assert.Equal(t, "`a` = $1 AND `b` IN ($2, $3)", PostgreSQL.Rebind(sql))
+}
+
+func TestOperatorToSQL(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	cases := []struct {
+		op      OpType
+		value   interface{}
+		dialect Dialect
+		sql     string
+		args    []interface{}
+	}{
+		{Equal, 1, MySQL, "`f` = ?", []interface{}{1}},
+		{NotEqual, "a", MySQL, "(`f` <> ? OR `f` IS NULL)", []interface{}{"a"}},
+		{In, []interface{}{1, 2}, MySQL, "`f` IN (?, ?)", []interface{}{1, 2}},
+		{In, []string{}, MySQL, "1 = 0", nil},
+		{NotIn, []string{"a"}, PostgreSQL, `("f" NOT IN (?) OR "f" IS NULL)`, []interface{}{"a"}},
+		{NotIn, []string{}, PostgreSQL, "1 = 1", nil},
+		{Less, 1, SQLite, `"f" < ?`, []interface{}{1}},
+		{LessOrEqual, 1, SQLite, `"f" <= ?`, []interface{}{1}},
+		{Greater, 1, SQLite, `"f" > ?`, []interface{}{1}},
+		{GreaterOrEqual, 1, SQLite, `"f" >= ?`, []interface{}{1}},
+		{DatetimeLess, ts.Unix(), MySQL, "`f` < ?", []interface{}{time.Unix(ts.Unix(), 0)}},
+		{DatetimeGreaterOrEqual, ts.Unix(), PostgreSQL, `"f" >= ?`, []interface{}{time.Unix(ts.Unix(), 0)}},
+		// 通配符需要转义
+		{BeginsWith, "a_%", MySQL, "`f` LIKE BINARY ?", []interface{}{`a\_\%%`}},
+		{BeginsWithInsensitive, "Ab", MySQL, "LOWER(`f`) LIKE ?", []interface{}{"ab%"}},
+		{NotBeginsWith, "a", PostgreSQL, `"f" NOT LIKE ?`, []interface{}{"a%"}},
+		{NotBeginsWithInsensitive, "a", PostgreSQL, `"f" NOT ILIKE ?`, []interface{}{"a%"}},
+		{Contains, "Ab", PostgreSQL, `"f" ILIKE ?`, []interface{}{"%Ab%"}},
+		{Contains, "Ab", SQLite, `LOWER("f") LIKE ? ESCAPE '\'`, []interface{}{"%ab%"}},
+		{ContainsSensitive, "a*", SQLite, `"f" GLOB ?`, []interface{}{"*a[*]*"}},
+		{NotContains, "a", MySQL, "`f` NOT LIKE BINARY ?", []interface{}{"%a%"}},
+		{NotContainsInsensitive, "A", MySQL, "LOWER(`f`) NOT LIKE ?", []interface{}{"%a%"}},
+		{EndsWith, "a", SQLite, `"f" GLOB ?`, []interface{}{"*a"}},
+		{EndsWithInsensitive, "A", PostgreSQL, `"f" ILIKE ?`, []interface{}{"%A"}},
+		{NotEndsWith, "a", SQLite, `"f" NOT GLOB ?`, []interface{}{"*a"}},
+		{NotEndsWithInsensitive, "A", SQLite, `LOWER("f") NOT LIKE ? ESCAPE '\'`, []interface{}{"%a"}},
+		{IsEmpty, nil, MySQL, "JSON_LENGTH(`f`) = ?", []interface{}{0}},
+		{IsNotEmpty, nil, SQLite, `json_array_length("f") > ?`, []interface{}{0}},
+		{Size, 3, PostgreSQL, `jsonb_array_length("f"::jsonb) = ?`, []interface{}{3}},
+		{IsNull, nil, MySQL, "`f` IS NULL", nil},
+		{IsNotNull, nil, MySQL, "`f` IS NOT NULL", nil},
+		{Exist, nil, MySQL, "`f` IS NOT NULL", nil},
+		{NotExist, nil, MySQL, "`f` IS NULL", nil},
+	}
+
+	for _, c := range cases {
+		sql, args, err := GetOperator(c.op).ToSQL("f", c.value, c.dialect)
+		assert.NoError(t, err, c.op)
+		assert.Equal(t, c.sql, sql, c.op)
+		assert.Equal(t, c.args, args, c.op)
+	}
+}
+
+func TestOperatorToSQLInvalid(t *testing.T) {
+	_, _, err := GetOperator(Equal).ToSQL("", 1, MySQL)
+	assert.Error(t, err)
+
+	_, _, err = GetOperator(Equal).ToSQL("f", 1, Dialect("oracle"))
+	assert.Error(t, err)
+
+	_, _, err = GetOperator(In).ToSQL("f", 1, MySQL)
+	assert.Error(t, err)
+
+	_, _, err = GetOperator(Contains).ToSQL("f", 1, MySQL)
+	assert.Error(t, err)
+
+	_, _, err = GetOperator(Object).ToSQL("f", nil, MySQL)
+	assert.Error(t, err)
+
+	_, _, err = GetOperator(Array).ToSQL("f", nil, MySQL)
+	assert.Error(t, err)
+
+	_, _, err = GetOperator(Unknown).ToSQL("f", nil, MySQL)
+	assert.Error(t, err)
+}
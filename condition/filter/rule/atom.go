@@ -195,6 +195,83 @@ func (ar *AtomRule) ToMgo(opts ...*operator.RuleOption) (map[string]interface{},
 	return operator.GetOperator(ar.Operator).ToMgo(ar.Field, ar.Value)
 }
 
+// ToSQL convert this atom rule to a parameterized sql condition.
+func (ar *AtomRule) ToSQL(dialect operator.Dialect, opts ...*operator.RuleOption) (string, []interface{}, error) {
+	// 过滤对象和数组元素的规则依赖文档结构，关系型数据库不支持
+	if len(opts) > 0 && opts[0] != nil {
+		return "", nil, fmt.Errorf("filter %s field %s is not supported by sql", opts[0].ParentType, ar.Field)
+	}
+
+	return operator.GetOperator(ar.Operator).ToSQL(ar.Field, ar.Value, dialect)
+}
+
+// ToES convert this atom rule to an elasticsearch query.
+func (ar *AtomRule) ToES(opts ...*operator.RuleOption) (map[string]interface{}, error) {
+	if len(opts) > 0 && opts[0] != nil {
+		opt := opts[0]
+		if len(opt.Parent) == 0 {
+			return nil, errors.New("parent is empty")
+		}
+
+		switch opt.ParentType {
+		case criteria.Object:
+			// elasticsearch 中对象的字段以 . 拼接
+			return operator.GetOperator(ar.Operator).ToES(opt.Parent+"."+ar.Field, ar.Value)
+		case criteria.Array:
+			switch ar.Field {
+			case operator.ArrayElement:
+				// 数组字段的查询条件匹配任一元素
+				return operator.GetOperator(ar.Operator).ToES(opt.Parent, ar.Value)
+			default:
+				return nil, fmt.Errorf("filter array field %s is invalid", ar.Field)
+			}
+		default:
+			return nil, fmt.Errorf("parent type %s is invalid", opt.ParentType)
+		}
+	}
+
+	return operator.GetOperator(ar.Operator).ToES(ar.Field, ar.Value)
+}
+
+// ToCEL convert this atom rule to a cel expression, the parent of the filter object and filter array option
+// is the cel expression of the object or array.
+func (ar *AtomRule) ToCEL(opts ...*operator.RuleOption) (string, error) {
+	if len(opts) > 0 && opts[0] != nil {
+		opt := opts[0]
+		if len(opt.Parent) == 0 {
+			return "", errors.New("parent is empty")
+		}
+
+		switch opt.ParentType {
+		case criteria.Object:
+			expr, err := operator.GetOperator(ar.Operator).ToCEL(operator.CELField(opt.Parent, ar.Field), ar.Value)
+			if err != nil {
+				return "", err
+			}
+			return expr.String(), nil
+		case criteria.Array:
+			if ar.Field != operator.ArrayElement {
+				return "", fmt.Errorf("filter array field %s is invalid", ar.Field)
+			}
+
+			// 与 Match 一致，匹配数组中的任一元素
+			expr, err := operator.GetOperator(ar.Operator).ToCEL(operator.CELElementVar, ar.Value)
+			if err != nil {
+				return "", err
+			}
+			return operator.CELAnyElement(opt.Parent, expr), nil
+		default:
+			return "", fmt.Errorf("parent type %s is invalid", opt.ParentType)
+		}
+	}
+
+	expr, err := operator.GetOperator(ar.Operator).ToCEL(operator.CELField(operator.CELDataVar, ar.Field), ar.Value)
+	if err != nil {
+		return "", err
+	}
+	return expr.String(), nil
+}
+
 // Match checks if the input data matches this atomic rule
 func (ar *AtomRule) Match(data operator.MatchedData, opts ...*operator.RuleOption) (bool, error) {
 	value, err := data.GetValue(ar.Field)
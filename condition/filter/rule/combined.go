@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
 	log "github.com/sirupsen/logrus"
@@ -96,6 +97,102 @@ func (cr *CombinedRule) ToMgo(opt ...*operator.RuleOption) (map[string]interface
 	}
 }
 
+// ToSQL convert the combined rule to a parameterized sql condition.
+func (cr *CombinedRule) ToSQL(dialect operator.Dialect, opt ...*operator.RuleOption) (string, []interface{}, error) {
+	if err := cr.Condition.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	if len(cr.Rules) == 0 {
+		return "", nil, errors.New("combined rules shouldn't be empty")
+	}
+
+	conditions := make([]string, 0, len(cr.Rules))
+	args := make([]interface{}, 0)
+	for idx, rule := range cr.Rules {
+		condition, ruleArgs, err := rule.ToSQL(dialect, opt...)
+		if err != nil {
+			return "", nil, fmt.Errorf("rules[%d] is invalid, err: %v", idx, err)
+		}
+		conditions = append(conditions, "("+condition+")")
+		args = append(args, ruleArgs...)
+	}
+
+	switch cr.Condition {
+	case operator.Or:
+		return strings.Join(conditions, " OR "), args, nil
+	case operator.And:
+		return strings.Join(conditions, " AND "), args, nil
+	default:
+		return "", nil, fmt.Errorf("unexpected operator %s", cr.Condition)
+	}
+}
+
+// ToES convert the combined rule to an elasticsearch bool query.
+func (cr *CombinedRule) ToES(opt ...*operator.RuleOption) (map[string]interface{}, error) {
+	if err := cr.Condition.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(cr.Rules) == 0 {
+		return nil, errors.New("combined rules shouldn't be empty")
+	}
+
+	queries := make([]interface{}, 0, len(cr.Rules))
+	for idx, rule := range cr.Rules {
+		query, err := rule.ToES(opt...)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d] is invalid, err: %v", idx, err)
+		}
+		queries = append(queries, query)
+	}
+
+	switch cr.Condition {
+	case operator.Or:
+		return map[string]interface{}{
+			operator.ESBool: map[string]interface{}{
+				operator.ESShould:             queries,
+				operator.ESMinimumShouldMatch: 1,
+			},
+		}, nil
+	case operator.And:
+		return map[string]interface{}{
+			operator.ESBool: map[string]interface{}{operator.ESFilter: queries},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unexpected operator %s", cr.Condition)
+	}
+}
+
+// ToCEL convert the combined rule to a cel expression.
+func (cr *CombinedRule) ToCEL(opt ...*operator.RuleOption) (string, error) {
+	if err := cr.Condition.Validate(); err != nil {
+		return "", err
+	}
+
+	if len(cr.Rules) == 0 {
+		return "", errors.New("combined rules shouldn't be empty")
+	}
+
+	conditions := make([]string, 0, len(cr.Rules))
+	for idx, rule := range cr.Rules {
+		condition, err := rule.ToCEL(opt...)
+		if err != nil {
+			return "", fmt.Errorf("rules[%d] is invalid, err: %v", idx, err)
+		}
+		conditions = append(conditions, "("+condition+")")
+	}
+
+	switch cr.Condition {
+	case operator.Or:
+		return strings.Join(conditions, " || "), nil
+	case operator.And:
+		return strings.Join(conditions, " && "), nil
+	default:
+		return "", fmt.Errorf("unexpected operator %s", cr.Condition)
+	}
+}
+
 // Match checks if the input data matches this combined rule
 func (cr *CombinedRule) Match(
 	data operator.MatchedData,
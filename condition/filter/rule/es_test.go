@@ -0,0 +1,69 @@
+package rule
+
+import (
+	"testing"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombinedRuleToES(t *testing.T) {
+	r, err := ParseJsonRule([]byte(`{
+		"condition": "AND",
+		"rules": [
+			{"field": "name", "operator": "not_equal", "value": "bob"},
+			{"condition": "OR", "rules": [
+				{"field": "age", "operator": "greater", "value": 18},
+				{"field": "labels", "operator": "filter_object", "value": {
+					"field": "env", "operator": "in", "value": ["prod"]
+				}},
+				{"field": "tags", "operator": "filter_array", "value": {
+					"field": "element", "operator": "equal", "value": "hot"
+				}}
+			]}
+		]
+	}`))
+	assert.NoError(t, err)
+
+	query, err := r.ToES()
+	assert.NoError(t, err)
+
+	expected := map[string]interface{}{
+		operator.ESBool: map[string]interface{}{
+			operator.ESFilter: []interface{}{
+				map[string]interface{}{operator.ESBool: map[string]interface{}{
+					operator.ESMustNot: []interface{}{
+						map[string]interface{}{operator.ESTerm: map[string]interface{}{"name": "bob"}},
+					},
+				}},
+				map[string]interface{}{operator.ESBool: map[string]interface{}{
+					operator.ESShould: []interface{}{
+						map[string]interface{}{operator.ESRange: map[string]interface{}{
+							"age": map[string]interface{}{operator.ESGT: float64(18)},
+						}},
+						map[string]interface{}{operator.ESTerms: map[string]interface{}{
+							"labels.env": []interface{}{"prod"},
+						}},
+						map[string]interface{}{operator.ESTerm: map[string]interface{}{"tags": "hot"}},
+					},
+					operator.ESMinimumShouldMatch: 1,
+				}},
+			},
+		},
+	}
+	assert.Equal(t, expected, query)
+}
+
+func TestRuleToESInvalid(t *testing.T) {
+	_, err := (&CombinedRule{Condition: "XOR", Rules: exampleRule.Rules}).ToES()
+	assert.Error(t, err)
+
+	_, err = (&CombinedRule{Condition: operator.And}).ToES()
+	assert.Error(t, err)
+
+	// 过滤数组元素的规则只支持 element 字段
+	_, err = (&AtomRule{Field: "tags", Operator: operator.Array, Value: &AtomRule{
+		Field: "name", Operator: operator.Equal, Value: "a",
+	}}).ToES()
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package rule
+
+import (
+	"testing"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombinedRuleToSQL(t *testing.T) {
+	r, err := ParseJsonRule([]byte(`{
+		"condition": "AND",
+		"rules": [
+			{"field": "name", "operator": "contains", "value": "bob"},
+			{"condition": "OR", "rules": [
+				{"field": "age", "operator": "greater_or_equal", "value": 18},
+				{"field": "tag", "operator": "in", "value": ["a", "b"]}
+			]}
+		]
+	}`))
+	assert.NoError(t, err)
+
+	sql, args, err := r.ToSQL(operator.MySQL)
+	assert.NoError(t, err)
+	assert.Equal(t, "(LOWER(`name`) LIKE ?) AND ((`age` >= ?) OR (`tag` IN (?, ?)))", sql)
+	assert.Equal(t, []interface{}{"%bob%", float64(18), "a", "b"}, args)
+
+	sql, _, err = r.ToSQL(operator.PostgreSQL)
+	assert.NoError(t, err)
+	assert.Equal(t, `("name" ILIKE ?) AND (("age" >= ?) OR ("tag" IN (?, ?)))`, sql)
+}
+
+func TestRuleToSQLUnsupported(t *testing.T) {
+	// 过滤对象和数组元素的规则不支持转换为 sql
+	_, _, err := exampleRule.ToSQL(operator.MySQL)
+	assert.Error(t, err)
+
+	_, _, err = (&CombinedRule{Condition: "XOR", Rules: exampleRule.Rules}).ToSQL(operator.MySQL)
+	assert.Error(t, err)
+
+	_, _, err = (&CombinedRule{Condition: operator.And}).ToSQL(operator.MySQL)
+	assert.Error(t, err)
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fengzhongzhu1621/xgo/cast"
+	"github.com/fengzhongzhu1621/xgo/collections/maps"
+	xpflag "github.com/fengzhongzhu1621/xgo/config/pflag"
+	"github.com/fengzhongzhu1621/xgo/logging"
+	"gopkg.in/yaml.v3"
+)
+
+var _ IConfig = (*LayeredConfig)(nil)
+
+// LayeredConfig 将多层配置深度合并，后面的配置层优先级更高，并记录每个配置项的来源
+type LayeredConfig struct {
+	layers []ILayer
+
+	mutex   sync.RWMutex
+	data    map[string]interface{} // 合并后的配置
+	sources map[string]string      // 配置项路径 => 配置层名称
+}
+
+// NewLayeredConfig 创建分层配置，layers 按优先级从低到高排列，需要调用 Load 加载配置
+func NewLayeredConfig(layers ...ILayer) *LayeredConfig {
+	return &LayeredConfig{
+		layers:  layers,
+		data:    make(map[string]interface{}),
+		sources: make(map[string]string),
+	}
+}
+
+// StandardLayerOptions 标准分层配置的选项，为空的配置层不加载
+type StandardLayerOptions struct {
+	Defaults    map[string]interface{} // 默认值
+	BaseFile    string                 // 基础配置文件
+	EnvFile     string                 // 环境配置文件，例如 config.prod.yaml，不存在时忽略
+	DotEnvFiles []string               // .env 文件
+	EnvPrefix   string                 // 环境变量前缀，例如 APP_
+	Flags       xpflag.IFlagValueSet   // 命令行参数
+	LoadOptions []LoadOption           // 读取配置文件的选项，例如 WithCodec
+}
+
+// StandardLayers 按 默认值 < 基础配置文件 < 环境配置文件 < .env < 环境变量 < 命令行参数 的优先级创建配置层
+func StandardLayers(opts StandardLayerOptions) []ILayer {
+	var layers []ILayer
+	if opts.Defaults != nil {
+		layers = append(layers, DefaultsLayer(opts.Defaults))
+	}
+	if opts.BaseFile != "" {
+		layers = append(layers, FileLayer(opts.BaseFile, opts.BaseFile, opts.LoadOptions...))
+	}
+	if opts.EnvFile != "" {
+		layers = append(layers, OptionalFileLayer(opts.EnvFile, opts.EnvFile, opts.LoadOptions...))
+	}
+	if len(opts.DotEnvFiles) > 0 {
+		layers = append(layers, DotEnvLayer(opts.EnvPrefix, opts.DotEnvFiles...))
+	}
+	layers = append(layers, EnvLayer(opts.EnvPrefix))
+	if opts.Flags != nil {
+		layers = append(layers, FlagLayer(opts.Flags))
+	}
+	return layers
+}
+
+// Load 按优先级从低到高加载并合并所有配置层
+func (c *LayeredConfig) Load() error {
+	data := make(map[string]interface{})
+	flats := make([]map[string]interface{}, len(c.layers))
+
+	for i, layer := range c.layers {
+		values, err := layer.Load(data)
+		if err != nil {
+			return fmt.Errorf("xgo/config: failed to load layer %s: %w", layer.Name(), err)
+		}
+
+		// 复制并统一为小写的 key，避免修改配置层的数据
+		values = normalizeMap(values)
+		maps.MergeMaps(values, data, nil)
+		flats[i] = maps.FlattenAndMergeMap(nil, values, "", keyDelimiter)
+	}
+
+	// 配置项的来源为包含该配置项的优先级最高的配置层
+	sources := make(map[string]string)
+	for key := range maps.FlattenAndMergeMap(nil, data, "", keyDelimiter) {
+		for i := len(flats) - 1; i >= 0; i-- {
+			if _, ok := flats[i][key]; ok {
+				sources[key] = c.layers[i].Name()
+				break
+			}
+		}
+	}
+
+	c.mutex.Lock()
+	c.data = data
+	c.sources = sources
+	c.mutex.Unlock()
+	return nil
+}
+
+// Reload reloads config.
+func (c *LayeredConfig) Reload() {
+	if err := c.Load(); err != nil {
+		logging.Tracef("xgo/config: failed to reload layered config: %v", err)
+	}
+}
+
+// Source 返回配置项的来源配置层，key 需要是叶子配置项
+func (c *LayeredConfig) Source(key string) (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	source, ok := c.sources[strings.ToLower(key)]
+	return source, ok
+}
+
+// Keys 返回排序后的所有叶子配置项路径
+func (c *LayeredConfig) Keys() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	keys := make([]string, 0, len(c.sources))
+	for k := range c.sources {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AllSettings 返回合并后的配置
+func (c *LayeredConfig) AllSettings() map[string]interface{} {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return normalizeMap(c.data)
+}
+
+// search 根据 keys 查询配置中的 value 值
+func (c *LayeredConfig) search(key string) (interface{}, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	value, err := searchByKeys(c.data, strings.Split(strings.ToLower(key), keyDelimiter))
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// Get returns config value by key. If key is absent will return the default value.
+func (c *LayeredConfig) Get(key string, defaultValue interface{}) interface{} {
+	if v, ok := c.search(key); ok {
+		return v
+	}
+	return defaultValue
+}
+
+// Unmarshal deserializes the config into input param, the yaml tag is used.
+func (c *LayeredConfig) Unmarshal(out interface{}) error {
+	return yaml.Unmarshal(c.Bytes(), out)
+}
+
+// IsSet returns if the config specified by key exists.
+func (c *LayeredConfig) IsSet(key string) bool {
+	_, ok := c.search(key)
+	return ok
+}
+
+// Bytes returns the merged config as yaml.
+func (c *LayeredConfig) Bytes() []byte {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	out, err := yaml.Marshal(c.data)
+	if err != nil {
+		logging.Tracef("xgo/config: failed to marshal layered config: %v", err)
+		return nil
+	}
+	return out
+}
+
+// findWithDefaultValue ensures that the type of `value` is same as `defaultValue`
+func (c *LayeredConfig) findWithDefaultValue(key string, defaultValue interface{}) interface{} {
+	v, ok := c.search(key)
+	if !ok {
+		return defaultValue
+	}
+	return castWithDefaultValue(v, defaultValue)
+}
+
+// GetInt returns int value by key, the second parameter
+// is default value when key is absent or type conversion fails.
+func (c *LayeredConfig) GetInt(key string, defaultValue int) int {
+	return c.findWithDefaultValue(key, defaultValue).(int)
+}
+
+// GetInt32 returns int32 value by key, the second parameter
+// is default value when key is absent or type conversion fails.
+func (c *LayeredConfig) GetInt32(key string, defaultValue int32) int32 {
+	return c.findWithDefaultValue(key, defaultValue).(int32)
+}
+
+// GetInt64 returns int64 value by key, the second parameter
+// is default value when key is absent or type conversion fails.
+func (c *LayeredConfig) GetInt64(key string, defaultValue int64) int64 {
+	return c.findWithDefaultValue(key, defaultValue).(int64)
+}
+
+// GetUint returns uint value by key, the second parameter
+// is default value when key is absent or type conversion fails.
+func (c *LayeredConfig) GetUint(key string, defaultValue uint) uint {
+	return c.findWithDefaultValue(key, defaultValue).(uint)
+}
+
+// GetUint32 returns uint32 value by key, the second parameter
+// is default value when key is absent or type conversion fails.
+func (c *LayeredConfig) GetUint32(key string, defaultValue uint32) uint32 {
+	return c.findWithDefaultValue(key, defaultValue).(uint32)
+}
+
+// GetUint64 returns uint64 value by key, the second parameter
+// is default value when key is absent or type conversion fails.
+func (c *LayeredConfig) GetUint64(key string, defaultValue uint64) uint64 {
+	return c.findWithDefaultValue(key, defaultValue).(uint64)
+}
+
+// GetFloat64 returns float64 value by key, the second parameter
+// is default value when key is absent or type conversion fails.
+func (c *LayeredConfig) GetFloat64(key string, defaultValue float64) float64 {
+	return c.findWithDefaultValue(key, defaultValue).(float64)
+}
+
+// GetFloat32 returns float32 value by key, the second parameter
+// is default value when key is absent or type conversion fails.
+func (c *LayeredConfig) GetFloat32(key string, defaultValue float32) float32 {
+	return c.findWithDefaultValue(key, defaultValue).(float32)
+}
+
+// GetBool returns bool value by key, the second parameter
+// is default value when key is absent or type conversion fails.
+func (c *LayeredConfig) GetBool(key string, defaultValue bool) bool {
+	return c.findWithDefaultValue(key, defaultValue).(bool)
+}
+
+// GetString returns string value by key, the second parameter
+// is default value when key is absent or type conversion fails.
+func (c *LayeredConfig) GetString(key string, defaultValue string) string {
+	return c.findWithDefaultValue(key, defaultValue).(string)
+}
+
+// normalizeMap 深度复制字典，key 统一转换为小写
+func normalizeMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			out[strings.ToLower(k)] = normalizeMap(v)
+		case map[interface{}]interface{}:
+			out[strings.ToLower(k)] = normalizeMap(cast.ToStringMap(v))
+		default:
+			out[strings.ToLower(k)] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fengzhongzhu1621/xgo/cast"
+	"github.com/fengzhongzhu1621/xgo/safe/mask"
+	"github.com/spf13/cobra"
+)
+
+// defaultSecretKeywords 配置项名称包含这些关键字时认为是密钥
+var defaultSecretKeywords = []string{
+	"password", "passwd", "pwd", "secret", "token", "credential", "private_key", "access_key", "apikey", "api_key",
+}
+
+// dumpOptions is the options of dumping the layered config.
+type dumpOptions struct {
+	secretKeywords []string
+}
+
+// DumpOption is the option for dumping the layered config.
+type DumpOption func(*dumpOptions)
+
+// WithSecretKeywords returns an option which adds the keywords of the secret config, the value of the config
+// whose last key contains the keyword is masked.
+func WithSecretKeywords(keywords ...string) DumpOption {
+	return func(o *dumpOptions) {
+		for _, k := range keywords {
+			o.secretKeywords = append(o.secretKeywords, strings.ToLower(k))
+		}
+	}
+}
+
+// isSecret 判断配置项是否是密钥，只检查路径的最后一级
+func (o *dumpOptions) isSecret(key string) bool {
+	name := key[strings.LastIndex(key, keyDelimiter)+1:]
+	for _, keyword := range o.secretKeywords {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dump 按配置项路径排序输出生效的配置及其来源，密钥脱敏
+func (c *LayeredConfig) Dump(w io.Writer, opts ...DumpOption) error {
+	o := &dumpOptions{secretKeywords: append([]string(nil), defaultSecretKeywords...)}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE"); err != nil {
+		return err
+	}
+
+	for _, key := range c.Keys() {
+		v, _ := c.search(key)
+		value := cast.ToString(v)
+		if _, ok := v.([]interface{}); ok {
+			value = fmt.Sprintf("%v", v)
+		}
+		if o.isSecret(key) {
+			value = mask.MaskSecret(value)
+		}
+
+		source, _ := c.Source(key)
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", key, value, source); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// NewDumpCommand 返回输出生效配置的命令，load 返回已加载的分层配置
+func NewDumpCommand(load func() (*LayeredConfig, error), opts ...DumpOption) *cobra.Command {
+	return &cobra.Command{
+		Use:   "dump",
+		Short: "Print the effective config with the source of each value",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := load()
+			if err != nil {
+				return err
+			}
+			return c.Dump(cmd.OutOrStdout(), opts...)
+		},
+	}
+}
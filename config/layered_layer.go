@@ -0,0 +1,293 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/fengzhongzhu1621/xgo/cast"
+	"github.com/fengzhongzhu1621/xgo/collections/maps"
+	xpflag "github.com/fengzhongzhu1621/xgo/config/pflag"
+	"github.com/fengzhongzhu1621/xgo/env/godotenv"
+)
+
+// 内置配置层的名称
+const (
+	LayerDefault = "default"
+	LayerEnv     = "env"
+	LayerDotEnv  = ".env"
+	LayerFlag    = "flag"
+)
+
+// ILayer 定义了分层配置中的一层配置
+type ILayer interface {
+	// Name 返回配置层的名称，用于查询配置项的来源
+	Name() string
+
+	// Load 加载配置，lower 为优先级更低的配置层合并后的结果，只读
+	Load(lower map[string]interface{}) (map[string]interface{}, error)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////////////////////
+// mapLayer 使用固定的配置内容
+type mapLayer struct {
+	name   string
+	values map[string]interface{}
+}
+
+// DefaultsLayer 返回默认值配置层，key 支持使用 . 分隔的路径
+func DefaultsLayer(values map[string]interface{}) ILayer {
+	return &mapLayer{name: LayerDefault, values: values}
+}
+
+// MapLayer 返回指定名称的固定配置层，key 支持使用 . 分隔的路径
+func MapLayer(name string, values map[string]interface{}) ILayer {
+	return &mapLayer{name: name, values: values}
+}
+
+// Name 返回配置层的名称
+func (l *mapLayer) Name() string {
+	return l.name
+}
+
+// Load 加载配置
+func (l *mapLayer) Load(map[string]interface{}) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	for k, v := range l.values {
+		setByPath(data, k, v)
+	}
+	return data, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////////////////////
+// fileLayer 通过数据提供者和编解码器读取配置，支持本地文件和配置中心
+type fileLayer struct {
+	name     string
+	path     string
+	optional bool
+	opts     []LoadOption
+}
+
+// FileLayer 返回文件配置层，opts 与 Load 相同，可以通过 WithProvider 读取配置中心的配置
+func FileLayer(name, path string, opts ...LoadOption) ILayer {
+	return &fileLayer{name: name, path: path, opts: opts}
+}
+
+// OptionalFileLayer 返回文件配置层，文件不存在时忽略，适用于环境配置文件
+func OptionalFileLayer(name, path string, opts ...LoadOption) ILayer {
+	return &fileLayer{name: name, path: path, optional: true, opts: opts}
+}
+
+// Name 返回配置层的名称
+func (l *fileLayer) Name() string {
+	return l.name
+}
+
+// Load 读取并解析配置文件
+func (l *fileLayer) Load(map[string]interface{}) (map[string]interface{}, error) {
+	c, err := newXGoConfig(l.path, l.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.Load(); err != nil {
+		if l.optional && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	data, _ := c.get().GetData().(map[string]interface{})
+	return data, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////////////////////
+// envLayer 使用环境变量覆盖已有的配置项
+// 配置项 server.read_timeout 对应的环境变量为 prefix + SERVER_READ_TIMEOUT
+type envLayer struct {
+	name    string
+	prefix  string
+	environ func() (map[string]string, error)
+}
+
+// EnvLayer 返回进程环境变量配置层
+func EnvLayer(prefix string) ILayer {
+	return &envLayer{
+		name:   LayerEnv,
+		prefix: prefix,
+		environ: func() (map[string]string, error) {
+			envMap := make(map[string]string)
+			for _, kv := range os.Environ() {
+				if k, v, ok := strings.Cut(kv, "="); ok {
+					envMap[k] = v
+				}
+			}
+			return envMap, nil
+		},
+	}
+}
+
+// DotEnvLayer 返回 .env 文件配置层，filenames 为空时读取当前目录的 .env 文件，文件中的变量不会写入进程环境变量
+func DotEnvLayer(prefix string, filenames ...string) ILayer {
+	return &envLayer{
+		name:   LayerDotEnv,
+		prefix: prefix,
+		environ: func() (map[string]string, error) {
+			envMap, err := godotenv.Read(filenames...)
+			if err != nil && len(filenames) == 0 && errors.Is(err, fs.ErrNotExist) {
+				// 默认的 .env 文件不存在时忽略
+				return nil, nil
+			}
+			return envMap, err
+		},
+	}
+}
+
+// Name 返回配置层的名称
+func (l *envLayer) Name() string {
+	return l.name
+}
+
+// EnvKey 返回配置项对应的环境变量名称
+func EnvKey(prefix, key string) string {
+	return prefix + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// Load 根据已有的配置项查找对应的环境变量，并转换为已有配置项的类型
+func (l *envLayer) Load(lower map[string]interface{}) (map[string]interface{}, error) {
+	envMap, err := l.environ()
+	if err != nil {
+		return nil, err
+	}
+	if len(envMap) == 0 {
+		return nil, nil
+	}
+
+	data := make(map[string]interface{})
+	for key, current := range maps.FlattenAndMergeMap(nil, lower, "", keyDelimiter) {
+		name := EnvKey(l.prefix, key)
+		s, ok := envMap[name]
+		if !ok {
+			continue
+		}
+
+		v, err := castLike(s, current)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s for %s, err: %v", name, key, err)
+		}
+		setByPath(data, key, v)
+	}
+	return data, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////////////////////
+// flagLayer 使用命令行参数覆盖配置，只使用用户设置的参数，参数名称即配置项的路径
+type flagLayer struct {
+	flags xpflag.IFlagValueSet
+}
+
+// FlagLayer 返回命令行参数配置层，可以使用 pflag.NewPFlagValueSet 包装 *pflag.FlagSet
+func FlagLayer(flags xpflag.IFlagValueSet) ILayer {
+	return &flagLayer{flags: flags}
+}
+
+// Name 返回配置层的名称
+func (l *flagLayer) Name() string {
+	return LayerFlag
+}
+
+// Load 根据参数类型转换参数值
+func (l *flagLayer) Load(map[string]interface{}) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+
+	var err error
+	l.flags.VisitAll(func(flag xpflag.IFlagValue) {
+		if err != nil || !flag.HasChanged() {
+			return
+		}
+
+		v, castErr := castFlagValue(flag)
+		if castErr != nil {
+			err = fmt.Errorf("invalid value of flag %s, err: %v", flag.Name(), castErr)
+			return
+		}
+		setByPath(data, flag.Name(), v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// castFlagValue 根据参数类型转换参数值
+func castFlagValue(flag xpflag.IFlagValue) (interface{}, error) {
+	s := flag.ValueString()
+	switch flag.ValueType() {
+	case "bool":
+		return cast.ToBoolE(s)
+	case "int", "int8", "int16", "int32", "int64":
+		return cast.ToInt64E(s)
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		return cast.ToUint64E(s)
+	case "float32", "float64":
+		return cast.ToFloat64E(s)
+	case "stringSlice", "stringArray", "intSlice", "boolSlice", "floatSlice":
+		// 切片参数的格式为 [a,b]
+		return splitList(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")), nil
+	default:
+		return s, nil
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////////////////////
+// keyDelimiter 配置项路径的分隔符
+const keyDelimiter = "."
+
+// setByPath 按路径设置嵌套的配置项
+func setByPath(data map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(strings.ToLower(path), keyDelimiter)
+	m := data
+	for _, k := range keys[:len(keys)-1] {
+		sub, ok := m[k].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			m[k] = sub
+		}
+		m = sub
+	}
+	m[keys[len(keys)-1]] = value
+}
+
+// castLike 将字符串转换为 current 的类型，列表使用逗号分隔
+func castLike(s string, current interface{}) (interface{}, error) {
+	switch current.(type) {
+	case bool:
+		return cast.ToBoolE(s)
+	case int, int8, int16, int32, int64:
+		return cast.ToInt64E(s)
+	case uint, uint8, uint16, uint32, uint64:
+		return cast.ToUint64E(s)
+	case float32, float64:
+		return cast.ToFloat64E(s)
+	case []interface{}, []string:
+		return splitList(s), nil
+	default:
+		return s, nil
+	}
+}
+
+// splitList 使用逗号分隔列表
+func splitList(s string) []interface{} {
+	list := make([]interface{}, 0)
+	if s == "" {
+		return list
+	}
+	for _, item := range strings.Split(s, ",") {
+		list = append(list, strings.TrimSpace(item))
+	}
+	return list
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	xpflag "github.com/fengzhongzhu1621/xgo/config/pflag"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayeredConfig(t *testing.T) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	require.NoError(os.WriteFile(base, []byte(`
+server:
+  host: 0.0.0.0
+  port: 8000
+  tags: [a, b]
+db:
+  user: root
+  password: base-secret
+log:
+  level: info
+`), 0o644))
+	envFile := filepath.Join(dir, "base.prod.yaml")
+	require.NoError(os.WriteFile(envFile, []byte("log:\n  level: warn\n"), 0o644))
+	dotEnv := filepath.Join(dir, ".env")
+	require.NoError(os.WriteFile(dotEnv, []byte("APP_DB_USER=admin\nAPP_SERVER_PORT=8100\n"), 0o644))
+
+	t.Setenv("APP_SERVER_PORT", "8200")
+	t.Setenv("APP_SERVER_TAGS", "x, y")
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Bool("debug", false, "")
+	flags.String("log.level", "info", "")
+	flags.Int("server.port", 0, "")
+	require.NoError(flags.Parse([]string{"--log.level=debug", "--debug"}))
+
+	c := NewLayeredConfig(StandardLayers(StandardLayerOptions{
+		Defaults: map[string]interface{}{
+			"server.timeout": 30,
+			"server.port":    80,
+			"app":            map[string]interface{}{"Name": "demo"},
+		},
+		BaseFile:    base,
+		EnvFile:     envFile,
+		DotEnvFiles: []string{dotEnv},
+		EnvPrefix:   "APP_",
+		Flags:       xpflag.NewPFlagValueSet(flags),
+	})...)
+	require.NoError(c.Load())
+
+	tests := []struct {
+		key    string
+		value  interface{}
+		source string
+	}{
+		{"app.name", "demo", LayerDefault},
+		{"server.timeout", 30, LayerDefault},
+		{"server.host", "0.0.0.0", base},
+		{"db.password", "base-secret", base},
+		{"log.level", "debug", LayerFlag},
+		{"db.user", "admin", LayerDotEnv},
+		{"server.port", int64(8200), LayerEnv},
+		{"server.tags", []interface{}{"x", "y"}, LayerEnv},
+		{"debug", true, LayerFlag},
+	}
+	for _, tt := range tests {
+		require.Equal(tt.value, c.Get(tt.key, nil), tt.key)
+		source, ok := c.Source(tt.key)
+		require.True(ok, tt.key)
+		require.Equal(tt.source, source, tt.key)
+	}
+
+	require.Equal(8200, c.GetInt("server.port", 0))
+	require.Equal("demo", c.GetString("APP.Name", ""))
+	require.True(c.IsSet("server"))
+	require.False(c.IsSet("server.none"))
+	_, ok := c.Source("server")
+	require.False(ok)
+
+	var out struct {
+		Server struct {
+			Port int `yaml:"port"`
+		} `yaml:"server"`
+	}
+	require.NoError(c.Unmarshal(&out))
+	require.Equal(8200, out.Server.Port)
+
+	// 环境配置文件不存在时忽略
+	c = NewLayeredConfig(StandardLayers(StandardLayerOptions{
+		BaseFile: base,
+		EnvFile:  filepath.Join(dir, "base.test.yaml"),
+	})...)
+	require.NoError(c.Load())
+	require.Equal("info", c.GetString("log.level", ""))
+
+	// 基础配置文件不存在时返回错误
+	c = NewLayeredConfig(FileLayer("base", filepath.Join(dir, "none.yaml")))
+	require.Error(c.Load())
+
+	// 环境变量无法转换为已有配置项的类型
+	t.Setenv("APP_SERVER_TIMEOUT", "abc")
+	c = NewLayeredConfig(DefaultsLayer(map[string]interface{}{"server.timeout": 30}), EnvLayer("APP_"))
+	require.ErrorContains(c.Load(), "APP_SERVER_TIMEOUT")
+}
+
+func TestLayeredConfigDoNotModifyLayer(t *testing.T) {
+	require := require.New(t)
+
+	defaults := map[string]interface{}{"server": map[string]interface{}{"port": 80}}
+	c := NewLayeredConfig(
+		MapLayer("a", defaults),
+		MapLayer("b", map[string]interface{}{"server.host": "h"}),
+	)
+	require.NoError(c.Load())
+	require.Equal(map[string]interface{}{"server": map[string]interface{}{"port": 80}}, defaults)
+	require.Equal([]string{"server.host", "server.port"}, c.Keys())
+
+	// 标量不会覆盖字典
+	c = NewLayeredConfig(
+		MapLayer("a", defaults),
+		MapLayer("b", map[string]interface{}{"server": "x"}),
+	)
+	require.NoError(c.Load())
+	source, _ := c.Source("server.port")
+	require.Equal("a", source)
+}
+
+func TestLayeredConfigDump(t *testing.T) {
+	require := require.New(t)
+
+	c := NewLayeredConfig(
+		DefaultsLayer(map[string]interface{}{
+			"db.password":    "p",
+			"db.user":        "root",
+			"auth.app_token": "t",
+			"cache.ttl":      "",
+		}),
+		MapLayer("local", map[string]interface{}{"auth.cookie_name": "sid", "db.hosts": []interface{}{"a", "b"}}),
+	)
+	require.NoError(c.Load())
+
+	var buf bytes.Buffer
+	require.NoError(c.Dump(&buf, WithSecretKeywords("cookie")))
+	require.Equal(`KEY               VALUE   SOURCE
+auth.app_token    ******  default
+auth.cookie_name  ******  local
+cache.ttl                 default
+db.hosts          [a b]   local
+db.password       ******  default
+db.user           root    default
+`, buf.String())
+
+	buf.Reset()
+	cmd := NewDumpCommand(func() (*LayeredConfig, error) { return c, nil })
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{})
+	require.NoError(cmd.Execute())
+	require.Contains(buf.String(), "auth.cookie_name  sid     local")
+}
@@ -33,6 +33,11 @@ type PFlagValueSet struct {
 	flags *pflag.FlagSet
 }
 
+// NewPFlagValueSet wraps the *pflag.FlagSet as IFlagValueSet.
+func NewPFlagValueSet(flags *pflag.FlagSet) PFlagValueSet {
+	return PFlagValueSet{flags: flags}
+}
+
 // VisitAll iterates over all *pflag.Flag inside the *pflag.FlagSet.
 // 遍历所有的 IFlagValue .
 func (p PFlagValueSet) VisitAll(fn func(flag IFlagValue)) {
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fengzhongzhu1621/xgo/logging"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// 编译时检查 EtcdSource 是否实现了 IRemoteSource 接口
+var _ IRemoteSource = (*EtcdSource)(nil)
+
+// EtcdSource 从 etcd 读取和监听配置，配置路径对应 etcd 的 key
+type EtcdSource struct {
+	kv      clientv3.KV
+	watcher clientv3.Watcher
+}
+
+// NewEtcdSource 创建一个 etcd 配置源
+func NewEtcdSource(kv clientv3.KV, watcher clientv3.Watcher) *EtcdSource {
+	return &EtcdSource{
+		kv:      kv,
+		watcher: watcher,
+	}
+}
+
+// NewEtcdProvider 创建一个名称为 etcd 的数据提供者，需要调用 RegisterProvider 注册后才能通过
+// config.WithProvider("etcd") 使用
+func NewEtcdProvider(client *clientv3.Client, opts ...Option) *RemoteProvider {
+	return NewRemoteProvider("etcd", NewEtcdSource(client.KV, client.Watcher), opts...)
+}
+
+// Get 读取 key 的内容和 etcd 的 revision
+func (s *EtcdSource) Get(ctx context.Context, key string) ([]byte, int64, error) {
+	resp, err := s.kv.Get(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, resp.Header.GetRevision(), ErrNotFound
+	}
+	return resp.Kvs[0].Value, resp.Header.GetRevision(), nil
+}
+
+// Watch 从 version 之后的 revision 开始监听，避免读取和监听之间的变更丢失
+func (s *EtcdSource) Watch(ctx context.Context, key string, version int64, onChange func([]byte)) error {
+	// 与 leader 失去连接时关闭监听，以便重新连接
+	ctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+
+	for resp := range s.watcher.Watch(ctx, key, clientv3.WithRev(version+1)) {
+		if err := resp.Err(); err != nil {
+			return err
+		}
+
+		for _, ev := range resp.Events {
+			if ev.Type == clientv3.EventTypeDelete {
+				// 删除配置时保留当前的配置
+				logging.Warnf("config %s is deleted from etcd, keep the current config", key)
+				continue
+			}
+			onChange(ev.Kv.Value)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.New("etcd watch channel is closed")
+}
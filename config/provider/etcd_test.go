@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeEtcdKV 只实现了 Get 方法
+type fakeEtcdKV struct {
+	clientv3.KV
+	resp *clientv3.GetResponse
+}
+
+func (kv *fakeEtcdKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	return kv.resp, nil
+}
+
+// fakeEtcdWatcher 返回预先设置的监听通道
+type fakeEtcdWatcher struct {
+	clientv3.Watcher
+	ch  chan clientv3.WatchResponse
+	rev int64
+}
+
+func (w *fakeEtcdWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	op := clientv3.OpGet(key, opts...)
+	w.rev = op.Rev()
+	return w.ch
+}
+
+func TestEtcdSource(t *testing.T) {
+	kv := &fakeEtcdKV{resp: &clientv3.GetResponse{
+		Header: &etcdserverpb.ResponseHeader{Revision: 10},
+		Kvs:    []*mvccpb.KeyValue{{Key: []byte("app.yaml"), Value: []byte("a: 1")}},
+	}}
+	watcher := &fakeEtcdWatcher{ch: make(chan clientv3.WatchResponse, 3)}
+	s := NewEtcdSource(kv, watcher)
+
+	data, version, err := s.Get(context.Background(), "app.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1", string(data))
+	assert.Equal(t, int64(10), version)
+
+	watcher.ch <- clientv3.WatchResponse{Events: []*clientv3.Event{
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Value: []byte("a: 2")}},
+		{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{}},
+	}}
+	watcher.ch <- clientv3.WatchResponse{CompactRevision: 11}
+
+	var changes []string
+	err = s.Watch(context.Background(), "app.yaml", version, func(data []byte) {
+		changes = append(changes, string(data))
+	})
+	assert.ErrorIs(t, err, rpctypes.ErrCompacted)
+	assert.Equal(t, []string{"a: 2"}, changes)
+	// 从读取的下一个版本开始监听
+	assert.Equal(t, int64(11), watcher.rev)
+
+	// 监听通道关闭
+	close(watcher.ch)
+	err = s.Watch(context.Background(), "app.yaml", version, func([]byte) {})
+	assert.Error(t, err)
+
+	kv.resp = &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: 12}}
+	_, version, err = s.Get(context.Background(), "app.yaml")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, int64(12), version)
+}
@@ -0,0 +1,76 @@
+package provider
+
+import "time"
+
+const (
+	// defaultTimeout 读取配置中心的默认超时时间
+	defaultTimeout = 3 * time.Second
+	// defaultRetryInterval 监听中断后重新连接的默认间隔
+	defaultRetryInterval = 5 * time.Second
+)
+
+// options is the options of the config center provider.
+type options struct {
+	name          string        // 数据提供者的名称，用于 config.WithProvider
+	pathPrefix    string        // 配置路径的前缀，配置中心的 key 为 pathPrefix + path
+	snapshotDir   string        // 本地快照目录，为空时不保存快照
+	timeout       time.Duration // 读取配置中心的超时时间
+	retryInterval time.Duration // 监听中断后重新连接的间隔
+}
+
+// Option is the option for the config center provider.
+type Option func(*options)
+
+// newOptions 创建默认配置并应用选项
+func newOptions(name string, opts ...Option) *options {
+	o := &options{
+		name:          name,
+		timeout:       defaultTimeout,
+		retryInterval: defaultRetryInterval,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithName returns an option which sets the provider's name, it is useful when registering multiple providers
+// of the same config center.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithPathPrefix returns an option which sets the prefix of the key in the config center.
+func WithPathPrefix(prefix string) Option {
+	return func(o *options) {
+		o.pathPrefix = prefix
+	}
+}
+
+// WithSnapshotDir returns an option which saves the config data to the local directory,
+// the snapshot is used when the config center is unavailable.
+func WithSnapshotDir(dir string) Option {
+	return func(o *options) {
+		o.snapshotDir = dir
+	}
+}
+
+// WithTimeout returns an option which sets the timeout of reading the config center.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.timeout = timeout
+		}
+	}
+}
+
+// WithRetryInterval returns an option which sets the interval of reconnecting after the watch is broken.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.retryInterval = interval
+		}
+	}
+}
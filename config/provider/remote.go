@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/logging"
+)
+
+// ErrNotFound 配置中心中不存在配置
+var ErrNotFound = errors.New("config not found in config center")
+
+// IRemoteSource 定义了配置中心的读取和监听接口，RemoteProvider 基于它实现 IDataProvider
+type IRemoteSource interface {
+	// Get 读取 key 的内容和版本，key 不存在时返回 ErrNotFound 和当前版本
+	Get(ctx context.Context, key string) ([]byte, int64, error)
+
+	// Watch 监听 version 之后的变更，每次变更调用 onChange，监听中断或 ctx 结束时返回
+	Watch(ctx context.Context, key string, version int64, onChange func([]byte)) error
+}
+
+// 编译时检查 RemoteProvider 是否实现了 IDataProvider 接口
+var _ IDataProvider = (*RemoteProvider)(nil)
+
+// RemoteProvider 是一个从配置中心获取配置的配置提供者
+// 读取失败时使用本地快照，监听中断后自动重新连接，重连成功后配置有变化时触发回调函数
+type RemoteProvider struct {
+	opts     *options
+	source   IRemoteSource
+	snapshot *Snapshot
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	cbs      []ProviderCallback // 回调函数
+	watching map[string]bool    // 正在监听的配置路径
+	last     map[string][]byte  // 配置路径最新的内容
+}
+
+// NewRemoteProvider 创建一个配置中心的数据提供者，name 为默认的名称
+func NewRemoteProvider(name string, source IRemoteSource, opts ...Option) *RemoteProvider {
+	o := newOptions(name, opts...)
+
+	p := &RemoteProvider{
+		opts:     o,
+		source:   source,
+		watching: make(map[string]bool),
+		last:     make(map[string][]byte),
+	}
+	if o.snapshotDir != "" {
+		// 不同的数据提供者使用不同的快照目录
+		p.snapshot = NewSnapshot(filepath.Join(o.snapshotDir, o.name))
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	return p
+}
+
+// Name 返回数据提供者的名称
+func (p *RemoteProvider) Name() string {
+	return p.opts.name
+}
+
+// key 返回配置路径在配置中心中的 key
+func (p *RemoteProvider) key(path string) string {
+	return p.opts.pathPrefix + path
+}
+
+// Read 从配置中心读取配置，配置中心不可用时读取本地快照，并开始监听配置变更
+func (p *RemoteProvider) Read(path string) ([]byte, error) {
+	if err := p.ctx.Err(); err != nil {
+		return nil, errors.New("provider is closed")
+	}
+
+	// 配置中心不可用时也开始监听，恢复后通过回调函数更新配置
+	defer p.startWatch(path)
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.opts.timeout)
+	defer cancel()
+
+	data, _, err := p.source.Get(ctx, p.key(path))
+	if err == nil {
+		p.mu.Lock()
+		p.last[path] = data
+		p.mu.Unlock()
+		p.saveSnapshot(path, data)
+		return data, nil
+	}
+
+	if p.snapshot == nil {
+		return nil, fmt.Errorf("read %s from %s failed, err: %w", path, p.opts.name, err)
+	}
+
+	snapshot, snapshotErr := p.snapshot.Load(path)
+	if snapshotErr != nil {
+		return nil, fmt.Errorf("read %s from %s failed, err: %w, load snapshot failed, err: %v",
+			path, p.opts.name, err, snapshotErr)
+	}
+	logging.Warnf("read %s from %s failed, use the local snapshot, err: %v", path, p.opts.name, err)
+
+	p.mu.Lock()
+	p.last[path] = snapshot
+	p.mu.Unlock()
+	return snapshot, nil
+}
+
+// Watch 监听配置变更，变更将通过回调函数处理
+func (p *RemoteProvider) Watch(cb ProviderCallback) {
+	p.mu.Lock()
+	p.cbs = append(p.cbs, cb)
+	p.mu.Unlock()
+}
+
+// Close 停止监听配置变更
+func (p *RemoteProvider) Close() error {
+	p.cancel()
+	return nil
+}
+
+// startWatch 每个配置路径只启动一个监听协程
+func (p *RemoteProvider) startWatch(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.watching[path] {
+		return
+	}
+	p.watching[path] = true
+
+	go p.run(path)
+}
+
+// run 监听配置变更，监听中断后间隔 retryInterval 重新读取配置并监听
+func (p *RemoteProvider) run(path string) {
+	key := p.key(path)
+	onChange := func(data []byte) {
+		p.update(path, data)
+	}
+
+	for {
+		ctx, cancel := context.WithTimeout(p.ctx, p.opts.timeout)
+		data, version, err := p.source.Get(ctx, key)
+		cancel()
+
+		switch {
+		case err == nil:
+			// 重连期间配置可能发生了变化
+			onChange(data)
+			err = p.source.Watch(p.ctx, key, version, onChange)
+		case errors.Is(err, ErrNotFound):
+			err = p.source.Watch(p.ctx, key, version, onChange)
+		}
+
+		if p.ctx.Err() != nil {
+			return
+		}
+		logging.Warnf("watch %s of %s is broken, retry after %s, err: %v", path, p.opts.name, p.opts.retryInterval, err)
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(p.opts.retryInterval):
+		}
+	}
+}
+
+// update 配置内容发生变化时保存快照并触发所有回调函数
+func (p *RemoteProvider) update(path string, data []byte) {
+	p.mu.Lock()
+	if last, ok := p.last[path]; ok && bytes.Equal(last, data) {
+		p.mu.Unlock()
+		return
+	}
+	p.last[path] = data
+	cbs := make([]ProviderCallback, len(p.cbs))
+	copy(cbs, p.cbs)
+	p.mu.Unlock()
+
+	p.saveSnapshot(path, data)
+
+	for _, f := range cbs {
+		// path: 配置文件路径
+		// data: 最新的配置文件内容
+		go f(path, data) // 异步执行回调
+	}
+}
+
+// saveSnapshot 保存本地快照，失败时只记录日志
+func (p *RemoteProvider) saveSnapshot(path string, data []byte) {
+	if p.snapshot == nil {
+		return
+	}
+	if err := p.snapshot.Save(path, data); err != nil {
+		logging.Errorf("save snapshot of %s failed, err: %v", path, err)
+	}
+}
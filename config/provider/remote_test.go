@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource 模拟配置中心，支持修改配置和模拟不可用
+type fakeSource struct {
+	mu      sync.Mutex
+	data    map[string][]byte
+	version int64
+	down    bool
+	changed chan struct{}
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		data:    make(map[string][]byte),
+		changed: make(chan struct{}),
+	}
+}
+
+func (s *fakeSource) Get(ctx context.Context, key string) ([]byte, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.down {
+		return nil, 0, errors.New("config center is down")
+	}
+	data, ok := s.data[key]
+	if !ok {
+		return nil, s.version, ErrNotFound
+	}
+	return data, s.version, nil
+}
+
+func (s *fakeSource) Watch(ctx context.Context, key string, version int64, onChange func([]byte)) error {
+	for {
+		s.mu.Lock()
+		if s.down {
+			s.mu.Unlock()
+			return errors.New("config center is down")
+		}
+		if s.version > version {
+			version = s.version
+			if data, ok := s.data[key]; ok {
+				onChange(data)
+			}
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (s *fakeSource) set(key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = data
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *fakeSource) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// collect 记录回调函数收到的配置
+func collect(p IDataProvider) <-chan string {
+	ch := make(chan string, 10)
+	p.Watch(func(path string, data []byte) {
+		ch <- path + ":" + string(data)
+	})
+	return ch
+}
+
+func waitFor(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(3 * time.Second):
+		t.Fatal("wait for callback timeout")
+		return ""
+	}
+}
+
+func TestRemoteProviderWatch(t *testing.T) {
+	source := newFakeSource()
+	source.set("/conf/app.yaml", []byte("a: 1"))
+
+	p := NewRemoteProvider("fake", source, WithPathPrefix("/conf/"), WithRetryInterval(10*time.Millisecond))
+	defer p.Close()
+	assert.Equal(t, "fake", p.Name())
+
+	ch := collect(p)
+	data, err := p.Read("app.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1", string(data))
+
+	source.set("/conf/app.yaml", []byte("a: 2"))
+	assert.Equal(t, "app.yaml:a: 2", waitFor(t, ch))
+
+	// 配置中心恢复后触发变更期间的配置
+	source.setDown(true)
+	time.Sleep(30 * time.Millisecond)
+	source.mu.Lock()
+	source.data["/conf/app.yaml"] = []byte("a: 3")
+	source.mu.Unlock()
+	source.setDown(false)
+	assert.Equal(t, "app.yaml:a: 3", waitFor(t, ch))
+
+	// 内容没有变化时不触发回调
+	source.set("/conf/app.yaml", []byte("a: 3"))
+	source.set("/conf/app.yaml", []byte("a: 4"))
+	assert.Equal(t, "app.yaml:a: 4", waitFor(t, ch))
+}
+
+func TestRemoteProviderSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	source := newFakeSource()
+	source.set("app.yaml", []byte("a: 1"))
+
+	p := NewRemoteProvider("fake", source, WithSnapshotDir(dir), WithRetryInterval(10*time.Millisecond))
+	_, err := p.Read("app.yaml")
+	require.NoError(t, err)
+	p.Close()
+
+	// 配置中心不可用时使用快照启动
+	source.setDown(true)
+	p = NewRemoteProvider("fake", source, WithSnapshotDir(dir), WithRetryInterval(10*time.Millisecond))
+	defer p.Close()
+
+	ch := collect(p)
+	data, err := p.Read("app.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1", string(data))
+
+	// 没有快照时返回错误
+	_, err = p.Read("other.yaml")
+	assert.Error(t, err)
+
+	// 配置中心恢复后通过回调更新配置，并更新快照
+	source.mu.Lock()
+	source.data["app.yaml"] = []byte("a: 2")
+	source.mu.Unlock()
+	source.setDown(false)
+	assert.Equal(t, "app.yaml:a: 2", waitFor(t, ch))
+
+	data, err = NewSnapshot(dir + "/fake").Load("app.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "a: 2", string(data))
+}
+
+func TestRemoteProviderNotFound(t *testing.T) {
+	source := newFakeSource()
+	p := NewRemoteProvider("fake", source, WithName("fake2"), WithRetryInterval(10*time.Millisecond))
+	assert.Equal(t, "fake2", p.Name())
+
+	ch := collect(p)
+	_, err := p.Read("app.yaml")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 配置创建后触发回调
+	source.set("app.yaml", []byte("a: 1"))
+	assert.Equal(t, "app.yaml:a: 1", waitFor(t, ch))
+
+	p.Close()
+	_, err = p.Read("app.yaml")
+	assert.Error(t, err)
+}
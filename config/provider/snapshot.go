@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// snapshotExt 快照文件的扩展名，同时避免文件名为 . 或 ..
+const snapshotExt = ".snapshot"
+
+// Snapshot 将配置中心的配置保存到本地目录，配置中心不可用时使用本地快照启动服务
+type Snapshot struct {
+	dir string
+}
+
+// NewSnapshot 创建一个本地快照，dir 为快照保存的目录
+func NewSnapshot(dir string) *Snapshot {
+	return &Snapshot{dir: dir}
+}
+
+// Dir 返回快照保存的目录
+func (s *Snapshot) Dir() string {
+	return s.dir
+}
+
+// filename 返回配置路径对应的快照文件，配置路径转义后作为文件名
+func (s *Snapshot) filename(path string) string {
+	return filepath.Join(s.dir, url.PathEscape(path)+snapshotExt)
+}
+
+// Save 保存配置内容，先写入临时文件再重命名，避免进程退出时快照文件不完整
+func (s *Snapshot) Save(path string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err = os.Rename(tmp, s.filename(path)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Load 读取配置内容的快照
+func (s *Snapshot) Load(path string) ([]byte, error) {
+	return os.ReadFile(s.filename(path))
+}
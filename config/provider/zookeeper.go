@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fengzhongzhu1621/xgo/db/zookeeper/zkclient"
+	"github.com/go-zookeeper/zk"
+)
+
+// IZkClient 定义了 zookeeper 数据源依赖的客户端接口，*zkclient.ZkClient 实现了该接口
+type IZkClient interface {
+	GetEx(path string) ([]byte, *zk.Stat, error)
+	GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
+	IsConnectionError(err error) bool
+	Connect() error
+}
+
+// 编译时检查
+var (
+	_ IRemoteSource = (*ZookeeperSource)(nil)
+	_ IZkClient     = (*zkclient.ZkClient)(nil)
+)
+
+// ZookeeperSource 从 zookeeper 读取和监听配置，配置路径对应 zookeeper 的节点
+type ZookeeperSource struct {
+	client IZkClient
+}
+
+// NewZookeeperSource 创建一个 zookeeper 配置源，client 需要已经建立连接
+func NewZookeeperSource(client IZkClient) *ZookeeperSource {
+	return &ZookeeperSource{client: client}
+}
+
+// NewZookeeperProvider 创建一个名称为 zookeeper 的数据提供者，需要调用 RegisterProvider 注册后才能通过
+// config.WithProvider("zookeeper") 使用
+func NewZookeeperProvider(client *zkclient.ZkClient, opts ...Option) *RemoteProvider {
+	return NewRemoteProvider("zookeeper", NewZookeeperSource(client), opts...)
+}
+
+// Get 读取节点的内容和数据版本
+func (s *ZookeeperSource) Get(ctx context.Context, key string) ([]byte, int64, error) {
+	type result struct {
+		data []byte
+		stat *zk.Stat
+		err  error
+	}
+
+	// zookeeper 客户端不支持 context，超时后放弃等待
+	ch := make(chan result, 1)
+	go func() {
+		data, stat, err := s.client.GetEx(key)
+		ch <- result{data: data, stat: stat, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	case r := <-ch:
+		if r.err != nil {
+			return nil, 0, s.convertErr(r.err)
+		}
+		return r.data, int64(r.stat.Version), nil
+	}
+}
+
+// Watch 监听节点的变更，zookeeper 的 watch 只触发一次，所以每次触发后重新读取并监听
+func (s *ZookeeperSource) Watch(ctx context.Context, key string, version int64, onChange func([]byte)) error {
+	for {
+		data, stat, events, err := s.client.GetW(key)
+		if err != nil {
+			return s.convertErr(err)
+		}
+
+		// 读取和监听之间的变更
+		if int64(stat.Version) != version {
+			version = int64(stat.Version)
+			onChange(data)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-events:
+			if ev.Err != nil {
+				return ev.Err
+			}
+			if ev.Type == zk.EventNotWatching {
+				return errors.New("zookeeper watch is removed")
+			}
+		}
+	}
+}
+
+// convertErr 转换节点不存在的错误，连接断开时重新连接
+func (s *ZookeeperSource) convertErr(err error) error {
+	if errors.Is(err, zk.ErrNoNode) {
+		return ErrNotFound
+	}
+
+	if s.client.IsConnectionError(err) {
+		if connErr := s.client.Connect(); connErr != nil {
+			return errors.Join(err, connErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeZkClient 模拟 zookeeper 节点，每次 GetW 返回一个新的监听通道
+type fakeZkClient struct {
+	data      []byte
+	version   int32
+	err       error
+	events    chan zk.Event
+	connected int
+}
+
+func (c *fakeZkClient) GetEx(path string) ([]byte, *zk.Stat, error) {
+	if c.err != nil {
+		return nil, nil, c.err
+	}
+	return c.data, &zk.Stat{Version: c.version}, nil
+}
+
+func (c *fakeZkClient) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	data, stat, err := c.GetEx(path)
+	return data, stat, c.events, err
+}
+
+func (c *fakeZkClient) IsConnectionError(err error) bool {
+	return err == zk.ErrConnectionClosed
+}
+
+func (c *fakeZkClient) Connect() error {
+	c.connected++
+	return nil
+}
+
+func TestZookeeperSource(t *testing.T) {
+	client := &fakeZkClient{data: []byte("a: 1"), version: 1, events: make(chan zk.Event, 2)}
+	s := NewZookeeperSource(client)
+
+	data, version, err := s.Get(context.Background(), "/app.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1", string(data))
+	assert.Equal(t, int64(1), version)
+
+	// 节点变更后重新读取，会话过期后返回错误
+	var changes []string
+	client.events <- zk.Event{Type: zk.EventNodeDataChanged}
+	client.events <- zk.Event{Type: zk.EventNotWatching, Err: zk.ErrSessionExpired}
+	err = s.Watch(context.Background(), "/app.yaml", version, func(data []byte) {
+		changes = append(changes, string(data))
+		client.version++
+	})
+	assert.ErrorIs(t, err, zk.ErrSessionExpired)
+	assert.Empty(t, changes)
+
+	client.data = []byte("a: 2")
+	client.version = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = s.Watch(ctx, "/app.yaml", version, func(data []byte) {
+		changes = append(changes, string(data))
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"a: 2"}, changes)
+
+	client.err = zk.ErrNoNode
+	_, _, err = s.Get(context.Background(), "/app.yaml")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 连接断开时重新连接
+	client.err = zk.ErrConnectionClosed
+	_, _, err = s.Get(context.Background(), "/app.yaml")
+	assert.ErrorIs(t, err, zk.ErrConnectionClosed)
+	assert.Equal(t, 1, client.connected)
+}
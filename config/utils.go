@@ -74,3 +74,36 @@ func searchByKeys(unmarshalledData map[string]interface{}, keys []string) (inter
 		return nil, ErrConfigNotExist
 	}
 }
+
+// castWithDefaultValue 将 v 转换为 defaultValue 的类型，转换失败时返回 defaultValue
+func castWithDefaultValue(v interface{}, defaultValue interface{}) interface{} {
+	var err error
+	switch defaultValue.(type) {
+	case bool:
+		v, err = cast.ToBoolE(v)
+	case string:
+		v, err = cast.ToStringE(v)
+	case int:
+		v, err = cast.ToIntE(v)
+	case int32:
+		v, err = cast.ToInt32E(v)
+	case int64:
+		v, err = cast.ToInt64E(v)
+	case uint:
+		v, err = cast.ToUintE(v)
+	case uint32:
+		v, err = cast.ToUint32E(v)
+	case uint64:
+		v, err = cast.ToUint64E(v)
+	case float64:
+		v, err = cast.ToFloat64E(v)
+	case float32:
+		v, err = cast.ToFloat32E(v)
+	default:
+	}
+
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
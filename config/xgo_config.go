@@ -5,7 +5,6 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/fengzhongzhu1621/xgo/cast"
 	"github.com/fengzhongzhu1621/xgo/config/entity"
 	"github.com/fengzhongzhu1621/xgo/config/hooks"
 	"github.com/fengzhongzhu1621/xgo/config/provider"
@@ -276,35 +275,7 @@ func (c *XGoConfig) findWithDefaultValue(key string, defaultValue interface{}) (
 		return defaultValue
 	}
 
-	var err error
-	switch defaultValue.(type) {
-	case bool:
-		v, err = cast.ToBoolE(v)
-	case string:
-		v, err = cast.ToStringE(v)
-	case int:
-		v, err = cast.ToIntE(v)
-	case int32:
-		v, err = cast.ToInt32E(v)
-	case int64:
-		v, err = cast.ToInt64E(v)
-	case uint:
-		v, err = cast.ToUintE(v)
-	case uint32:
-		v, err = cast.ToUint32E(v)
-	case uint64:
-		v, err = cast.ToUint64E(v)
-	case float64:
-		v, err = cast.ToFloat64E(v)
-	case float32:
-		v, err = cast.ToFloat32E(v)
-	default:
-	}
-
-	if err != nil {
-		return defaultValue
-	}
-	return v
+	return castWithDefaultValue(v, defaultValue)
 }
 
 // search 根据 keys 查询配置中的 value 值
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/config/hooks"
+	"github.com/fengzhongzhu1621/xgo/config/provider"
+	"github.com/stretchr/testify/require"
+)
+
+var _ provider.IRemoteSource = (*chanSource)(nil)
+
+// chanSource 通过通道推送配置变更的配置中心
+type chanSource struct {
+	data    []byte
+	updates chan []byte
+}
+
+func (s *chanSource) Get(ctx context.Context, key string) ([]byte, int64, error) {
+	return s.data, 1, nil
+}
+
+func (s *chanSource) Watch(ctx context.Context, key string, version int64, onChange func([]byte)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case data := <-s.updates:
+			onChange(data)
+		}
+	}
+}
+
+func TestLoadRemoteProviderWatch(t *testing.T) {
+	require := require.New(t)
+
+	source := &chanSource{data: []byte("server:\n  app: a\n"), updates: make(chan []byte)}
+	p := provider.NewRemoteProvider("remote-test", source)
+	defer p.Close()
+	provider.RegisterProvider(p)
+
+	messages := make(chan hooks.WatchMessage, 1)
+	c, err := Load("app.yaml", WithProvider("remote-test"), WithWatch(), WithWatchHook(func(msg hooks.WatchMessage) {
+		messages <- msg
+	}))
+	require.NoError(err)
+	require.Equal("a", c.GetString("server.app", ""))
+
+	// 配置中心的变更更新配置并执行 WatchMessage 回调
+	source.updates <- []byte("server:\n  app: b\n")
+	select {
+	case msg := <-messages:
+		require.Equal("remote-test", msg.Provider)
+		require.Equal("app.yaml", msg.Path)
+		require.Equal("server:\n  app: b\n", string(msg.Value))
+		require.NoError(msg.Error)
+	case <-time.After(3 * time.Second):
+		t.Fatal("wait for watch message timeout")
+	}
+	require.Equal("b", c.GetString("server.app", ""))
+}
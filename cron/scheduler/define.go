@@ -1,6 +1,15 @@
 package scheduler
 
+import "errors"
+
 const (
 	scheduleTaskRunning = "running"
 	scheduleTaskPaused  = "paused"
 )
+
+var (
+	// ErrTaskNotFound 任务不存在
+	ErrTaskNotFound = errors.New("scheduler: task not found")
+	// ErrJobFuncNotFound 任务的执行函数没有注册
+	ErrJobFuncNotFound = errors.New("scheduler: job func not found")
+)
@@ -2,8 +2,12 @@ package scheduler
 
 var sched *Scheduler
 
+// Init 设置默认的调度器，s 为空时创建使用内存存储的调度器
 func Init(s *Scheduler) {
-	sched = NewScheduler()
+	if s == nil {
+		s = NewScheduler()
+	}
+	sched = s
 }
 
 func GetScheduler() *Scheduler {
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"os"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/collections/backoff"
+)
+
+const (
+	defaultSyncInterval = 10 * time.Second
+	defaultRetryInitial = time.Second
+	defaultRetryMaximum = time.Minute
+)
+
+// IBackoff 返回第 attempt 次重试前的等待时间，*backoff.ExponentialBackoff 实现了这个接口
+type IBackoff interface {
+	Backoff(attempt int) time.Duration
+}
+
+// Options 调度器的配置
+type Options struct {
+	// Store 任务和执行记录的存储，多个节点共享同一个存储时每次触发只会在一个节点上执行
+	Store JobStore
+	// Node 节点名称，记录在执行记录中，默认为主机名
+	Node string
+	// Backoff 重试的等待时间
+	Backoff IBackoff
+	// SyncInterval 从存储中同步任务的间隔，用于感知其他节点对任务的修改
+	SyncInterval time.Duration
+}
+
+// Option 设置调度器的配置
+type Option func(*Options)
+
+// WithStore 设置任务的存储
+func WithStore(store JobStore) Option {
+	return func(o *Options) {
+		o.Store = store
+	}
+}
+
+// WithNode 设置节点名称
+func WithNode(node string) Option {
+	return func(o *Options) {
+		o.Node = node
+	}
+}
+
+// WithBackoff 设置重试的等待时间
+func WithBackoff(bf IBackoff) Option {
+	return func(o *Options) {
+		o.Backoff = bf
+	}
+}
+
+// WithSyncInterval 设置从存储中同步任务的间隔
+func WithSyncInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.SyncInterval = interval
+	}
+}
+
+func newOptions(opt ...Option) *Options {
+	opts := &Options{SyncInterval: defaultSyncInterval}
+	for _, o := range opt {
+		o(opts)
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	if opts.Node == "" {
+		opts.Node, _ = os.Hostname()
+	}
+	if opts.Backoff == nil {
+		opts.Backoff, _ = backoff.NewExponentialBackoff(defaultRetryInitial, defaultRetryMaximum, 2)
+	}
+	return opts
+}
@@ -2,100 +2,352 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
 
+	"github.com/fengzhongzhu1621/xgo/logging"
 	"github.com/robfig/cron/v3"
 )
 
+// parser 秒级的 Cron 表达式解析器，和 cron.WithSeconds 相同
+var parser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
 // Scheduler 表示任务调度器，负责管理多个 Task 的调度和执行。
+// 任务保存在 JobStore 中，重启后自动恢复；多个节点共享同一个 JobStore 时，
+// 每个节点都会调度所有任务，每次触发通过 JobStore.ClaimRun 认领，只会在一个节点上执行。
 type Scheduler struct {
-	c      *cron.Cron              // 底层使用的 Cron 调度器
-	mu     sync.Mutex              // 互斥锁，用于保护并发访问（如 tasks、funcs、status 的修改）
-	tasks  map[string]cron.EntryID // 存储任务 ID 到 Cron EntryID 的映射（用于取消或查找任务）
-	funcs  map[string]*Task        // 存储任务 ID 到 Task 结构体的映射（用于获取任务详情）
-	status map[string]string       // 存储任务的状态（如 "running"、"pending"、"failed"）
+	c     *cron.Cron              // 底层使用的 Cron 调度器
+	opts  *Options                // 调度器的配置
+	mu    sync.Mutex              // 互斥锁，用于保护并发访问（如 tasks、funcs 的修改）
+	tasks map[string]cron.EntryID // 存储任务 ID 到 Cron EntryID 的映射（用于取消或查找任务）
+	funcs map[string]*Task        // 存储任务 ID 到 Task 结构体的映射（用于获取任务详情）
+
+	stop chan struct{}
+	done chan struct{}
 }
 
-func NewScheduler() *Scheduler {
+func NewScheduler(opt ...Option) *Scheduler {
 	return &Scheduler{
-		c:      cron.New(cron.WithSeconds()), // 创建新的 Cron 实例，启用秒级调度（默认 cron 不支持秒级，需要额外启用）
-		tasks:  make(map[string]cron.EntryID),
-		funcs:  make(map[string]*Task),
-		status: make(map[string]string),
+		c:     cron.New(cron.WithParser(parser)), // 创建新的 Cron 实例，启用秒级调度（默认 cron 不支持秒级，需要额外启用）
+		opts:  newOptions(opt...),
+		tasks: make(map[string]cron.EntryID),
+		funcs: make(map[string]*Task),
 	}
 }
 
-// Start 启动调度器
+// Start 从存储中恢复任务并启动调度器，之后定时同步其他节点对任务的修改
 func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	if err := s.sync(context.Background()); err != nil {
+		logging.Errorf("scheduler: sync tasks err: %v", err)
+	}
 	s.c.Start()
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go s.syncLoop(s.stop, s.done)
 }
 
 // Stop 停止调度器
 // 调度器停止后，不会再触发新的任务，但已在执行的任务会继续完成
 func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+	if stop == nil {
+		return
+	}
+
+	close(stop)
+	<-done
 	s.c.Stop()
 }
 
-// AddTask 添加定时任务
-func (s *Scheduler) AddTask(t *Task) error {
+// syncLoop 定时从存储中同步任务
+func (s *Scheduler) syncLoop(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(s.opts.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := s.Sync(context.Background()); err != nil {
+				logging.Errorf("scheduler: sync tasks err: %v", err)
+			}
+		}
+	}
+}
+
+// Sync 从存储中同步任务：添加新的任务，更新修改过的任务，删除已经删除的任务
+func (s *Scheduler) Sync(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.sync(ctx)
+}
 
-	id, err := s.c.AddFunc(t.Schedule, func() {
-		ctx := context.Background()
-		t.JobFunc(ctx)
-	})
+func (s *Scheduler) sync(ctx context.Context) error {
+	tasks, err := s.opts.Store.ListTasks(ctx)
 	if err != nil {
 		return err
 	}
 
-	s.tasks[t.ID] = id
+	seen := make(map[string]struct{}, len(tasks))
+	for _, t := range tasks {
+		seen[t.ID] = struct{}{}
+		if old, ok := s.funcs[t.ID]; ok && old.handler() == t.handler() {
+			// 保留添加任务时指定的执行函数
+			t.JobFunc = old.JobFunc
+		} else {
+			t.JobFunc = GetJobFunc(t.handler())
+		}
+		if t.JobFunc == nil {
+			logging.Warnf("scheduler: job func %s of task %s not found", t.handler(), t.ID)
+			continue
+		}
+		if err := s.apply(t); err != nil {
+			logging.Errorf("scheduler: schedule task %s err: %v", t.ID, err)
+		}
+	}
+	for id := range s.funcs {
+		if _, ok := seen[id]; !ok {
+			s.remove(id)
+		}
+	}
+	return nil
+}
+
+// apply 按照任务的状态和 Cron 表达式调度任务，调用方需要持有锁
+func (s *Scheduler) apply(t *Task) error {
+	old, ok := s.funcs[t.ID]
 	s.funcs[t.ID] = t
-	s.status[t.ID] = scheduleTaskRunning
+	if ok && old.Schedule == t.Schedule && old.Status == t.Status {
+		return nil
+	}
 
+	if id, ok := s.tasks[t.ID]; ok {
+		s.c.Remove(id)
+		delete(s.tasks, t.ID)
+	}
+	if t.Status == scheduleTaskPaused {
+		return nil
+	}
+	job := &fireJob{s: s, taskID: t.ID}
+	id, err := s.c.AddJob(t.Schedule, job)
+	if err != nil {
+		return err
+	}
+	job.id = id
+	s.tasks[t.ID] = id
 	return nil
 }
 
-// RunNow 立即执行指定的任务（一次性执行 once）
-func (s *Scheduler) RunNow(taskID string) {
+// fireJob 定时触发任务的 cron.Job，id 为任务在 Cron 中的 EntryID，由 apply 在持有锁时设置
+type fireJob struct {
+	s      *Scheduler
+	taskID string
+	id     cron.EntryID
+}
+
+// Run implements cron.Job.
+func (j *fireJob) Run() {
+	j.s.fire(j)
+}
+
+// remove 取消调度并删除任务，调用方需要持有锁
+func (s *Scheduler) remove(taskID string) {
+	if id, ok := s.tasks[taskID]; ok {
+		s.c.Remove(id)
+		delete(s.tasks, taskID)
+	}
+	delete(s.funcs, taskID)
+}
+
+// AddTask 添加定时任务，任务保存到存储中，已存在时覆盖
+func (s *Scheduler) AddTask(t *Task) error {
+	if _, err := parser.Parse(t.Schedule); err != nil {
+		return err
+	}
+	task := *t
+	if task.Status == "" {
+		task.Status = scheduleTaskRunning
+	}
+	if task.JobFunc == nil {
+		task.JobFunc = GetJobFunc(task.handler())
+	}
+	if task.JobFunc == nil {
+		return ErrJobFuncNotFound
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	if t, ok := s.funcs[taskID]; ok {
-		go t.JobFunc(context.Background())
+	if err := s.opts.Store.SaveTask(context.Background(), &task); err != nil {
+		return err
 	}
+	return s.apply(&task)
 }
 
-// PauseTask 暂停指定的任务
-func (s *Scheduler) PauseTask(taskID string) {
+// RemoveTask 删除任务及其执行记录
+func (s *Scheduler) RemoveTask(taskID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if err := s.opts.Store.DeleteTask(context.Background(), taskID); err != nil {
+		return err
+	}
+	s.remove(taskID)
+	return nil
+}
 
-	if id, ok := s.tasks[taskID]; ok {
-		s.c.Remove(id)
-		s.status[taskID] = scheduleTaskPaused
+// RunNow 立即执行指定的任务（一次性执行 once）
+func (s *Scheduler) RunNow(taskID string) error {
+	s.mu.Lock()
+	_, ok := s.funcs[taskID]
+	s.mu.Unlock()
+	if !ok {
+		return ErrTaskNotFound
 	}
+
+	go s.run(taskID, fmt.Sprintf("%s@manual-%d", taskID, time.Now().UnixNano()))
+	return nil
+}
+
+// PauseTask 暂停指定的任务
+func (s *Scheduler) PauseTask(taskID string) error {
+	return s.setStatus(taskID, scheduleTaskPaused)
 }
 
 // ResumeTask 将使用PauseTask暂停的任务进行恢复
 func (s *Scheduler) ResumeTask(taskID string) error {
-	if s.Status(taskID) != scheduleTaskPaused {
+	return s.setStatus(taskID, scheduleTaskRunning)
+}
+
+func (s *Scheduler) setStatus(taskID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.funcs[taskID]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	if old.Status == status {
 		return nil
 	}
 
+	task := *old
+	task.Status = status
+	if err := s.opts.Store.SaveTask(context.Background(), &task); err != nil {
+		return err
+	}
+	return s.apply(&task)
+}
+
+// Status 返回任务的状态，任务不存在时返回空字符串
+func (s *Scheduler) Status(taskID string) string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if t, ok := s.funcs[taskID]; ok {
-		return s.AddTask(t)
+		return t.Status
 	}
-
-	return nil
+	return ""
 }
 
-func (s *Scheduler) Status(taskID string) string {
+// Tasks 按 ID 排序返回所有任务
+func (s *Scheduler) Tasks() []*Task {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	tasks := make([]*Task, 0, len(s.funcs))
+	for _, t := range s.funcs {
+		task := *t
+		tasks = append(tasks, &task)
+	}
+	sortTasks(tasks)
+	return tasks
+}
+
+// Runs 返回任务最近的 limit 条执行记录
+func (s *Scheduler) Runs(ctx context.Context, taskID string, limit int) ([]*Run, error) {
+	return s.opts.Store.ListRuns(ctx, taskID, limit)
+}
 
-	return s.status[taskID]
+// fire 定时触发任务，执行 ID 由任务 ID 和 Cron 表达式计划的触发时间组成，与节点的时钟无关，
+// 所有节点同一次触发的执行 ID 相同
+func (s *Scheduler) fire(j *fireJob) {
+	s.mu.Lock()
+	id := j.id
+	s.mu.Unlock()
+
+	// Cron 在启动任务的同一轮调度中更新 Prev，查询到的 Prev 就是本次触发的计划时间
+	fireTime := s.c.Entry(id).Prev
+	if fireTime.IsZero() {
+		// 任务已经取消调度
+		return
+	}
+	s.run(j.taskID, fmt.Sprintf("%s@%d", j.taskID, fireTime.Unix()))
+}
+
+// run 认领成功后执行任务并记录执行结果
+func (s *Scheduler) run(taskID, runID string) {
+	s.mu.Lock()
+	t, ok := s.funcs[taskID]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	run := &Run{ID: runID, TaskID: taskID, Node: s.opts.Node, StartTime: time.Now()}
+	claimed, err := s.opts.Store.ClaimRun(ctx, run)
+	if err != nil {
+		logging.Errorf("scheduler: claim run %s err: %v", runID, err)
+		return
+	}
+	if !claimed {
+		// 其他节点已经执行
+		return
+	}
+
+	err = s.execute(t, run)
+	end := time.Now()
+	run.EndTime, run.Duration = &end, end.Sub(run.StartTime)
+	if err != nil {
+		run.Error = err.Error()
+		logging.Errorf("scheduler: run %s failed after %d attempts: %v", runID, run.Attempts, err)
+	}
+	if err := s.opts.Store.FinishRun(ctx, run); err != nil {
+		logging.Errorf("scheduler: finish run %s err: %v", runID, err)
+	}
+}
+
+// execute 执行任务，失败时按照 Task.Retry 退避重试
+func (s *Scheduler) execute(t *Task, run *Run) error {
+	for attempt := 1; ; attempt++ {
+		run.Attempts = attempt
+		err := call(t)
+		if err == nil || attempt > t.Retry {
+			return err
+		}
+		time.Sleep(s.opts.Backoff.Backoff(attempt))
+	}
+}
+
+// call 执行一次任务，超时时间通过 context 传递
+func call(t *Task) (err error) {
+	ctx := context.Background()
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("scheduler: task %s panic: %v", t.ID, r)
+		}
+	}()
+	return t.JobFunc(ctx)
 }
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noBackoff 重试时不等待
+type noBackoff struct{}
+
+func (noBackoff) Backoff(int) time.Duration { return 0 }
+
+func waitRuns(t *testing.T, s *Scheduler, taskID string, n int) []*Run {
+	var runs []*Run
+	require.Eventually(t, func() bool {
+		runs, _ = s.Runs(context.Background(), taskID, 0)
+		finished := 0
+		for _, r := range runs {
+			if r.EndTime != nil {
+				finished++
+			}
+		}
+		return finished >= n
+	}, 5*time.Second, 10*time.Millisecond)
+	return runs
+}
+
+func TestSchedulerCluster(t *testing.T) {
+	// 两个节点共享存储，每次触发只执行一次
+	store := NewMemoryStore()
+	var count atomic.Int32
+	RegisterJobFunc("cluster", func(ctx context.Context) error {
+		count.Add(1)
+		return nil
+	})
+
+	s1 := NewScheduler(WithStore(store), WithNode("node1"))
+	require.NoError(t, s1.AddTask(&Task{ID: "cluster", Schedule: "* * * * * *"}))
+	s2 := NewScheduler(WithStore(store), WithNode("node2"))
+	s1.Start()
+	s2.Start()
+	// 从存储中恢复任务
+	assert.Equal(t, scheduleTaskRunning, s2.Status("cluster"))
+
+	runs := waitRuns(t, s1, "cluster", 2)
+	s1.Stop()
+	s2.Stop()
+	time.Sleep(10 * time.Millisecond)
+	runs, _ = s1.Runs(context.Background(), "cluster", 0)
+	assert.Equal(t, len(runs), int(count.Load()))
+	for _, r := range runs {
+		assert.Contains(t, []string{"node1", "node2"}, r.Node)
+		// 执行 ID 使用计划的触发时间
+		var fireTime int64
+		_, err := fmt.Sscanf(r.ID, "cluster@%d", &fireTime)
+		require.NoError(t, err)
+		assert.False(t, r.StartTime.Before(time.Unix(fireTime, 0)))
+		assert.Less(t, r.StartTime.Sub(time.Unix(fireTime, 0)), time.Second)
+	}
+}
+
+func TestSchedulerRetryAndTimeout(t *testing.T) {
+	s := NewScheduler(WithBackoff(noBackoff{}))
+	var attempts atomic.Int32
+	require.NoError(t, s.AddTask(&Task{
+		ID:       "retry",
+		Schedule: "@every 1h",
+		Retry:    2,
+		JobFunc: func(ctx context.Context) error {
+			if attempts.Add(1) < 3 {
+				return errors.New("failed")
+			}
+			return nil
+		},
+	}))
+	require.NoError(t, s.RunNow("retry"))
+	runs := waitRuns(t, s, "retry", 1)
+	assert.Equal(t, 3, runs[0].Attempts)
+	assert.Empty(t, runs[0].Error)
+	assert.NotNil(t, runs[0].EndTime)
+
+	// 超时通过 context 传递，panic 作为错误处理
+	require.NoError(t, s.AddTask(&Task{
+		ID:       "timeout",
+		Schedule: "@every 1h",
+		Retry:    1,
+		Timeout:  10 * time.Millisecond,
+		JobFunc: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}))
+	require.NoError(t, s.RunNow("timeout"))
+	runs = waitRuns(t, s, "timeout", 1)
+	assert.Equal(t, 2, runs[0].Attempts)
+	assert.Equal(t, context.DeadlineExceeded.Error(), runs[0].Error)
+	assert.GreaterOrEqual(t, runs[0].Duration, 20*time.Millisecond)
+
+	require.NoError(t, s.AddTask(&Task{
+		ID:       "panic",
+		Schedule: "@every 1h",
+		JobFunc:  func(ctx context.Context) error { panic("boom") },
+	}))
+	require.NoError(t, s.RunNow("panic"))
+	runs = waitRuns(t, s, "panic", 1)
+	assert.Contains(t, runs[0].Error, "boom")
+
+	assert.ErrorIs(t, s.RunNow("unknown"), ErrTaskNotFound)
+}
+
+func TestSchedulerManage(t *testing.T) {
+	store := NewMemoryStore()
+	RegisterJobFunc("manage", func(ctx context.Context) error { return nil })
+	s := NewScheduler(WithStore(store))
+
+	assert.Error(t, s.AddTask(&Task{ID: "manage", Schedule: "invalid"}))
+	assert.ErrorIs(t, s.AddTask(&Task{ID: "unknown", Schedule: "@every 1h"}), ErrJobFuncNotFound)
+	require.NoError(t, s.AddTask(&Task{ID: "manage", Name: "manage", Schedule: "@every 1h", Retry: 1}))
+	assert.Len(t, s.c.Entries(), 1)
+
+	// 暂停和恢复保存到存储中
+	require.NoError(t, s.PauseTask("manage"))
+	assert.Equal(t, scheduleTaskPaused, s.Status("manage"))
+	assert.Empty(t, s.c.Entries())
+	tasks, err := store.ListTasks(context.Background())
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, scheduleTaskPaused, tasks[0].Status)
+	assert.Equal(t, 1, tasks[0].Retry)
+
+	require.NoError(t, s.ResumeTask("manage"))
+	assert.Equal(t, scheduleTaskRunning, s.Status("manage"))
+	assert.Len(t, s.c.Entries(), 1)
+	assert.ErrorIs(t, s.PauseTask("unknown"), ErrTaskNotFound)
+
+	// 其他节点的修改通过 Sync 同步
+	task := *tasks[0]
+	task.Status = scheduleTaskPaused
+	require.NoError(t, store.SaveTask(context.Background(), &task))
+	require.NoError(t, s.Sync(context.Background()))
+	assert.Equal(t, scheduleTaskPaused, s.Status("manage"))
+	require.NoError(t, store.DeleteTask(context.Background(), "manage"))
+	require.NoError(t, s.Sync(context.Background()))
+	assert.Empty(t, s.Tasks())
+
+	require.NoError(t, s.AddTask(&Task{ID: "manage", Schedule: "@every 1h"}))
+	require.NoError(t, s.RemoveTask("manage"))
+	assert.Empty(t, s.Tasks())
+	assert.Empty(t, s.c.Entries())
+}
@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+const defaultHistoryLimit = 100
+
+// JobStore 任务和执行记录的存储
+type JobStore interface {
+	// SaveTask 保存任务，任务已存在时覆盖
+	SaveTask(ctx context.Context, t *Task) error
+	// DeleteTask 删除任务
+	DeleteTask(ctx context.Context, id string) error
+	// ListTasks 列出所有任务
+	ListTasks(ctx context.Context) ([]*Task, error)
+	// ClaimRun 认领一次执行并记录开始时间，同一个 Run.ID 只有一个节点能认领成功
+	ClaimRun(ctx context.Context, run *Run) (bool, error)
+	// FinishRun 记录执行的结果
+	FinishRun(ctx context.Context, run *Run) error
+	// ListRuns 按开始时间倒序列出任务最近的 limit 条执行记录
+	ListRuns(ctx context.Context, taskID string, limit int) ([]*Run, error)
+}
+
+// MemoryStore 内存存储，只能在单个节点上使用，重启后丢失
+type MemoryStore struct {
+	mu    sync.Mutex
+	tasks map[string]Task
+	runs  map[string][]*Run
+	ids   map[string]struct{}
+}
+
+// NewMemoryStore 创建内存存储，每个任务保留最近 100 条执行记录
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tasks: make(map[string]Task),
+		runs:  make(map[string][]*Run),
+		ids:   make(map[string]struct{}),
+	}
+}
+
+// SaveTask implements JobStore.
+func (s *MemoryStore) SaveTask(_ context.Context, t *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task := *t
+	task.JobFunc = nil
+	s.tasks[t.ID] = task
+	return nil
+}
+
+// DeleteTask implements JobStore.
+func (s *MemoryStore) DeleteTask(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tasks, id)
+	for _, run := range s.runs[id] {
+		delete(s.ids, run.ID)
+	}
+	delete(s.runs, id)
+	return nil
+}
+
+// ListTasks implements JobStore.
+func (s *MemoryStore) ListTasks(_ context.Context) ([]*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks := make([]*Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		task := t
+		tasks = append(tasks, &task)
+	}
+	sortTasks(tasks)
+	return tasks, nil
+}
+
+// ClaimRun implements JobStore.
+func (s *MemoryStore) ClaimRun(_ context.Context, run *Run) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.ids[run.ID]; ok {
+		return false, nil
+	}
+	s.ids[run.ID] = struct{}{}
+
+	r := *run
+	runs := append([]*Run{&r}, s.runs[run.TaskID]...)
+	if len(runs) > defaultHistoryLimit {
+		for _, old := range runs[defaultHistoryLimit:] {
+			delete(s.ids, old.ID)
+		}
+		runs = runs[:defaultHistoryLimit]
+	}
+	s.runs[run.TaskID] = runs
+	return true, nil
+}
+
+// FinishRun implements JobStore.
+func (s *MemoryStore) FinishRun(_ context.Context, run *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.runs[run.TaskID] {
+		if r.ID == run.ID {
+			*r = *run
+			return nil
+		}
+	}
+	return nil
+}
+
+// ListRuns implements JobStore.
+func (s *MemoryStore) ListRuns(_ context.Context, taskID string, limit int) ([]*Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := s.runs[taskID]
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	res := make([]*Run, 0, len(runs))
+	for _, r := range runs {
+		run := *r
+		res = append(res, &run)
+	}
+	return res, nil
+}
+
+// sortTasks 按 ID 排序
+func sortTasks(tasks []*Task) {
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultRedisPrefix = "{xgo:scheduler}:"
+	defaultRunTTL      = 7 * 24 * time.Hour
+)
+
+// RedisStore redis 存储。任务保存在 hash 中；每次执行保存在单独的 key 中，通过 SETNX 认领，
+// 超过 runTTL 后过期；每个任务最近的执行 ID 保存在 list 中
+type RedisStore struct {
+	cli    redis.UniversalClient
+	prefix string
+	runTTL time.Duration
+}
+
+// NewRedisStore 创建 redis 存储，prefix 为空时使用 "{xgo:scheduler}:"。
+// 事务和 MGET 同时访问多个 key，为了支持 redis 集群，所有 key 使用同一个 hash tag，prefix 没有 hash tag 时作为 hash tag
+func NewRedisStore(cli redis.UniversalClient, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = defaultRedisPrefix
+	}
+	return &RedisStore{cli: cli, prefix: hashTagPrefix(prefix), runTTL: defaultRunTTL}
+}
+
+// hashTagPrefix 为 prefix 加上 hash tag，例如 "app:" 转换为 "{app}:"，已经有 hash tag 时不修改
+func hashTagPrefix(prefix string) string {
+	if start := strings.IndexByte(prefix, '{'); start >= 0 {
+		if end := strings.IndexByte(prefix[start+1:], '}'); end > 0 {
+			return prefix
+		}
+	}
+	return "{" + strings.TrimSuffix(prefix, ":") + "}:"
+}
+
+func (s *RedisStore) tasksKey() string {
+	return s.prefix + "tasks"
+}
+
+func (s *RedisStore) runKey(id string) string {
+	return s.prefix + "run:" + id
+}
+
+func (s *RedisStore) runsKey(taskID string) string {
+	return s.prefix + "runs:" + taskID
+}
+
+// SaveTask implements JobStore.
+func (s *RedisStore) SaveTask(ctx context.Context, t *Task) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return s.cli.HSet(ctx, s.tasksKey(), t.ID, data).Err()
+}
+
+// DeleteTask implements JobStore.
+func (s *RedisStore) DeleteTask(ctx context.Context, id string) error {
+	_, err := s.cli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, s.tasksKey(), id)
+		pipe.Del(ctx, s.runsKey(id))
+		return nil
+	})
+	return err
+}
+
+// ListTasks implements JobStore.
+func (s *RedisStore) ListTasks(ctx context.Context) ([]*Task, error) {
+	values, err := s.cli.HGetAll(ctx, s.tasksKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]*Task, 0, len(values))
+	for _, v := range values {
+		t := &Task{}
+		if err := json.Unmarshal([]byte(v), t); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	sortTasks(tasks)
+	return tasks, nil
+}
+
+// ClaimRun implements JobStore.
+func (s *RedisStore) ClaimRun(ctx context.Context, run *Run) (bool, error) {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return false, err
+	}
+	ok, err := s.cli.SetNX(ctx, s.runKey(run.ID), data, s.runTTL).Result()
+	if err != nil || !ok {
+		return false, err
+	}
+	_, err = s.cli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, s.runsKey(run.TaskID), run.ID)
+		pipe.LTrim(ctx, s.runsKey(run.TaskID), 0, defaultHistoryLimit-1)
+		return nil
+	})
+	return true, err
+}
+
+// FinishRun implements JobStore.
+func (s *RedisStore) FinishRun(ctx context.Context, run *Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	return s.cli.Set(ctx, s.runKey(run.ID), data, redis.KeepTTL).Err()
+}
+
+// ListRuns implements JobStore.
+func (s *RedisStore) ListRuns(ctx context.Context, taskID string, limit int) ([]*Run, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	ids, err := s.cli.LRange(ctx, s.runsKey(taskID), 0, int64(limit-1)).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.runKey(id)
+	}
+	values, err := s.cli.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*Run, 0, len(values))
+	for _, v := range values {
+		data, ok := v.(string)
+		if !ok {
+			// 执行记录已过期
+			continue
+		}
+		run := &Run{}
+		if err := json.Unmarshal([]byte(data), run); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fengzhongzhu1621/xgo/db/mysql/sqlxx"
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDuplicateEntry 唯一键冲突的错误码
+const mysqlDuplicateEntry = 1062
+
+// SQLSchema SQLStore 使用的 MySQL 表结构
+const SQLSchema = `
+CREATE TABLE IF NOT EXISTS scheduler_task (
+    id       VARCHAR(128) NOT NULL PRIMARY KEY,
+    name     VARCHAR(255) NOT NULL DEFAULT '',
+    schedule VARCHAR(128) NOT NULL,
+    handler  VARCHAR(128) NOT NULL DEFAULT '',
+    retry    INT          NOT NULL DEFAULT 0,
+    timeout  BIGINT       NOT NULL DEFAULT 0,
+    status   VARCHAR(32)  NOT NULL DEFAULT 'running'
+);
+CREATE TABLE IF NOT EXISTS scheduler_run (
+    id         VARCHAR(191) NOT NULL PRIMARY KEY,
+    task_id    VARCHAR(128) NOT NULL,
+    node       VARCHAR(255) NOT NULL DEFAULT '',
+    start_time DATETIME(3)  NOT NULL,
+    end_time   DATETIME(3)  NULL,
+    duration   BIGINT       NOT NULL DEFAULT 0,
+    attempts   INT          NOT NULL DEFAULT 0,
+    error      TEXT         NOT NULL,
+    KEY idx_task_start (task_id, start_time)
+);`
+
+const (
+	sqlSaveTask = "INSERT INTO scheduler_task (id, name, schedule, handler, retry, timeout, status) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name), schedule = VALUES(schedule), " +
+		"handler = VALUES(handler), retry = VALUES(retry), timeout = VALUES(timeout), status = VALUES(status)"
+	sqlDeleteTask = "DELETE FROM scheduler_task WHERE id = ?"
+	sqlDeleteRuns = "DELETE FROM scheduler_run WHERE task_id = ?"
+	sqlListTasks  = "SELECT id, name, schedule, handler, retry, timeout, status FROM scheduler_task ORDER BY id"
+	sqlClaimRun   = "INSERT INTO scheduler_run (id, task_id, node, start_time, duration, attempts, error) " +
+		"VALUES (?, ?, ?, ?, 0, 0, '')"
+	sqlFinishRun = "UPDATE scheduler_run SET end_time = ?, duration = ?, attempts = ?, error = ? WHERE id = ?"
+	sqlListRuns  = "SELECT id, task_id, node, start_time, end_time, duration, attempts, error FROM scheduler_run " +
+		"WHERE task_id = ? ORDER BY start_time DESC LIMIT ?"
+)
+
+// SQLStore 基于 sqlxx 的 MySQL 存储，表结构见 SQLSchema。执行记录的 ID 为主键，插入成功即认领成功
+type SQLStore struct {
+	db *sqlxx.SqlxDBClient
+}
+
+// NewSQLStore 创建 MySQL 存储
+func NewSQLStore(db *sqlxx.SqlxDBClient) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// SaveTask implements JobStore.
+func (s *SQLStore) SaveTask(ctx context.Context, t *Task) error {
+	_, err := s.db.DB.ExecContext(ctx, sqlSaveTask, t.ID, t.Name, t.Schedule, t.Handler, t.Retry, t.Timeout, t.Status)
+	return err
+}
+
+// DeleteTask implements JobStore.
+func (s *SQLStore) DeleteTask(ctx context.Context, id string) error {
+	tx, err := s.db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, sqlDeleteTask, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, sqlDeleteRuns, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListTasks implements JobStore.
+func (s *SQLStore) ListTasks(ctx context.Context) ([]*Task, error) {
+	var tasks []*Task
+	if err := s.db.DB.SelectContext(ctx, &tasks, sqlListTasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ClaimRun implements JobStore.
+func (s *SQLStore) ClaimRun(ctx context.Context, run *Run) (bool, error) {
+	_, err := s.db.DB.ExecContext(ctx, sqlClaimRun, run.ID, run.TaskID, run.Node, run.StartTime)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		// 其他节点已经认领
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// FinishRun implements JobStore.
+func (s *SQLStore) FinishRun(ctx context.Context, run *Run) error {
+	_, err := s.db.DB.ExecContext(ctx, sqlFinishRun, run.EndTime, run.Duration, run.Attempts, run.Error, run.ID)
+	return err
+}
+
+// ListRuns implements JobStore.
+func (s *SQLStore) ListRuns(ctx context.Context, taskID string, limit int) ([]*Run, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	var runs []*Run
+	if err := s.db.DB.SelectContext(ctx, &runs, sqlListRuns, taskID, limit); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
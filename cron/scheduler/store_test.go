@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fengzhongzhu1621/xgo/db/mysql/sqlxx"
+	"github.com/go-redis/redis/v8"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+	store := NewRedisStore(cli, "")
+	ctx := context.Background()
+
+	task := &Task{ID: "a", Schedule: "@every 1m", Retry: 2, Timeout: time.Second, Status: scheduleTaskRunning}
+	require.NoError(t, store.SaveTask(ctx, task))
+	require.NoError(t, store.SaveTask(ctx, &Task{ID: "b", Schedule: "@every 1m"}))
+	tasks, err := store.ListTasks(ctx)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, task, tasks[0])
+
+	// 同一次执行只能认领一次
+	run := &Run{ID: "a@1", TaskID: "a", Node: "node1", StartTime: time.Now().UTC()}
+	ok, err := store.ClaimRun(ctx, run)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.ClaimRun(ctx, &Run{ID: "a@1", TaskID: "a", Node: "node2"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	end := run.StartTime.Add(time.Second)
+	run.EndTime, run.Duration, run.Attempts, run.Error = &end, time.Second, 1, "failed"
+	require.NoError(t, store.FinishRun(ctx, run))
+	_, err = store.ClaimRun(ctx, &Run{ID: "a@2", TaskID: "a", StartTime: end})
+	require.NoError(t, err)
+	runs, err := store.ListRuns(ctx, "a", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "a@2", runs[0].ID)
+	assert.Nil(t, runs[0].EndTime)
+	assert.Equal(t, run, runs[1])
+	// 所有 key 使用同一个 hash tag，在 redis 集群的同一个 slot
+	assert.True(t, mr.TTL("{xgo:scheduler}:run:a@1") > 0)
+	assert.True(t, mr.Exists("{xgo:scheduler}:runs:a"))
+	assert.True(t, mr.Exists("{xgo:scheduler}:tasks"))
+
+	require.NoError(t, store.DeleteTask(ctx, "a"))
+	tasks, err = store.ListTasks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	runs, err = store.ListRuns(ctx, "a", 10)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestRedisStorePrefix(t *testing.T) {
+	assert.Equal(t, "{app}:", NewRedisStore(nil, "app:").prefix)
+	assert.Equal(t, "{app}:", NewRedisStore(nil, "app").prefix)
+	assert.Equal(t, "{app}:scheduler:", NewRedisStore(nil, "{app}:scheduler:").prefix)
+}
+
+func TestSQLStore(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db := sqlx.NewDb(conn, "mysql")
+	defer db.Close()
+	store := NewSQLStore(&sqlxx.SqlxDBClient{DB: db})
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta(sqlSaveTask)).
+		WithArgs("a", "", "@every 1m", "", 2, int64(time.Second), scheduleTaskRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, store.SaveTask(ctx, &Task{
+		ID: "a", Schedule: "@every 1m", Retry: 2, Timeout: time.Second, Status: scheduleTaskRunning,
+	}))
+
+	mock.ExpectQuery(regexp.QuoteMeta(sqlListTasks)).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "schedule", "handler", "retry", "timeout", "status"}).
+			AddRow("a", "", "@every 1m", "", 2, int64(time.Second), scheduleTaskRunning))
+	tasks, err := store.ListTasks(ctx)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, time.Second, tasks[0].Timeout)
+
+	// 主键冲突时认领失败
+	start := time.Now()
+	mock.ExpectExec(regexp.QuoteMeta(sqlClaimRun)).WithArgs("a@1", "a", "node1", start).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(sqlClaimRun)).WithArgs("a@1", "a", "node2", start).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	ok, err := store.ClaimRun(ctx, &Run{ID: "a@1", TaskID: "a", Node: "node1", StartTime: start})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.ClaimRun(ctx, &Run{ID: "a@1", TaskID: "a", Node: "node2", StartTime: start})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	end := start.Add(time.Second)
+	mock.ExpectExec(regexp.QuoteMeta(sqlFinishRun)).WithArgs(&end, int64(time.Second), 1, "", "a@1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, store.FinishRun(ctx, &Run{ID: "a@1", EndTime: &end, Duration: time.Second, Attempts: 1}))
+
+	mock.ExpectQuery(regexp.QuoteMeta(sqlListRuns)).WithArgs("a", defaultHistoryLimit).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "task_id", "node", "start_time", "end_time", "duration", "attempts", "error"}).
+			AddRow("a@1", "a", "node1", start, end, int64(time.Second), 1, "").
+			AddRow("a@0", "a", "node1", start, nil, 0, 0, ""))
+	runs, err := store.ListRuns(ctx, "a", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, time.Second, runs[0].Duration)
+	assert.Nil(t, runs[1].EndTime)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(sqlDeleteTask)).WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(sqlDeleteRuns)).WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, store.DeleteTask(ctx, "a"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
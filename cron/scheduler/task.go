@@ -1,12 +1,61 @@
 package scheduler
 
-import "context"
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// JobFunc 任务的执行函数，返回错误时按照 Task.Retry 重试
+type JobFunc func(ctx context.Context) error
 
 // Task 表示一个可调度的任务，包含任务的基本信息和执行逻辑。
 type Task struct {
-	ID       string                    // 任务的唯一标识符（如 "task-1"），用于在调度器中区分不同任务
-	Name     string                    // 任务的名称（如 "Daily Report Generator"），便于人类阅读
-	Schedule string                    // Cron 表达式（如 "0 0 * * *"），定义任务的执行时间
-	JobFunc  func(ctx context.Context) // 任务的具体执行逻辑（函数），接收 context.Context 参数
-	Retry    int                       // 任务失败时的重试次数（如 3 表示最多重试 3 次）
+	ID       string        `json:"id" db:"id"`             // 任务的唯一标识符（如 "task-1"），用于在调度器中区分不同任务
+	Name     string        `json:"name" db:"name"`         // 任务的名称（如 "Daily Report Generator"），便于人类阅读
+	Schedule string        `json:"schedule" db:"schedule"` // Cron 表达式（如 "0 0 * * *"），定义任务的执行时间
+	Handler  string        `json:"handler" db:"handler"`   // 通过 RegisterJobFunc 注册的执行函数名称，为空时使用 ID，用于从存储中恢复任务
+	JobFunc  JobFunc       `json:"-" db:"-"`               // 任务的具体执行逻辑（函数），为空时根据 Handler 查找
+	Retry    int           `json:"retry" db:"retry"`       // 任务失败时的重试次数（如 3 表示最多重试 3 次）
+	Timeout  time.Duration `json:"timeout" db:"timeout"`   // 每次执行的超时时间，通过 context 传递给 JobFunc，为 0 时不限制
+	Status   string        `json:"status" db:"status"`     // 任务的状态（"running" 或 "paused"）
+}
+
+// handler 返回执行函数的名称
+func (t *Task) handler() string {
+	if t.Handler != "" {
+		return t.Handler
+	}
+	return t.ID
+}
+
+// Run 任务的一次执行记录
+type Run struct {
+	ID        string        `json:"id" db:"id"`                 // 执行的唯一标识，同一次触发在所有节点上相同
+	TaskID    string        `json:"task_id" db:"task_id"`       // 任务 ID
+	Node      string        `json:"node" db:"node"`             // 执行任务的节点
+	StartTime time.Time     `json:"start_time" db:"start_time"` // 开始时间
+	EndTime   *time.Time    `json:"end_time" db:"end_time"`     // 结束时间，执行中为空
+	Duration  time.Duration `json:"duration" db:"duration"`     // 执行耗时，包括重试的等待时间
+	Attempts  int           `json:"attempts" db:"attempts"`     // 执行次数
+	Error     string        `json:"error" db:"error"`           // 最后一次执行的错误
+}
+
+var (
+	jobFuncsMu sync.RWMutex
+	jobFuncs   = make(map[string]JobFunc)
+)
+
+// RegisterJobFunc 注册任务的执行函数，从存储中恢复的任务根据 Task.Handler 查找执行函数
+func RegisterJobFunc(name string, fn JobFunc) {
+	jobFuncsMu.Lock()
+	defer jobFuncsMu.Unlock()
+	jobFuncs[name] = fn
+}
+
+// GetJobFunc 获取注册的执行函数
+func GetJobFunc(name string) JobFunc {
+	jobFuncsMu.RLock()
+	defer jobFuncsMu.RUnlock()
+	return jobFuncs[name]
 }
@@ -0,0 +1,41 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+)
+
+// Message kafka 消息
+type Message struct {
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// Producer 同步发送消息的生产者，NewProducer 提供了基于 sarama 的实现，
+// 也可以使用其他客户端实现
+type Producer interface {
+	// SendMessages 发送一批消息到 topic，全部发送成功时返回 nil
+	SendMessages(ctx context.Context, topic string, msgs []*Message) error
+	// Close 关闭生产者
+	Close() error
+}
+
+var (
+	producersMu sync.RWMutex
+	producers   = make(map[string]Producer)
+)
+
+// RegisterProducer 注册生产者，供日志等组件通过名称使用
+func RegisterProducer(name string, p Producer) {
+	producersMu.Lock()
+	defer producersMu.Unlock()
+	producers[name] = p
+}
+
+// GetProducer 获取注册的生产者，不存在时返回 nil
+func GetProducer(name string) Producer {
+	producersMu.RLock()
+	defer producersMu.RUnlock()
+	return producers[name]
+}
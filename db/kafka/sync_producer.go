@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+
+	"github.com/IBM/sarama"
+)
+
+// ProducerOption 修改生产者的 sarama 配置，如 Kafka 版本、压缩算法等
+type ProducerOption func(*sarama.Config)
+
+// syncProducer 基于 sarama.SyncProducer 实现 Producer
+type syncProducer struct {
+	p sarama.SyncProducer
+}
+
+// NewProducer 根据配置创建同步生产者，等待所有副本确认后返回。
+// 配置了 User 时使用 SASL/SCRAM-SHA-512 认证，注册后可以作为日志的 kafka 输出：
+//
+//	p, err := kafka.NewProducer(cfg)
+//	kafka.RegisterProducer("default", p)
+func NewProducer(cfg *Config, opts ...ProducerOption) (Producer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("can not find kafka brokers config")
+	}
+
+	sc := sarama.NewConfig()
+	sc.Producer.RequiredAcks = sarama.WaitForAll
+	// SyncProducer 需要返回发送结果
+	sc.Producer.Return.Successes = true
+	sc.Producer.Return.Errors = true
+	if cfg.User != "" {
+		sc.Net.SASL.Enable = true
+		sc.Net.SASL.User = cfg.User
+		sc.Net.SASL.Password = cfg.Password
+		sc.Net.SASL.Handshake = true
+		sc.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		sc.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &XDGSCRAMClient{HashGeneratorFcn: SHA512}
+		}
+	}
+	for _, o := range opts {
+		o(sc)
+	}
+
+	p, err := sarama.NewSyncProducer(cfg.Brokers, sc)
+	if err != nil {
+		return nil, err
+	}
+	return &syncProducer{p: p}, nil
+}
+
+// SendMessages implements Producer. sarama 的发送不支持 ctx，ctx 结束时立即返回 ctx.Err()，
+// 此时已经提交给 sarama 的消息仍然可能发送成功
+func (s *syncProducer) SendMessages(ctx context.Context, topic string, msgs []*Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	pms := make([]*sarama.ProducerMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		pm := &sarama.ProducerMessage{Topic: topic, Value: sarama.ByteEncoder(msg.Value)}
+		if msg.Key != nil {
+			pm.Key = sarama.ByteEncoder(msg.Key)
+		}
+		for k, v := range msg.Headers {
+			pm.Headers = append(pm.Headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+		}
+		pms = append(pms, pm)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.p.SendMessages(pms)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close implements Producer.
+func (s *syncProducer) Close() error {
+	return s.p.Close()
+}
@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProducerWithoutBrokers(t *testing.T) {
+	_, err := NewProducer(&Config{})
+	assert.Error(t, err)
+}
+
+func TestSyncProducerSendMessages(t *testing.T) {
+	mp := mocks.NewSyncProducer(t, nil)
+	mp.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(pm *sarama.ProducerMessage) error {
+		assert.Equal(t, "app-logs", pm.Topic)
+		assert.Equal(t, sarama.ByteEncoder("k"), pm.Key)
+		assert.Equal(t, sarama.ByteEncoder("v"), pm.Value)
+		assert.Equal(t, []sarama.RecordHeader{{Key: []byte("host"), Value: []byte("h1")}}, pm.Headers)
+		return nil
+	})
+	mp.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(pm *sarama.ProducerMessage) error {
+		assert.Nil(t, pm.Key)
+		return nil
+	})
+	p := &syncProducer{p: mp}
+
+	err := p.SendMessages(context.Background(), "app-logs", []*Message{
+		{Key: []byte("k"), Value: []byte("v"), Headers: map[string]string{"host": "h1"}},
+		{Value: []byte("no key")},
+	})
+	assert.NoError(t, err)
+
+	// ctx 结束后不再发送
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, p.SendMessages(ctx, "app-logs", []*Message{{Value: []byte("v")}}), context.Canceled)
+	assert.NoError(t, p.Close())
+}
+
+// blockingProducer 发送时阻塞直到 release 关闭
+type blockingProducer struct {
+	*mocks.SyncProducer
+	release chan struct{}
+}
+
+func (p *blockingProducer) SendMessages([]*sarama.ProducerMessage) error {
+	<-p.release
+	return nil
+}
+
+func TestSyncProducerSendMessagesCanceled(t *testing.T) {
+	bp := &blockingProducer{SyncProducer: mocks.NewSyncProducer(t, nil), release: make(chan struct{})}
+	defer close(bp.release)
+	p := &syncProducer{p: bp}
+
+	// 发送阻塞时 ctx 超时立即返回
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := p.SendMessages(ctx, "app-logs", []*Message{{Value: []byte("v")}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -1,5 +1,7 @@
 package mysql
 
+import "fmt"
+
 type Database struct {
 	ID       string
 	Host     string
@@ -17,6 +19,44 @@ type Database struct {
 
 	// 是否打印sql 语句
 	DebugMode bool
+
+	// 只读副本，读请求按权重路由到健康的副本，写请求和事务使用主库
+	Replicas []Replica
+	// 副本健康检查的间隔（秒），默认 5 秒
+	HealthCheckIntervalSecond int
+	// 副本连续检查失败的次数达到 MaxFailures 时摘除，默认 3 次
+	MaxFailures int
+	// 心跳表，为空时不检查复制延迟，表结构参考 replica.HeartbeatSchema
+	HeartbeatTable string
+	// 复制延迟超过 MaxLagSecond 时视为检查失败，默认 10 秒
+	MaxLagSecond int
+}
+
+// Replica 只读副本的配置，User、Password 和 Name 为空时使用主库的配置
+type Replica struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	// 路由权重，默认 1
+	Weight int
+}
+
+// ReplicaDatabase 返回第 i 个副本的连接配置，连接池等配置和主库相同
+func (d *Database) ReplicaDatabase(i int) Database {
+	r := d.Replicas[i]
+	db := *d
+	db.ID = fmt.Sprintf("%s-replica-%d", d.ID, i)
+	db.Host, db.Port = r.Host, r.Port
+	if r.User != "" {
+		db.User, db.Password = r.User, r.Password
+	}
+	if r.Name != "" {
+		db.Name = r.Name
+	}
+	db.Replicas = nil
+	return db
 }
 
 // CommonQueryConditionMap 通用查询条件
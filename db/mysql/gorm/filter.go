@@ -0,0 +1,28 @@
+package gorm
+
+import (
+	"github.com/fengzhongzhu1621/xgo/condition/filter/expression"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"gorm.io/gorm"
+)
+
+// Filter 将通用过滤规则转换为查询条件，方言由 db 的驱动决定，支持 mysql、sqlite 和 postgres
+// 规则中的字段必须在 opt.RuleFields 中，转换失败时错误记录到 db.Error
+// e.g. db.Scopes(Filter(rule, operator.NewDefaultExprOpt(fields))).Find(&students)
+func Filter(rule operator.IRuleFactory, opt *operator.ExprOption) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		dialect, err := operator.ParseDialect(db.Dialector.Name())
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+
+		where, args, err := expression.Expression{IRuleFactory: rule}.ToWhere(dialect, opt)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+
+		return db.Where(where, args...)
+	}
+}
@@ -0,0 +1,72 @@
+package gorm
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/criteria"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/rule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      conn,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+	return db, mock
+}
+
+var studentFields = map[string]criteria.FieldType{
+	"Name": criteria.String,
+	"Age":  criteria.Numeric,
+}
+
+func TestFilter(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	r, err := rule.ParseJsonRule([]byte(`{
+		"condition": "OR",
+		"rules": [
+			{"field": "Name", "operator": "begins_with_i", "value": "Bo"},
+			{"field": "Age", "operator": "in", "value": [10, 11]}
+		]
+	}`))
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT * FROM `Student` WHERE `Age` > ? AND ((LOWER(`Name`) LIKE ?) OR (`Age` IN (?, ?)))",
+	)).
+		WithArgs(5, "bo%", float64(10), float64(11)).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "age", "id"}).AddRow("bob", 10, 1))
+
+	var students []Student
+	err = db.Scopes(Filter(r, operator.NewDefaultExprOpt(studentFields))).
+		Where("`Age` > ?", 5).
+		Find(&students).Error
+	assert.NoError(t, err)
+	assert.Equal(t, []Student{{Name: "bob", Age: 10, Id: 1}}, students)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFilterInvalidField(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	r := &rule.AtomRule{Field: "Password", Operator: operator.Equal, Value: "x"}
+
+	// 不在白名单中的字段不会生成查询
+	var students []Student
+	err := db.Scopes(Filter(r, operator.NewDefaultExprOpt(studentFields))).Find(&students).Error
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
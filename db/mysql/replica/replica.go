@@ -0,0 +1,326 @@
+// Package replica 实现 mysql 读写分离的副本路由：读请求按权重路由到健康的副本，
+// 周期性地检查副本的连通性和复制延迟，连续失败的副本被摘除，恢复后重新加入
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/db/mysql"
+	"github.com/fengzhongzhu1621/xgo/logging"
+)
+
+// HeartbeatSchema 心跳表的建表语句，%s 为表名。主库定期写入数据库的当前时间（毫秒），
+// 副本上数据库的当前时间和读到的时间的差即为复制延迟，使用数据库的时钟避免应用主机之间的时钟偏差
+const HeartbeatSchema = "CREATE TABLE IF NOT EXISTS `%s` (`id` INT NOT NULL PRIMARY KEY, `ts` BIGINT NOT NULL)"
+
+// nowMillis 数据库当前时间的毫秒时间戳
+const nowMillis = "ROUND(UNIX_TIMESTAMP(NOW(3)) * 1000)"
+
+const (
+	defaultInterval     = 5 * time.Second
+	defaultMaxFailures  = 3
+	defaultMaxLag       = 10 * time.Second
+	defaultProbeTimeout = 2 * time.Second
+)
+
+type primaryKey struct{}
+
+// WithPrimary 返回强制读主库的 context，用于写入之后需要立即读到最新数据的场景
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsePrimary 判断 context 是否要求读主库
+func UsePrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+// Node 副本节点
+type Node struct {
+	Name   string
+	DB     *sql.DB
+	Weight int // 路由权重，小于等于 0 时为 1
+}
+
+// Stat 副本的状态
+type Stat struct {
+	Name      string        `json:"name"`
+	Weight    int           `json:"weight"`
+	Healthy   bool          `json:"healthy"`
+	Failures  int           `json:"failures"`   // 连续检查失败的次数
+	Lag       time.Duration `json:"lag"`        // 最近一次检查到的复制延迟，没有配置心跳表时为 0
+	LastError string        `json:"last_error"` // 最近一次检查失败的原因
+}
+
+// Options 副本健康检查的配置
+type Options struct {
+	Interval       time.Duration // 检查间隔，小于等于 0 时不自动检查
+	MaxFailures    int           // 连续失败多少次后摘除副本
+	ProbeTimeout   time.Duration // 每次检查的超时时间
+	Primary        *sql.DB       // 写入心跳的主库
+	HeartbeatTable string        // 心跳表，为空时不检查复制延迟
+	MaxLag         time.Duration // 允许的最大复制延迟
+}
+
+// Option 设置副本健康检查的配置
+type Option func(*Options)
+
+// WithInterval 设置检查间隔，小于等于 0 时不自动检查，需要调用 Probe
+func WithInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.Interval = interval
+	}
+}
+
+// WithMaxFailures 设置连续失败多少次后摘除副本
+func WithMaxFailures(n int) Option {
+	return func(o *Options) {
+		if n > 0 {
+			o.MaxFailures = n
+		}
+	}
+}
+
+// WithProbeTimeout 设置每次检查的超时时间
+func WithProbeTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		if timeout > 0 {
+			o.ProbeTimeout = timeout
+		}
+	}
+}
+
+// WithHeartbeat 通过心跳表检查复制延迟，primary 为写入心跳的主库，延迟超过 maxLag 视为检查失败
+func WithHeartbeat(primary *sql.DB, table string, maxLag time.Duration) Option {
+	return func(o *Options) {
+		o.Primary, o.HeartbeatTable = primary, table
+		if maxLag > 0 {
+			o.MaxLag = maxLag
+		}
+	}
+}
+
+// ConfigOptions 根据数据库配置返回健康检查的配置，primary 为主库的连接
+func ConfigOptions(cfg *mysql.Database, primary *sql.DB) []Option {
+	opts := []Option{WithMaxFailures(cfg.MaxFailures)}
+	if cfg.HealthCheckIntervalSecond > 0 {
+		opts = append(opts, WithInterval(time.Duration(cfg.HealthCheckIntervalSecond)*time.Second))
+	}
+	if cfg.HeartbeatTable != "" {
+		opts = append(opts, WithHeartbeat(primary, cfg.HeartbeatTable, time.Duration(cfg.MaxLagSecond)*time.Second))
+	}
+	return opts
+}
+
+type node struct {
+	Node
+	healthy  bool
+	failures int
+	lag      time.Duration
+	lastErr  error
+}
+
+// Set 一组副本，并发安全
+type Set struct {
+	nodes []*node
+	opts  *Options
+
+	mu  sync.RWMutex
+	rnd *rand.Rand
+
+	closeOnce sync.Once
+	close     chan struct{}
+	done      chan struct{}
+}
+
+// New 创建副本集合，所有副本初始为健康状态，配置了检查间隔时在后台定期检查
+func New(nodes []Node, opt ...Option) *Set {
+	opts := &Options{
+		Interval:     defaultInterval,
+		MaxFailures:  defaultMaxFailures,
+		ProbeTimeout: defaultProbeTimeout,
+		MaxLag:       defaultMaxLag,
+	}
+	for _, o := range opt {
+		o(opts)
+	}
+
+	s := &Set{
+		opts:  opts,
+		rnd:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		close: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	for _, n := range nodes {
+		if n.Weight <= 0 {
+			n.Weight = 1
+		}
+		s.nodes = append(s.nodes, &node{Node: n, healthy: true})
+	}
+
+	if opts.Interval > 0 && len(s.nodes) > 0 {
+		go s.probeLoop()
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+// Len 返回副本的数量
+func (s *Set) Len() int {
+	return len(s.nodes)
+}
+
+// Pick 按权重随机选择一个健康的副本，返回副本的下标。
+// context 要求读主库或者没有健康的副本时返回 -1，调用方应使用主库
+func (s *Set) Pick(ctx context.Context) int {
+	if len(s.nodes) == 0 || UsePrimary(ctx) {
+		return -1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0
+	for _, n := range s.nodes {
+		if n.healthy {
+			total += n.Weight
+		}
+	}
+	if total == 0 {
+		return -1
+	}
+	r := s.rnd.Intn(total)
+	for i, n := range s.nodes {
+		if !n.healthy {
+			continue
+		}
+		if r < n.Weight {
+			return i
+		}
+		r -= n.Weight
+	}
+	return -1
+}
+
+// Stats 返回所有副本的状态
+func (s *Set) Stats() []Stat {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := make([]Stat, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		stat := Stat{Name: n.Name, Weight: n.Weight, Healthy: n.healthy, Failures: n.failures, Lag: n.lag}
+		if n.lastErr != nil {
+			stat.LastError = n.lastErr.Error()
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+// Probe 写入心跳并检查所有副本一次
+// 心跳写入失败时副本上的心跳已经过期，本轮只检查连通性，避免主库不可用时所有副本因为延迟被摘除
+func (s *Set) Probe(ctx context.Context) {
+	checkLag := s.opts.HeartbeatTable != ""
+	if checkLag && s.opts.Primary != nil {
+		if err := s.writeHeartbeat(ctx); err != nil {
+			logging.Errorf("replica: write heartbeat err, skip lag check: %v", err)
+			checkLag = false
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, n := range s.nodes {
+		wg.Add(1)
+		go func(n *node) {
+			defer wg.Done()
+			lag, err := s.probe(ctx, n, checkLag)
+			s.report(n, err, checkLag, lag)
+		}(n)
+	}
+	wg.Wait()
+}
+
+// Close 停止后台检查，不关闭副本的连接
+func (s *Set) Close() {
+	s.closeOnce.Do(func() {
+		close(s.close)
+	})
+	<-s.done
+}
+
+func (s *Set) probeLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Probe(context.Background())
+		case <-s.close:
+			return
+		}
+	}
+}
+
+// probe 检查副本的连通性，checkLag 为 true 时通过心跳表检查复制延迟
+func (s *Set) probe(ctx context.Context, n *node, checkLag bool) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.ProbeTimeout)
+	defer cancel()
+	if !checkLag {
+		return 0, n.DB.PingContext(ctx)
+	}
+
+	var ms int64
+	query := fmt.Sprintf("SELECT %s - `ts` FROM %s WHERE `id` = 1", nowMillis, quoteTable(s.opts.HeartbeatTable))
+	if err := n.DB.QueryRowContext(ctx, query).Scan(&ms); err != nil {
+		return 0, err
+	}
+	lag := max(time.Duration(ms)*time.Millisecond, 0)
+	if lag > s.opts.MaxLag {
+		return lag, fmt.Errorf("replication lag %s exceeds %s", lag, s.opts.MaxLag)
+	}
+	return lag, nil
+}
+
+// report 更新副本的状态，连续失败 MaxFailures 次后摘除，检查成功后恢复；checkLag 为 false 时保留上一次的延迟
+func (s *Set) report(n *node, err error, checkLag bool, lag time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n.lastErr = err
+	if checkLag {
+		n.lag = lag
+	}
+	if err == nil {
+		if !n.healthy {
+			logging.Infof("replica: %s recovered", n.Name)
+		}
+		n.healthy, n.failures = true, 0
+		return
+	}
+
+	n.failures++
+	if n.healthy && n.failures >= s.opts.MaxFailures {
+		n.healthy = false
+		logging.Warnf("replica: eject %s after %d failures, last err: %v", n.Name, n.failures, err)
+	}
+}
+
+func (s *Set) writeHeartbeat(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.ProbeTimeout)
+	defer cancel()
+	query := fmt.Sprintf("INSERT INTO %s (`id`, `ts`) VALUES (1, %s) ON DUPLICATE KEY UPDATE `ts` = VALUES(`ts`)",
+		quoteTable(s.opts.HeartbeatTable), nowMillis)
+	_, err := s.opts.Primary.ExecContext(ctx, query)
+	return err
+}
+
+func quoteTable(table string) string {
+	return "`" + strings.ReplaceAll(table, "`", "``") + "`"
+}
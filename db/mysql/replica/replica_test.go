@@ -0,0 +1,156 @@
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fengzhongzhu1621/xgo/db/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, mock
+}
+
+func TestPick(t *testing.T) {
+	db1, _ := newMockDB(t)
+	db2, _ := newMockDB(t)
+	s := New([]Node{{Name: "r1", DB: db1, Weight: 3}, {Name: "r2", DB: db2}}, WithInterval(0))
+	defer s.Close()
+
+	counts := make([]int, 2)
+	for i := 0; i < 4000; i++ {
+		counts[s.Pick(context.Background())]++
+	}
+	assert.InDelta(t, 3000, counts[0], 200)
+	assert.InDelta(t, 1000, counts[1], 200)
+
+	// 强制读主库
+	assert.Equal(t, -1, s.Pick(WithPrimary(context.Background())))
+	assert.Equal(t, -1, New(nil).Pick(context.Background()))
+}
+
+func TestEjectAndRecover(t *testing.T) {
+	db1, mock1 := newMockDB(t)
+	db2, mock2 := newMockDB(t)
+	s := New([]Node{{Name: "r1", DB: db1}, {Name: "r2", DB: db2}}, WithInterval(0), WithMaxFailures(2))
+	defer s.Close()
+	ctx := context.Background()
+
+	// 连续失败两次后摘除
+	for i := 0; i < 2; i++ {
+		mock1.ExpectPing().WillReturnError(errors.New("connection refused"))
+		mock2.ExpectPing()
+		s.Probe(ctx)
+	}
+	stats := s.Stats()
+	assert.False(t, stats[0].Healthy)
+	assert.Equal(t, 2, stats[0].Failures)
+	assert.Equal(t, "connection refused", stats[0].LastError)
+	assert.True(t, stats[1].Healthy)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, 1, s.Pick(ctx))
+	}
+
+	// 所有副本都不可用时读主库
+	mock1.ExpectPing().WillReturnError(errors.New("connection refused"))
+	mock2.ExpectPing().WillReturnError(errors.New("connection refused"))
+	mock1.ExpectPing().WillReturnError(errors.New("connection refused"))
+	mock2.ExpectPing().WillReturnError(errors.New("connection refused"))
+	s.Probe(ctx)
+	s.Probe(ctx)
+	assert.Equal(t, -1, s.Pick(ctx))
+
+	// 检查成功后恢复
+	mock1.ExpectPing()
+	mock2.ExpectPing().WillReturnError(errors.New("connection refused"))
+	s.Probe(ctx)
+	assert.Equal(t, 0, s.Pick(ctx))
+	assert.Equal(t, 0, s.Stats()[0].Failures)
+	assert.NoError(t, mock1.ExpectationsWereMet())
+	assert.NoError(t, mock2.ExpectationsWereMet())
+}
+
+func TestHeartbeat(t *testing.T) {
+	primary, pmock := newMockDB(t)
+	db, mock := newMockDB(t)
+	s := New([]Node{{Name: "r1", DB: db}}, WithInterval(0), WithMaxFailures(1),
+		WithHeartbeat(primary, "heartbeat", time.Second))
+	defer s.Close()
+	ctx := context.Background()
+
+	write := regexp.QuoteMeta("INSERT INTO `heartbeat` (`id`, `ts`) VALUES (1, ROUND(UNIX_TIMESTAMP(NOW(3)) * 1000)) " +
+		"ON DUPLICATE KEY UPDATE `ts` = VALUES(`ts`)")
+	read := regexp.QuoteMeta("SELECT ROUND(UNIX_TIMESTAMP(NOW(3)) * 1000) - `ts` FROM `heartbeat` WHERE `id` = 1")
+	pmock.ExpectExec(write).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(read).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(200))
+	s.Probe(ctx)
+	stat := s.Stats()[0]
+	assert.True(t, stat.Healthy)
+	assert.Equal(t, 200*time.Millisecond, stat.Lag)
+
+	// 复制延迟过大时摘除
+	pmock.ExpectExec(write).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(read).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(time.Minute.Milliseconds()))
+	s.Probe(ctx)
+	stat = s.Stats()[0]
+	assert.False(t, stat.Healthy)
+	assert.Equal(t, time.Minute, stat.Lag)
+	assert.Contains(t, stat.LastError, "replication lag")
+
+	// 主库不可用时心跳过期，只检查副本的连通性
+	pmock.ExpectExec(write).WillReturnError(errors.New("connection refused"))
+	mock.ExpectPing()
+	s.Probe(ctx)
+	stat = s.Stats()[0]
+	assert.True(t, stat.Healthy)
+	assert.Equal(t, time.Minute, stat.Lag)
+	assert.Empty(t, stat.LastError)
+	assert.NoError(t, pmock.ExpectationsWereMet())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProbeLoop(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	s := New([]Node{{Name: "r1", DB: db}}, WithInterval(10*time.Millisecond), WithMaxFailures(1))
+	assert.Eventually(t, func() bool { return !s.Stats()[0].Healthy }, time.Second, 5*time.Millisecond)
+	s.Close()
+	s.Close()
+}
+
+func TestConfigOptions(t *testing.T) {
+	primary, _ := newMockDB(t)
+	cfg := &mysql.Database{
+		ID: "default", Host: "primary", Port: 3306, User: "root", Password: "pwd", Name: "xgo",
+		Replicas:                  []mysql.Replica{{Host: "replica1", Port: 3307, Weight: 2}, {Host: "replica2", Port: 3306, User: "ro"}},
+		HealthCheckIntervalSecond: 1, MaxFailures: 5, HeartbeatTable: "heartbeat", MaxLagSecond: 3,
+	}
+	opts := &Options{}
+	for _, o := range ConfigOptions(cfg, primary) {
+		o(opts)
+	}
+	assert.Equal(t, &Options{
+		Interval: time.Second, MaxFailures: 5, Primary: primary, HeartbeatTable: "heartbeat", MaxLag: 3 * time.Second,
+	}, opts)
+
+	r := cfg.ReplicaDatabase(0)
+	assert.Equal(t, "default-replica-0", r.ID)
+	assert.Equal(t, "replica1", r.Host)
+	assert.Equal(t, "root", r.User)
+	assert.Equal(t, "pwd", r.Password)
+	assert.Empty(t, r.Replicas)
+	r = cfg.ReplicaDatabase(1)
+	assert.Equal(t, "ro", r.User)
+	assert.Empty(t, r.Password)
+	assert.Equal(t, "xgo", r.Name)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/fengzhongzhu1621/xgo/db/mysql"
+	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -49,7 +50,23 @@ func (db *SqlxDBClient) Connect() error {
 	if err != nil {
 		panic(fmt.Errorf("sqlx.Connect error: %v", err))
 	}
+	db.setPool()
 
+	return nil
+}
+
+// open 创建连接池但不检查连接，用于副本，不可用的副本由健康检查摘除
+func (db *SqlxDBClient) open() error {
+	var err error
+	if db.DB, err = sqlx.Open("mysql", db.dataSource); err != nil {
+		return err
+	}
+	db.setPool()
+	return nil
+}
+
+// setPool 设置连接池参数
+func (db *SqlxDBClient) setPool() {
 	// 设置连接数
 	db.DB.SetMaxOpenConns(db.maxOpenConns)
 	// 设置数据库连接池中最大空闲连接数。这个方法可以帮助你控制数据库连接的资源使用，优化应用程序的性能。
@@ -59,8 +76,6 @@ func (db *SqlxDBClient) Connect() error {
 	db.DB.SetMaxIdleConns(db.maxIdleConns)
 	// 设置数据库连接池中单个连接的最大生命周期。可以控制数据库连接的复用时间，避免因长时间使用同一个连接而导致潜在的问题。
 	db.DB.SetConnMaxLifetime(db.connMaxLifetime)
-
-	return nil
 }
 
 // Close close db connection
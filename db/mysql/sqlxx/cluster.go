@@ -0,0 +1,89 @@
+package sqlxx
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/fengzhongzhu1621/xgo/db/mysql"
+	"github.com/fengzhongzhu1621/xgo/db/mysql/replica"
+	"github.com/jmoiron/sqlx"
+)
+
+// ClusterClient 一主多从的 sqlx 客户端，写请求使用主库，读请求按权重路由到健康的副本
+type ClusterClient struct {
+	Primary  *SqlxDBClient
+	Replicas []*SqlxDBClient
+
+	set *replica.Set
+}
+
+// NewClusterClient 根据 db 配置创建一主多从的客户端，opt 覆盖配置中的健康检查参数。
+// 主库连接失败时 panic，和 Connect 一致；副本启动时不检查连接，不可用的副本由健康检查摘除
+func NewClusterClient(cfg *mysql.Database, opt ...replica.Option) *ClusterClient {
+	c := &ClusterClient{Primary: NewSqlxDBClient(cfg)}
+	c.Primary.Connect()
+
+	var nodes []replica.Node
+	for i := range cfg.Replicas {
+		rcfg := cfg.ReplicaDatabase(i)
+		r := NewSqlxDBClient(&rcfg)
+		if err := r.open(); err != nil {
+			panic(fmt.Errorf("sqlx.Open replica %s error: %v", rcfg.ID, err))
+		}
+		c.Replicas = append(c.Replicas, r)
+		nodes = append(nodes, replica.Node{Name: rcfg.ID, DB: r.DB.DB, Weight: cfg.Replicas[i].Weight})
+	}
+	c.set = replica.New(nodes, append(replica.ConfigOptions(cfg, c.Primary.DB.DB), opt...)...)
+	return c
+}
+
+// NewClusterClientFromDB 使用已经建立的连接创建一主多从的客户端
+func NewClusterClientFromDB(primary *sqlx.DB, replicas []*sqlx.DB, opt ...replica.Option) *ClusterClient {
+	c := &ClusterClient{Primary: &SqlxDBClient{DB: primary}}
+	nodes := make([]replica.Node, 0, len(replicas))
+	for i, db := range replicas {
+		c.Replicas = append(c.Replicas, &SqlxDBClient{DB: db})
+		nodes = append(nodes, replica.Node{Name: "replica-" + strconv.Itoa(i), DB: db.DB})
+	}
+	c.set = replica.New(nodes, opt...)
+	return c
+}
+
+// NewClusterRepository 创建读写分离的 Repository，写操作和事务使用主库，读操作使用 c.Reader
+func NewClusterRepository[T any](c *ClusterClient, opt ...RepositoryOption) (*Repository[T], error) {
+	r, err := NewRepository[T](c.Writer(), opt...)
+	if err != nil {
+		return nil, err
+	}
+	r.reader = c.Reader
+	return r, nil
+}
+
+// Writer 返回主库
+func (c *ClusterClient) Writer() *sqlx.DB {
+	return c.Primary.DB
+}
+
+// Reader 返回执行读请求的连接，context 要求读主库或者没有健康的副本时返回主库。
+// context 中有事务时 Repository 使用事务，不会调用 Reader
+func (c *ClusterClient) Reader(ctx context.Context) *sqlx.DB {
+	if i := c.set.Pick(ctx); i >= 0 {
+		return c.Replicas[i].DB
+	}
+	return c.Primary.DB
+}
+
+// Stats 返回所有副本的状态
+func (c *ClusterClient) Stats() []replica.Stat {
+	return c.set.Stats()
+}
+
+// Close 停止健康检查并关闭所有连接
+func (c *ClusterClient) Close() {
+	c.set.Close()
+	c.Primary.Close()
+	for _, r := range c.Replicas {
+		r.Close()
+	}
+}
@@ -0,0 +1,53 @@
+package sqlxx
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fengzhongzhu1621/xgo/db/mysql/replica"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterRepository(t *testing.T) {
+	primary, pmock := newMockDB(t, "mysql")
+	replicaDB, rmock := newMockDB(t, "mysql")
+	c := NewClusterClientFromDB(primary, []*sqlx.DB{replicaDB}, replica.WithInterval(0))
+	defer c.set.Close()
+	r, err := NewClusterRepository[account](c)
+	require.NoError(t, err)
+	ctx := context.Background()
+	get := regexp.QuoteMeta(accountSelect + " WHERE `id` = ?")
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows(accountColumns).AddRow(1, "bob", 10, 1, time.Now())
+	}
+
+	// 读请求使用副本，写请求使用主库
+	rmock.ExpectQuery(get).WithArgs(1).WillReturnRows(row())
+	_, err = r.Get(ctx, 1)
+	require.NoError(t, err)
+	pmock.ExpectExec("DELETE FROM").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, r.Delete(ctx, 1))
+
+	// 强制读主库
+	pmock.ExpectQuery(get).WithArgs(1).WillReturnRows(row())
+	_, err = r.Get(replica.WithPrimary(ctx), 1)
+	require.NoError(t, err)
+
+	// 事务中的读请求使用主库
+	pmock.ExpectBegin()
+	pmock.ExpectQuery(get).WithArgs(1).WillReturnRows(row())
+	pmock.ExpectCommit()
+	require.NoError(t, r.Transaction(ctx, func(ctx context.Context) error {
+		_, err := r.Get(ctx, 1)
+		return err
+	}))
+
+	assert.Equal(t, []replica.Stat{{Name: "replica-0", Weight: 1, Healthy: true}}, c.Stats())
+	assert.NoError(t, pmock.ExpectationsWereMet())
+	assert.NoError(t, rmock.ExpectationsWereMet())
+}
@@ -0,0 +1,55 @@
+package sqlxx
+
+import (
+	"context"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/expression"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/jmoiron/sqlx"
+)
+
+// Where 将通用过滤规则转换为 WHERE 条件（不包含 WHERE 关键字），方言和占位符由 db 的驱动决定
+// 规则中的字段必须在 opt.RuleFields 中
+func Where(db *sqlx.DB, rule operator.IRuleFactory, opt *operator.ExprOption) (string, []interface{}, error) {
+	dialect, err := operator.ParseDialect(db.DriverName())
+	if err != nil {
+		return "", nil, err
+	}
+
+	where, args, err := expression.Expression{IRuleFactory: rule}.ToWhere(dialect, opt)
+	if err != nil {
+		return "", nil, err
+	}
+	return db.Rebind(where), args, nil
+}
+
+// BuildQuery 在 query 后追加过滤规则生成的 WHERE 条件，query 中不能包含占位符
+// e.g. BuildQuery(db, "SELECT * FROM student", rule, opt)
+func BuildQuery(
+	db *sqlx.DB,
+	query string,
+	rule operator.IRuleFactory,
+	opt *operator.ExprOption,
+) (string, []interface{}, error) {
+	where, args, err := Where(db, rule, opt)
+	if err != nil {
+		return "", nil, err
+	}
+	return query + " WHERE " + where, args, nil
+}
+
+// SelectContext 执行追加了过滤条件的查询，结果写入 dest
+func SelectContext(
+	ctx context.Context,
+	db *sqlx.DB,
+	dest interface{},
+	query string,
+	rule operator.IRuleFactory,
+	opt *operator.ExprOption,
+) error {
+	q, args, err := BuildQuery(db, query, rule, opt)
+	if err != nil {
+		return err
+	}
+	return db.SelectContext(ctx, dest, q, args...)
+}
@@ -0,0 +1,80 @@
+package sqlxx
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/criteria"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/rule"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type student struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+}
+
+var studentOpt = operator.NewDefaultExprOpt(map[string]criteria.FieldType{
+	"id":   criteria.Numeric,
+	"name": criteria.String,
+})
+
+var studentRule = &rule.CombinedRule{
+	Condition: operator.And,
+	Rules: []operator.IRuleFactory{
+		&rule.AtomRule{Field: "name", Operator: operator.NotEqual, Value: "alice"},
+		&rule.AtomRule{Field: "id", Operator: operator.GreaterOrEqual, Value: 1},
+	},
+}
+
+func newMockDB(t *testing.T, driverName string) (*sqlx.DB, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db := sqlx.NewDb(conn, driverName)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, mock
+}
+
+func TestSelectContext(t *testing.T) {
+	db, mock := newMockDB(t, "mysql")
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT id, name FROM student WHERE ((`name` <> ? OR `name` IS NULL)) AND (`id` >= ?)",
+	)).
+		WithArgs("alice", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "bob").AddRow(2, "carol"))
+
+	var students []student
+	err := SelectContext(context.Background(), db, &students, "SELECT id, name FROM student", studentRule, studentOpt)
+	assert.NoError(t, err)
+	assert.Equal(t, []student{{ID: 1, Name: "bob"}, {ID: 2, Name: "carol"}}, students)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWherePostgres(t *testing.T) {
+	db, _ := newMockDB(t, "postgres")
+
+	where, args, err := Where(db, studentRule, studentOpt)
+	assert.NoError(t, err)
+	assert.Equal(t, `(("name" <> $1 OR "name" IS NULL)) AND ("id" >= $2)`, where)
+	assert.Equal(t, []interface{}{"alice", 1}, args)
+}
+
+func TestWhereInvalid(t *testing.T) {
+	db, _ := newMockDB(t, "oracle")
+	_, _, err := Where(db, studentRule, studentOpt)
+	assert.Error(t, err)
+
+	db, _ = newMockDB(t, "sqlite3")
+	_, _, err = BuildQuery(db, "SELECT * FROM student", &rule.AtomRule{
+		Field:    "password",
+		Operator: operator.Equal,
+		Value:    "x",
+	}, studentOpt)
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package sqlxx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/criteria"
+	"github.com/fengzhongzhu1621/xgo/str/stringutils/strcase"
+	"github.com/jmoiron/sqlx"
+)
+
+// TagName 定义列属性的结构体标签，多个属性用逗号分隔
+//
+//	ID      int64 `db:"id" sqlxx:"pk,autoincr"`
+//	Version int64 `db:"version" sqlxx:"version"`
+//	Created time.Time `db:"created_at" sqlxx:"readonly"`
+//
+// pk 为主键，没有指定主键时使用 id 列；autoincr 为自增主键，插入时为零值则由数据库生成；
+// version 为乐观锁版本列；readonly 的列由数据库维护，插入和更新时忽略
+const TagName = "sqlxx"
+
+// ITableName 自定义表名，没有实现时使用结构体名称的蛇形命名
+type ITableName interface {
+	TableName() string
+}
+
+// columnMeta 列的元数据
+type columnMeta struct {
+	name      string
+	index     []int
+	fieldType criteria.FieldType
+	pk        bool
+	autoIncr  bool
+	version   bool
+	readonly  bool
+}
+
+// tableMeta 表的元数据
+type tableMeta struct {
+	table   string
+	columns []*columnMeta
+	byName  map[string]*columnMeta
+	pk      *columnMeta
+	version *columnMeta
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// parseTableMeta 从结构体标签中解析表的元数据，列名的规则和 sqlx 相同
+func parseTableMeta(t reflect.Type) (*tableMeta, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sqlxx: repository model %s must be a struct", t)
+	}
+	meta := &tableMeta{table: strcase.ToSnake(t.Name()), byName: make(map[string]*columnMeta)}
+	if v, ok := reflect.New(t).Interface().(ITableName); ok {
+		meta.table = v.TableName()
+	}
+	if err := meta.parseFields(t, nil); err != nil {
+		return nil, err
+	}
+	if len(meta.columns) == 0 {
+		return nil, fmt.Errorf("sqlxx: repository model %s has no columns", t)
+	}
+
+	if meta.pk == nil {
+		// 默认使用 id 列作为主键，整数类型为自增主键
+		pk, ok := meta.byName["id"]
+		if !ok {
+			return nil, fmt.Errorf("sqlxx: repository model %s has no primary key", t)
+		}
+		pk.pk = true
+		pk.autoIncr = pk.fieldType == criteria.Numeric
+		meta.pk = pk
+	}
+	return meta, nil
+}
+
+func (m *tableMeta) parseFields(t reflect.Type, index []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := f.Tag.Lookup("db")
+		name, _, _ = strings.Cut(name, ",")
+		if name == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		// 没有标签的嵌入结构体展开为当前表的列
+		if f.Anonymous && !ok && f.Type.Kind() == reflect.Struct {
+			if err := m.parseFields(f.Type, fieldIndex); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sqlx.NameMapper(f.Name)
+		}
+		if _, ok := m.byName[name]; ok {
+			return fmt.Errorf("sqlxx: duplicate column %s in table %s", name, m.table)
+		}
+
+		c := &columnMeta{name: name, index: fieldIndex, fieldType: fieldType(f.Type)}
+		for _, opt := range strings.Split(f.Tag.Get(TagName), ",") {
+			switch strings.TrimSpace(opt) {
+			case "pk":
+				c.pk = true
+			case "autoincr":
+				c.autoIncr = true
+			case "version":
+				c.version = true
+			case "readonly":
+				c.readonly = true
+			}
+		}
+		if c.pk {
+			if m.pk != nil {
+				return fmt.Errorf("sqlxx: table %s has more than one primary key", m.table)
+			}
+			m.pk = c
+		}
+		if c.version {
+			if c.fieldType != criteria.Numeric || m.version != nil {
+				return fmt.Errorf("sqlxx: table %s has invalid version column %s", m.table, name)
+			}
+			m.version = c
+		}
+		m.columns = append(m.columns, c)
+		m.byName[name] = c
+	}
+	return nil
+}
+
+// fieldType 根据字段类型获得过滤规则的字段类型
+func fieldType(t reflect.Type) criteria.FieldType {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return criteria.Time
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return criteria.Numeric
+	case reflect.Bool:
+		return criteria.Boolean
+	default:
+		return criteria.String
+	}
+}
+
+// ruleFields 返回过滤规则可以使用的列
+func (m *tableMeta) ruleFields() map[string]criteria.FieldType {
+	fields := make(map[string]criteria.FieldType, len(m.columns))
+	for _, c := range m.columns {
+		fields[c.name] = c.fieldType
+	}
+	return fields
+}
@@ -0,0 +1,133 @@
+package sqlxx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/expression"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+)
+
+// Query Repository 的查询条件，多个条件之间是 AND 关系
+//
+//	q := NewQuery().Filter(rule).Where("deleted_at IS NULL").OrderBy("id", true).Limit(10)
+type Query struct {
+	rules  []operator.IRuleFactory
+	conds  []string
+	args   []interface{}
+	orders []order
+	limit  int
+	offset int
+}
+
+type order struct {
+	column string
+	desc   bool
+}
+
+// NewQuery 创建查询条件
+func NewQuery() *Query {
+	return &Query{}
+}
+
+// Filter 添加过滤规则，规则中的字段必须是表的列
+func (q *Query) Filter(rule operator.IRuleFactory) *Query {
+	if rule != nil {
+		q.rules = append(q.rules, rule)
+	}
+	return q
+}
+
+// Where 添加原生的条件，使用 ? 作为占位符
+func (q *Query) Where(cond string, args ...interface{}) *Query {
+	q.conds = append(q.conds, cond)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// OrderBy 添加排序的列
+func (q *Query) OrderBy(column string, desc bool) *Query {
+	q.orders = append(q.orders, order{column: column, desc: desc})
+	return q
+}
+
+// Limit 设置返回的最大行数
+func (q *Query) Limit(limit int) *Query {
+	q.limit = limit
+	return q
+}
+
+// Offset 设置跳过的行数，需要同时设置 Limit
+func (q *Query) Offset(offset int) *Query {
+	q.offset = offset
+	return q
+}
+
+// clone 复制查询条件，分页时在副本上追加条件
+func (q *Query) clone() *Query {
+	if q == nil {
+		return NewQuery()
+	}
+	c := *q
+	c.rules = append([]operator.IRuleFactory(nil), q.rules...)
+	c.conds = append([]string(nil), q.conds...)
+	c.args = append([]interface{}(nil), q.args...)
+	c.orders = append([]order(nil), q.orders...)
+	return &c
+}
+
+// where 生成 WHERE 子句（包含 WHERE 关键字），使用 ? 作为占位符
+func (q *Query) where(dialect operator.Dialect, opt *operator.ExprOption) (string, []interface{}, error) {
+	if q == nil {
+		return "", nil, nil
+	}
+	var parts []string
+	var args []interface{}
+	for _, rule := range q.rules {
+		where, ruleArgs, err := expression.Expression{IRuleFactory: rule}.ToWhere(dialect, opt)
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, "("+where+")")
+		args = append(args, ruleArgs...)
+	}
+	for _, cond := range q.conds {
+		parts = append(parts, "("+cond+")")
+	}
+	args = append(args, q.args...)
+	if len(parts) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(parts, " AND "), args, nil
+}
+
+// suffix 生成 ORDER BY 和 LIMIT 子句，排序的列必须是表的列
+func (q *Query) suffix(dialect operator.Dialect, meta *tableMeta) (string, error) {
+	if q == nil {
+		return "", nil
+	}
+	var b strings.Builder
+	for i, o := range q.orders {
+		if _, ok := meta.byName[o.column]; !ok {
+			return "", fmt.Errorf("sqlxx: unknown order column %s of table %s", o.column, meta.table)
+		}
+		if i == 0 {
+			b.WriteString(" ORDER BY ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(dialect.QuoteIdent(o.column))
+		if o.desc {
+			b.WriteString(" DESC")
+		}
+	}
+	// mysql 不支持只有 OFFSET 没有 LIMIT
+	if q.limit > 0 {
+		b.WriteString(" LIMIT " + strconv.Itoa(q.limit))
+		if q.offset > 0 {
+			b.WriteString(" OFFSET " + strconv.Itoa(q.offset))
+		}
+	}
+	return b.String(), nil
+}
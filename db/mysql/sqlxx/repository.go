@@ -0,0 +1,412 @@
+package sqlxx
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/fengzhongzhu1621/xgo/db/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrRecordNotFound 记录不存在
+	ErrRecordNotFound = errors.New("sqlxx: record not found")
+	// ErrVersionConflict 更新时版本号不一致，记录已经被其他请求修改
+	ErrVersionConflict = errors.New("sqlxx: version conflict")
+	// ErrInvalidCursor 游标不合法
+	ErrInvalidCursor = errors.New("sqlxx: invalid cursor")
+)
+
+// RepositoryOptions Repository 的配置
+type RepositoryOptions struct {
+	Table      string               // 表名，默认从模型获得
+	ExprOption *operator.ExprOption // 过滤规则的限制，RuleFields 为空时使用表的所有列
+}
+
+// RepositoryOption 设置 Repository 的配置
+type RepositoryOption func(*RepositoryOptions)
+
+// WithTable 设置表名
+func WithTable(table string) RepositoryOption {
+	return func(o *RepositoryOptions) {
+		o.Table = table
+	}
+}
+
+// WithExprOption 设置过滤规则的限制，例如只允许部分列参与过滤
+func WithExprOption(opt *operator.ExprOption) RepositoryOption {
+	return func(o *RepositoryOptions) {
+		o.ExprOption = opt
+	}
+}
+
+// PageResult 分页查询的结果
+type PageResult[T any] struct {
+	Items    []T   `json:"items"`
+	Total    int64 `json:"total"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+}
+
+// CursorResult 游标分页查询的结果，NextCursor 为空表示没有更多数据
+type CursorResult[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// Repository 基于 sqlx 的泛型数据访问对象，表和列的元数据从 T 的结构体标签中获得，参考 TagName。
+// 所有方法的 context 中有事务时在事务中执行，参考 Transaction；读写分离参考 NewClusterRepository
+type Repository[T any] struct {
+	db      *sqlx.DB
+	reader  func(ctx context.Context) *sqlx.DB // 读写分离时返回读请求使用的连接
+	meta    *tableMeta
+	dialect operator.Dialect
+	exprOpt *operator.ExprOption
+	columns string // 查询的列
+}
+
+// NewRepository 创建 T 的 Repository
+func NewRepository[T any](db *sqlx.DB, opt ...RepositoryOption) (*Repository[T], error) {
+	opts := &RepositoryOptions{}
+	for _, o := range opt {
+		o(opts)
+	}
+
+	meta, err := parseTableMeta(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	if opts.Table != "" {
+		meta.table = opts.Table
+	}
+	dialect, err := operator.ParseDialect(db.DriverName())
+	if err != nil {
+		return nil, err
+	}
+
+	exprOpt := operator.NewDefaultExprOpt(meta.ruleFields())
+	if opts.ExprOption != nil {
+		exprOpt = operator.CloneExprOption(opts.ExprOption)
+		if len(exprOpt.RuleFields) == 0 {
+			exprOpt.RuleFields = meta.ruleFields()
+		}
+	}
+
+	r := &Repository[T]{db: db, meta: meta, dialect: dialect, exprOpt: exprOpt}
+	names := make([]string, 0, len(meta.columns))
+	for _, c := range meta.columns {
+		names = append(names, dialect.QuoteIdent(c.name))
+	}
+	r.columns = strings.Join(names, ", ")
+	return r, nil
+}
+
+// Table 返回表名
+func (r *Repository[T]) Table() string {
+	return r.meta.table
+}
+
+// Transaction 在事务中执行 fn，fn 中使用回调的 context 访问数据库
+func (r *Repository[T]) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Transaction(ctx, r.db, fn)
+}
+
+// readDB 返回读请求使用的连接
+func (r *Repository[T]) readDB(ctx context.Context) *sqlx.DB {
+	if r.reader != nil {
+		return r.reader(ctx)
+	}
+	return r.db
+}
+
+// Get 根据主键查询，记录不存在时返回 ErrRecordNotFound
+func (r *Repository[T]) Get(ctx context.Context, id interface{}) (*T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?",
+		r.columns, r.dialect.QuoteIdent(r.meta.table), r.dialect.QuoteIdent(r.meta.pk.name))
+	var item T
+	err := sqlx.GetContext(ctx, executor(ctx, r.readDB(ctx)), &item, r.db.Rebind(query), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// List 查询满足条件的记录，q 为 nil 时查询所有记录
+func (r *Repository[T]) List(ctx context.Context, q *Query) ([]T, error) {
+	where, args, err := q.where(r.dialect, r.exprOpt)
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := q.suffix(r.dialect, r.meta)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + r.columns + " FROM " + r.dialect.QuoteIdent(r.meta.table) + where + suffix
+	items := make([]T, 0)
+	if err := sqlx.SelectContext(ctx, executor(ctx, r.readDB(ctx)), &items, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Count 统计满足条件的记录数，忽略排序和分页
+func (r *Repository[T]) Count(ctx context.Context, q *Query) (int64, error) {
+	where, args, err := q.where(r.dialect, r.exprOpt)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "SELECT COUNT(*) FROM " + r.dialect.QuoteIdent(r.meta.table) + where
+	var count int64
+	if err := sqlx.GetContext(ctx, executor(ctx, r.readDB(ctx)), &count, r.db.Rebind(query), args...); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Page 按页码分页查询，页码和每页数量的默认值和上限与 mysql.Page 相同，
+// 没有指定排序时按主键排序，保证分页结果稳定
+func (r *Repository[T]) Page(ctx context.Context, q *Query, page mysql.Page) (*PageResult[T], error) {
+	if page.Page <= 0 {
+		page.Page = mysql.DefaultPage
+	}
+	if page.PageSize <= 0 {
+		page.PageSize = mysql.DefaultPageSize
+	}
+	page.PageSize = min(page.PageSize, mysql.MaxPageSize)
+
+	total, err := r.Count(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	res := &PageResult[T]{Items: make([]T, 0), Total: total, Page: page.Page, PageSize: page.PageSize}
+	offset := page.PageLimitOffset()
+	if int64(offset) >= total {
+		return res, nil
+	}
+
+	q = q.clone()
+	if len(q.orders) == 0 {
+		q.OrderBy(r.meta.pk.name, false)
+	}
+	if res.Items, err = r.List(ctx, q.Limit(page.PageSize).Offset(offset)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Cursor 按主键升序的游标分页查询，cursor 为上一页返回的 NextCursor，第一页为空。
+// 和 Page 相比不需要统计总数，翻页的性能和页数无关，q 中不能指定排序和分页
+func (r *Repository[T]) Cursor(ctx context.Context, q *Query, cursor string, limit int) (*CursorResult[T], error) {
+	if q != nil && (len(q.orders) > 0 || q.limit > 0 || q.offset > 0) {
+		return nil, errors.New("sqlxx: cursor pagination can not be used with order, limit or offset")
+	}
+	if limit <= 0 {
+		limit = mysql.DefaultPageSize
+	}
+	limit = min(limit, mysql.MaxPageSize)
+
+	q = q.clone()
+	pk := r.meta.pk.name
+	if cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		q.Where(r.dialect.QuoteIdent(pk)+" > ?", after)
+	}
+	// 多查询一条判断是否还有下一页
+	items, err := r.List(ctx, q.OrderBy(pk, false).Limit(limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CursorResult[T]{Items: items}
+	if len(items) > limit {
+		res.Items = items[:limit]
+		last := reflect.ValueOf(&res.Items[limit-1]).Elem().FieldByIndex(r.meta.pk.index).Interface()
+		if res.NextCursor, err = encodeCursor(last); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// Insert 插入记录，自增主键为零值时由数据库生成并回写到 item，版本号为零值时设置为 1
+func (r *Repository[T]) Insert(ctx context.Context, item *T) error {
+	v := reflect.ValueOf(item).Elem()
+	if r.meta.version != nil {
+		if f := v.FieldByIndex(r.meta.version.index); f.IsZero() {
+			setInt(f, 1)
+		}
+	}
+
+	var names, marks []string
+	var args []interface{}
+	pk := v.FieldByIndex(r.meta.pk.index)
+	generated := r.meta.pk.autoIncr && pk.IsZero()
+	for _, c := range r.meta.columns {
+		if c.readonly || (c.pk && generated) {
+			continue
+		}
+		names = append(names, r.dialect.QuoteIdent(c.name))
+		marks = append(marks, "?")
+		args = append(args, v.FieldByIndex(c.index).Interface())
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		r.dialect.QuoteIdent(r.meta.table), strings.Join(names, ", "), strings.Join(marks, ", "))
+
+	exec := executor(ctx, r.db)
+	if !generated {
+		_, err := exec.ExecContext(ctx, r.db.Rebind(query), args...)
+		return err
+	}
+	// postgres 不支持 LastInsertId
+	if r.dialect == operator.PostgreSQL {
+		query += " RETURNING " + r.dialect.QuoteIdent(r.meta.pk.name)
+		return exec.QueryRowxContext(ctx, r.db.Rebind(query), args...).Scan(pk.Addr().Interface())
+	}
+	result, err := exec.ExecContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	setInt(pk, id)
+	return nil
+}
+
+// Update 根据主键更新记录的所有列。有版本列时只更新版本号和 item 一致的记录并递增版本号，
+// 版本号不一致或者记录不存在时返回 ErrVersionConflict
+func (r *Repository[T]) Update(ctx context.Context, item *T) error {
+	v := reflect.ValueOf(item).Elem()
+	var sets []string
+	var args []interface{}
+	for _, c := range r.meta.columns {
+		if c.pk || c.readonly || c.version {
+			continue
+		}
+		sets = append(sets, r.dialect.QuoteIdent(c.name)+" = ?")
+		args = append(args, v.FieldByIndex(c.index).Interface())
+	}
+	where := r.dialect.QuoteIdent(r.meta.pk.name) + " = ?"
+	args = append(args, v.FieldByIndex(r.meta.pk.index).Interface())
+
+	var version reflect.Value
+	if r.meta.version != nil {
+		name := r.dialect.QuoteIdent(r.meta.version.name)
+		version = v.FieldByIndex(r.meta.version.index)
+		sets = append(sets, name+" = "+name+" + 1")
+		where += " AND " + name + " = ?"
+		args = append(args, version.Interface())
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		r.dialect.QuoteIdent(r.meta.table), strings.Join(sets, ", "), where)
+	result, err := executor(ctx, r.db).ExecContext(ctx, r.db.Rebind(query), args...)
+	if err != nil || r.meta.version == nil {
+		// mysql 更新的值和原来的值相同时影响的行数为 0，没有版本列时无法判断记录是否存在
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrVersionConflict
+	}
+	setInt(version, intValue(version)+1)
+	return nil
+}
+
+// Delete 根据主键删除记录，记录不存在时返回 ErrRecordNotFound
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?",
+		r.dialect.QuoteIdent(r.meta.table), r.dialect.QuoteIdent(r.meta.pk.name))
+	result, err := executor(ctx, r.db).ExecContext(ctx, r.db.Rebind(query), id)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// setInt 设置整数字段的值，兼容有符号和无符号类型
+func setInt(f reflect.Value, n int64) {
+	switch f.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f.SetFloat(float64(n))
+	default:
+		f.SetInt(n)
+	}
+}
+
+// intValue 获得整数字段的值
+func intValue(f reflect.Value) int64 {
+	switch f.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(f.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int64(f.Float())
+	default:
+		return f.Int()
+	}
+}
+
+// encodeCursor 把主键编码为游标
+func encodeCursor(pk interface{}) (string, error) {
+	data, err := json.Marshal(pk)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 从游标中解码主键，整数主键解码为 int64
+func decodeCursor(cursor string) (interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	var pk interface{}
+	if err := dec.Decode(&pk); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	switch v := pk.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.String(), nil
+	case string:
+		return v, nil
+	default:
+		return nil, ErrInvalidCursor
+	}
+}
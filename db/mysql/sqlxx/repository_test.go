@@ -0,0 +1,249 @@
+package sqlxx
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/operator"
+	"github.com/fengzhongzhu1621/xgo/condition/filter/rule"
+	"github.com/fengzhongzhu1621/xgo/db/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type baseModel struct {
+	CreatedAt time.Time `db:"created_at" sqlxx:"readonly"`
+}
+
+type account struct {
+	ID      int64  `db:"id" sqlxx:"pk,autoincr"`
+	Name    string `db:"name"`
+	Balance int    `db:"balance"`
+	Version int64  `db:"version" sqlxx:"version"`
+	Secret  string `db:"-"`
+	baseModel
+}
+
+func (account) TableName() string {
+	return "t_account"
+}
+
+var accountColumns = []string{"id", "name", "balance", "version", "created_at"}
+
+const accountSelect = "SELECT `id`, `name`, `balance`, `version`, `created_at` FROM `t_account`"
+
+func TestRepositoryMeta(t *testing.T) {
+	db, _ := newMockDB(t, "mysql")
+	r, err := NewRepository[account](db)
+	require.NoError(t, err)
+	assert.Equal(t, "t_account", r.Table())
+	assert.Equal(t, "id", r.meta.pk.name)
+	assert.Equal(t, "version", r.meta.version.name)
+	assert.Equal(t, []int{5, 0}, r.meta.byName["created_at"].index)
+
+	// 默认使用 id 列作为主键，表名为结构体名称的蛇形命名
+	r2, err := NewRepository[student](db)
+	require.NoError(t, err)
+	assert.Equal(t, "student", r2.Table())
+	assert.True(t, r2.meta.pk.autoIncr)
+
+	_, err = NewRepository[struct{ Name string }](db)
+	assert.Error(t, err)
+	_, err = NewRepository[account](db, WithTable("t_account_2"))
+	assert.NoError(t, err)
+}
+
+func TestRepositoryQuery(t *testing.T) {
+	db, mock := newMockDB(t, "mysql")
+	r, err := NewRepository[account](db)
+	require.NoError(t, err)
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(accountSelect + " WHERE `id` = ?")).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, "bob", 10, 2, now))
+	a, err := r.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &account{ID: 1, Name: "bob", Balance: 10, Version: 2, baseModel: baseModel{CreatedAt: now}}, a)
+
+	mock.ExpectQuery(regexp.QuoteMeta(accountSelect + " WHERE `id` = ?")).WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(accountColumns))
+	_, err = r.Get(ctx, 2)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+
+	// 过滤规则和原生条件组合
+	q := NewQuery().
+		Filter(&rule.AtomRule{Field: "balance", Operator: operator.Greater, Value: 5}).
+		Where("name <> ?", "alice").
+		OrderBy("balance", true).
+		Limit(10)
+	mock.ExpectQuery(regexp.QuoteMeta(accountSelect+" WHERE (`balance` > ?) AND (name <> ?) ORDER BY `balance` DESC LIMIT 10")).
+		WithArgs(5, "alice").
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, "bob", 10, 2, now))
+	list, err := r.List(ctx, q)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "bob", list[0].Name)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `t_account` WHERE (`balance` > ?) AND (name <> ?)")).
+		WithArgs(5, "alice").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	n, err := r.Count(ctx, q)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+
+	// 规则字段必须是表的列，排序的列必须是表的列
+	_, err = r.List(ctx, NewQuery().Filter(&rule.AtomRule{Field: "secret", Operator: operator.Equal, Value: "x"}))
+	assert.Error(t, err)
+	_, err = r.List(ctx, NewQuery().OrderBy("secret", false))
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryPagination(t *testing.T) {
+	db, mock := newMockDB(t, "mysql")
+	r, err := NewRepository[account](db)
+	require.NoError(t, err)
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `t_account`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery(regexp.QuoteMeta(accountSelect + " ORDER BY `id` LIMIT 2 OFFSET 2")).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, "c", 0, 1, now).AddRow(4, "d", 0, 1, now))
+	page, err := r.Page(ctx, nil, mysql.Page{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, page.Total)
+	assert.Equal(t, 2, page.Page)
+	require.Len(t, page.Items, 2)
+	assert.EqualValues(t, 3, page.Items[0].ID)
+
+	// 超出范围的页不查询数据
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `t_account`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	page, err = r.Page(ctx, nil, mysql.Page{Page: 4, PageSize: 2})
+	require.NoError(t, err)
+	assert.Empty(t, page.Items)
+
+	mock.ExpectQuery(regexp.QuoteMeta(accountSelect + " ORDER BY `id` LIMIT 3")).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, "a", 0, 1, now).AddRow(2, "b", 0, 1, now).
+			AddRow(3, "c", 0, 1, now))
+	res, err := r.Cursor(ctx, nil, "", 2)
+	require.NoError(t, err)
+	require.Len(t, res.Items, 2)
+	require.NotEmpty(t, res.NextCursor)
+
+	mock.ExpectQuery(regexp.QuoteMeta(accountSelect + " WHERE (`id` > ?) ORDER BY `id` LIMIT 3")).WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, "c", 0, 1, now))
+	res, err = r.Cursor(ctx, nil, res.NextCursor, 2)
+	require.NoError(t, err)
+	require.Len(t, res.Items, 1)
+	assert.Empty(t, res.NextCursor)
+
+	_, err = r.Cursor(ctx, nil, "!", 2)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = r.Cursor(ctx, NewQuery().OrderBy("name", false), "", 2)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryWrite(t *testing.T) {
+	db, mock := newMockDB(t, "mysql")
+	r, err := NewRepository[account](db)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `t_account` (`name`, `balance`, `version`) VALUES (?, ?, ?)")).
+		WithArgs("bob", 10, 1).WillReturnResult(sqlmock.NewResult(7, 1))
+	a := &account{Name: "bob", Balance: 10}
+	require.NoError(t, r.Insert(ctx, a))
+	assert.EqualValues(t, 7, a.ID)
+	assert.EqualValues(t, 1, a.Version)
+
+	// 乐观锁
+	update := "UPDATE `t_account` SET `name` = ?, `balance` = ?, `version` = `version` + 1 WHERE `id` = ? AND `version` = ?"
+	mock.ExpectExec(regexp.QuoteMeta(update)).WithArgs("bob", 20, 7, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	a.Balance = 20
+	require.NoError(t, r.Update(ctx, a))
+	assert.EqualValues(t, 2, a.Version)
+
+	mock.ExpectExec(regexp.QuoteMeta(update)).WithArgs("bob", 20, 7, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, r.Update(ctx, a), ErrVersionConflict)
+	assert.EqualValues(t, 2, a.Version)
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `t_account` WHERE `id` = ?")).WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, r.Delete(ctx, 7))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `t_account` WHERE `id` = ?")).WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, r.Delete(ctx, 7), ErrRecordNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryPostgres(t *testing.T) {
+	db, mock := newMockDB(t, "postgres")
+	r, err := NewRepository[account](db)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		`INSERT INTO "t_account" ("name", "balance", "version") VALUES ($1, $2, $3) RETURNING "id"`)).
+		WithArgs("bob", 10, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	a := &account{Name: "bob", Balance: 10}
+	require.NoError(t, r.Insert(ctx, a))
+	assert.EqualValues(t, 9, a.ID)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM "t_account" WHERE ("name" = $1) AND (balance > $2)`)).
+		WithArgs("bob", 1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	n, err := r.Count(ctx, NewQuery().
+		Filter(&rule.AtomRule{Field: "name", Operator: operator.Equal, Value: "bob"}).
+		Where("balance > ?", 1))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryTransaction(t *testing.T) {
+	db, mock := newMockDB(t, "mysql")
+	r, err := NewRepository[account](db)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// 嵌套的事务复用外层事务
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err = r.Transaction(ctx, func(ctx context.Context) error {
+		require.NotNil(t, TxFromContext(ctx))
+		if err := r.Delete(ctx, 1); err != nil {
+			return err
+		}
+		return Transaction(ctx, db, func(ctx context.Context) error {
+			return r.Delete(ctx, 2)
+		})
+	})
+	require.NoError(t, err)
+
+	// 返回错误时回滚
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = r.Transaction(ctx, func(ctx context.Context) error {
+		return r.Delete(ctx, 1)
+	})
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+
+	// panic 时回滚
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	assert.Panics(t, func() {
+		_ = r.Transaction(ctx, func(ctx context.Context) error {
+			panic(errors.New("boom"))
+		})
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
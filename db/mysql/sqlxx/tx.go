@@ -0,0 +1,56 @@
+package sqlxx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey struct{}
+
+// ContextWithTx 返回携带事务的 context，使用该 context 的 Repository 操作都在事务中执行
+func ContextWithTx(ctx context.Context, tx *sqlx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext 获得 context 中的事务，没有事务时返回 nil
+func TxFromContext(ctx context.Context) *sqlx.Tx {
+	tx, _ := ctx.Value(txKey{}).(*sqlx.Tx)
+	return tx
+}
+
+// Transaction 在事务中执行 fn，fn 返回错误或者 panic 时回滚，否则提交。
+// ctx 中已经有事务时直接复用，由最外层的 Transaction 提交或者回滚
+func Transaction(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) (err error) {
+	if TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				err = fmt.Errorf("%w, rollback: %v", err, rbErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+	return fn(ContextWithTx(ctx, tx))
+}
+
+// executor 返回执行 sql 的对象，context 中有事务时使用事务
+func executor(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return db
+}
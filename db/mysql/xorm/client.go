@@ -1,11 +1,13 @@
 package xorm
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/fengzhongzhu1621/xgo/db/mysql"
+	"github.com/fengzhongzhu1621/xgo/db/mysql/replica"
 	"github.com/fengzhongzhu1621/xgo/logging/zaplogger"
 	log "github.com/sirupsen/logrus"
 	"xorm.io/core"
@@ -43,6 +45,12 @@ type XormDBClient struct {
 
 	// 用于调试时打印sql
 	debugMode bool
+
+	// 只读副本，Connect 时创建
+	replicas []*XormDBClient
+	weights  []int
+	config   mysql.Database
+	set      *replica.Set
 }
 
 // SyncTable 将 Go 结构体映射到数据库中的表，并确保表结构是最新的
@@ -89,52 +97,100 @@ func (db *XormDBClient) TestConnection() (err error) {
 
 // Close 关闭 db 连接
 func (db *XormDBClient) Close() {
+	if db.set != nil {
+		db.set.Close()
+	}
+	for _, r := range db.replicas {
+		r.Close()
+	}
 	if db.DB != nil {
 		db.DB.Close()
 	}
 }
 
+// Reader 返回执行读请求的引擎，按权重选择健康的副本，
+// context 要求读主库（replica.WithPrimary）、没有配置副本或者没有健康的副本时返回主库。
+// 写请求和事务直接使用 DB
+func (db *XormDBClient) Reader(ctx context.Context) *xorm.Engine {
+	if db.set != nil {
+		if i := db.set.Pick(ctx); i >= 0 {
+			return db.replicas[i].DB
+		}
+	}
+	return db.DB
+}
+
+// ReplicaStats 返回所有副本的状态
+func (db *XormDBClient) ReplicaStats() []replica.Stat {
+	if db.set == nil {
+		return nil
+	}
+	return db.set.Stats()
+}
+
 // Connect to db, and update some settings
 func (db *XormDBClient) Connect() error {
 	var err error
 
 	// 创建一个新的 Engine 实例
-	if db.DB, err = xorm.NewEngine("mysql", db.dataSource); err != nil {
+	if db.DB, err = db.newEngine(); err != nil {
 		panic(fmt.Errorf("xorm.NewEngine error: %v", err))
 	}
+
+	err = db.DB.Ping()
+	if err != nil {
+		panic(fmt.Errorf("ping error: %v", err))
+	}
+
+	go func() {
+		db.KeepAlive()
+	}()
+
+	// 副本启动时不检查连接，不可用的副本由健康检查摘除
+	if len(db.replicas) > 0 {
+		nodes := make([]replica.Node, 0, len(db.replicas))
+		for i, r := range db.replicas {
+			if r.DB, err = r.newEngine(); err != nil {
+				panic(fmt.Errorf("xorm.NewEngine replica error: %v", err))
+			}
+			nodes = append(nodes, replica.Node{Name: r.config.ID, DB: r.DB.DB().DB, Weight: db.weights[i]})
+		}
+		db.set = replica.New(nodes, replica.ConfigOptions(&db.config, db.DB.DB().DB)...)
+	}
+
+	return nil
+}
+
+// newEngine 创建数据库引擎并设置连接池，不检查连接
+func (db *XormDBClient) newEngine() (*xorm.Engine, error) {
+	engine, err := xorm.NewEngine("mysql", db.dataSource)
+	if err != nil {
+		return nil, err
+	}
 	// core.GonicMapper 是一个自定义的映射器，它会将结构体字段名保持原样（即驼峰命名法，camelCase），而不是转换为蛇形命名法。
 	// core.SnakeCaseMapper 它会将结构体字段名转换为蛇形命名法（snake_case）的数据库列名。
-	db.DB.SetMapper(core.GonicMapper{})
+	engine.SetMapper(core.GonicMapper{})
 
 	// 设置连接数
-	db.DB.SetMaxOpenConns(db.maxOpenConns)
+	engine.SetMaxOpenConns(db.maxOpenConns)
 	// 设置数据库连接池中最大空闲连接数。这个方法可以帮助你控制数据库连接的资源使用，优化应用程序的性能。
 	// 如果没有显式设置最大空闲连接数，sqlx 会使用 database/sql 包的默认值，通常是 2
 	// 假设你的应用程序在高并发时段需要处理大量的数据库请求，但在低峰时段请求量较少。
 	// 在这种情况下，你可以设置一个较高的最大空闲连接数，以确保在高并发时段有足够的连接可用；而在低峰时段，多余的连接会自动关闭，释放资源。
-	db.DB.SetMaxIdleConns(db.maxIdleConns)
+	engine.SetMaxIdleConns(db.maxIdleConns)
 	// 设置数据库连接池中单个连接的最大生命周期。可以控制数据库连接的复用时间，避免因长时间使用同一个连接而导致潜在的问题。
-	db.DB.SetConnMaxLifetime(db.connMaxLifetime)
-
-	err = db.DB.Ping()
-	if err != nil {
-		panic(fmt.Errorf("ping error: %v", err))
-	}
+	engine.SetConnMaxLifetime(db.connMaxLifetime)
 
 	// 每次执行数据库操作时，将生成的 SQL 语句及其参数打印到控制台。
 	if db.debugMode {
 		dbLogger := zaplogger.GetDbLogger()
 		if dbLogger != nil {
-			db.DB.SetLogger(xormLog.NewLoggerAdapter(dbLogger))
+			engine.SetLogger(xormLog.NewLoggerAdapter(dbLogger))
 		}
-		db.DB.ShowSQL(true)
+		engine.ShowSQL(true)
 	}
 
-	go func() {
-		db.KeepAlive()
-	}()
-
-	return nil
+	return engine, nil
 }
 
 // NewXormDBClient 创建 mysql 客户端
@@ -180,14 +236,21 @@ func NewXormDBClient(config *mysql.Database) *XormDBClient {
 		}
 	}
 
-	return &XormDBClient{
+	client := &XormDBClient{
 		name:            config.Name,
 		dataSource:      dataSource,
 		maxOpenConns:    maxOpenConns,
 		maxIdleConns:    maxIdleConns,
 		connMaxLifetime: connMaxLifetime,
 		debugMode:       config.DebugMode,
+		config:          *config,
+	}
+	for i := range config.Replicas {
+		replicaConfig := config.ReplicaDatabase(i)
+		client.replicas = append(client.replicas, NewXormDBClient(&replicaConfig))
+		client.weights = append(client.weights, config.Replicas[i].Weight)
 	}
+	return client
 }
 
 // TestConnection 根据 db 配置测试数据库连接是否正常
@@ -0,0 +1,299 @@
+package email
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/collections/backoff"
+	"github.com/fengzhongzhu1621/xgo/logging"
+)
+
+// 发件箱中邮件的状态
+const (
+	OutboxPending = "pending" // 等待发送
+	OutboxSending = "sending" // 正在发送
+	OutboxSent    = "sent"    // 发送成功
+	OutboxDead    = "dead"    // 多次发送失败或者被 SMTP 服务永久拒绝，不再重试
+)
+
+var ErrOutboxNotFound = errors.New("outbox message not found")
+
+// OutboxMessage 发件箱中的一封邮件
+type OutboxMessage struct {
+	ID            string    `json:"id"`              // 幂等键的哈希，没有幂等键时随机生成
+	Key           string    `json:"key"`             // 幂等键
+	From          string    `json:"from"`            // 信封的发件人
+	To            []string  `json:"to"`              // 信封的收件人，包括抄送和密送
+	Body          []byte    `json:"body"`            // Email.Bytes() 的结果，重试时 Message-Id 不变
+	Status        string    `json:"status"`          // 状态
+	Attempts      int       `json:"attempts"`        // 发送次数
+	LastError     string    `json:"last_error"`      // 最后一次发送的错误
+	NextAttemptAt time.Time `json:"next_attempt_at"` // 下次发送的时间，发送中时为认领的过期时间
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// NewOutboxMessage 序列化邮件，key 为幂等键，相同幂等键的邮件只会发送一次
+func NewOutboxMessage(key string, e *Email) (*OutboxMessage, error) {
+	to, err := addressLists(e.To, e.Cc, e.Bcc)
+	if err != nil {
+		return nil, err
+	}
+	from, err := emailOnly(e.From)
+	if err != nil {
+		return nil, err
+	}
+	body, err := e.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	id, err := outboxID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &OutboxMessage{
+		ID:            id,
+		Key:           key,
+		From:          from,
+		To:            to,
+		Body:          body,
+		Status:        OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}, nil
+}
+
+// outboxID 根据幂等键生成邮件 ID
+func outboxID(key string) (string, error) {
+	if key == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b), nil
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// OutboxStore 发件箱的持久化存储
+type OutboxStore interface {
+	// Add 保存邮件，ID 已存在时不保存，返回已有的邮件和 false
+	Add(ctx context.Context, msg *OutboxMessage) (*OutboxMessage, bool, error)
+	// Claim 认领最多 limit 封到期的待发送邮件，认领后状态为 sending，lease 时间内不会被再次认领
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*OutboxMessage, error)
+	// Update 更新邮件的状态、发送次数、错误和下次发送时间
+	Update(ctx context.Context, msg *OutboxMessage) error
+	// Get 查询邮件，不存在时返回 ErrOutboxNotFound
+	Get(ctx context.Context, id string) (*OutboxMessage, error)
+	// List 按创建时间列出指定状态的最多 limit 封邮件
+	List(ctx context.Context, status string, limit int) ([]*OutboxMessage, error)
+}
+
+// RawSender 发送序列化后的邮件，*Pool 实现了这个接口
+type RawSender interface {
+	SendRaw(from string, recipients []string, msg []byte, timeout time.Duration) error
+}
+
+// IBackoff 返回第 attempt 次发送失败后的等待时间，*backoff.ExponentialBackoff 实现了这个接口
+type IBackoff interface {
+	Backoff(attempt int) time.Duration
+}
+
+// OutboxOptions 发件箱的配置
+type OutboxOptions struct {
+	Workers      int           // 发送邮件的协程数
+	PollInterval time.Duration // 查询待发送邮件的间隔
+	SendTimeout  time.Duration // 每次发送的超时时间
+	Lease        time.Duration // 认领的有效期，进程崩溃后超过有效期的邮件会被重新发送，应该大于 SendTimeout
+	MaxAttempts  int           // 最大发送次数，超过后进入死信
+	Backoff      IBackoff      // 发送失败后的等待时间
+}
+
+// OutboxOption 设置发件箱的配置
+type OutboxOption func(*OutboxOptions)
+
+// WithOutboxWorkers 设置发送邮件的协程数
+func WithOutboxWorkers(n int) OutboxOption {
+	return func(o *OutboxOptions) {
+		o.Workers = n
+	}
+}
+
+// WithOutboxPollInterval 设置查询待发送邮件的间隔
+func WithOutboxPollInterval(d time.Duration) OutboxOption {
+	return func(o *OutboxOptions) {
+		o.PollInterval = d
+	}
+}
+
+// WithOutboxSendTimeout 设置每次发送的超时时间和认领的有效期
+func WithOutboxSendTimeout(timeout, lease time.Duration) OutboxOption {
+	return func(o *OutboxOptions) {
+		o.SendTimeout, o.Lease = timeout, lease
+	}
+}
+
+// WithOutboxRetry 设置最大发送次数和发送失败后的等待时间
+func WithOutboxRetry(maxAttempts int, bf IBackoff) OutboxOption {
+	return func(o *OutboxOptions) {
+		o.MaxAttempts, o.Backoff = maxAttempts, bf
+	}
+}
+
+func newOutboxOptions(opt ...OutboxOption) *OutboxOptions {
+	opts := &OutboxOptions{
+		Workers:      4,
+		PollInterval: time.Second,
+		SendTimeout:  30 * time.Second,
+		Lease:        time.Minute,
+		MaxAttempts:  8,
+	}
+	for _, o := range opt {
+		o(opts)
+	}
+	if opts.Backoff == nil {
+		opts.Backoff, _ = backoff.NewExponentialBackoff(time.Second, 10*time.Minute, 2)
+	}
+	return opts
+}
+
+// Outbox 持久化的异步发件箱。邮件先保存到 OutboxStore，再由后台协程通过 Pool 发送，
+// 失败时指数退避重试，超过最大发送次数或者被 SMTP 服务永久拒绝（5xx）时进入死信。
+// 进程崩溃时正在发送的邮件可能被重复发送，重试时邮件的 Message-Id 不变，收件方可以据此去重
+type Outbox struct {
+	sender RawSender
+	store  OutboxStore
+	opts   *OutboxOptions
+
+	wake chan struct{}
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// NewOutbox 创建发件箱并启动发送协程
+func NewOutbox(sender RawSender, store OutboxStore, opt ...OutboxOption) *Outbox {
+	opts := newOutboxOptions(opt...)
+	o := &Outbox{
+		sender: sender,
+		store:  store,
+		opts:   opts,
+		wake:   make(chan struct{}, opts.Workers),
+		stop:   make(chan struct{}),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		o.wg.Add(1)
+		go o.work()
+	}
+	return o
+}
+
+// Enqueue 把邮件保存到发件箱，返回邮件 ID。key 为幂等键，相同幂等键的邮件只会保存和发送一次
+func (o *Outbox) Enqueue(ctx context.Context, key string, e *Email) (string, error) {
+	msg, err := NewOutboxMessage(key, e)
+	if err != nil {
+		return "", err
+	}
+	msg, added, err := o.store.Add(ctx, msg)
+	if err != nil {
+		return "", err
+	}
+	if added {
+		o.Notify()
+	}
+	return msg.ID, nil
+}
+
+// Notify 唤醒发送协程，在事务中保存邮件并提交后调用可以立即发送
+func (o *Outbox) Notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Status 查询邮件的发送状态
+func (o *Outbox) Status(ctx context.Context, id string) (*OutboxMessage, error) {
+	return o.store.Get(ctx, id)
+}
+
+// DeadLetters 列出进入死信的邮件
+func (o *Outbox) DeadLetters(ctx context.Context, limit int) ([]*OutboxMessage, error) {
+	return o.store.List(ctx, OutboxDead, limit)
+}
+
+// Close 停止发送协程，等待正在发送的邮件发送完成
+func (o *Outbox) Close() {
+	o.once.Do(func() {
+		close(o.stop)
+		o.wg.Wait()
+	})
+}
+
+// work 认领并发送到期的邮件，没有待发送的邮件时等待唤醒或者下一次查询
+func (o *Outbox) work() {
+	defer o.wg.Done()
+	ticker := time.NewTicker(o.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-o.stop:
+			return
+		default:
+		}
+
+		msgs, err := o.store.Claim(context.Background(), time.Now(), o.opts.Lease, 1)
+		if err != nil {
+			logging.Errorf("email outbox: claim messages err: %v", err)
+		}
+		if len(msgs) > 0 {
+			o.deliver(msgs[0])
+			continue
+		}
+
+		select {
+		case <-o.stop:
+			return
+		case <-o.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver 发送一封邮件并更新状态
+func (o *Outbox) deliver(msg *OutboxMessage) {
+	err := o.sender.SendRaw(msg.From, msg.To, msg.Body, o.opts.SendTimeout)
+	now := time.Now()
+	msg.Attempts++
+	msg.UpdatedAt = now
+	switch {
+	case err == nil:
+		msg.Status, msg.LastError = OutboxSent, ""
+	case isPermanent(err) || msg.Attempts >= o.opts.MaxAttempts:
+		msg.Status, msg.LastError = OutboxDead, err.Error()
+		logging.Errorf("email outbox: message %s is dead after %d attempts: %v", msg.ID, msg.Attempts, err)
+	default:
+		msg.Status, msg.LastError = OutboxPending, err.Error()
+		msg.NextAttemptAt = now.Add(o.opts.Backoff.Backoff(msg.Attempts))
+	}
+
+	if err := o.store.Update(context.Background(), msg); err != nil {
+		logging.Errorf("email outbox: update message %s err: %v", msg.ID, err)
+	}
+}
+
+// isPermanent SMTP 服务返回 5xx 时不再重试
+func isPermanent(err error) bool {
+	var tpErr *textproto.Error
+	return errors.As(err, &tpErr) && tpErr.Code >= 500
+}
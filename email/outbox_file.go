@@ -0,0 +1,179 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileOutboxStore 本地文件发件箱，每封邮件保存为目录下的一个 json 文件，只能被一个进程使用。
+// 发送成功和进入死信的邮件不会自动删除，可以通过 Purge 清理
+type FileOutboxStore struct {
+	dir  string
+	mu   sync.Mutex
+	msgs map[string]*OutboxMessage
+}
+
+// NewFileOutboxStore 打开目录中的发件箱，目录不存在时创建
+func NewFileOutboxStore(dir string) (*FileOutboxStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FileOutboxStore{dir: dir, msgs: make(map[string]*OutboxMessage)}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		msg := &OutboxMessage{}
+		if err := json.Unmarshal(data, msg); err != nil {
+			return nil, err
+		}
+		s.msgs[msg.ID] = msg
+	}
+	return s, nil
+}
+
+// save 先写临时文件再重命名，保证文件内容完整
+func (s *FileOutboxStore) save(msg *OutboxMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(s.dir, msg.ID+".json")
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// Add implements OutboxStore.
+func (s *FileOutboxStore) Add(_ context.Context, msg *OutboxMessage) (*OutboxMessage, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.msgs[msg.ID]; ok {
+		m := *old
+		return &m, false, nil
+	}
+	if err := s.save(msg); err != nil {
+		return nil, false, err
+	}
+	m := *msg
+	s.msgs[msg.ID] = &m
+	return msg, true, nil
+}
+
+// Claim implements OutboxStore.
+func (s *FileOutboxStore) Claim(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*OutboxMessage
+	for _, msg := range s.msgs {
+		if (msg.Status == OutboxPending || msg.Status == OutboxSending) && !msg.NextAttemptAt.After(now) {
+			due = append(due, msg)
+		}
+	}
+	sortOutboxMessages(due)
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	res := make([]*OutboxMessage, 0, len(due))
+	for _, msg := range due {
+		m := *msg
+		m.Status, m.NextAttemptAt, m.UpdatedAt = OutboxSending, now.Add(lease), now
+		if err := s.save(&m); err != nil {
+			return res, err
+		}
+		*msg = m
+		res = append(res, &m)
+	}
+	return res, nil
+}
+
+// Update implements OutboxStore.
+func (s *FileOutboxStore) Update(_ context.Context, msg *OutboxMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.msgs[msg.ID]
+	if !ok {
+		return ErrOutboxNotFound
+	}
+	if err := s.save(msg); err != nil {
+		return err
+	}
+	*old = *msg
+	return nil
+}
+
+// Get implements OutboxStore.
+func (s *FileOutboxStore) Get(_ context.Context, id string) (*OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, ok := s.msgs[id]
+	if !ok {
+		return nil, ErrOutboxNotFound
+	}
+	m := *msg
+	return &m, nil
+}
+
+// List implements OutboxStore.
+func (s *FileOutboxStore) List(_ context.Context, status string, limit int) ([]*OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res []*OutboxMessage
+	for _, msg := range s.msgs {
+		if msg.Status == status {
+			m := *msg
+			res = append(res, &m)
+		}
+	}
+	sortOutboxMessages(res)
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+// Purge 删除 before 之前发送成功或者进入死信的邮件，返回删除的数量
+func (s *FileOutboxStore) Purge(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for id, msg := range s.msgs {
+		if (msg.Status != OutboxSent && msg.Status != OutboxDead) || !msg.UpdatedAt.Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, id+".json")); err != nil && !os.IsNotExist(err) {
+			return n, err
+		}
+		delete(s.msgs, id)
+		n++
+	}
+	return n, nil
+}
+
+// sortOutboxMessages 按创建时间排序
+func sortOutboxMessages(msgs []*OutboxMessage) {
+	sort.Slice(msgs, func(i, j int) bool {
+		if msgs[i].CreatedAt.Equal(msgs[j].CreatedAt) {
+			return msgs[i].ID < msgs[j].ID
+		}
+		return msgs[i].CreatedAt.Before(msgs[j].CreatedAt)
+	})
+}
@@ -0,0 +1,190 @@
+package email
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/db/mysql/sqlxx"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// mysqlDuplicateEntry 唯一键冲突的错误码
+const mysqlDuplicateEntry = 1062
+
+// OutboxSchema SQLOutboxStore 使用的 MySQL 表结构
+const OutboxSchema = `
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id              VARCHAR(32)  NOT NULL PRIMARY KEY,
+    idempotency_key VARCHAR(255) NOT NULL DEFAULT '',
+    sender          VARCHAR(255) NOT NULL,
+    recipients      TEXT         NOT NULL,
+    body            MEDIUMBLOB   NOT NULL,
+    status          VARCHAR(16)  NOT NULL,
+    attempts        INT          NOT NULL DEFAULT 0,
+    last_error      TEXT         NOT NULL,
+    next_attempt_at DATETIME(3)  NOT NULL,
+    created_at      DATETIME(3)  NOT NULL,
+    updated_at      DATETIME(3)  NOT NULL,
+    KEY idx_status_next_attempt (status, next_attempt_at)
+);`
+
+const (
+	outboxColumns = "id, idempotency_key, sender, recipients, body, status, attempts, last_error, " +
+		"next_attempt_at, created_at, updated_at"
+	sqlOutboxAdd = "INSERT INTO email_outbox (" + outboxColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	sqlOutboxGet = "SELECT " + outboxColumns + " FROM email_outbox WHERE id = ?"
+	sqlOutboxDue = "SELECT " + outboxColumns + " FROM email_outbox " +
+		"WHERE status IN (?, ?) AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ?"
+	sqlOutboxClaim = "UPDATE email_outbox SET status = ?, next_attempt_at = ?, updated_at = ? " +
+		"WHERE id = ? AND status IN (?, ?) AND next_attempt_at <= ?"
+	sqlOutboxUpdate = "UPDATE email_outbox SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, " +
+		"updated_at = ? WHERE id = ?"
+	sqlOutboxList = "SELECT " + outboxColumns + " FROM email_outbox WHERE status = ? ORDER BY created_at LIMIT ?"
+)
+
+// outboxDB *sqlx.DB 和 *sqlx.Tx 共同实现的接口
+type outboxDB interface {
+	sqlx.QueryerContext
+	sqlx.ExecerContext
+}
+
+// outboxRow 发件箱表的一行，收件人以换行分隔
+type outboxRow struct {
+	ID            string    `db:"id"`
+	Key           string    `db:"idempotency_key"`
+	From          string    `db:"sender"`
+	To            string    `db:"recipients"`
+	Body          []byte    `db:"body"`
+	Status        string    `db:"status"`
+	Attempts      int       `db:"attempts"`
+	LastError     string    `db:"last_error"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+func (r *outboxRow) message() *OutboxMessage {
+	var to []string
+	if r.To != "" {
+		to = strings.Split(r.To, "\n")
+	}
+	return &OutboxMessage{
+		ID:            r.ID,
+		Key:           r.Key,
+		From:          r.From,
+		To:            to,
+		Body:          r.Body,
+		Status:        r.Status,
+		Attempts:      r.Attempts,
+		LastError:     r.LastError,
+		NextAttemptAt: r.NextAttemptAt,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}
+}
+
+// SQLOutboxStore 基于 sqlxx 的 MySQL 发件箱，表结构见 OutboxSchema，可以被多个进程共享。
+// 通过 AddTx 在业务事务中保存邮件，实现事务性发件箱
+type SQLOutboxStore struct {
+	db *sqlxx.SqlxDBClient
+}
+
+// NewSQLOutboxStore 创建 MySQL 发件箱
+func NewSQLOutboxStore(db *sqlxx.SqlxDBClient) *SQLOutboxStore {
+	return &SQLOutboxStore{db: db}
+}
+
+// Add implements OutboxStore.
+func (s *SQLOutboxStore) Add(ctx context.Context, msg *OutboxMessage) (*OutboxMessage, bool, error) {
+	return s.add(ctx, s.db.DB, msg)
+}
+
+// AddTx 在事务中保存邮件，事务提交后才会被发送
+func (s *SQLOutboxStore) AddTx(ctx context.Context, tx *sqlx.Tx, msg *OutboxMessage) (*OutboxMessage, bool, error) {
+	return s.add(ctx, tx, msg)
+}
+
+func (s *SQLOutboxStore) add(ctx context.Context, db outboxDB, msg *OutboxMessage) (*OutboxMessage, bool, error) {
+	_, err := db.ExecContext(ctx, sqlOutboxAdd, msg.ID, msg.Key, msg.From, strings.Join(msg.To, "\n"), msg.Body,
+		msg.Status, msg.Attempts, msg.LastError, msg.NextAttemptAt, msg.CreatedAt, msg.UpdatedAt)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		// 幂等键已存在
+		old, err := s.get(ctx, db, msg.ID)
+		return old, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return msg, true, nil
+}
+
+// Claim implements OutboxStore.
+func (s *SQLOutboxStore) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*OutboxMessage, error) {
+	var rows []*outboxRow
+	if err := s.db.DB.SelectContext(ctx, &rows, sqlOutboxDue, OutboxPending, OutboxSending, now, limit); err != nil {
+		return nil, err
+	}
+
+	res := make([]*OutboxMessage, 0, len(rows))
+	for _, row := range rows {
+		next := now.Add(lease)
+		result, err := s.db.DB.ExecContext(ctx, sqlOutboxClaim, OutboxSending, next, now, row.ID,
+			OutboxPending, OutboxSending, now)
+		if err != nil {
+			return res, err
+		}
+		if n, err := result.RowsAffected(); err != nil || n != 1 {
+			// 已被其他进程认领
+			continue
+		}
+		msg := row.message()
+		msg.Status, msg.NextAttemptAt, msg.UpdatedAt = OutboxSending, next, now
+		res = append(res, msg)
+	}
+	return res, nil
+}
+
+// Update implements OutboxStore.
+func (s *SQLOutboxStore) Update(ctx context.Context, msg *OutboxMessage) error {
+	_, err := s.db.DB.ExecContext(ctx, sqlOutboxUpdate, msg.Status, msg.Attempts, msg.LastError, msg.NextAttemptAt,
+		msg.UpdatedAt, msg.ID)
+	return err
+}
+
+// Get implements OutboxStore.
+func (s *SQLOutboxStore) Get(ctx context.Context, id string) (*OutboxMessage, error) {
+	return s.get(ctx, s.db.DB, id)
+}
+
+func (s *SQLOutboxStore) get(ctx context.Context, db sqlx.QueryerContext, id string) (*OutboxMessage, error) {
+	row := &outboxRow{}
+	err := sqlx.GetContext(ctx, db, row, sqlOutboxGet, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOutboxNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return row.message(), nil
+}
+
+// List implements OutboxStore.
+func (s *SQLOutboxStore) List(ctx context.Context, status string, limit int) ([]*OutboxMessage, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var rows []*outboxRow
+	if err := s.db.DB.SelectContext(ctx, &rows, sqlOutboxList, status, limit); err != nil {
+		return nil, err
+	}
+	res := make([]*OutboxMessage, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, row.message())
+	}
+	return res, nil
+}
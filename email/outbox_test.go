@@ -0,0 +1,224 @@
+package email
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fengzhongzhu1621/xgo/db/mysql/sqlxx"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noBackoff 重试时不等待
+type noBackoff struct{}
+
+func (noBackoff) Backoff(int) time.Duration { return 0 }
+
+func newTestEmail(subject string) *Email {
+	e := NewEmail()
+	e.From = "Sender <sender@example.com>"
+	e.To = []string{"to@example.com"}
+	e.Bcc = []string{"bcc@example.com"}
+	e.Subject = subject
+	e.Text = []byte("hello")
+	return e
+}
+
+func newTestPool(t *testing.T, srv *fakeSMTPServer) *Pool {
+	p, err := NewPool(srv.Addr(), 2, nil)
+	require.NoError(t, err)
+	return p
+}
+
+func waitStatus(t *testing.T, o *Outbox, id, status string) *OutboxMessage {
+	var msg *OutboxMessage
+	require.Eventually(t, func() bool {
+		var err error
+		msg, err = o.Status(context.Background(), id)
+		return err == nil && msg.Status == status
+	}, 5*time.Second, 10*time.Millisecond)
+	return msg
+}
+
+func TestPoolSend(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	p := newTestPool(t, srv)
+	defer p.Close()
+
+	require.NoError(t, p.Send(newTestEmail("pool"), time.Second))
+	mails := srv.Mails()
+	require.Len(t, mails, 1)
+	assert.Equal(t, "sender@example.com", mails[0].From)
+	// 密送只出现在信封中
+	assert.Equal(t, []string{"to@example.com", "bcc@example.com"}, mails[0].To)
+	assert.Contains(t, mails[0].Data, "Subject: pool")
+	assert.NotContains(t, mails[0].Data, "bcc@example.com")
+}
+
+func TestOutboxDeliver(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	p := newTestPool(t, srv)
+	defer p.Close()
+	store, err := NewFileOutboxStore(t.TempDir())
+	require.NoError(t, err)
+	o := NewOutbox(p, store, WithOutboxRetry(5, noBackoff{}), WithOutboxPollInterval(10*time.Millisecond))
+	defer o.Close()
+
+	// 临时失败时重试
+	srv.setFailMail(2)
+	ctx := context.Background()
+	id, err := o.Enqueue(ctx, "order-1", newTestEmail("order 1"))
+	require.NoError(t, err)
+	msg := waitStatus(t, o, id, OutboxSent)
+	assert.Equal(t, 3, msg.Attempts)
+	assert.Empty(t, msg.LastError)
+
+	// 相同幂等键的邮件只发送一次
+	dup, err := o.Enqueue(ctx, "order-1", newTestEmail("order 1"))
+	require.NoError(t, err)
+	assert.Equal(t, id, dup)
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, srv.Mails(), 1)
+
+	_, err = o.Status(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrOutboxNotFound)
+}
+
+func TestOutboxDeadLetter(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	p := newTestPool(t, srv)
+	defer p.Close()
+	store, err := NewFileOutboxStore(t.TempDir())
+	require.NoError(t, err)
+	o := NewOutbox(p, store, WithOutboxRetry(3, noBackoff{}), WithOutboxPollInterval(10*time.Millisecond))
+	defer o.Close()
+	ctx := context.Background()
+
+	// 永久拒收时不重试
+	srv.setReject(true)
+	id, err := o.Enqueue(ctx, "", newTestEmail("rejected"))
+	require.NoError(t, err)
+	msg := waitStatus(t, o, id, OutboxDead)
+	assert.Equal(t, 1, msg.Attempts)
+	assert.Contains(t, msg.LastError, "550")
+
+	// 超过最大发送次数
+	srv.setReject(false)
+	srv.setFailMail(100)
+	id, err = o.Enqueue(ctx, "", newTestEmail("failed"))
+	require.NoError(t, err)
+	msg = waitStatus(t, o, id, OutboxDead)
+	assert.Equal(t, 3, msg.Attempts)
+	assert.Contains(t, msg.LastError, "451")
+
+	dead, err := o.DeadLetters(ctx, 10)
+	require.NoError(t, err)
+	assert.Len(t, dead, 2)
+	n, err := store.Purge(time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestOutboxRecover(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileOutboxStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// 进程崩溃前保存的邮件和认领过期的邮件在重启后发送
+	pending, err := NewOutboxMessage("pending", newTestEmail("pending"))
+	require.NoError(t, err)
+	_, _, err = store.Add(ctx, pending)
+	require.NoError(t, err)
+	sending, err := NewOutboxMessage("sending", newTestEmail("sending"))
+	require.NoError(t, err)
+	_, _, err = store.Add(ctx, sending)
+	require.NoError(t, err)
+	claimed, err := store.Claim(ctx, time.Now(), time.Millisecond, 1)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.Len(t, files, 2)
+
+	srv := newFakeSMTPServer(t)
+	p := newTestPool(t, srv)
+	defer p.Close()
+	store, err = NewFileOutboxStore(dir)
+	require.NoError(t, err)
+	o := NewOutbox(p, store, WithOutboxPollInterval(10*time.Millisecond))
+	defer o.Close()
+	waitStatus(t, o, pending.ID, OutboxSent)
+	waitStatus(t, o, sending.ID, OutboxSent)
+	assert.Len(t, srv.Mails(), 2)
+	// 重试时 Message-Id 不变
+	assert.Contains(t, srv.Mails()[0].Data, "Message-Id")
+	_, err = os.Stat(filepath.Join(dir, pending.ID+".json.tmp"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSQLOutboxStore(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db := sqlx.NewDb(conn, "mysql")
+	defer db.Close()
+	store := NewSQLOutboxStore(&sqlxx.SqlxDBClient{DB: db})
+	ctx := context.Background()
+
+	msg, err := NewOutboxMessage("order-1", newTestEmail("sql"))
+	require.NoError(t, err)
+	columns := strings.Split(outboxColumns, ", ")
+	row := func(status string) *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(msg.ID, msg.Key, msg.From, strings.Join(msg.To, "\n"), msg.Body,
+			status, 0, "", msg.NextAttemptAt, msg.CreatedAt, msg.UpdatedAt)
+	}
+
+	// 在事务中保存
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(sqlOutboxAdd)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	tx, err := db.BeginTxx(ctx, nil)
+	require.NoError(t, err)
+	_, added, err := store.AddTx(ctx, tx, msg)
+	require.NoError(t, err)
+	assert.True(t, added)
+	require.NoError(t, tx.Commit())
+
+	// 幂等键冲突时返回已有的邮件
+	mock.ExpectExec(regexp.QuoteMeta(sqlOutboxAdd)).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectQuery(regexp.QuoteMeta(sqlOutboxGet)).WithArgs(msg.ID).WillReturnRows(row(OutboxSent))
+	old, added, err := store.Add(ctx, msg)
+	require.NoError(t, err)
+	assert.False(t, added)
+	assert.Equal(t, OutboxSent, old.Status)
+	assert.Equal(t, msg.To, old.To)
+
+	// 被其他进程认领的邮件跳过
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(sqlOutboxDue)).WithArgs(OutboxPending, OutboxSending, now, 2).
+		WillReturnRows(row(OutboxPending).AddRow("other", "", "a@example.com", "b@example.com", []byte("x"),
+			OutboxPending, 0, "", now, now, now))
+	mock.ExpectExec(regexp.QuoteMeta(sqlOutboxClaim)).
+		WithArgs(OutboxSending, now.Add(time.Minute), now, msg.ID, OutboxPending, OutboxSending, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(sqlOutboxClaim)).WillReturnResult(sqlmock.NewResult(0, 0))
+	claimed, err := store.Claim(ctx, now, time.Minute, 2)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, OutboxSending, claimed[0].Status)
+
+	mock.ExpectExec(regexp.QuoteMeta(sqlOutboxUpdate)).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, store.Update(ctx, claimed[0]))
+	mock.ExpectQuery(regexp.QuoteMeta(sqlOutboxGet)).WithArgs("unknown").WillReturnRows(sqlmock.NewRows(columns))
+	_, err = store.Get(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrOutboxNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -89,7 +89,10 @@ func (p *Pool) get(timeout time.Duration) *client {
 	default:
 	}
 	// 如果没有可用的客户端，则创建一个新的客户端
-	if p.created < p.max {
+	p.mut.Lock()
+	full := p.created >= p.max
+	p.mut.Unlock()
+	if !full {
 		p.makeOne()
 	}
 
@@ -121,10 +124,6 @@ func (p *Pool) replace(c *client) {
 
 // 将可用的客户端加一，计数增加了，但是实际上创建连接失败
 func (p *Pool) inc() bool {
-	if p.created >= p.max {
-		return false
-	}
-
 	p.mut.Lock()
 	defer p.mut.Unlock()
 
@@ -240,7 +239,28 @@ func (p *Pool) failedToGet(startTime time.Time) error {
 // be <0 to indicate no timeout. Otherwise reaching the timeout will produce
 // and error building a connection that occurred while we were waiting, or
 // otherwise ErrTimeout.
-func (p *Pool) Send(e *Email, timeout time.Duration) (err error) {
+func (p *Pool) Send(e *Email, timeout time.Duration) error {
+	recipients, err := addressLists(e.To, e.Cc, e.Bcc)
+	if err != nil {
+		return err
+	}
+
+	msg, err := e.Bytes()
+	if err != nil {
+		return err
+	}
+
+	from, err := emailOnly(e.From)
+	if err != nil {
+		return err
+	}
+
+	return p.SendRaw(from, recipients, msg, timeout)
+}
+
+// SendRaw 发送已经序列化的邮件，from 和 recipients 为信封的发件人和收件人（包括密送），
+// msg 为 Email.Bytes() 的结果。timeout 的含义和 Send 相同
+func (p *Pool) SendRaw(from string, recipients []string, msg []byte, timeout time.Duration) (err error) {
 	start := time.Now()
 	// 获得客户端连接，如果没有则创建一个新的连接
 	c := p.get(timeout)
@@ -254,20 +274,6 @@ func (p *Pool) Send(e *Email, timeout time.Duration) (err error) {
 		p.maybeReplace(err, c)
 	}()
 
-	recipients, err := addressLists(e.To, e.Cc, e.Bcc)
-	if err != nil {
-		return
-	}
-
-	msg, err := e.Bytes()
-	if err != nil {
-		return
-	}
-
-	from, err := emailOnly(e.From)
-	if err != nil {
-		return
-	}
 	if err = c.Mail(from); err != nil {
 		return
 	}
@@ -0,0 +1,128 @@
+package email
+
+import (
+	"net"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeMail 测试 SMTP 服务收到的邮件
+type fakeMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTPServer 进程内的 SMTP 服务，支持模拟临时失败和拒收
+type fakeSMTPServer struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	mails    []fakeMail
+	failMail int  // MAIL 命令返回 451 的剩余次数
+	reject   bool // RCPT 命令返回 550
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{ln: ln}
+	go s.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *fakeSMTPServer) Mails() []fakeMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeMail(nil), s.mails...)
+}
+
+func (s *fakeSMTPServer) setFailMail(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failMail = n
+}
+
+func (s *fakeSMTPServer) setReject(reject bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reject = reject
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+var addrRe = regexp.MustCompile(`<(.*)>`)
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	defer tp.Close()
+	_ = tp.PrintfLine("220 fake ESMTP")
+
+	var mail fakeMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.mu.Lock()
+		switch cmd {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250 fake")
+		case "MAIL":
+			if s.failMail > 0 {
+				s.failMail--
+				_ = tp.PrintfLine("451 try again later")
+				break
+			}
+			mail = fakeMail{From: addrRe.FindStringSubmatch(line)[1]}
+			_ = tp.PrintfLine("250 ok")
+		case "RCPT":
+			if s.reject {
+				_ = tp.PrintfLine("550 no such user")
+				break
+			}
+			mail.To = append(mail.To, addrRe.FindStringSubmatch(line)[1])
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			s.mu.Unlock()
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			mail.Data = string(data)
+			s.mails = append(s.mails, mail)
+			_ = tp.PrintfLine("250 queued")
+		case "RSET", "NOOP":
+			mail = fakeMail{}
+			_ = tp.PrintfLine("250 ok")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			s.mu.Unlock()
+			return
+		default:
+			_ = tp.PrintfLine("502 not implemented")
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,276 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	"golang.org/x/net/html"
+)
+
+// 模板文件名，按语言区分的变体在扩展名前加语言，例如 welcome/subject.zh-CN.txt
+const (
+	templateSubject = "subject.txt"
+	templateHTML    = "body.html"
+	templateText    = "body.txt"
+)
+
+// ErrTemplateNotFound 模板不存在
+var ErrTemplateNotFound = errors.New("email template not found")
+
+// RendererOptions 模板渲染的配置
+type RendererOptions struct {
+	Funcs       map[string]any // 模板函数
+	DefaultLang string         // 没有对应语言的变体时使用的语言
+	InlineCSS   bool           // 是否把 <style> 中的样式内联到元素的 style 属性
+	EmbedImages bool           // 是否把引用的本地图片作为 HTML 关联附件嵌入邮件
+}
+
+// RendererOption 设置模板渲染的配置
+type RendererOption func(*RendererOptions)
+
+// WithTemplateFuncs 添加模板函数，同时作用于主题、HTML 和纯文本模板
+func WithTemplateFuncs(funcs map[string]any) RendererOption {
+	return func(o *RendererOptions) {
+		for k, v := range funcs {
+			o.Funcs[k] = v
+		}
+	}
+}
+
+// WithDefaultLang 设置没有对应语言的变体时使用的语言
+func WithDefaultLang(lang string) RendererOption {
+	return func(o *RendererOptions) {
+		o.DefaultLang = normalizeLang(lang)
+	}
+}
+
+// WithInlineCSS 设置是否内联样式，默认内联
+func WithInlineCSS(inline bool) RendererOption {
+	return func(o *RendererOptions) {
+		o.InlineCSS = inline
+	}
+}
+
+// WithEmbedImages 设置是否嵌入本地图片，默认嵌入
+func WithEmbedImages(embed bool) RendererOption {
+	return func(o *RendererOptions) {
+		o.EmbedImages = embed
+	}
+}
+
+// Renderer 从 fs.FS 中读取邮件模板并渲染为 Email。
+//
+// 每个模板是一个目录，包含 subject.txt、body.html 和 body.txt，至少需要主题和一种正文，
+// 主题和纯文本使用 text/template 渲染，HTML 使用 html/template 渲染。
+// 没有 body.txt 时从渲染后的 HTML 生成纯文本。
+// 文件名在扩展名前加语言即为该语言的变体（subject.zh-CN.txt），
+// 查找顺序为 zh-CN、zh、默认语言、不带语言的文件。
+// HTML 中引用的相对路径图片相对于模板目录读取，作为关联附件嵌入，并替换为 cid: 引用
+type Renderer struct {
+	fsys fs.FS
+	opts *RendererOptions
+
+	mu    sync.RWMutex
+	cache map[string]any // 文件路径 -> 解析后的模板
+}
+
+// NewRenderer 创建模板渲染器
+func NewRenderer(fsys fs.FS, opt ...RendererOption) *Renderer {
+	opts := &RendererOptions{Funcs: make(map[string]any), InlineCSS: true, EmbedImages: true}
+	for _, o := range opt {
+		o(opts)
+	}
+	return &Renderer{fsys: fsys, opts: opts, cache: make(map[string]any)}
+}
+
+// Render 使用 data 渲染 lang 语言的模板 name，返回的 Email 只设置了主题、正文和内嵌图片
+func (r *Renderer) Render(name, lang string, data any) (*Email, error) {
+	e := NewEmail()
+	if err := r.RenderTo(e, name, lang, data); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// RenderTo 使用 data 渲染 lang 语言的模板 name，设置 e 的主题和正文并添加内嵌图片
+func (r *Renderer) RenderTo(e *Email, name, lang string, data any) error {
+	subject, ok, err := r.executeText(name, templateSubject, lang, data)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s/%s", ErrTemplateNotFound, name, templateSubject)
+	}
+	htmlBody, hasHTML, err := r.executeHTML(name, lang, data)
+	if err != nil {
+		return err
+	}
+	textBody, hasText, err := r.executeText(name, templateText, lang, data)
+	if err != nil {
+		return err
+	}
+	if !hasHTML && !hasText {
+		return fmt.Errorf("%w: %s has no %s or %s", ErrTemplateNotFound, name, templateHTML, templateText)
+	}
+
+	if hasHTML {
+		doc, err := parseHTML(htmlBody)
+		if err != nil {
+			return fmt.Errorf("email template %s: %w", name, err)
+		}
+		if r.opts.InlineCSS {
+			inlineCSS(doc)
+		}
+		if r.opts.EmbedImages {
+			if err := r.embedImages(e, name, doc); err != nil {
+				return err
+			}
+		}
+		if e.HTML, err = renderHTML(doc); err != nil {
+			return err
+		}
+		if !hasText {
+			textBody = htmlToText(doc)
+		}
+	}
+
+	e.Subject = sanitizeSubject(subject)
+	e.Text = []byte(textBody)
+	return nil
+}
+
+// embedImages 把 doc 中引用的相对路径图片添加为 HTML 关联附件，src 替换为 cid: 引用
+func (r *Renderer) embedImages(e *Email, name string, doc *html.Node) error {
+	embedded := make(map[string]string)
+	return walkImages(doc, func(src string) (string, error) {
+		if cid, ok := embedded[src]; ok {
+			return cid, nil
+		}
+		file := path.Join(name, src)
+		if !fs.ValidPath(file) || !strings.HasPrefix(file, name+"/") {
+			return "", fmt.Errorf("email template %s: invalid image path %q", name, src)
+		}
+		content, err := fs.ReadFile(r.fsys, file)
+		if err != nil {
+			return "", fmt.Errorf("email template %s: %w", name, err)
+		}
+
+		cid := strings.ReplaceAll(strings.TrimPrefix(file, name+"/"), "/", ".")
+		a, err := e.Attach(bytes.NewReader(content), path.Base(file), mime.TypeByExtension(path.Ext(file)))
+		if err != nil {
+			return "", err
+		}
+		a.HTMLRelated = true
+		a.Header.Set("Content-ID", "<"+cid+">")
+		embedded[src] = "cid:" + cid
+		return embedded[src], nil
+	})
+}
+
+// executeText 渲染纯文本模板，模板不存在时 ok 为 false
+func (r *Renderer) executeText(name, file, lang string, data any) (string, bool, error) {
+	t, err := r.lookup(name, file, lang, func(p string, content []byte) (any, error) {
+		return texttemplate.New(p).Funcs(r.opts.Funcs).Option("missingkey=error").Parse(string(content))
+	})
+	if t == nil || err != nil {
+		return "", false, err
+	}
+	var buf bytes.Buffer
+	if err := t.(*texttemplate.Template).Execute(&buf, data); err != nil {
+		return "", false, err
+	}
+	return buf.String(), true, nil
+}
+
+// executeHTML 渲染 HTML 模板，模板不存在时 ok 为 false
+func (r *Renderer) executeHTML(name, lang string, data any) (string, bool, error) {
+	t, err := r.lookup(name, templateHTML, lang, func(p string, content []byte) (any, error) {
+		return htmltemplate.New(p).Funcs(r.opts.Funcs).Option("missingkey=error").Parse(string(content))
+	})
+	if t == nil || err != nil {
+		return "", false, err
+	}
+	var buf bytes.Buffer
+	if err := t.(*htmltemplate.Template).Execute(&buf, data); err != nil {
+		return "", false, err
+	}
+	return buf.String(), true, nil
+}
+
+// lookup 按语言查找并解析模板文件，结果缓存，所有变体都不存在时返回 nil
+func (r *Renderer) lookup(name, file, lang string, parse func(string, []byte) (any, error)) (any, error) {
+	for _, p := range r.candidates(name, file, lang) {
+		r.mu.RLock()
+		t, ok := r.cache[p]
+		r.mu.RUnlock()
+		if ok {
+			return t, nil
+		}
+
+		content, err := fs.ReadFile(r.fsys, p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if t, err = parse(p, content); err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		r.cache[p] = t
+		r.mu.Unlock()
+		return t, nil
+	}
+	return nil, nil
+}
+
+// candidates 返回按优先级排列的模板文件路径
+func (r *Renderer) candidates(name, file, lang string) []string {
+	ext := path.Ext(file)
+	base := strings.TrimSuffix(file, ext)
+
+	var langs []string
+	lang = normalizeLang(lang)
+	if lang != "" {
+		langs = append(langs, lang)
+		if i := strings.Index(lang, "-"); i > 0 {
+			langs = append(langs, lang[:i])
+		}
+	}
+	if r.opts.DefaultLang != "" {
+		langs = append(langs, r.opts.DefaultLang)
+	}
+
+	paths := make([]string, 0, len(langs)+1)
+	seen := make(map[string]bool)
+	for _, l := range langs {
+		if !seen[l] {
+			seen[l] = true
+			paths = append(paths, path.Join(name, base+"."+l+ext))
+		}
+	}
+	return append(paths, path.Join(name, file))
+}
+
+// normalizeLang 统一语言标记的格式，zh_cn 转换为 zh-CN
+func normalizeLang(lang string) string {
+	lang = strings.ReplaceAll(strings.TrimSpace(lang), "_", "-")
+	if i := strings.Index(lang, "-"); i > 0 {
+		return strings.ToLower(lang[:i]) + "-" + strings.ToUpper(lang[i+1:])
+	}
+	return strings.ToLower(lang)
+}
+
+// sanitizeSubject 去掉主题中的换行，防止注入邮件头
+func sanitizeSubject(subject string) string {
+	return strings.Join(strings.Fields(subject), " ")
+}
@@ -0,0 +1,353 @@
+package email
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// cssRule 一条可以内联的样式规则
+type cssRule struct {
+	selector    simpleSelector
+	specificity int
+	decls       [][2]string
+}
+
+// simpleSelector 不含组合符和伪类的选择器，例如 td、.btn、a#home.link
+type simpleSelector struct {
+	tag     string
+	id      string
+	classes []string
+}
+
+var (
+	cssCommentRe  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	simpleSelRe   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*|[.#][a-zA-Z0-9_-]+`)
+	spaceRe       = regexp.MustCompile(`[ \t\r\n]+`)
+	blankLinesRe  = regexp.MustCompile(`\n{3,}`)
+	remoteSrcRe   = regexp.MustCompile(`^(?i)([a-z][a-z0-9+.-]*:|//)`)
+	simpleSelChar = regexp.MustCompile(`^[a-zA-Z0-9_.#-]+$`)
+)
+
+func parseHTML(s string) (*html.Node, error) {
+	return html.Parse(strings.NewReader(s))
+}
+
+func renderHTML(doc *html.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// walk 深度优先遍历节点
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func getAttr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// walkImages 把 <img> 中相对路径的 src 替换为 fn 的返回值，远程地址、data: 和 cid: 引用保持不变
+func walkImages(doc *html.Node, fn func(src string) (string, error)) error {
+	var err error
+	walk(doc, func(n *html.Node) {
+		if err != nil || n.Type != html.ElementNode || n.DataAtom != atom.Img {
+			return
+		}
+		src, ok := getAttr(n, "src")
+		if !ok || src == "" || remoteSrcRe.MatchString(src) {
+			return
+		}
+		var ref string
+		if ref, err = fn(src); err == nil {
+			setAttr(n, "src", ref)
+		}
+	})
+	return err
+}
+
+// inlineCSS 把 <style> 中的简单选择器规则合并到元素的 style 属性，
+// 元素原有的 style 优先级最高；@media 等无法内联的规则保留在 <style> 中
+func inlineCSS(doc *html.Node) {
+	var rules []cssRule
+	var styles []*html.Node
+	walk(doc, func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Style {
+			styles = append(styles, n)
+		}
+	})
+	for _, n := range styles {
+		if n.FirstChild == nil {
+			n.Parent.RemoveChild(n)
+			continue
+		}
+		var kept string
+		rules, kept = parseCSS(n.FirstChild.Data, rules)
+		if strings.TrimSpace(kept) == "" {
+			n.Parent.RemoveChild(n)
+		} else {
+			n.FirstChild.Data = kept
+		}
+	}
+	if len(rules) == 0 {
+		return
+	}
+	// 优先级相同时后定义的规则覆盖先定义的规则
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].specificity < rules[j].specificity
+	})
+
+	walk(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		var decls [][2]string
+		for _, rule := range rules {
+			if rule.selector.match(n) {
+				decls = append(decls, rule.decls...)
+			}
+		}
+		if len(decls) == 0 {
+			return
+		}
+		if style, ok := getAttr(n, "style"); ok {
+			decls = append(decls, parseDecls(style)...)
+		}
+		setAttr(n, "style", mergeDecls(decls))
+	})
+}
+
+// parseCSS 解析样式表，返回追加了可内联规则的 rules 和需要保留的样式
+func parseCSS(css string, rules []cssRule) ([]cssRule, string) {
+	css = cssCommentRe.ReplaceAllString(css, "")
+	var kept strings.Builder
+	for {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			break
+		}
+		prelude := strings.TrimSpace(css[:open])
+		if strings.HasPrefix(prelude, "@") {
+			// @media 等嵌套规则原样保留
+			end := matchBrace(css, open)
+			kept.WriteString(css[:end] + "\n")
+			css = css[end:]
+			continue
+		}
+		end := strings.IndexByte(css[open:], '}')
+		if end < 0 {
+			break
+		}
+		body := css[open+1 : open+end]
+		css = css[open+end+1:]
+
+		decls := parseDecls(body)
+		var keptSelectors []string
+		for _, s := range strings.Split(prelude, ",") {
+			s = strings.TrimSpace(s)
+			sel, ok := parseSelector(s)
+			if !ok {
+				keptSelectors = append(keptSelectors, s)
+				continue
+			}
+			rules = append(rules, cssRule{selector: sel, specificity: sel.specificity(), decls: decls})
+		}
+		if len(keptSelectors) > 0 {
+			kept.WriteString(strings.Join(keptSelectors, ", ") + " {" + body + "}\n")
+		}
+	}
+	return rules, kept.String()
+}
+
+// matchBrace 返回 css[open] 处的左括号匹配的右括号之后的位置
+func matchBrace(css string, open int) int {
+	depth := 0
+	for i := open; i < len(css); i++ {
+		switch css[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(css)
+}
+
+func parseDecls(body string) [][2]string {
+	var decls [][2]string
+	for _, d := range strings.Split(body, ";") {
+		k, v, ok := strings.Cut(d, ":")
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+		if ok && k != "" && v != "" {
+			decls = append(decls, [2]string{k, v})
+		}
+	}
+	return decls
+}
+
+// mergeDecls 合并样式声明，后面的声明覆盖前面的同名声明
+func mergeDecls(decls [][2]string) string {
+	values := make(map[string]string, len(decls))
+	var keys []string
+	for _, d := range decls {
+		if _, ok := values[d[0]]; !ok {
+			keys = append(keys, d[0])
+		}
+		values[d[0]] = d[1]
+	}
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+": "+values[k])
+	}
+	return strings.Join(parts, "; ")
+}
+
+func parseSelector(s string) (simpleSelector, bool) {
+	var sel simpleSelector
+	if s == "" || !simpleSelChar.MatchString(s) {
+		return sel, false
+	}
+	parts := simpleSelRe.FindAllString(s, -1)
+	if strings.Join(parts, "") != s {
+		return sel, false
+	}
+	for _, p := range parts {
+		switch p[0] {
+		case '.':
+			sel.classes = append(sel.classes, p[1:])
+		case '#':
+			sel.id = p[1:]
+		default:
+			sel.tag = strings.ToLower(p)
+		}
+	}
+	return sel, true
+}
+
+func (s simpleSelector) specificity() int {
+	n := 10 * len(s.classes)
+	if s.id != "" {
+		n += 100
+	}
+	if s.tag != "" {
+		n++
+	}
+	return n
+}
+
+func (s simpleSelector) match(n *html.Node) bool {
+	if s.tag != "" && s.tag != n.Data {
+		return false
+	}
+	if s.id != "" {
+		if id, _ := getAttr(n, "id"); id != s.id {
+			return false
+		}
+	}
+	if len(s.classes) > 0 {
+		class, _ := getAttr(n, "class")
+		have := strings.Fields(class)
+		for _, c := range s.classes {
+			found := false
+			for _, h := range have {
+				if h == c {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// blockElements 生成纯文本时前后空行的块级元素
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Table: true, atom.Tr: true, atom.Ul: true, atom.Ol: true, atom.Hr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Blockquote: true,
+}
+
+// htmlToText 从 HTML 生成纯文本正文，块级元素换行，链接在文本后附上地址
+func htmlToText(doc *html.Node) string {
+	var buf strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			buf.WriteString(spaceRe.ReplaceAllString(n.Data, " "))
+			return
+		case html.ElementNode:
+			switch n.DataAtom {
+			case atom.Head, atom.Style, atom.Script, atom.Title:
+				return
+			case atom.Br:
+				buf.WriteString("\n")
+				return
+			case atom.Img:
+				if alt, _ := getAttr(n, "alt"); alt != "" {
+					buf.WriteString(alt)
+				}
+				return
+			case atom.Li:
+				buf.WriteString("\n- ")
+			case atom.Td, atom.Th:
+				buf.WriteString(" ")
+			}
+		}
+		if n.Type == html.ElementNode && blockElements[n.DataAtom] {
+			buf.WriteString("\n\n")
+		}
+		start := buf.Len()
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+		if n.Type == html.ElementNode && blockElements[n.DataAtom] {
+			buf.WriteString("\n\n")
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			href, _ := getAttr(n, "href")
+			text := strings.TrimSpace(buf.String()[start:])
+			if href != "" && href != text && !strings.HasPrefix(href, "#") {
+				buf.WriteString(" (" + href + ")")
+			}
+		}
+	}
+	visit(doc)
+
+	lines := strings.Split(buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text := blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text) + "\n"
+}
@@ -0,0 +1,64 @@
+package email
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPreviewCommand 返回预览邮件模板的命令，把渲染结果写入 .eml 文件，可以直接用邮件客户端打开。
+//
+//	preview welcome --lang zh-CN --data welcome.json --out welcome.eml
+func NewPreviewCommand(r *Renderer) *cobra.Command {
+	var lang, dataFile, out, from string
+	var to []string
+	cmd := &cobra.Command{
+		Use:   "preview <template>",
+		Short: "Render an email template and write the result as an .eml file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			var data any
+			if dataFile != "" {
+				content, err := os.ReadFile(dataFile)
+				if err != nil {
+					return err
+				}
+				if err := json.Unmarshal(content, &data); err != nil {
+					return fmt.Errorf("parse %s: %w", dataFile, err)
+				}
+			}
+
+			e, err := r.Render(name, lang, data)
+			if err != nil {
+				return err
+			}
+			e.From, e.To = from, to
+			msg, err := e.Bytes()
+			if err != nil {
+				return err
+			}
+
+			if out == "" {
+				out = name + ".eml"
+			}
+			if out == "-" {
+				_, err = cmd.OutOrStdout().Write(msg)
+				return err
+			}
+			if err := os.WriteFile(out, msg, 0o644); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "wrote %s\n", out)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&lang, "lang", "", "language of the template variant, e.g. zh-CN")
+	cmd.Flags().StringVar(&dataFile, "data", "", "JSON file with the template data")
+	cmd.Flags().StringVar(&out, "out", "", "output file, defaults to <template>.eml, - for stdout")
+	cmd.Flags().StringVar(&from, "from", "preview@example.com", "From header of the preview")
+	cmd.Flags().StringSliceVar(&to, "to", []string{"designer@example.com"}, "To header of the preview")
+	return cmd
+}
@@ -0,0 +1,145 @@
+package email
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTemplates = fstest.MapFS{
+	"welcome/subject.txt":       {Data: []byte("Welcome, {{.Name}}\r\nBcc: evil@example.com")},
+	"welcome/subject.zh.txt":    {Data: []byte("欢迎，{{.Name}}")},
+	"welcome/subject.zh-TW.txt": {Data: []byte("歡迎，{{.Name}}")},
+	"welcome/body.html": {Data: []byte(`<html><head><style>
+p { color: #333; margin: 0 }
+.btn { color: #fff; background: blue }
+a.btn { padding: 4px }
+#title { font-size: 20px }
+a:hover { color: red }
+@media (max-width: 600px) { p { margin: 4px } }
+</style></head><body>
+<h1 id="title">Hi {{.Name}}</h1>
+<p style="color: green">Your code is <b>{{.Code}}</b>.</p>
+<img src="images/logo.png" alt="Logo"><img src="images/logo.png"><img src="https://cdn.example.com/x.png">
+<ul><li>one</li><li>two</li></ul>
+<a class="btn" href="https://example.com/start">Start</a>
+</body></html>`)},
+	"welcome/images/logo.png": {Data: []byte("\x89PNG")},
+	"plain/subject.txt":       {Data: []byte("Plain {{.Name}}")},
+	"plain/body.txt":          {Data: []byte("Hello {{.Name | upper}}")},
+	"broken/subject.txt":      {Data: []byte("Broken")},
+	"broken/body.html":        {Data: []byte(`<img src="../plain/body.txt">`)},
+}
+
+func newTestRenderer(opt ...RendererOption) *Renderer {
+	opt = append([]RendererOption{WithTemplateFuncs(map[string]any{"upper": strings.ToUpper})}, opt...)
+	return NewRenderer(testTemplates, opt...)
+}
+
+func TestRendererRender(t *testing.T) {
+	r := newTestRenderer()
+	e, err := r.Render("welcome", "", map[string]any{"Name": "<Bob>", "Code": 42})
+	require.NoError(t, err)
+
+	// 主题中的换行被去掉
+	assert.Equal(t, "Welcome, <Bob> Bcc: evil@example.com", e.Subject)
+
+	body := string(e.HTML)
+	assert.Contains(t, body, "Hi &lt;Bob&gt;")
+	assert.Contains(t, body, `<h1 id="title" style="font-size: 20px">`)
+	// 元素原有的样式优先
+	assert.Contains(t, body, `<p style="color: green; margin: 0">`)
+	assert.Contains(t, body, `<a class="btn" href="https://example.com/start" style="color: #fff; background: blue; padding: 4px">`)
+	// 无法内联的规则保留
+	assert.Contains(t, body, "a:hover { color: red }")
+	assert.Contains(t, body, "@media (max-width: 600px)")
+	assert.NotContains(t, body, ".btn {")
+
+	// 同一图片只嵌入一次，远程图片不处理
+	assert.Equal(t, 2, strings.Count(body, `src="cid:images.logo.png"`))
+	assert.Contains(t, body, `src="https://cdn.example.com/x.png"`)
+	require.Len(t, e.Attachments, 1)
+	a := e.Attachments[0]
+	assert.True(t, a.HTMLRelated)
+	assert.Equal(t, "logo.png", a.Filename)
+	assert.Equal(t, "image/png", a.ContentType)
+	assert.Equal(t, "<images.logo.png>", a.Header.Get("Content-ID"))
+
+	// 没有纯文本模板时从 HTML 生成
+	assert.Equal(t, "Hi <Bob>\n\nYour code is 42.\n\nLogo\n\n- one\n- two\n\nStart (https://example.com/start)\n",
+		string(e.Text))
+
+	e.From = "sender@example.com"
+	e.To = []string{"to@example.com"}
+	msg, err := e.Bytes()
+	require.NoError(t, err)
+	assert.Contains(t, string(msg), "multipart/alternative")
+	assert.Contains(t, string(msg), "multipart/related")
+	assert.Contains(t, string(msg), "Content-Id: <images.logo.png>")
+}
+
+func TestRendererLang(t *testing.T) {
+	r := newTestRenderer(WithDefaultLang("zh"))
+	data := map[string]any{"Name": "Bob", "Code": 1}
+	for lang, subject := range map[string]string{
+		"zh_tw": "歡迎，Bob",
+		"zh-CN": "欢迎，Bob",
+		"en":    "欢迎，Bob",
+	} {
+		e, err := r.Render("welcome", lang, data)
+		require.NoError(t, err, lang)
+		assert.Equal(t, subject, e.Subject, lang)
+	}
+
+	e, err := newTestRenderer().Render("welcome", "en-US", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome, Bob Bcc: evil@example.com", e.Subject)
+}
+
+func TestRendererErrors(t *testing.T) {
+	r := newTestRenderer(WithInlineCSS(false))
+	e, err := r.Render("plain", "zh", map[string]any{"Name": "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, "Plain bob", e.Subject)
+	assert.Equal(t, "Hello BOB", string(e.Text))
+	assert.Empty(t, e.HTML)
+
+	_, err = r.Render("unknown", "", nil)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+	_, err = r.Render("plain", "", map[string]any{})
+	assert.Error(t, err)
+	e, err = r.Render("broken", "", nil)
+	assert.ErrorContains(t, err, "invalid image path")
+	assert.Nil(t, e)
+	e, err = NewRenderer(testTemplates, WithEmbedImages(false)).Render("broken", "", nil)
+	require.NoError(t, err)
+	assert.Contains(t, string(e.HTML), `src="../plain/body.txt"`)
+}
+
+func TestPreviewCommand(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := filepath.Join(dir, "data.json")
+	require.NoError(t, os.WriteFile(dataFile, []byte(`{"Name": "Bob", "Code": 7}`), 0o644))
+	out := filepath.Join(dir, "welcome.eml")
+
+	cmd := NewPreviewCommand(newTestRenderer())
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{"welcome", "--lang", "zh", "--data", dataFile, "--out", out})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "wrote "+out+"\n", stdout.String())
+
+	f, err := os.Open(out)
+	require.NoError(t, err)
+	defer f.Close()
+	e, err := NewEmailFromReader(f)
+	require.NoError(t, err)
+	assert.Equal(t, "欢迎，Bob", e.Subject)
+	assert.Contains(t, string(e.Text), "Your code is 7.")
+}
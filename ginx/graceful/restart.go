@@ -0,0 +1,43 @@
+package graceful
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/fengzhongzhu1621/xgo/network/transport/server_transport"
+	log "github.com/sirupsen/logrus"
+)
+
+// Serve 使用可以热重启的监听器启动 HTTP 服务，设置了证书和私钥时使用 HTTPS
+// 监听器在热重启时传递给新进程，停止服务时 HTTP 服务与 RPC 服务的连接排空同时进行，
+// 因此 HTTP 服务和 RPC 服务由同一个 Restarter 一起热重启
+func Serve(r *server_transport.Restarter, srv *http.Server, certFile, keyFile string) error {
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":http"
+		if certFile != "" && keyFile != "" {
+			addr = ":https"
+		}
+	}
+
+	ln, err := server_transport.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	r.OnShutdown(srv.Shutdown)
+
+	go func() {
+		var err error
+		if certFile != "" && keyFile != "" {
+			err = srv.ServeTLS(ln, certFile, keyFile)
+		} else {
+			err = srv.Serve(ln)
+		}
+		// 停止监听时监听器被关闭，已经建立的连接由 Shutdown 关闭
+		if err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+			log.Errorf("http server %s serve error: %v", addr, err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
+	"github.com/gin-gonic/gin"
+)
+
+// CacheInfoSerializer 缓存的统计信息
+type CacheInfoSerializer struct {
+	Name string `json:"name"`
+	// 缓存中的数量，-1 表示未知
+	Len      int         `json:"len"`
+	HitRatio float64     `json:"hit_ratio"`
+	Stats    stats.Stats `json:"stats"`
+}
+
+func newCacheInfo(name string, c stats.Inspector) CacheInfoSerializer {
+	s := c.Stats()
+	return CacheInfoSerializer{
+		Name:     name,
+		Len:      c.Len(),
+		HitRatio: s.HitRatio(),
+		Stats:    s,
+	}
+}
+
+// ListCaches 列出所有注册的缓存及其统计信息
+func ListCaches(c *gin.Context) {
+	caches := make([]CacheInfoSerializer, 0)
+	stats.Each(func(name string, inspector stats.Inspector) {
+		caches = append(caches, newCacheInfo(name, inspector))
+	})
+
+	c.JSON(http.StatusOK, gin.H{"caches": caches})
+}
+
+// GetCache 获取一个缓存的统计信息
+func GetCache(c *gin.Context) {
+	name := c.Param("name")
+	inspector, ok := stats.Get(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cache not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newCacheInfo(name, inspector))
+}
+
+// InspectCacheKey 查看一个 key 的缓存值，不会触发回源
+func InspectCacheKey(c *gin.Context) {
+	inspector, key, ok := cacheKeyParams(c)
+	if !ok {
+		return
+	}
+
+	value, ok := inspector.Inspect(c.Request.Context(), key)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "key not found"})
+		return
+	}
+
+	// 空缓存中的 error 无法直接序列化
+	if emptyCache, isEmptyCache := value.(cache.EmptyCache); isEmptyCache {
+		errMsg := ""
+		if emptyCache.Err != nil {
+			errMsg = emptyCache.Err.Error()
+		}
+		c.JSON(http.StatusOK, gin.H{"key": key, "empty": true, "error": errMsg})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"key": key, "value": value})
+}
+
+// EvictCacheKey 删除一个 key 的缓存
+func EvictCacheKey(c *gin.Context) {
+	inspector, key, ok := cacheKeyParams(c)
+	if !ok {
+		return
+	}
+
+	if err := inspector.Evict(c.Request.Context(), key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "key evicted"})
+}
+
+// cacheKeyParams 解析路径中的缓存名称和 key，key 使用通配符以支持包含 / 的 key
+func cacheKeyParams(c *gin.Context) (stats.Inspector, string, bool) {
+	inspector, ok := stats.Get(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cache not found"})
+		return nil, "", false
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return nil, "", false
+	}
+	return inspector, key, true
+}
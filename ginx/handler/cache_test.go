@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cache "github.com/fengzhongzhu1621/xgo/cache/common"
+	"github.com/fengzhongzhu1621/xgo/cache/memory"
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
+	"github.com/fengzhongzhu1621/xgo/ginx"
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheAdmin(t *testing.T) {
+	t.Parallel()
+
+	c := memory.NewCache("handler-test", false, func(ctx context.Context, key cache.Key) (interface{}, error) {
+		return nil, errors.New("user not found")
+	}, time.Minute, nil, memory.WithEmptyCache(time.Minute))
+	defer stats.Unregister("handler-test")
+
+	ctx := context.Background()
+	c.Set(ctx, cache.NewStringKey("user/1"), "alice")
+	_, _ = c.Get(ctx, cache.NewStringKey("user/1"))
+	_, _ = c.Get(ctx, cache.NewStringKey("user/2"))
+
+	// 注册路由
+	r := ginx.SetupRouter()
+	r.GET("/cache", ListCaches)
+	r.GET("/cache/:name", GetCache)
+	r.GET("/cache/:name/keys/*key", InspectCacheKey)
+	r.DELETE("/cache/:name/keys/*key", EvictCacheKey)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cache/handler-test", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var info CacheInfoSerializer
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, "handler-test", info.Name)
+	assert.Equal(t, 2, info.Len)
+	assert.Equal(t, uint64(1), info.Stats.Hits)
+	assert.Equal(t, uint64(1), info.Stats.Misses)
+	assert.Equal(t, 0.5, info.HitRatio)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cache", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Caches []CacheInfoSerializer `json:"caches"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Contains(t, list.Caches, info)
+
+	apitest.New().
+		Handler(r).
+		Get("/cache/handler-test/keys/user/1").
+		Expect(t).
+		Body(`{"key":"user/1","value":"alice"}`).
+		Status(http.StatusOK).
+		End()
+
+	apitest.New().
+		Handler(r).
+		Get("/cache/handler-test/keys/user/2").
+		Expect(t).
+		Body(`{"key":"user/2","empty":true,"error":"user not found"}`).
+		Status(http.StatusOK).
+		End()
+
+	apitest.New().
+		Handler(r).
+		Delete("/cache/handler-test/keys/user/1").
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+
+	apitest.New().
+		Handler(r).
+		Get("/cache/handler-test/keys/user/1").
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+
+	apitest.New().
+		Handler(r).
+		Get("/cache/not-exist").
+		Expect(t).
+		Status(http.StatusNotFound).
+		End()
+}
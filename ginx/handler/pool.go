@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
+	"github.com/gin-gonic/gin"
+)
+
+// PoolInfoSerializer 连接池中每个目标节点的连接状态
+type PoolInfoSerializer struct {
+	Name  string                `json:"name"`
+	Nodes []poolstats.NodeStats `json:"nodes"`
+}
+
+// newPoolInfo 查询连接池的状态，address 不为空时只返回该目标地址的节点
+func newPoolInfo(name string, s poolstats.ISnapshotter, address string) PoolInfoSerializer {
+	nodes := make([]poolstats.NodeStats, 0)
+	for _, node := range s.Snapshot() {
+		if address == "" || node.Address == address {
+			nodes = append(nodes, node)
+		}
+	}
+	return PoolInfoSerializer{Name: name, Nodes: nodes}
+}
+
+// ListPools 列出所有注册的连接池及其每个目标节点的连接状态，支持使用 address 参数过滤目标地址
+func ListPools(c *gin.Context) {
+	address := c.Query("address")
+	pools := make([]PoolInfoSerializer, 0)
+	poolstats.Each(func(name string, s poolstats.ISnapshotter) {
+		pools = append(pools, newPoolInfo(name, s, address))
+	})
+
+	c.JSON(http.StatusOK, gin.H{"pools": pools})
+}
+
+// GetPool 获取一个连接池每个目标节点的连接状态，支持使用 address 参数过滤目标地址
+func GetPool(c *gin.Context) {
+	name := c.Param("name")
+	s, ok := poolstats.Get(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pool not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, newPoolInfo(name, s, c.Query("address")))
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fengzhongzhu1621/xgo/ginx"
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolDebug(t *testing.T) {
+	t.Parallel()
+
+	poolstats.Register("handler-test", poolstats.SnapshotterFunc(func() []poolstats.NodeStats {
+		return []poolstats.NodeStats{
+			{Node: "tcp_127.0.0.1:8000", Network: "tcp", Address: "127.0.0.1:8000", Conns: 2, Idle: 1, Active: 1},
+			{Node: "tcp_127.0.0.1:8001", Network: "tcp", Address: "127.0.0.1:8001", Conns: 1, DropFulls: 3},
+		}
+	}))
+	defer poolstats.Unregister("handler-test")
+
+	// 注册路由
+	r := ginx.SetupRouter()
+	r.GET("/pools", ListPools)
+	r.GET("/pools/:name", GetPool)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pools", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Pools []PoolInfoSerializer `json:"pools"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	var names []string
+	for _, p := range list.Pools {
+		names = append(names, p.Name)
+	}
+	assert.Contains(t, names, "handler-test")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pools/handler-test", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var info PoolInfoSerializer
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, "handler-test", info.Name)
+	assert.Len(t, info.Nodes, 2)
+	assert.Equal(t, 1, info.Nodes[0].Active)
+
+	// 按目标地址过滤
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pools/handler-test?address=127.0.0.1:8001", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Len(t, info.Nodes, 1)
+	assert.Equal(t, uint64(3), info.Nodes[0].DropFulls)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pools/none", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -2,22 +2,84 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/fengzhongzhu1621/xgo/cron/scheduler"
 	"github.com/gin-gonic/gin"
 )
 
+// JobFuncMap 任务的执行函数，添加任务时注册到 scheduler
+//
+// Deprecated: 使用 scheduler.RegisterJobFunc 注册执行函数，执行函数可以返回错误触发重试，
+// 从存储中恢复的任务也能找到执行函数。
 var JobFuncMap = map[string]func(ctx context.Context){
 	"demo": func(ctx context.Context) {
 		println("Task executed")
 	},
 }
 
+func init() {
+	for name := range JobFuncMap {
+		registerJobFuncMap(name)
+	}
+}
+
+// registerJobFuncMap 将 JobFuncMap 中的执行函数注册到 scheduler，已经通过 scheduler.RegisterJobFunc 注册的优先
+func registerJobFuncMap(name string) {
+	if scheduler.GetJobFunc(name) != nil {
+		return
+	}
+	if fn := JobFuncMap[name]; fn != nil {
+		scheduler.RegisterJobFunc(name, func(ctx context.Context) error {
+			fn(ctx)
+			return nil
+		})
+	}
+}
+
 type TaskRequestSerializer struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
 	Schedule string `json:"schedule"`
+	Handler  string `json:"handler"` // 通过 scheduler.RegisterJobFunc 注册的执行函数，为空时使用 id
+	Retry    int    `json:"retry"`
+	Timeout  int    `json:"timeout"` // 每次执行的超时时间（秒）
+}
+
+// TaskResponseSerializer 任务列表的响应，字段和单位与 TaskRequestSerializer 相同，可以直接用于重新添加任务
+type TaskResponseSerializer struct {
+	TaskRequestSerializer
+	Status string `json:"status"`
+}
+
+// newTaskResponse 将任务转换为响应，超时时间转换为秒
+func newTaskResponse(t *scheduler.Task) TaskResponseSerializer {
+	return TaskResponseSerializer{
+		TaskRequestSerializer: TaskRequestSerializer{
+			ID:       t.ID,
+			Name:     t.Name,
+			Schedule: t.Schedule,
+			Handler:  t.Handler,
+			Retry:    t.Retry,
+			Timeout:  int(t.Timeout / time.Second),
+		},
+		Status: t.Status,
+	}
+}
+
+// taskError 返回任务操作失败的响应
+func taskError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, scheduler.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, scheduler.ErrJobFuncNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
 func AddTask(c *gin.Context) {
@@ -28,35 +90,79 @@ func AddTask(c *gin.Context) {
 		return
 	}
 
-	// 获得任务执行函数
-	jobFunc := JobFuncMap[req.ID]
-	if jobFunc == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "job func not found"})
-		return
+	// 兼容通过 JobFuncMap 设置的执行函数
+	handler := req.Handler
+	if handler == "" {
+		handler = req.ID
 	}
+	registerJobFuncMap(handler)
 
-	// 添加任务
+	// 添加任务，执行函数根据 Handler 查找
 	task := &scheduler.Task{
 		ID:       req.ID,
 		Name:     req.Name,
 		Schedule: req.Schedule,
-		JobFunc:  jobFunc,
+		Handler:  req.Handler,
+		Retry:    req.Retry,
+		Timeout:  time.Duration(req.Timeout) * time.Second,
 	}
 	sched := scheduler.GetScheduler()
 	err := sched.AddTask(task)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		taskError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "task added"})
 }
 
+// ListTasks 列出所有任务
+func ListTasks(c *gin.Context) {
+	sched := scheduler.GetScheduler()
+	tasks := sched.Tasks()
+	rsp := make([]TaskResponseSerializer, 0, len(tasks))
+	for _, t := range tasks {
+		rsp = append(rsp, newTaskResponse(t))
+	}
+	c.JSON(http.StatusOK, gin.H{"tasks": rsp})
+}
+
+// RemoveTask 删除任务及其执行记录
+func RemoveTask(c *gin.Context) {
+	id := c.Param("id")
+
+	sched := scheduler.GetScheduler()
+	if err := sched.RemoveTask(id); err != nil {
+		taskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "task removed"})
+}
+
+// ListTaskRuns 按开始时间倒序列出任务的执行记录，支持使用 limit 参数限制条数
+func ListTaskRuns(c *gin.Context) {
+	id := c.Param("id")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	sched := scheduler.GetScheduler()
+	runs, err := sched.Runs(c.Request.Context(), id, limit)
+	if err != nil {
+		taskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
 func RunNow(c *gin.Context) {
 	id := c.Param("id")
 
 	sched := scheduler.GetScheduler()
-	sched.RunNow(id)
+	if err := sched.RunNow(id); err != nil {
+		taskError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "task executed now"})
 }
@@ -65,8 +171,10 @@ func PauseTask(c *gin.Context) {
 	id := c.Param("id")
 
 	sched := scheduler.GetScheduler()
-
-	sched.PauseTask(id)
+	if err := sched.PauseTask(id); err != nil {
+		taskError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "task paused"})
 }
@@ -78,7 +186,7 @@ func ResumeTask(c *gin.Context) {
 
 	err := sched.ResumeTask(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		taskError(c, err)
 		return
 	}
 
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/cron/scheduler"
+	"github.com/fengzhongzhu1621/xgo/ginx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule(t *testing.T) {
+	scheduler.Init(nil)
+
+	// 注册路由
+	r := ginx.SetupRouter()
+	r.POST("/task", AddTask)
+	r.GET("/task", ListTasks)
+	r.DELETE("/task/:id", RemoveTask)
+	r.GET("/task/:id/runs", ListTaskRuns)
+	r.POST("/task/:id/run", RunNow)
+	r.POST("/task/:id/pause", PauseTask)
+	r.POST("/task/:id/resume", ResumeTask)
+	serve := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewReader(data)))
+		return w
+	}
+
+	w := serve(http.MethodPost, "/task", TaskRequestSerializer{ID: "demo", Schedule: "@every 1h", Retry: 1, Timeout: 5})
+	require.Equal(t, http.StatusOK, w.Code)
+	w = serve(http.MethodPost, "/task", TaskRequestSerializer{ID: "unknown", Schedule: "@every 1h"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 兼容通过 JobFuncMap 设置的执行函数
+	executed := make(chan struct{}, 1)
+	JobFuncMap["legacy"] = func(ctx context.Context) { executed <- struct{}{} }
+	defer delete(JobFuncMap, "legacy")
+	require.Equal(t, http.StatusOK, serve(http.MethodPost, "/task", TaskRequestSerializer{ID: "legacy", Schedule: "@every 1h"}).Code)
+	require.Equal(t, http.StatusOK, serve(http.MethodPost, "/task/legacy/run", nil).Code)
+	select {
+	case <-executed:
+	case <-time.After(time.Second):
+		t.Fatal("legacy job func not executed")
+	}
+	require.Equal(t, http.StatusOK, serve(http.MethodDelete, "/task/legacy", nil).Code)
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/task/demo/pause", nil).Code)
+	w = serve(http.MethodGet, "/task", nil)
+	var list struct {
+		Tasks []TaskResponseSerializer `json:"tasks"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Tasks, 1)
+	assert.Equal(t, "paused", list.Tasks[0].Status)
+	assert.Equal(t, 5, list.Tasks[0].Timeout)
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/task/demo/resume", nil).Code)
+
+	// 列表返回的任务可以原样重新添加，超时时间的单位相同
+	require.Equal(t, http.StatusOK, serve(http.MethodPost, "/task", list.Tasks[0].TaskRequestSerializer).Code)
+	assert.Equal(t, 5*time.Second, scheduler.GetScheduler().Tasks()[0].Timeout)
+
+	// 执行记录
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/task/demo/run", nil).Code)
+	assert.Eventually(t, func() bool {
+		w := serve(http.MethodGet, "/task/demo/runs?limit=10", nil)
+		var runs struct {
+			Runs []*scheduler.Run `json:"runs"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &runs)
+		return len(runs.Runs) == 1 && runs.Runs[0].EndTime != nil
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/task/unknown/run", nil).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/task/unknown/pause", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodDelete, "/task/demo", nil).Code)
+	w = serve(http.MethodGet, "/task", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Empty(t, list.Tasks)
+}
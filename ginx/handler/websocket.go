@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"github.com/fengzhongzhu1621/xgo/network/transport/ws"
+	"github.com/gin-gonic/gin"
+)
+
+// WebSocket 将请求升级为 WebSocket 连接并交给 ln，ln 需要通过 options.WithListener 传给 WebSocket 服务端传输
+func WebSocket(ln *ws.Listener) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ln.ServeHTTP(c.Writer, c.Request)
+		// 连接已经被接管，不再执行后续的处理函数
+		c.Abort()
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fengzhongzhu1621/xgo/codec"
+	"github.com/fengzhongzhu1621/xgo/ginx"
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/fengzhongzhu1621/xgo/network/transport/ws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineFramerBuilder 使用换行符分包
+type lineFramerBuilder struct{}
+
+func (fb *lineFramerBuilder) New(r io.Reader) codec.IFramer {
+	return &lineFramer{r: bufio.NewReader(r)}
+}
+
+type lineFramer struct {
+	r *bufio.Reader
+}
+
+func (f *lineFramer) ReadFrame() ([]byte, error) {
+	return f.r.ReadBytes('\n')
+}
+
+type upperHandler struct{}
+
+func (h *upperHandler) Handle(ctx context.Context, req []byte) ([]byte, error) {
+	return bytes.ToUpper(req), nil
+}
+
+func TestWebSocket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ln := ws.NewListener()
+	err := ws.NewServerTransport().ListenAndServe(ctx,
+		options.WithListener(ln),
+		options.WithHandler(&upperHandler{}),
+		options.WithServerFramerBuilder(&lineFramerBuilder{}),
+	)
+	require.NoError(t, err)
+
+	// 注册路由
+	r := ginx.SetupRouter()
+	r.GET("/ws", WebSocket(ln))
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	rsp, err := ws.NewClientTransport().RoundTrip(context.Background(), []byte("hello\n"),
+		options.WithDialAddress("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws"),
+		options.WithClientFramerBuilder(&lineFramerBuilder{}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO\n", string(rsp))
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"github.com/fengzhongzhu1621/xgo/config"
+	"github.com/fengzhongzhu1621/xgo/ginx/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterCache 注册缓存管理路由，可以查看和删除缓存的 key，非 debug 模式下需要与 pprof 相同的认证
+// 默认不注册，需要时由业务自行调用
+func RegisterCache(cfg *config.Config, router *gin.Engine) {
+	cacheRouter := router.Group("/debug/cache")
+	if !cfg.Debug {
+		cacheRouter.Use(gin.BasicAuth(cfg.PProf.Account))
+	}
+
+	cacheRouter.GET("", handler.ListCaches)
+	cacheRouter.GET("/:name", handler.GetCache)
+	cacheRouter.GET("/:name/keys/*key", handler.InspectCacheKey)
+	cacheRouter.DELETE("/:name/keys/*key", handler.EvictCacheKey)
+}
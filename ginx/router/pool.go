@@ -0,0 +1,19 @@
+package router
+
+import (
+	"github.com/fengzhongzhu1621/xgo/config"
+	"github.com/fengzhongzhu1621/xgo/ginx/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPool 注册连接池调试路由，可以查看每个目标节点的连接状态，非 debug 模式下需要与 pprof 相同的认证
+// 默认不注册，需要时由业务自行调用
+func RegisterPool(cfg *config.Config, router *gin.Engine) {
+	poolRouter := router.Group("/debug/pools")
+	if !cfg.Debug {
+		poolRouter.Use(gin.BasicAuth(cfg.PProf.Account))
+	}
+
+	poolRouter.GET("", handler.ListPools)
+	poolRouter.GET("/:name", handler.GetPool)
+}
@@ -9,6 +9,9 @@ import (
 // RegisterSchedule 注册cron调度任务路由
 func RegisterSchedule(cfg *config.Config, router *gin.Engine) {
 	router.POST("/task", handler.AddTask)
+	router.GET("/task", handler.ListTasks)
+	router.DELETE("/task/:id", handler.RemoveTask)
+	router.GET("/task/:id/runs", handler.ListTaskRuns)
 	router.POST("/task/:id/run", handler.RunNow)
 	router.POST("/task/:id/pause", handler.PauseTask)
 	router.POST("/task/:id/resume", handler.ResumeTask)
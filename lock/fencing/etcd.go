@@ -0,0 +1,88 @@
+package fencing
+
+import (
+	"context"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// EtcdMutex 基于 etcd concurrency.Mutex 的分布式锁。
+// 每次加锁创建一个有效期为 TTL 的租约，由 etcd 客户端每隔 TTL/3 自动续期，
+// 租约过期或者超过 TTL 没有续期成功时视为锁已丢失。fencing token 为加锁成功时的 etcd revision
+type EtcdMutex struct {
+	state
+	client *clientv3.Client
+	pfx    string
+	opts   *Options
+
+	session *concurrency.Session
+	mutex   *concurrency.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+var _ Mutex = (*EtcdMutex)(nil)
+
+// NewEtcdMutex 创建基于 etcd 的分布式锁，pfx 为锁的 key 前缀
+func NewEtcdMutex(client *clientv3.Client, pfx string, opt ...Option) *EtcdMutex {
+	return &EtcdMutex{state: newState(), client: client, pfx: pfx, opts: newOptions(opt...)}
+}
+
+// Lock implements Mutex.
+func (m *EtcdMutex) Lock(ctx context.Context) error {
+	m.op.Lock()
+	defer m.op.Unlock()
+	if m.isLocked() {
+		return ErrLocked
+	}
+
+	// 租约的有效期为秒级
+	ttl := int(max(m.opts.TTL/time.Second, 1))
+	session, err := concurrency.NewSession(m.client, concurrency.WithTTL(ttl))
+	if err != nil {
+		return err
+	}
+	mutex := concurrency.NewMutex(session, m.pfx)
+	if err := mutex.Lock(ctx); err != nil {
+		_ = session.Close()
+		return err
+	}
+
+	m.session, m.mutex = session, mutex
+	m.stop, m.done = make(chan struct{}), make(chan struct{})
+	lost := m.acquired(mutex.Header().Revision)
+	go m.watchdog(session, lost)
+	return nil
+}
+
+// watchdog 租约过期时取消 context
+func (m *EtcdMutex) watchdog(session *concurrency.Session, lost func()) {
+	defer close(m.done)
+	select {
+	case <-m.stop:
+	case <-session.Done():
+		lost()
+	}
+}
+
+// Unlock implements Mutex.
+func (m *EtcdMutex) Unlock(ctx context.Context) error {
+	m.op.Lock()
+	defer m.op.Unlock()
+	if !m.isLocked() {
+		return ErrNotLocked
+	}
+
+	close(m.stop)
+	<-m.done
+	lost := m.released()
+	err := m.mutex.Unlock(ctx)
+	// 撤销租约
+	_ = m.session.Close()
+	if lost {
+		return ErrLockLost
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+// Package fencing 提供自动续期、带有 fencing token 的分布式锁。
+//
+// 进程暂停（GC、换页、虚拟机迁移）期间锁可能已经过期并被其他客户端持有，
+// 恢复后的进程如果继续写入会破坏数据。Mutex 加锁成功后返回单调递增的 fencing token，
+// 下游存储只接受不小于已见过的最大 token 的写入；同时 Mutex.Context() 在锁丢失时立即取消，
+// 持有锁期间的操作应该使用这个 context。
+package fencing
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrLocked 重复加锁
+	ErrLocked = errors.New("fencing: already locked")
+	// ErrNotLocked 没有加锁
+	ErrNotLocked = errors.New("fencing: not locked")
+	// ErrLockLost 锁已过期或者被其他客户端持有
+	ErrLockLost = errors.New("fencing: lock lost")
+)
+
+// Mutex 自动续期的分布式锁，redis、etcd 和 zookeeper 的实现共享这个接口
+type Mutex interface {
+	// Lock 阻塞直到加锁成功或者 ctx 结束，加锁成功后在后台自动续期
+	Lock(ctx context.Context) error
+	// Unlock 停止续期并释放锁，锁已经丢失时返回 ErrLockLost
+	Unlock(ctx context.Context) error
+	// Context 返回持有锁期间有效的 context，锁丢失或者释放时取消，
+	// 锁丢失时 context.Cause 返回 ErrLockLost
+	Context() context.Context
+	// Token 返回最近一次加锁的 fencing token，每次加锁成功后单调递增
+	Token() int64
+}
+
+// state 各个实现共享的加锁状态
+type state struct {
+	// op 串行化 Lock 和 Unlock
+	op sync.Mutex
+
+	mu     sync.RWMutex
+	locked bool
+	token  int64
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+func newState() state {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(ErrNotLocked)
+	return state{ctx: ctx, cancel: cancel}
+}
+
+// Context 返回持有锁期间有效的 context
+func (s *state) Context() context.Context {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ctx
+}
+
+// Token 返回最近一次加锁的 fencing token
+func (s *state) Token() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.token
+}
+
+// acquired 记录加锁成功，返回锁丢失时调用的函数
+func (s *state) acquired(token int64) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locked, s.token = true, token
+	s.ctx, s.cancel = context.WithCancelCause(context.Background())
+	cancel := s.cancel
+	return func() { cancel(ErrLockLost) }
+}
+
+// isLocked 是否已经加锁
+func (s *state) isLocked() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.locked
+}
+
+// released 记录锁已释放，返回释放前锁是否已经丢失
+func (s *state) released() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lost := errors.Is(context.Cause(s.ctx), ErrLockLost)
+	s.locked = false
+	s.cancel(nil)
+	return lost
+}
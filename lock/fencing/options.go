@@ -0,0 +1,55 @@
+package fencing
+
+import "time"
+
+const (
+	defaultTTL           = 10 * time.Second
+	defaultRetryInterval = 100 * time.Millisecond
+)
+
+// Options 分布式锁的配置
+type Options struct {
+	// TTL 锁的有效期，看门狗每隔 TTL/3 续期一次，超过 TTL 没有续期成功时视为锁已丢失。
+	// etcd 实现中为租约的有效期（秒级），zookeeper 实现中为会话超时时间
+	TTL time.Duration
+	// RetryInterval 锁被其他客户端持有时重新加锁的间隔，仅 redis 实现使用
+	RetryInterval time.Duration
+	// FenceKey 保存 fencing token 的 key，仅 redis 实现使用。默认为 "{key}:fencing"，和锁的 key 在 redis 集群的同一个 slot，
+	// 锁的 key 已经有 hash tag 时为 "key:fencing"。自定义时需要保证和锁的 key 在同一个 slot
+	FenceKey string
+}
+
+// Option 设置分布式锁的配置
+type Option func(*Options)
+
+// WithTTL 设置锁的有效期
+func WithTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithRetryInterval 设置重新加锁的间隔
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.RetryInterval = interval
+	}
+}
+
+// WithFenceKey 设置保存 fencing token 的 key
+func WithFenceKey(key string) Option {
+	return func(o *Options) {
+		o.FenceKey = key
+	}
+}
+
+func newOptions(opt ...Option) *Options {
+	opts := &Options{
+		TTL:           defaultTTL,
+		RetryInterval: defaultRetryInterval,
+	}
+	for _, o := range opt {
+		o(opts)
+	}
+	return opts
+}
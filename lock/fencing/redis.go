@@ -0,0 +1,145 @@
+package fencing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/lock/redislock"
+)
+
+// RedisMutex 基于 redislock 的分布式锁。
+// 看门狗每隔 TTL/3 续期一次，续期时发现锁被其他客户端持有，或者超过 TTL 没有续期成功时视为锁已丢失。
+// fencing token 在加锁成功后由 lua 脚本在确认仍然持有锁的前提下原子递增
+type RedisMutex struct {
+	state
+	client *redislock.RedislockClient
+	key    string
+	opts   *Options
+
+	lock *redislock.Lock
+	stop chan struct{}
+	done chan struct{}
+}
+
+var _ Mutex = (*RedisMutex)(nil)
+
+// NewRedisMutex 创建基于 redis 的分布式锁，TTL 向下取整到秒，最小为 1 秒
+func NewRedisMutex(client *redislock.RedislockClient, key string, opt ...Option) *RedisMutex {
+	opts := newOptions(opt...)
+	// 续期使用 expire 命令，精度为秒
+	opts.TTL = max(opts.TTL.Truncate(time.Second), time.Second)
+	if opts.FenceKey == "" {
+		opts.FenceKey = defaultFenceKey(key)
+	}
+	return &RedisMutex{state: newState(), client: client, key: key, opts: opts}
+}
+
+// defaultFenceKey 返回默认的 fencing token key。lua 脚本同时访问锁和计数器，
+// redis 集群要求两个 key 在同一个 slot，因此使用锁的 key 作为 hash tag；锁的 key 已经有 hash tag 时直接追加后缀
+func defaultFenceKey(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key + ":fencing"
+		}
+	}
+	return "{" + key + "}:fencing"
+}
+
+// Lock implements Mutex.
+func (m *RedisMutex) Lock(ctx context.Context) error {
+	m.op.Lock()
+	defer m.op.Unlock()
+	if m.isLocked() {
+		return ErrLocked
+	}
+
+	start := time.Now()
+	lock, err := m.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	token, err := lock.Fence(ctx, m.opts.FenceKey)
+	if err != nil {
+		_ = lock.Release(context.Background())
+		return err
+	}
+
+	m.lock = lock
+	m.stop, m.done = make(chan struct{}), make(chan struct{})
+	lost := m.acquired(token)
+	go m.watchdog(lock, start, lost)
+	return nil
+}
+
+// acquire 加锁，锁被其他客户端持有时每隔 RetryInterval 重试
+func (m *RedisMutex) acquire(ctx context.Context) (*redislock.Lock, error) {
+	for {
+		lock, err := m.client.AcquireLock(ctx, m.key, m.opts.TTL, nil)
+		if err == nil {
+			return lock, nil
+		}
+		if !errors.Is(err, redislock.ErrNotObtained) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(m.opts.RetryInterval):
+		}
+	}
+}
+
+// watchdog 定时续期，start 为最后一次续期前的时间，锁最晚在 start + TTL 过期
+func (m *RedisMutex) watchdog(lock *redislock.Lock, start time.Time, lost func()) {
+	defer close(m.done)
+
+	interval := m.opts.TTL / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	expiry := time.NewTimer(time.Until(start.Add(m.opts.TTL)))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-expiry.C:
+			// 一直续期失败，无法确认是否仍然持有锁
+			lost()
+			return
+		case <-ticker.C:
+			renewStart := time.Now()
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := lock.Lease(ctx, m.opts.TTL, nil)
+			cancel()
+			if errors.Is(err, redislock.ErrNotObtained) {
+				lost()
+				return
+			}
+			if err == nil {
+				expiry.Reset(time.Until(renewStart.Add(m.opts.TTL)))
+			}
+		}
+	}
+}
+
+// Unlock implements Mutex.
+func (m *RedisMutex) Unlock(ctx context.Context) error {
+	m.op.Lock()
+	defer m.op.Unlock()
+	if !m.isLocked() {
+		return ErrNotLocked
+	}
+
+	close(m.stop)
+	<-m.done
+	lost := m.released()
+	err := m.lock.Release(ctx)
+	if lost || errors.Is(err, redislock.ErrLockNotHeld) {
+		return ErrLockLost
+	}
+	return err
+}
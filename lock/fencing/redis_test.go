@@ -0,0 +1,115 @@
+package fencing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fengzhongzhu1621/xgo/lock/redislock"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redisClient 补全 redislock.IRedisClient 中 go-redis 没有的方法
+type redisClient struct {
+	*redis.Client
+}
+
+func (c *redisClient) AcquireLock(context.Context, string, time.Duration, *redislock.IOptions) (*redislock.Lock, error) {
+	panic("not implemented")
+}
+
+func (c *redisClient) CheckUnLock(context.Context, string) (bool, error) {
+	panic("not implemented")
+}
+
+func (c *redisClient) ForceRelease(context.Context, string, string) (bool, error) {
+	panic("not implemented")
+}
+
+func newRedislockClient(t *testing.T) (*redislock.RedislockClient, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = cli.Close() })
+	return redislock.NewRedislockClient(&redisClient{Client: cli}), mr
+}
+
+func TestRedisMutex(t *testing.T) {
+	client, mr := newRedislockClient(t)
+	ctx := context.Background()
+	m1 := NewRedisMutex(client, "job", WithTTL(time.Second), WithRetryInterval(10*time.Millisecond))
+	m2 := NewRedisMutex(client, "job", WithTTL(time.Second), WithRetryInterval(10*time.Millisecond))
+
+	assert.ErrorIs(t, m1.Unlock(ctx), ErrNotLocked)
+	assert.Error(t, m1.Context().Err())
+
+	require.NoError(t, m1.Lock(ctx))
+	assert.Equal(t, int64(1), m1.Token())
+	assert.NoError(t, m1.Context().Err())
+	assert.ErrorIs(t, m1.Lock(ctx), ErrLocked)
+
+	// 看门狗续期后锁一直有效
+	mr.FastForward(900 * time.Millisecond)
+	time.Sleep(500 * time.Millisecond)
+	mr.FastForward(900 * time.Millisecond)
+	assert.True(t, mr.Exists("job"))
+	assert.NoError(t, m1.Context().Err())
+
+	// 锁被持有时等待
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, m2.Lock(timeout), context.DeadlineExceeded)
+
+	lockCtx := m1.Context()
+	require.NoError(t, m1.Unlock(ctx))
+	assert.ErrorIs(t, lockCtx.Err(), context.Canceled)
+	assert.NotErrorIs(t, context.Cause(lockCtx), ErrLockLost)
+
+	// 每次加锁 token 递增
+	require.NoError(t, m2.Lock(ctx))
+	assert.Equal(t, int64(2), m2.Token())
+	require.NoError(t, m2.Unlock(ctx))
+	require.NoError(t, m1.Lock(ctx))
+	assert.Equal(t, int64(3), m1.Token())
+	require.NoError(t, m1.Unlock(ctx))
+
+	// 计数器和锁使用相同的 hash tag，在 redis 集群的同一个 slot
+	v, err := mr.Get("{job}:fencing")
+	require.NoError(t, err)
+	assert.Equal(t, "3", v)
+	assert.Equal(t, "{order}:1:fencing", defaultFenceKey("{order}:1"))
+	assert.Equal(t, "{a{}b}:fencing", defaultFenceKey("a{}b"))
+}
+
+func TestRedisMutexLost(t *testing.T) {
+	client, mr := newRedislockClient(t)
+	ctx := context.Background()
+	m := NewRedisMutex(client, "job", WithTTL(time.Second))
+	require.NoError(t, m.Lock(ctx))
+
+	// 锁过期后被其他客户端持有，续期失败时立即取消 context
+	mr.Set("job", "other")
+	select {
+	case <-m.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("lock context is not cancelled")
+	}
+	assert.ErrorIs(t, context.Cause(m.Context()), ErrLockLost)
+	assert.ErrorIs(t, m.Unlock(ctx), ErrLockLost)
+	got, err := mr.Get("job")
+	require.NoError(t, err)
+	assert.Equal(t, "other", got)
+
+	// redis 不可用时超过 TTL 视为锁丢失
+	mr.Del("job")
+	require.NoError(t, m.Lock(ctx))
+	mr.Close()
+	select {
+	case <-m.Context().Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("lock context is not cancelled")
+	}
+	assert.ErrorIs(t, context.Cause(m.Context()), ErrLockLost)
+}
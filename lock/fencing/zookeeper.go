@@ -0,0 +1,204 @@
+package fencing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+)
+
+// ZkConn 分布式锁使用的 zookeeper 连接，*zk.Conn 实现了这个接口
+type ZkConn interface {
+	CreateProtectedEphemeralSequential(path string, data []byte, acl []zk.ACL) (string, error)
+	Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error)
+	Children(path string) ([]string, *zk.Stat, error)
+	ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
+	Delete(path string, version int32) error
+	State() zk.State
+}
+
+// ZkMutex 基于 zookeeper 临时顺序节点的分布式锁。
+// 会话由 zookeeper 客户端自动保持，锁节点被删除、会话过期或者断开连接超过 TTL 时视为锁已丢失。
+// fencing token 为锁节点的序号
+type ZkMutex struct {
+	state
+	conn ZkConn
+	path string
+	acl  []zk.ACL
+	opts *Options
+
+	node string
+	stop chan struct{}
+	done chan struct{}
+}
+
+var _ Mutex = (*ZkMutex)(nil)
+
+// NewZkMutex 创建基于 zookeeper 的分布式锁，path 为只用于这个锁的节点，TTL 应该等于会话超时时间
+func NewZkMutex(conn ZkConn, path string, acl []zk.ACL, opt ...Option) *ZkMutex {
+	return &ZkMutex{state: newState(), conn: conn, path: path, acl: acl, opts: newOptions(opt...)}
+}
+
+// Lock implements Mutex.
+func (m *ZkMutex) Lock(ctx context.Context) error {
+	m.op.Lock()
+	defer m.op.Unlock()
+	if m.isLocked() {
+		return ErrLocked
+	}
+
+	node, err := m.create()
+	if err != nil {
+		return err
+	}
+	seq, err := parseSeq(node)
+	if err == nil {
+		err = m.wait(ctx, seq)
+	}
+	if err != nil {
+		_ = m.conn.Delete(node, -1)
+		return err
+	}
+
+	m.node = node
+	m.stop, m.done = make(chan struct{}), make(chan struct{})
+	lost := m.acquired(seq)
+	go m.watchdog(node, lost)
+	return nil
+}
+
+// create 创建锁节点，父节点不存在时先创建父节点
+func (m *ZkMutex) create() (string, error) {
+	prefix := m.path + "/lock-"
+	node, err := m.conn.CreateProtectedEphemeralSequential(prefix, nil, m.acl)
+	if !errors.Is(err, zk.ErrNoNode) {
+		return node, err
+	}
+
+	var parent string
+	for _, p := range strings.Split(strings.Trim(m.path, "/"), "/") {
+		parent += "/" + p
+		if _, err := m.conn.Create(parent, nil, 0, m.acl); err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return "", err
+		}
+	}
+	return m.conn.CreateProtectedEphemeralSequential(prefix, nil, m.acl)
+}
+
+// wait 等待序号更小的锁节点全部删除
+func (m *ZkMutex) wait(ctx context.Context, seq int64) error {
+	for {
+		children, _, err := m.conn.Children(m.path)
+		if err != nil {
+			return err
+		}
+
+		// 监听序号小于 seq 的最大的节点
+		var prev string
+		var prevSeq int64 = -1
+		for _, child := range children {
+			s, err := parseSeq(child)
+			if err != nil {
+				continue
+			}
+			if s < seq && s > prevSeq {
+				prev, prevSeq = child, s
+			}
+		}
+		if prev == "" {
+			return nil
+		}
+
+		exists, _, ch, err := m.conn.ExistsW(m.path + "/" + prev)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-ch:
+			if ev.Err != nil {
+				return ev.Err
+			}
+		}
+	}
+}
+
+// watchdog 监听锁节点，节点被删除、会话过期或者断开连接超过 TTL 时取消 context
+func (m *ZkMutex) watchdog(node string, lost func()) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.opts.TTL / 3)
+	defer ticker.Stop()
+	var disconnected time.Time
+	for {
+		exists, _, ch, err := m.conn.ExistsW(node)
+		if err == nil && !exists {
+			lost()
+			return
+		}
+
+	wait:
+		for {
+			select {
+			case <-m.stop:
+				return
+			case ev := <-ch:
+				if ev.Type == zk.EventNodeDeleted || ev.Type == zk.EventNotWatching {
+					lost()
+					return
+				}
+				break wait
+			case <-ticker.C:
+				if m.conn.State() == zk.StateHasSession {
+					disconnected = time.Time{}
+					if err != nil {
+						// 重新连接后重新监听
+						break wait
+					}
+					continue
+				}
+				if disconnected.IsZero() {
+					disconnected = time.Now()
+				} else if time.Since(disconnected) >= m.opts.TTL {
+					lost()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Unlock implements Mutex.
+func (m *ZkMutex) Unlock(_ context.Context) error {
+	m.op.Lock()
+	defer m.op.Unlock()
+	if !m.isLocked() {
+		return ErrNotLocked
+	}
+
+	close(m.stop)
+	<-m.done
+	lost := m.released()
+	err := m.conn.Delete(m.node, -1)
+	if lost || errors.Is(err, zk.ErrNoNode) {
+		return ErrLockLost
+	}
+	return err
+}
+
+// parseSeq 解析锁节点的序号
+func parseSeq(node string) (int64, error) {
+	idx := strings.LastIndex(node, "lock-")
+	if idx < 0 {
+		return 0, fmt.Errorf("fencing: invalid lock node %s", node)
+	}
+	return strconv.ParseInt(node[idx+len("lock-"):], 10, 64)
+}
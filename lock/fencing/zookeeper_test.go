@@ -0,0 +1,174 @@
+package fencing
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeZkConn 内存中的 zookeeper 节点树
+type fakeZkConn struct {
+	mu       sync.Mutex
+	nodes    map[string]bool
+	seq      int
+	watchers map[string][]chan zk.Event
+	state    zk.State
+}
+
+func newFakeZkConn() *fakeZkConn {
+	return &fakeZkConn{
+		nodes:    map[string]bool{},
+		watchers: map[string][]chan zk.Event{},
+		state:    zk.StateHasSession,
+	}
+}
+
+func (c *fakeZkConn) CreateProtectedEphemeralSequential(p string, _ []byte, _ []zk.ACL) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.nodes[path.Dir(p)] {
+		return "", zk.ErrNoNode
+	}
+	c.seq++
+	node := fmt.Sprintf("%s/_c_guid-%s%010d", path.Dir(p), path.Base(p), c.seq)
+	c.nodes[node] = true
+	return node, nil
+}
+
+func (c *fakeZkConn) Create(p string, _ []byte, _ int32, _ []zk.ACL) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nodes[p] {
+		return "", zk.ErrNodeExists
+	}
+	c.nodes[p] = true
+	return p, nil
+}
+
+func (c *fakeZkConn) Children(p string) ([]string, *zk.Stat, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var children []string
+	for node := range c.nodes {
+		if path.Dir(node) == p {
+			children = append(children, path.Base(node))
+		}
+	}
+	return children, &zk.Stat{}, nil
+}
+
+func (c *fakeZkConn) ExistsW(p string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state != zk.StateHasSession {
+		return false, nil, nil, zk.ErrNoServer
+	}
+	ch := make(chan zk.Event, 1)
+	c.watchers[p] = append(c.watchers[p], ch)
+	return c.nodes[p], &zk.Stat{}, ch, nil
+}
+
+func (c *fakeZkConn) Delete(p string, _ int32) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.nodes[p] {
+		return zk.ErrNoNode
+	}
+	delete(c.nodes, p)
+	for _, ch := range c.watchers[p] {
+		ch <- zk.Event{Type: zk.EventNodeDeleted, Path: p}
+	}
+	delete(c.watchers, p)
+	return nil
+}
+
+func (c *fakeZkConn) State() zk.State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (c *fakeZkConn) setState(state zk.State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state = state
+}
+
+func (c *fakeZkConn) lockNode() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for node := range c.nodes {
+		if strings.Contains(node, "lock-") {
+			return node
+		}
+	}
+	return ""
+}
+
+func TestZkMutex(t *testing.T) {
+	conn := newFakeZkConn()
+	ctx := context.Background()
+	m1 := NewZkMutex(conn, "/locks/job", zk.WorldACL(zk.PermAll))
+	m2 := NewZkMutex(conn, "/locks/job", zk.WorldACL(zk.PermAll))
+
+	// 自动创建父节点
+	require.NoError(t, m1.Lock(ctx))
+	assert.Equal(t, int64(1), m1.Token())
+
+	locked := make(chan error)
+	go func() { locked <- m2.Lock(ctx) }()
+	select {
+	case <-locked:
+		t.Fatal("lock is held by m1")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, m1.Unlock(ctx))
+	require.NoError(t, <-locked)
+	assert.Equal(t, int64(2), m2.Token())
+	require.NoError(t, m2.Unlock(ctx))
+
+	// 等待超时时删除锁节点
+	require.NoError(t, m1.Lock(ctx))
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, m2.Lock(timeout), context.DeadlineExceeded)
+	children, _, _ := conn.Children("/locks/job")
+	assert.Len(t, children, 1)
+	require.NoError(t, m1.Unlock(ctx))
+}
+
+func TestZkMutexLost(t *testing.T) {
+	conn := newFakeZkConn()
+	ctx := context.Background()
+	m := NewZkMutex(conn, "/job", nil, WithTTL(30*time.Millisecond))
+
+	// 锁节点被删除
+	require.NoError(t, m.Lock(ctx))
+	require.NoError(t, conn.Delete(conn.lockNode(), -1))
+	select {
+	case <-m.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("lock context is not cancelled")
+	}
+	assert.ErrorIs(t, context.Cause(m.Context()), ErrLockLost)
+	assert.ErrorIs(t, m.Unlock(ctx), ErrLockLost)
+
+	// 断开连接超过 TTL
+	require.NoError(t, m.Lock(ctx))
+	conn.setState(zk.StateDisconnected)
+	select {
+	case <-m.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("lock context is not cancelled")
+	}
+	assert.ErrorIs(t, m.Unlock(ctx), ErrLockLost)
+}
@@ -99,6 +99,20 @@ func (l *Lock) Lease(ctx context.Context, ttl time.Duration, opt *IOptions) erro
 	return ErrNotObtained
 }
 
+// Fence 锁仍然被持有时原子地递增计数器 fenceKey，返回单调递增的 fencing token，
+// 下游存储可以拒绝 token 小于已见过的最大值的写入
+func (l *Lock) Fence(ctx context.Context, fenceKey string) (int64, error) {
+	res, err := luaFence.Run(ctx, l.client.client, []string{l.key, fenceKey}, l.value).Int64()
+	if err != nil {
+		return 0, err
+	}
+	if res < 0 {
+		// 锁已经过期或者被其他客户端持有
+		return 0, ErrLockNotHeld
+	}
+	return res, nil
+}
+
 // Release 释放 Redis 中特定键（KEYS[1]）的锁
 func (l *Lock) Release(ctx context.Context) error {
 	res, err := luaRelease.Run(ctx, l.client.client, []string{l.key}, l.value).Result()
@@ -35,6 +35,14 @@ var (
 		`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`,
 	)
 
+	// luaFence 锁仍然被持有时递增 fencing token
+	// 检查 Redis 中指定键（KEYS[1]）的值是否等于传入的参数（ARGV[1]）。
+	// 如果值相等，则使用 redis.call("incr", KEYS[2]) 递增计数器并返回新的值。
+	// 如果值不相等，则返回 -1，表示锁已经丢失。
+	luaFence = redis.NewScript(
+		`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("incr", KEYS[2]) else return -1 end`,
+	)
+
 	// ErrNotObtained 加锁冲突，且不会重复加锁
 	ErrNotObtained = errors.New("redislock: not obtained")
 
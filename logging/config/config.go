@@ -30,6 +30,31 @@ type LogOutputConfig struct {
 
 	// EnableColor determines if the output is colored. The default value is false.
 	EnableColor bool `yaml:"enable_color"`
+
+	// Sampling is the sampling, rate limiting and deduplication policy of the output.
+	Sampling SamplingConfig `yaml:"sampling"`
+}
+
+// SamplingConfig is the log sampling config of an output.
+type SamplingConfig struct {
+	// Initial is the number of logs with the same level and message template written in each interval.
+	// Sampling is disabled when it's 0.
+	Initial int `yaml:"initial"`
+	// Thereafter writes every Thereafter-th log after Initial in each interval, 0 drops all of them.
+	Thereafter int `yaml:"thereafter"`
+	// Interval is the sampling interval(ms), default as 1000.
+	Interval int `yaml:"interval"`
+	// RateLimits is the max number of logs per second of each level, like error: 100.
+	RateLimits map[string]int `yaml:"rate_limits"`
+	// Dedup determines if consecutive duplicate logs are suppressed and summarized as "repeated N times".
+	Dedup bool `yaml:"dedup"`
+	// DedupWindow is the max time(ms) to suppress duplicates before writing the summary, default as 1000.
+	DedupWindow int `yaml:"dedup_window"`
+}
+
+// Enabled returns whether any sampling policy is configured.
+func (c *SamplingConfig) Enabled() bool {
+	return c.Initial > 0 || len(c.RateLimits) > 0 || c.Dedup
 }
 
 // LogWriteConfig is the local file config.
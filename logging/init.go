@@ -9,6 +9,8 @@ func init() {
 	// 注册 log writer 插件
 	output.RegisterWriter(output.OutputConsole, DefaultConsoleWriterFactory)
 	output.RegisterWriter(output.OutputFile, DefaultFileWriterFactory)
+	output.RegisterWriter(output.OutputKafka, DefaultKafkaWriterFactory)
+	output.RegisterWriter(output.OutputHTTP, DefaultHTTPWriterFactory)
 	// 创建默认 logger 实例
 	Register(defaultLoggerName, NewZapLog(defaultConfig))
 	// 注册 log default 插件
@@ -1,9 +1,11 @@
 package output
 
-// output name, default support console and file.
+// output name, default support console, file, kafka and http.
 const (
 	OutputConsole = "console"
 	OutputFile    = "file"
+	OutputKafka   = "kafka"
+	OutputHTTP    = "http"
 )
 
 type WriteType string
@@ -0,0 +1,77 @@
+package shipper
+
+import (
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/logging/output/rollwriter"
+)
+
+// Config 日志发送的配置，对应 writer 的 remote_config
+type Config struct {
+	// URL http 日志收集服务的地址
+	URL string `yaml:"url"`
+	// Producer 通过 kafka.RegisterProducer 注册的生产者名称
+	Producer string `yaml:"producer"`
+	// Topic kafka topic
+	Topic string `yaml:"topic"`
+	// Headers http 请求头或者 kafka 消息头
+	Headers map[string]string `yaml:"headers"`
+	// Compression 压缩算法，支持 gzip，默认不压缩
+	Compression string `yaml:"compression"`
+	// Timeout 每次发送的超时时间（毫秒）
+	Timeout int `yaml:"timeout"`
+
+	// QueueSize 日志队列长度
+	QueueSize int `yaml:"queue_size"`
+	// BatchSize 批量发送的字节数
+	BatchSize int `yaml:"batch_size"`
+	// FlushInterval 发送间隔（毫秒）
+	FlushInterval int `yaml:"flush_interval"`
+	// MaxRetries 发送失败后的重试次数
+	MaxRetries *int `yaml:"max_retries"`
+	// RetryBackoff 第一次重试的等待时间（毫秒）
+	RetryBackoff int `yaml:"retry_backoff"`
+	// SpoolDir 保存发送失败日志的目录
+	SpoolDir string `yaml:"spool_dir"`
+	// MaxSpoolSize 磁盘中保存的日志的最大字节数（MB）
+	MaxSpoolSize int64 `yaml:"max_spool_size"`
+}
+
+// SinkOptions 返回发送目标的配置
+func (c *Config) SinkOptions() []SinkOption {
+	opts := []SinkOption{WithCompression(c.Compression), WithHeaders(c.Headers)}
+	if c.Timeout > 0 {
+		opts = append(opts, WithSendTimeout(time.Duration(c.Timeout)*time.Millisecond))
+	}
+	return opts
+}
+
+// Options 返回 ShipWriter 的配置，dropLog 为队列满时是否丢弃日志
+func (c *Config) Options(dropLog bool) []Option {
+	async := []rollwriter.AsyncOption{rollwriter.WithDropLog(dropLog)}
+	if c.QueueSize > 0 {
+		async = append(async, rollwriter.WithLogQueueSize(c.QueueSize))
+	}
+	if c.BatchSize > 0 {
+		async = append(async, rollwriter.WithWriteLogSize(c.BatchSize))
+	}
+	if c.FlushInterval > 0 {
+		async = append(async, rollwriter.WithWriteLogInterval(c.FlushInterval))
+	}
+
+	opts := []Option{WithAsyncOptions(async...)}
+	if c.MaxRetries != nil || c.RetryBackoff > 0 {
+		maxRetries, backoff := defaultMaxRetries, defaultRetryBackoff
+		if c.MaxRetries != nil {
+			maxRetries = *c.MaxRetries
+		}
+		if c.RetryBackoff > 0 {
+			backoff = time.Duration(c.RetryBackoff) * time.Millisecond
+		}
+		opts = append(opts, WithRetry(maxRetries, backoff))
+	}
+	if c.SpoolDir != "" {
+		opts = append(opts, WithSpool(c.SpoolDir, c.MaxSpoolSize*1024*1024))
+	}
+	return opts
+}
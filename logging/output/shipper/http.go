@@ -0,0 +1,66 @@
+package shipper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPSink 以 JSON lines 格式把日志 POST 到 http 日志收集服务
+type HTTPSink struct {
+	url    string
+	client *http.Client
+	opts   *sinkOptions
+}
+
+// NewHTTPSink 创建 http 日志收集服务的发送目标
+func NewHTTPSink(url string, opt ...SinkOption) *HTTPSink {
+	return &HTTPSink{url: url, client: &http.Client{}, opts: newSinkOptions(opt...)}
+}
+
+// Send implements Sink.
+// 2xx 表示成功，408、429 和 5xx 可以重试，其他状态码不可重试
+func (s *HTTPSink) Send(ctx context.Context, batch []byte) error {
+	body, err := compress(s.opts.compression, batch)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.opts.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.opts.compression != CompressionNone {
+		req.Header.Set("Content-Encoding", s.opts.compression)
+	}
+	for k, v := range s.opts.headers {
+		req.Header.Set(k, v)
+	}
+
+	rsp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, rsp.Body)
+
+	switch code := rsp.StatusCode; {
+	case code >= 200 && code < 300:
+		return nil
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests, code >= 500:
+		return fmt.Errorf("http sink: post %s status %d", s.url, code)
+	default:
+		return Permanent(fmt.Errorf("http sink: post %s status %d", s.url, code))
+	}
+}
+
+// Close implements Sink.
+func (s *HTTPSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,53 @@
+package shipper
+
+import (
+	"context"
+
+	"github.com/fengzhongzhu1621/xgo/db/kafka"
+)
+
+// KafkaSink 把日志发送到 kafka topic。不压缩时每条日志一条消息，
+// 压缩时一批日志压缩为一条消息，消息头 Content-Encoding 为压缩算法
+type KafkaSink struct {
+	producer kafka.Producer
+	topic    string
+	opts     *sinkOptions
+}
+
+// NewKafkaSink 创建 kafka 发送目标
+func NewKafkaSink(producer kafka.Producer, topic string, opt ...SinkOption) *KafkaSink {
+	return &KafkaSink{producer: producer, topic: topic, opts: newSinkOptions(opt...)}
+}
+
+// Send implements Sink.
+func (s *KafkaSink) Send(ctx context.Context, batch []byte) error {
+	var msgs []*kafka.Message
+	if s.opts.compression == CompressionNone {
+		for _, line := range splitLines(batch) {
+			msgs = append(msgs, &kafka.Message{Value: line, Headers: s.opts.headers})
+		}
+	} else {
+		value, err := compress(s.opts.compression, batch)
+		if err != nil {
+			return Permanent(err)
+		}
+		headers := map[string]string{"Content-Encoding": s.opts.compression}
+		for k, v := range s.opts.headers {
+			headers[k] = v
+		}
+		msgs = append(msgs, &kafka.Message{Value: value, Headers: headers})
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.opts.timeout)
+	defer cancel()
+	return s.producer.SendMessages(ctx, s.topic, msgs)
+}
+
+// Close implements Sink.
+// 生产者可能被多个组件共享，由创建方负责关闭
+func (s *KafkaSink) Close() error {
+	return nil
+}
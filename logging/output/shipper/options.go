@@ -0,0 +1,86 @@
+// Package shipper 将日志批量发送到远端的日志收集服务，例如 kafka 和 http 日志收集器。
+// 日志的排队、批量和丢弃与 rollwriter.AsyncRollWriter 的语义相同，发送失败时重试，
+// 重试仍然失败的日志保存到磁盘，在远端恢复后（包括进程重启后）按顺序补发。
+package shipper
+
+import (
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/logging/output/rollwriter"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 100 * time.Millisecond
+	defaultMaxBackoff   = 30 * time.Second
+	defaultMaxSpoolSize = 512 * 1024 * 1024 // 512MB
+	defaultSendTimeout  = 10 * time.Second
+)
+
+// Options ShipWriter 的配置
+type Options struct {
+	rollwriter.AsyncOptions
+
+	// MaxRetries 一批日志发送失败后的重试次数
+	MaxRetries int
+	// RetryBackoff 第一次重试的等待时间，之后每次翻倍，最长为 MaxBackoff
+	RetryBackoff time.Duration
+	// MaxBackoff 重试和补发的最长等待时间
+	MaxBackoff time.Duration
+	// SpoolDir 保存发送失败日志的目录，为空时发送失败的日志被丢弃
+	SpoolDir string
+	// MaxSpoolSize 磁盘中保存的日志的最大字节数，超过时删除最早的日志
+	MaxSpoolSize int64
+}
+
+// Option 设置 ShipWriter 的配置
+type Option func(*Options)
+
+func defaultOptions() *Options {
+	return &Options{
+		AsyncOptions: rollwriter.AsyncOptions{
+			LogQueueSize:     10000,
+			WriteLogSize:     64 * 1024, // 64KB
+			WriteLogInterval: 1000,
+			DropLog:          false,
+		},
+		MaxRetries:   defaultMaxRetries,
+		RetryBackoff: defaultRetryBackoff,
+		MaxBackoff:   defaultMaxBackoff,
+		MaxSpoolSize: defaultMaxSpoolSize,
+	}
+}
+
+// WithAsyncOptions 设置队列长度、批量大小、发送间隔以及队列满时是否丢弃日志
+func WithAsyncOptions(opt ...rollwriter.AsyncOption) Option {
+	return func(o *Options) {
+		for _, f := range opt {
+			f(&o.AsyncOptions)
+		}
+	}
+}
+
+// WithRetry 设置重试次数和第一次重试的等待时间
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(o *Options) {
+		o.MaxRetries = maxRetries
+		o.RetryBackoff = backoff
+	}
+}
+
+// WithMaxBackoff 设置重试和补发的最长等待时间
+func WithMaxBackoff(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxBackoff = d
+	}
+}
+
+// WithSpool 设置保存发送失败日志的目录和最大字节数
+func WithSpool(dir string, maxSize int64) Option {
+	return func(o *Options) {
+		o.SpoolDir = dir
+		if maxSize > 0 {
+			o.MaxSpoolSize = maxSize
+		}
+	}
+}
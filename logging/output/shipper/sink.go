@@ -0,0 +1,122 @@
+package shipper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 压缩算法
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+)
+
+// Sink 日志的发送目标
+type Sink interface {
+	// Send 发送一批日志，batch 为换行分隔的多条日志。返回 Permanent 包装的错误时不再重试
+	Send(ctx context.Context, batch []byte) error
+	// Close 关闭发送目标
+	Close() error
+}
+
+// permanentError 不可重试的错误，例如请求格式错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent 包装不可重试的错误，ShipWriter 收到该错误时直接丢弃这批日志
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 返回错误是否不可重试
+func IsPermanent(err error) bool {
+	var e *permanentError
+	return errors.As(err, &e)
+}
+
+// sinkOptions 发送目标的配置
+type sinkOptions struct {
+	compression string
+	timeout     time.Duration
+	headers     map[string]string
+}
+
+// SinkOption 设置发送目标的配置
+type SinkOption func(*sinkOptions)
+
+func newSinkOptions(opt ...SinkOption) *sinkOptions {
+	opts := &sinkOptions{timeout: defaultSendTimeout}
+	for _, o := range opt {
+		o(opts)
+	}
+	return opts
+}
+
+// WithCompression 设置压缩算法，支持 gzip
+func WithCompression(compression string) SinkOption {
+	return func(o *sinkOptions) {
+		o.compression = compression
+	}
+}
+
+// WithSendTimeout 设置每次发送的超时时间
+func WithSendTimeout(d time.Duration) SinkOption {
+	return func(o *sinkOptions) {
+		o.timeout = d
+	}
+}
+
+// WithHeaders 设置 http 请求头或者 kafka 消息头
+func WithHeaders(headers map[string]string) SinkOption {
+	return func(o *sinkOptions) {
+		o.headers = headers
+	}
+}
+
+// compress 按照配置的算法压缩数据
+func compress(compression string, data []byte) ([]byte, error) {
+	switch compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+}
+
+// splitLines 按换行分割日志，忽略空行
+func splitLines(batch []byte) [][]byte {
+	lines := bytes.Split(batch, []byte{'\n'})
+	res := lines[:0]
+	for _, line := range lines {
+		if len(bytes.TrimSpace(line)) > 0 {
+			res = append(res, line)
+		}
+	}
+	return res
+}
@@ -0,0 +1,112 @@
+package shipper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const spoolSuffix = ".spool"
+
+// spool 按顺序把发送失败的日志批次保存到磁盘，每个批次一个文件，文件名为递增的序号
+type spool struct {
+	dir     string
+	maxSize int64
+
+	files []spoolFile // 按序号排序的批次
+	size  int64
+	seq   uint64
+}
+
+type spoolFile struct {
+	seq  uint64
+	size int64
+}
+
+// openSpool 打开目录，加载上次进程退出时没有补发的批次
+func openSpool(dir string, maxSize int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create spool dir %s fail: %w", dir, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read spool dir %s fail: %w", dir, err)
+	}
+
+	s := &spool{dir: dir, maxSize: maxSize}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, spoolSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		s.files = append(s.files, spoolFile{seq: seq, size: info.Size()})
+		s.size += info.Size()
+		if seq > s.seq {
+			s.seq = seq
+		}
+	}
+	sort.Slice(s.files, func(i, j int) bool { return s.files[i].seq < s.files[j].seq })
+	return s, nil
+}
+
+func (s *spool) path(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, spoolSuffix))
+}
+
+// empty 返回是否没有待补发的批次
+func (s *spool) empty() bool {
+	return len(s.files) == 0
+}
+
+// put 保存一个批次，超过最大字节数时删除最早的批次，返回删除的批次数
+func (s *spool) put(batch []byte) (int, error) {
+	s.seq++
+	path := s.path(s.seq)
+	// 先写临时文件再重命名，避免进程退出时留下不完整的批次
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, batch, 0o644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	s.files = append(s.files, spoolFile{seq: s.seq, size: int64(len(batch))})
+	s.size += int64(len(batch))
+
+	dropped := 0
+	for s.size > s.maxSize && len(s.files) > 1 {
+		if err := s.pop(); err != nil {
+			return dropped, err
+		}
+		dropped++
+	}
+	return dropped, nil
+}
+
+// peek 读取最早的批次
+func (s *spool) peek() ([]byte, error) {
+	return os.ReadFile(s.path(s.files[0].seq))
+}
+
+// pop 删除最早的批次
+func (s *spool) pop() error {
+	f := s.files[0]
+	if err := os.Remove(s.path(f.seq)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	s.files = s.files[1:]
+	s.size -= f.size
+	return nil
+}
@@ -0,0 +1,208 @@
+package shipper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/opentelemetry/report"
+	"github.com/hashicorp/go-multierror"
+)
+
+// ShipWriter 批量发送日志到 Sink 的异步 writer，实现了 zapcore.WriteSyncer。
+// 队列满时根据 DropLog 阻塞写入或者丢弃日志；日志达到 WriteLogSize 或者每隔 WriteLogInterval 毫秒发送一次；
+// 发送失败时重试 MaxRetries 次，仍然失败的批次保存到 SpoolDir，之后的批次也先保存到磁盘，
+// 远端恢复后按顺序补发，进程重启后继续补发上次没有发送的批次
+type ShipWriter struct {
+	sink  Sink
+	opts  *Options
+	spool *spool
+
+	// 补发失败后的等待时间
+	replayBackoff time.Duration
+	nextReplay    time.Time
+
+	logQueue chan []byte
+	sync     chan struct{}
+	syncErr  chan error
+	close    chan struct{}
+	closeErr chan error
+}
+
+// NewShipWriter 创建批量发送日志的 writer
+func NewShipWriter(sink Sink, opt ...Option) (*ShipWriter, error) {
+	opts := defaultOptions()
+	for _, o := range opt {
+		o(opts)
+	}
+
+	w := &ShipWriter{
+		sink:     sink,
+		opts:     opts,
+		logQueue: make(chan []byte, opts.LogQueueSize),
+		sync:     make(chan struct{}),
+		syncErr:  make(chan error),
+		close:    make(chan struct{}),
+		closeErr: make(chan error),
+	}
+	if opts.SpoolDir != "" {
+		s, err := openSpool(opts.SpoolDir, opts.MaxSpoolSize)
+		if err != nil {
+			return nil, err
+		}
+		w.spool = s
+	}
+
+	go w.batchWriteLog()
+	return w, nil
+}
+
+// Write 写入日志数据，实现io.Writer接口
+func (w *ShipWriter) Write(data []byte) (int, error) {
+	log := make([]byte, len(data))
+	copy(log, data)
+	if w.opts.DropLog {
+		select {
+		case w.logQueue <- log:
+		default:
+			report.LogQueueDropNum.Incr()
+			return 0, errors.New("ship writer: log queue is full")
+		}
+		return len(data), nil
+	}
+
+	w.logQueue <- log
+	return len(data), nil
+}
+
+// Sync 发送缓冲区和队列中的日志，保存到磁盘的日志视为发送成功。It implements zapcore.WriteSyncer.
+func (w *ShipWriter) Sync() error {
+	w.sync <- struct{}{}
+	return <-w.syncErr
+}
+
+// Close 发送剩余的日志并关闭 Sink. It implements io.Closer.
+func (w *ShipWriter) Close() error {
+	err := w.Sync()
+	close(w.close)
+	return multierror.Append(err, <-w.closeErr).ErrorOrNil()
+}
+
+// batchWriteLog 批量发送日志
+func (w *ShipWriter) batchWriteLog() {
+	buffer := bytes.NewBuffer(make([]byte, 0, w.opts.WriteLogSize*2))
+	ticker := time.NewTicker(time.Millisecond * time.Duration(w.opts.WriteLogInterval))
+	defer ticker.Stop()
+
+	flush := func() error {
+		if buffer.Len() == 0 {
+			return nil
+		}
+		batch := make([]byte, buffer.Len())
+		copy(batch, buffer.Bytes())
+		buffer.Reset()
+		return w.ship(batch)
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			w.replay()
+			handleErr(flush(), "flush on tick")
+		case data := <-w.logQueue:
+			buffer.Write(data)
+			if buffer.Len() >= w.opts.WriteLogSize {
+				handleErr(flush(), "flush on log queue")
+			}
+		case <-w.sync:
+			size := len(w.logQueue)
+			for i := 0; i < size; i++ {
+				buffer.Write(<-w.logQueue)
+			}
+			w.syncErr <- flush()
+		case <-w.close:
+			w.closeErr <- w.sink.Close()
+			return
+		}
+	}
+}
+
+// ship 发送一批日志，有待补发的批次时先保存到磁盘以保证顺序
+func (w *ShipWriter) ship(batch []byte) error {
+	if w.spool != nil && !w.spool.empty() {
+		w.replay()
+		if !w.spool.empty() {
+			return w.save(batch)
+		}
+	}
+
+	err := w.send(batch)
+	if err == nil {
+		return nil
+	}
+	if IsPermanent(err) {
+		return fmt.Errorf("ship writer: drop %d bytes of logs: %w", len(batch), err)
+	}
+	w.nextReplay = time.Now().Add(w.opts.RetryBackoff)
+	if saveErr := w.save(batch); saveErr != nil {
+		return multierror.Append(err, saveErr)
+	}
+	return nil
+}
+
+// send 发送一批日志，失败时按指数退避重试
+func (w *ShipWriter) send(batch []byte) error {
+	backoff := w.opts.RetryBackoff
+	for i := 0; ; i++ {
+		err := w.sink.Send(context.Background(), batch)
+		if err == nil || IsPermanent(err) || i >= w.opts.MaxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff = min(backoff*2, w.opts.MaxBackoff)
+	}
+}
+
+// save 把发送失败的批次保存到磁盘，没有配置 SpoolDir 时丢弃
+func (w *ShipWriter) save(batch []byte) error {
+	if w.spool == nil {
+		return fmt.Errorf("ship writer: drop %d bytes of logs, spool is not configured", len(batch))
+	}
+	dropped, err := w.spool.put(batch)
+	if dropped > 0 {
+		handleErr(fmt.Errorf("drop %d oldest batches", dropped), "spool exceeds max size")
+	}
+	return err
+}
+
+// replay 按顺序补发磁盘中的批次，失败时等待一段时间后再补发
+func (w *ShipWriter) replay() {
+	if w.spool == nil || w.spool.empty() || time.Now().Before(w.nextReplay) {
+		return
+	}
+	for !w.spool.empty() {
+		batch, err := w.spool.peek()
+		if err == nil {
+			err = w.sink.Send(context.Background(), batch)
+		}
+		if err != nil && !IsPermanent(err) && !os.IsNotExist(err) {
+			w.replayBackoff = min(max(w.replayBackoff*2, w.opts.RetryBackoff), w.opts.MaxBackoff)
+			w.nextReplay = time.Now().Add(w.replayBackoff)
+			return
+		}
+		handleErr(err, "drop spooled logs")
+		handleErr(w.spool.pop(), "remove spooled logs")
+	}
+	w.replayBackoff = 0
+}
+
+func handleErr(err error, msg string) {
+	if err == nil {
+		return
+	}
+	// 日志输出本身失败，直接输出到标准错误
+	fmt.Fprintf(os.Stderr, "ship writer err: %+v, msg: %s\n", err, msg)
+}
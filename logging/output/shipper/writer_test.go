@@ -0,0 +1,241 @@
+package shipper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/db/kafka"
+	"github.com/fengzhongzhu1621/xgo/logging/output/rollwriter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSink 记录收到的批次，err 不为空时发送失败
+type fakeSink struct {
+	mu      sync.Mutex
+	err     error
+	block   chan struct{}
+	batches []string
+}
+
+func (s *fakeSink) Send(_ context.Context, batch []byte) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, string(batch))
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	return nil
+}
+
+func (s *fakeSink) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *fakeSink) content() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.batches, "")
+}
+
+func writeLines(t *testing.T, w io.Writer, from, to int) string {
+	var expected strings.Builder
+	for i := from; i < to; i++ {
+		line := fmt.Sprintf("{\"M\":\"log %d\"}\n", i)
+		_, err := w.Write([]byte(line))
+		require.NoError(t, err)
+		expected.WriteString(line)
+	}
+	return expected.String()
+}
+
+func TestShipWriterBatch(t *testing.T) {
+	sink := &fakeSink{}
+	w, err := NewShipWriter(sink, WithAsyncOptions(rollwriter.WithWriteLogSize(64), rollwriter.WithWriteLogInterval(10)))
+	require.NoError(t, err)
+
+	expected := writeLines(t, w, 0, 10)
+	require.NoError(t, w.Sync())
+	assert.Equal(t, expected, sink.content())
+
+	// 定时发送
+	expected += writeLines(t, w, 10, 11)
+	assert.Eventually(t, func() bool { return sink.content() == expected }, time.Second, 10*time.Millisecond)
+	require.NoError(t, w.Close())
+}
+
+func TestShipWriterSpool(t *testing.T) {
+	dir := t.TempDir()
+	sink := &fakeSink{err: errors.New("connection refused")}
+	opts := []Option{
+		WithAsyncOptions(rollwriter.WithWriteLogSize(64), rollwriter.WithWriteLogInterval(10)),
+		WithRetry(1, time.Millisecond),
+		WithSpool(dir, 0),
+	}
+	w, err := NewShipWriter(sink, opts...)
+	require.NoError(t, err)
+
+	// 发送失败的日志保存到磁盘
+	expected := writeLines(t, w, 0, 10)
+	require.NoError(t, w.Sync())
+	require.NoError(t, w.Close())
+	assert.Empty(t, sink.content())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.NotEmpty(t, entries)
+
+	// 重启后按顺序补发，新的日志在补发之后发送
+	sink.setErr(nil)
+	w, err = NewShipWriter(sink, opts...)
+	require.NoError(t, err)
+	expected += writeLines(t, w, 10, 20)
+	require.NoError(t, w.Sync())
+	assert.Eventually(t, func() bool { return sink.content() == expected }, time.Second, 10*time.Millisecond)
+	require.NoError(t, w.Close())
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestShipWriterSpoolMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	sink := &fakeSink{err: errors.New("connection refused")}
+	w, err := NewShipWriter(sink, WithRetry(0, time.Millisecond), WithSpool(dir, 1))
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		writeLines(t, w, i, i+1)
+		require.NoError(t, w.Sync())
+	}
+	require.NoError(t, w.Close())
+
+	// 超过最大字节数时只保留最新的批次
+	sink.setErr(nil)
+	w, err = NewShipWriter(sink, WithAsyncOptions(rollwriter.WithWriteLogInterval(10)), WithSpool(dir, 1))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return sink.content() == "{\"M\":\"log 2\"}\n" }, time.Second, 10*time.Millisecond)
+	require.NoError(t, w.Close())
+}
+
+func TestShipWriterDrop(t *testing.T) {
+	// 不可重试的错误和没有配置磁盘时直接丢弃
+	sink := &fakeSink{err: Permanent(errors.New("bad request"))}
+	w, err := NewShipWriter(sink)
+	require.NoError(t, err)
+	writeLines(t, w, 0, 1)
+	assert.Error(t, w.Sync())
+	require.NoError(t, w.Close())
+
+	sink.setErr(errors.New("connection refused"))
+	w, err = NewShipWriter(sink, WithRetry(0, time.Millisecond))
+	require.NoError(t, err)
+	writeLines(t, w, 0, 1)
+	assert.Error(t, w.Sync())
+	require.NoError(t, w.Close())
+
+	// 队列满时丢弃日志
+	sink = &fakeSink{block: make(chan struct{})}
+	w, err = NewShipWriter(sink, WithAsyncOptions(rollwriter.WithLogQueueSize(1), rollwriter.WithWriteLogSize(1),
+		rollwriter.WithDropLog(true)))
+	require.NoError(t, err)
+	var dropErr error
+	for i := 0; i < 10 && dropErr == nil; i++ {
+		_, dropErr = w.Write([]byte("log\n"))
+	}
+	assert.Error(t, dropErr)
+	close(sink.block)
+	require.NoError(t, w.Close())
+}
+
+func gunzip(t *testing.T, data []byte) string {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	res, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return string(res)
+}
+
+func TestHTTPSink(t *testing.T) {
+	status := http.StatusOK
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "token", r.Header.Get("X-Token"))
+		data, _ := io.ReadAll(r.Body)
+		body = gunzip(t, data)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := NewHTTPSink(srv.URL, WithCompression(CompressionGzip), WithHeaders(map[string]string{"X-Token": "token"}))
+	defer sink.Close()
+	ctx := context.Background()
+	require.NoError(t, sink.Send(ctx, []byte("a\nb\n")))
+	assert.Equal(t, "a\nb\n", body)
+
+	status = http.StatusServiceUnavailable
+	err := sink.Send(ctx, []byte("a\n"))
+	assert.Error(t, err)
+	assert.False(t, IsPermanent(err))
+
+	status = http.StatusBadRequest
+	assert.True(t, IsPermanent(sink.Send(ctx, []byte("a\n"))))
+}
+
+// fakeProducer 记录发送的消息
+type fakeProducer struct {
+	topic string
+	msgs  []*kafka.Message
+}
+
+func (p *fakeProducer) SendMessages(_ context.Context, topic string, msgs []*kafka.Message) error {
+	p.topic = topic
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func (p *fakeProducer) Close() error {
+	return nil
+}
+
+func TestKafkaSink(t *testing.T) {
+	ctx := context.Background()
+	p := &fakeProducer{}
+
+	// 不压缩时每条日志一条消息
+	require.NoError(t, NewKafkaSink(p, "logs").Send(ctx, []byte("a\n\nb\n")))
+	assert.Equal(t, "logs", p.topic)
+	require.Len(t, p.msgs, 2)
+	assert.Equal(t, "a", string(p.msgs[0].Value))
+	assert.Equal(t, "b", string(p.msgs[1].Value))
+
+	// 压缩时一批日志一条消息
+	p.msgs = nil
+	sink := NewKafkaSink(p, "logs", WithCompression(CompressionGzip), WithHeaders(map[string]string{"app": "xgo"}))
+	require.NoError(t, sink.Send(ctx, []byte("a\nb\n")))
+	require.Len(t, p.msgs, 1)
+	assert.Equal(t, map[string]string{"Content-Encoding": "gzip", "app": "xgo"}, p.msgs[0].Headers)
+	assert.Equal(t, "a\nb\n", gunzip(t, p.msgs[0].Value))
+
+	assert.True(t, IsPermanent(NewKafkaSink(p, "logs", WithCompression("lz4")).Send(ctx, []byte("a\n"))))
+}
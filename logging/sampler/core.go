@@ -0,0 +1,248 @@
+// Package sampler 在日志输出前进行采样、按级别限流和合并重复日志，防止日志风暴拖垮磁盘和日志收集服务
+package sampler
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/opentelemetry/report"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
+)
+
+// TemplateKey 日志模板字段的名称，该字段不会被输出
+const TemplateKey = "__log_template"
+
+// countersPerLevel 每个级别的计数器个数，不同模板哈希冲突时共用一个计数器
+const countersPerLevel = 4096
+
+// Template 返回携带日志模板的字段，采样时按模板而不是格式化后的日志计数。
+// 字段类型为 zapcore.SkipType，encoder 会忽略它
+func Template(format string) zap.Field {
+	return zap.Field{Key: TemplateKey, Type: zapcore.SkipType, String: format}
+}
+
+// NewCore 创建采样的 zapcore.Core，依次合并重复日志、按模板采样、按级别限流，
+// 丢弃的日志数量通过 report 上报。DPanic 及以上级别的日志不会被丢弃
+func NewCore(core zapcore.Core, opt ...Option) zapcore.Core {
+	opts := defaultOptions()
+	for _, o := range opt {
+		o(opts)
+	}
+
+	s := &sampler{opts: opts}
+	if opts.Initial > 0 {
+		s.counts = &counters{}
+	}
+	if len(opts.RateLimits) > 0 {
+		s.limiters = make(map[zapcore.Level]*rate.Limiter, len(opts.RateLimits))
+		for lvl, limit := range opts.RateLimits {
+			s.limiters[lvl] = rate.NewLimiter(rate.Limit(limit), limit)
+		}
+	}
+	if opts.Dedup {
+		s.dedup = &dedup{window: opts.DedupWindow}
+	}
+	return &sampleCore{Core: core, s: s}
+}
+
+// sampleCore 包装 zapcore.Core，With 创建的 core 共享同一个 sampler
+type sampleCore struct {
+	zapcore.Core
+	s *sampler
+}
+
+// With implements zapcore.Core.
+func (c *sampleCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sampleCore{Core: c.Core.With(fields), s: c.s}
+}
+
+// Check implements zapcore.Core.
+func (c *sampleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+// Write implements zapcore.Core.
+func (c *sampleCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	tmpl, fields := extractTemplate(ent.Message, fields)
+	if ent.Level >= zapcore.DPanicLevel {
+		return c.Core.Write(ent, fields)
+	}
+
+	if c.s.dedup != nil {
+		summary, ok := c.s.dedup.check(ent, c.Core, fields, c.s.opts.now())
+		if summary != nil {
+			_ = summary.write()
+		}
+		if !ok {
+			report.LogDedupDropNum.Incr()
+			return nil
+		}
+	}
+	if !c.s.sample(ent.Level, tmpl) {
+		report.LogSampledDropNum.Incr()
+		return nil
+	}
+	if !c.s.allow(ent.Level) {
+		report.LogRateLimitDropNum.Incr()
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// Sync implements zapcore.Core.
+// 先输出还没有输出的重复日志汇总
+func (c *sampleCore) Sync() error {
+	if c.s.dedup != nil {
+		if summary := c.s.dedup.flush(); summary != nil {
+			_ = summary.write()
+		}
+	}
+	return c.Core.Sync()
+}
+
+// extractTemplate 取出并删除模板字段，没有模板字段时以日志内容为模板
+func extractTemplate(msg string, fields []zapcore.Field) (string, []zapcore.Field) {
+	for i := range fields {
+		if fields[i].Key == TemplateKey && fields[i].Type == zapcore.SkipType {
+			res := make([]zapcore.Field, 0, len(fields)-1)
+			res = append(res, fields[:i]...)
+			return fields[i].String, append(res, fields[i+1:]...)
+		}
+	}
+	return msg, fields
+}
+
+// sampler 保存采样、限流和去重的状态
+type sampler struct {
+	opts     *Options
+	counts   *counters
+	limiters map[zapcore.Level]*rate.Limiter
+	dedup    *dedup
+}
+
+// sample 每个周期内同一级别、同一模板的日志先输出 Initial 条，之后每 Thereafter 条输出一条
+func (s *sampler) sample(lvl zapcore.Level, tmpl string) bool {
+	if s.counts == nil {
+		return true
+	}
+	n := s.counts.get(lvl, tmpl).incr(s.opts.now().UnixNano(), int64(s.opts.Interval))
+	if n <= uint64(s.opts.Initial) {
+		return true
+	}
+	return s.opts.Thereafter > 0 && (n-uint64(s.opts.Initial))%uint64(s.opts.Thereafter) == 0
+}
+
+// allow 按级别的令牌桶限流
+func (s *sampler) allow(lvl zapcore.Level) bool {
+	limiter, ok := s.limiters[lvl]
+	if !ok {
+		return true
+	}
+	return limiter.AllowN(s.opts.now(), 1)
+}
+
+type counters [zapcore.FatalLevel - zapcore.DebugLevel + 1][countersPerLevel]counter
+
+func (cs *counters) get(lvl zapcore.Level, tmpl string) *counter {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(tmpl))
+	return &cs[lvl-zapcore.DebugLevel][h.Sum32()%countersPerLevel]
+}
+
+// counter 一个采样周期内的日志计数
+type counter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+// incr 计数加一并返回周期内的计数，周期结束后重新计数
+func (c *counter) incr(now, interval int64) uint64 {
+	resetAt := c.resetAt.Load()
+	if resetAt > now {
+		return c.n.Add(1)
+	}
+	c.n.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, now+interval) {
+		// 其他协程已经开始新的周期
+		return c.n.Add(1)
+	}
+	return 1
+}
+
+// dedup 合并连续重复的日志
+type dedup struct {
+	mu     sync.Mutex
+	window time.Duration
+	last   *pending
+	timer  *time.Timer
+}
+
+// pending 最后一条输出的日志和之后被合并的次数
+type pending struct {
+	ent    zapcore.Entry
+	core   zapcore.Core
+	fields []zapcore.Field
+	first  time.Time
+	count  int
+}
+
+// write 输出 "repeated N times" 的汇总日志
+func (p *pending) write() error {
+	ent := p.ent
+	ent.Message = fmt.Sprintf("%s (repeated %d times)", p.ent.Message, p.count)
+	ent.Time = time.Now()
+	return p.core.Write(ent, p.fields)
+}
+
+// check 判断日志是否和上一条重复，重复时返回 false；
+// 日志不重复或者超过合并时间时返回需要先输出的汇总日志
+func (d *dedup) check(ent zapcore.Entry, core zapcore.Core, fields []zapcore.Field, now time.Time) (*pending, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if last := d.last; last != nil && last.ent.Level == ent.Level && last.ent.Message == ent.Message &&
+		now.Sub(last.first) < d.window {
+		last.count++
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.window-now.Sub(last.first), d.flushAndWrite)
+		}
+		return nil, false
+	}
+
+	summary := d.take()
+	d.last = &pending{ent: ent, core: core, fields: fields, first: now}
+	return summary, true
+}
+
+// flush 返回还没有输出的汇总日志
+func (d *dedup) flush() *pending {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.take()
+}
+
+func (d *dedup) flushAndWrite() {
+	if summary := d.flush(); summary != nil {
+		_ = summary.write()
+	}
+}
+
+func (d *dedup) take() *pending {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.last == nil || d.last.count == 0 {
+		return nil
+	}
+	summary := *d.last
+	d.last.count = 0
+	return &summary
+}
@@ -0,0 +1,152 @@
+package sampler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/logging/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newLogger(opt ...Option) (*zap.Logger, *observer.ObservedLogs, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	core, logs := observer.New(zap.DebugLevel)
+	opt = append(opt, withClock(clock.Now))
+	return zap.New(NewCore(core, opt...)), logs, clock
+}
+
+func messages(logs *observer.ObservedLogs) []string {
+	var res []string
+	for _, e := range logs.TakeAll() {
+		res = append(res, e.Message)
+	}
+	return res
+}
+
+func TestSampling(t *testing.T) {
+	logger, logs, clock := newLogger(WithSampling(2, 3, time.Second))
+
+	// 同一模板的日志先输出 2 条，之后每 3 条输出 1 条
+	for i := 1; i <= 8; i++ {
+		logger.Info("user login", Template("user %d login"), zap.Int("i", i))
+	}
+	entries := logs.TakeAll()
+	assert.Len(t, entries, 4)
+	for _, e := range entries {
+		// 模板字段不会被输出
+		assert.Len(t, e.Context, 1)
+	}
+	assert.Equal(t, int64(8), entries[3].Context[0].Integer)
+
+	// 不同级别、不同模板分别计数
+	logger.Warn("user login", Template("user %d login"))
+	logger.Info("other")
+	assert.Len(t, logs.TakeAll(), 2)
+
+	// 新的周期重新计数
+	clock.Add(time.Second)
+	logger.Info("user login", Template("user %d login"))
+	assert.Len(t, logs.TakeAll(), 1)
+
+	// DPanic 及以上级别不丢弃
+	l, logs, _ := newLogger(WithSampling(1, 0, time.Second))
+	l.Info("a")
+	l.Info("a")
+	l.DPanic("b")
+	l.DPanic("b")
+	assert.Equal(t, []string{"a", "b", "b"}, messages(logs))
+}
+
+func TestRateLimit(t *testing.T) {
+	logger, logs, clock := newLogger(WithRateLimit(zapcore.ErrorLevel, 2))
+	for i := 0; i < 5; i++ {
+		logger.Error("error")
+		logger.Info("info")
+	}
+	assert.Equal(t, 2, logs.FilterMessage("error").Len())
+	assert.Equal(t, 5, logs.FilterMessage("info").Len())
+	logs.TakeAll()
+
+	clock.Add(500 * time.Millisecond)
+	logger.Error("error")
+	logger.Error("error")
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestDedup(t *testing.T) {
+	logger, logs, clock := newLogger(WithDedup(time.Second))
+	logger = logger.With(zap.String("k", "v"))
+
+	for i := 0; i < 3; i++ {
+		logger.Warn("db timeout")
+	}
+	logger.Warn("other")
+	assert.Equal(t, []string{"db timeout", "db timeout (repeated 2 times)", "other"}, messages(logs))
+
+	// 超过合并时间后输出汇总，并重新开始合并
+	logger.Warn("other")
+	clock.Add(time.Second)
+	logger.Warn("other")
+	logger.Warn("other")
+	assert.NoError(t, logger.Sync())
+	entries := logs.TakeAll()
+	assert.Equal(t, []string{"other (repeated 1 times)", "other", "other (repeated 1 times)"},
+		[]string{entries[0].Message, entries[1].Message, entries[2].Message})
+	assert.Equal(t, []zapcore.Field{zap.String("k", "v")}, entries[2].Context)
+
+	// 不同级别不合并
+	logger.Info("x")
+	logger.Warn("x")
+	assert.Equal(t, []string{"x", "x"}, messages(logs))
+}
+
+func TestDedupTimer(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(NewCore(core, WithDedup(20*time.Millisecond)))
+	logger.Info("a")
+	logger.Info("a")
+	assert.Eventually(t, func() bool {
+		return logs.FilterMessage("a (repeated 1 times)").Len() == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestConfigOptions(t *testing.T) {
+	opts := defaultOptions()
+	for _, o := range ConfigOptions(&config.SamplingConfig{
+		Initial:     10,
+		Thereafter:  100,
+		RateLimits:  map[string]int{"error": 50, "unknown": 1},
+		Dedup:       true,
+		DedupWindow: 2000,
+	}) {
+		o(opts)
+	}
+	assert.Equal(t, 10, opts.Initial)
+	assert.Equal(t, 100, opts.Thereafter)
+	assert.Equal(t, time.Second, opts.Interval)
+	assert.Equal(t, map[zapcore.Level]int{zapcore.ErrorLevel: 50}, opts.RateLimits)
+	assert.True(t, opts.Dedup)
+	assert.Equal(t, 2*time.Second, opts.DedupWindow)
+}
@@ -0,0 +1,98 @@
+package sampler
+
+import (
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/logging/config"
+	"github.com/fengzhongzhu1621/xgo/logging/level"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultInterval    = time.Second
+	defaultDedupWindow = time.Second
+)
+
+// Options 日志采样、限流和去重的配置
+type Options struct {
+	// Initial 每个周期内同一级别、同一模板的日志前 Initial 条全部输出，为 0 时不采样
+	Initial int
+	// Thereafter 超过 Initial 条后每 Thereafter 条输出一条，为 0 时全部丢弃
+	Thereafter int
+	// Interval 采样周期
+	Interval time.Duration
+	// RateLimits 每个级别每秒最多输出的日志条数
+	RateLimits map[zapcore.Level]int
+	// Dedup 是否合并连续重复的日志
+	Dedup bool
+	// DedupWindow 合并重复日志的最长时间，超过后输出 "repeated N times" 的汇总日志
+	DedupWindow time.Duration
+
+	now func() time.Time
+}
+
+// Option 设置采样配置
+type Option func(*Options)
+
+// WithSampling 设置每个周期内先输出 initial 条，之后每 thereafter 条输出一条
+func WithSampling(initial, thereafter int, interval time.Duration) Option {
+	return func(o *Options) {
+		o.Initial = initial
+		o.Thereafter = thereafter
+		if interval > 0 {
+			o.Interval = interval
+		}
+	}
+}
+
+// WithRateLimit 设置级别 lvl 每秒最多输出 limit 条日志
+func WithRateLimit(lvl zapcore.Level, limit int) Option {
+	return func(o *Options) {
+		if o.RateLimits == nil {
+			o.RateLimits = make(map[zapcore.Level]int)
+		}
+		o.RateLimits[lvl] = limit
+	}
+}
+
+// WithDedup 合并 window 时间内连续重复的日志
+func WithDedup(window time.Duration) Option {
+	return func(o *Options) {
+		o.Dedup = true
+		if window > 0 {
+			o.DedupWindow = window
+		}
+	}
+}
+
+// withClock 设置时钟，用于测试
+func withClock(now func() time.Time) Option {
+	return func(o *Options) {
+		o.now = now
+	}
+}
+
+func defaultOptions() *Options {
+	return &Options{
+		Interval:    defaultInterval,
+		DedupWindow: defaultDedupWindow,
+		now:         time.Now,
+	}
+}
+
+// ConfigOptions 把 writer 的 sampling 配置转换为采样配置
+func ConfigOptions(c *config.SamplingConfig) []Option {
+	var opts []Option
+	if c.Initial > 0 {
+		opts = append(opts, WithSampling(c.Initial, c.Thereafter, time.Duration(c.Interval)*time.Millisecond))
+	}
+	for name, limit := range c.RateLimits {
+		if lvl, ok := level.Levels[name]; ok && limit > 0 {
+			opts = append(opts, WithRateLimit(lvl, limit))
+		}
+	}
+	if c.Dedup {
+		opts = append(opts, WithDedup(time.Duration(c.DedupWindow)*time.Millisecond))
+	}
+	return opts
+}
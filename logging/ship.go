@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fengzhongzhu1621/xgo/db/kafka"
+	"github.com/fengzhongzhu1621/xgo/logging/config"
+	"github.com/fengzhongzhu1621/xgo/logging/output"
+	"github.com/fengzhongzhu1621/xgo/logging/output/shipper"
+	"github.com/fengzhongzhu1621/xgo/logging/zaplogger"
+	"github.com/fengzhongzhu1621/xgo/plugin"
+)
+
+var (
+	// DefaultKafkaWriterFactory is the default kafka output implementation.
+	DefaultKafkaWriterFactory = &KafkaWriterFactory{}
+	// DefaultHTTPWriterFactory is the default http collector output implementation.
+	DefaultHTTPWriterFactory = &HTTPWriterFactory{}
+)
+
+// KafkaWriterFactory is the kafka writer instance Factory.
+// The producer is registered by kafka.RegisterProducer and referred by remote_config.producer.
+type KafkaWriterFactory struct {
+}
+
+// Type returns the log plugin type.
+func (f *KafkaWriterFactory) Type() string {
+	return pluginType
+}
+
+// Setup starts, loads and registers kafka output writer.
+func (f *KafkaWriterFactory) Setup(name string, dec plugin.IDecoder) error {
+	decoder, cfg, remote, err := decodeShipConfig(dec)
+	if err != nil {
+		return fmt.Errorf("kafka writer: %w", err)
+	}
+	producer := kafka.GetProducer(remote.Producer)
+	if producer == nil {
+		return fmt.Errorf("kafka writer: producer %q not registered", remote.Producer)
+	}
+	if remote.Topic == "" {
+		return errors.New("kafka writer: topic should not be empty")
+	}
+	return setupShipCore(decoder, cfg, remote, shipper.NewKafkaSink(producer, remote.Topic, remote.SinkOptions()...))
+}
+
+// HTTPWriterFactory is the http collector writer instance Factory, logs are posted as JSON lines.
+type HTTPWriterFactory struct {
+}
+
+// Type returns the log plugin type.
+func (f *HTTPWriterFactory) Type() string {
+	return pluginType
+}
+
+// Setup starts, loads and registers http output writer.
+func (f *HTTPWriterFactory) Setup(name string, dec plugin.IDecoder) error {
+	decoder, cfg, remote, err := decodeShipConfig(dec)
+	if err != nil {
+		return fmt.Errorf("http writer: %w", err)
+	}
+	if remote.URL == "" {
+		return errors.New("http writer: url should not be empty")
+	}
+	return setupShipCore(decoder, cfg, remote, shipper.NewHTTPSink(remote.URL, remote.SinkOptions()...))
+}
+
+// decodeShipConfig decodes the output config and its remote_config.
+func decodeShipConfig(dec plugin.IDecoder) (*Decoder, *config.LogOutputConfig, *shipper.Config, error) {
+	if dec == nil {
+		return nil, nil, nil, errors.New("decoder empty")
+	}
+	decoder, ok := dec.(*Decoder)
+	if !ok {
+		return nil, nil, nil, errors.New("log decoder type invalid")
+	}
+	cfg := &config.LogOutputConfig{}
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, nil, nil, err
+	}
+	remote := &shipper.Config{}
+	if err := cfg.RemoteConfig.Decode(remote); err != nil {
+		return nil, nil, nil, fmt.Errorf("decode remote_config: %w", err)
+	}
+	return decoder, cfg, remote, nil
+}
+
+// setupShipCore creates the ship writer, logs are dropped on queue full in WriteFast mode,
+// otherwise the writing blocks.
+func setupShipCore(decoder *Decoder, cfg *config.LogOutputConfig, remote *shipper.Config, sink shipper.Sink) error {
+	dropLog := cfg.WriteConfig.WriteMode == 0 || cfg.WriteConfig.WriteMode == output.WriteFast
+	writer, err := shipper.NewShipWriter(sink, remote.Options(dropLog)...)
+	if err != nil {
+		return err
+	}
+	decoder.Core, decoder.ZapLevel = zaplogger.NewWriterCore(cfg, writer)
+	return nil
+}
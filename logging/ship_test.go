@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fengzhongzhu1621/xgo/logging/config"
+	"github.com/fengzhongzhu1621/xgo/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestHTTPWriterFactory(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		body strings.Builder
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		body.Write(data)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var remote yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("url: "+srv.URL+"\nflush_interval: 10\n"), &remote))
+	logger := NewZapLog([]config.LogOutputConfig{{
+		Writer:       "http",
+		Formatter:    "json",
+		Level:        "info",
+		RemoteConfig: remote,
+	}})
+	logger.Info("shipped")
+	require.NoError(t, logger.Sync())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, body.String(), `"M":"shipped"`)
+}
+
+func TestShipWriterFactoryInvalid(t *testing.T) {
+	for _, f := range []plugin.IFactory{DefaultKafkaWriterFactory, DefaultHTTPWriterFactory} {
+		assert.Equal(t, "log", f.Type())
+		assert.Error(t, f.Setup("default", nil))
+		assert.Error(t, f.Setup("default", &fakeDecoder{}))
+		// 缺少 url 或者 producer
+		assert.Error(t, f.Setup("default", &Decoder{OutputConfig: &config.LogOutputConfig{}}))
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/fengzhongzhu1621/xgo/logging/config"
 	"github.com/fengzhongzhu1621/xgo/logging/level"
 	"github.com/fengzhongzhu1621/xgo/logging/output"
+	"github.com/fengzhongzhu1621/xgo/logging/sampler"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -27,8 +28,9 @@ func NewZapLog(c config.LogOutputConfigs) ILogger {
 // NewZapLogWithCallerSkip creates a trpc default Logger from zap.
 func NewZapLogWithCallerSkip(cfg config.LogOutputConfigs, callerSkip int) ILogger {
 	var (
-		cores  []zapcore.Core
-		levels []zap.AtomicLevel
+		cores    []zapcore.Core
+		levels   []zap.AtomicLevel
+		template bool
 	)
 	for _, c := range cfg {
 		// 根据插件名称从插件仓库获取 writer 插件
@@ -41,11 +43,18 @@ func NewZapLogWithCallerSkip(cfg config.LogOutputConfigs, callerSkip int) ILogge
 		if err := writer.Setup(c.Writer, decoder); err != nil {
 			panic("log: writer core: " + c.Writer + " setup fail: " + err.Error())
 		}
-		cores = append(cores, decoder.Core)
+		core := decoder.Core
+		if c.Sampling.Enabled() {
+			// 按 output 的配置采样、限流和合并重复日志
+			core = sampler.NewCore(core, sampler.ConfigOptions(&c.Sampling)...)
+			template = true
+		}
+		cores = append(cores, core)
 		levels = append(levels, decoder.ZapLevel)
 	}
 	return &zapLog{
-		levels: levels,
+		levels:   levels,
+		template: template,
 		logger: zap.New(
 			zapcore.NewTee(cores...),
 			zap.AddCallerSkip(callerSkip),
@@ -58,6 +67,8 @@ func NewZapLogWithCallerSkip(cfg config.LogOutputConfigs, callerSkip int) ILogge
 type zapLog struct {
 	levels []zap.AtomicLevel
 	logger *zap.Logger
+	// template 为 true 时 *f 方法把日志模板传给采样的 core
+	template bool
 }
 
 func (l *zapLog) WithOptions(opts ...Option) ILogger {
@@ -66,8 +77,9 @@ func (l *zapLog) WithOptions(opts ...Option) ILogger {
 		opt(o)
 	}
 	return &zapLog{
-		levels: l.levels,
-		logger: l.logger.WithOptions(zap.AddCallerSkip(o.skip)),
+		levels:   l.levels,
+		logger:   l.logger.WithOptions(zap.AddCallerSkip(o.skip)),
+		template: l.template,
 	}
 }
 
@@ -79,8 +91,10 @@ func (l *zapLog) With(fields ...Field) ILogger {
 	}
 
 	return &zapLog{
-		levels: l.levels,
-		logger: l.logger.With(zapFields...)}
+		levels:   l.levels,
+		logger:   l.logger.With(zapFields...),
+		template: l.template,
+	}
 }
 
 // Trace logs to TRACE log. Arguments are handled in the manner of fmt.Println.
@@ -93,7 +107,7 @@ func (l *zapLog) Trace(args ...interface{}) {
 // Tracef logs to TRACE log. Arguments are handled in the manner of fmt.Printf.
 func (l *zapLog) Tracef(format string, args ...interface{}) {
 	if l.logger.Core().Enabled(zapcore.DebugLevel) {
-		l.logger.Debug(getLogMsgf(format, args...))
+		l.logger.Debug(getLogMsgf(format, args...), l.templateFields(format)...)
 	}
 }
 
@@ -107,7 +121,7 @@ func (l *zapLog) Debug(args ...interface{}) {
 // Debugf logs to DEBUG log. Arguments are handled in the manner of fmt.Printf.
 func (l *zapLog) Debugf(format string, args ...interface{}) {
 	if l.logger.Core().Enabled(zapcore.DebugLevel) {
-		l.logger.Debug(getLogMsgf(format, args...))
+		l.logger.Debug(getLogMsgf(format, args...), l.templateFields(format)...)
 	}
 }
 
@@ -121,7 +135,7 @@ func (l *zapLog) Info(args ...interface{}) {
 // Infof logs to INFO log. Arguments are handled in the manner of fmt.Printf.
 func (l *zapLog) Infof(format string, args ...interface{}) {
 	if l.logger.Core().Enabled(zapcore.InfoLevel) {
-		l.logger.Info(getLogMsgf(format, args...))
+		l.logger.Info(getLogMsgf(format, args...), l.templateFields(format)...)
 	}
 }
 
@@ -135,7 +149,7 @@ func (l *zapLog) Warn(args ...interface{}) {
 // Warnf logs to WARNING log. Arguments are handled in the manner of fmt.Printf.
 func (l *zapLog) Warnf(format string, args ...interface{}) {
 	if l.logger.Core().Enabled(zapcore.WarnLevel) {
-		l.logger.Warn(getLogMsgf(format, args...))
+		l.logger.Warn(getLogMsgf(format, args...), l.templateFields(format)...)
 	}
 }
 
@@ -149,7 +163,7 @@ func (l *zapLog) Error(args ...interface{}) {
 // Errorf logs to ERROR log. Arguments are handled in the manner of fmt.Printf.
 func (l *zapLog) Errorf(format string, args ...interface{}) {
 	if l.logger.Core().Enabled(zapcore.ErrorLevel) {
-		l.logger.Error(getLogMsgf(format, args...))
+		l.logger.Error(getLogMsgf(format, args...), l.templateFields(format)...)
 	}
 }
 
@@ -163,7 +177,7 @@ func (l *zapLog) Fatal(args ...interface{}) {
 // Fatalf logs to FATAL log. Arguments are handled in the manner of fmt.Printf.
 func (l *zapLog) Fatalf(format string, args ...interface{}) {
 	if l.logger.Core().Enabled(zapcore.FatalLevel) {
-		l.logger.Fatal(getLogMsgf(format, args...))
+		l.logger.Fatal(getLogMsgf(format, args...), l.templateFields(format)...)
 	}
 }
 
@@ -197,6 +211,14 @@ func (l *zapLog) GetLevel(output string) level.Level {
 	return level.ZapLevelToLevel[l.levels[i].Level()]
 }
 
+// templateFields 开启采样时返回携带日志模板的字段
+func (l *zapLog) templateFields(format string) []zap.Field {
+	if !l.template {
+		return nil
+	}
+	return []zap.Field{sampler.Template(format)}
+}
+
 func getLogMsg(args ...interface{}) string {
 	msg := fmt.Sprintln(args...)
 	msg = msg[:len(msg)-1]
@@ -6,10 +6,12 @@ import (
 
 	"github.com/fengzhongzhu1621/xgo/logging/config"
 	"github.com/fengzhongzhu1621/xgo/logging/level"
+	"github.com/fengzhongzhu1621/xgo/logging/output"
 	"github.com/fengzhongzhu1621/xgo/logging/zaplogger"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestNewZapLog(t *testing.T) {
@@ -68,3 +70,22 @@ func NewZapFatalLogger(h zapcore.CheckWriteHook) ILogger {
 			zap.WithFatalHook(h),
 		)}
 }
+
+func TestZapLogSampling(t *testing.T) {
+	sampled, sampledLogs := observer.New(zap.DebugLevel)
+	output.RegisterWriter("sampled_observer", &observeWriter{core: sampled})
+	all, allLogs := observer.New(zap.DebugLevel)
+	output.RegisterWriter("all_observer", &observeWriter{core: all})
+
+	logger := NewZapLog([]config.LogOutputConfig{
+		{Writer: "sampled_observer", Sampling: config.SamplingConfig{Initial: 2}},
+		{Writer: "all_observer"},
+	})
+	// 按日志模板而不是格式化后的日志采样
+	for i := 0; i < 5; i++ {
+		logger.Errorf("user %d not found", i)
+	}
+	assert.Equal(t, 2, sampledLogs.Len())
+	assert.Empty(t, sampledLogs.All()[0].Context)
+	assert.Equal(t, 5, allLogs.Len())
+}
@@ -88,6 +88,15 @@ func NewFileCore(c *config.LogOutputConfig) (zapcore.Core, zap.AtomicLevel, erro
 	), lvl, nil
 }
 
+// NewWriterCore 使用指定的 WriteSyncer 创建 core，用于远程日志等自定义输出
+func NewWriterCore(c *config.LogOutputConfig, ws zapcore.WriteSyncer) (zapcore.Core, zap.AtomicLevel) {
+	lvl := zap.NewAtomicLevelAt(level.Levels[c.Level])
+	return zapcore.NewCore(
+		newEncoder(c),
+		ws, lvl,
+	), lvl
+}
+
 func newEncoder(c *config.LogOutputConfig) zapcore.Encoder {
 	encoderCfg := zapcore.EncoderConfig{
 		TimeKey:        GetLogEncoderKey("T", c.FormatConfig.TimeKey),
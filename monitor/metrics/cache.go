@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CacheCollector exports the statistics of all caches registered in cache/stats
+type CacheCollector struct {
+	hits             *prometheus.Desc
+	misses           *prometheus.Desc
+	retrieves        *prometheus.Desc
+	retrieveErrors   *prometheus.Desc
+	retrieveDuration *prometheus.Desc
+	sharedRetrieves  *prometheus.Desc
+	evictions        *prometheus.Desc
+	items            *prometheus.Desc
+	hitRatio         *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*CacheCollector)(nil)
+
+// NewCacheCollector create a cache collector
+func NewCacheCollector() *CacheCollector {
+	constLabels := prometheus.Labels{"service": serviceName}
+	newDesc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(name, help, []string{"cache"}, constLabels)
+	}
+
+	return &CacheCollector{
+		hits:             newDesc("cache_hits_total", "number of cache hits"),
+		misses:           newDesc("cache_misses_total", "number of cache misses"),
+		retrieves:        newDesc("cache_retrieves_total", "number of calls to the retrieve function"),
+		retrieveErrors:   newDesc("cache_retrieve_errors_total", "number of failed calls to the retrieve function"),
+		retrieveDuration: newDesc("cache_retrieve_duration_seconds_total", "total duration of the retrieve function"),
+		sharedRetrieves:  newDesc("cache_retrieve_shared_total", "number of retrieves shared by singleflight"),
+		evictions:        newDesc("cache_evictions_total", "number of evicted items"),
+		items:            newDesc("cache_items", "number of items in the cache"),
+		hitRatio:         newDesc("cache_hit_ratio", "hits / (hits + misses)"),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *CacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.retrieves
+	ch <- c.retrieveErrors
+	ch <- c.retrieveDuration
+	ch <- c.sharedRetrieves
+	ch <- c.evictions
+	ch <- c.items
+	ch <- c.hitRatio
+}
+
+// Collect implements prometheus.Collector
+func (c *CacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats.Each(func(name string, cache stats.Inspector) {
+		s := cache.Stats()
+
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits), name)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses), name)
+		ch <- prometheus.MustNewConstMetric(c.retrieves, prometheus.CounterValue, float64(s.Retrieves), name)
+		ch <- prometheus.MustNewConstMetric(c.retrieveErrors, prometheus.CounterValue,
+			float64(s.RetrieveErrors), name)
+		ch <- prometheus.MustNewConstMetric(c.retrieveDuration, prometheus.CounterValue,
+			s.RetrieveDuration.Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(c.sharedRetrieves, prometheus.CounterValue,
+			float64(s.SharedRetrieves), name)
+		ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(s.Evictions), name)
+		ch <- prometheus.MustNewConstMetric(c.hitRatio, prometheus.GaugeValue, s.HitRatio(), name)
+		if n := cache.Len(); n >= 0 {
+			ch <- prometheus.MustNewConstMetric(c.items, prometheus.GaugeValue, float64(n), name)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fengzhongzhu1621/xgo/cache/stats"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCache struct {
+	stats.Collector
+}
+
+func (c *fakeCache) Stats() stats.Stats { return c.Snapshot() }
+
+func (c *fakeCache) Len() int { return 3 }
+
+func (c *fakeCache) Inspect(ctx context.Context, key string) (interface{}, bool) {
+	return nil, false
+}
+
+func (c *fakeCache) Evict(ctx context.Context, key string) error { return nil }
+
+func TestCacheCollector(t *testing.T) {
+	c := &fakeCache{}
+	c.Hit()
+	c.Hit()
+	c.Hit()
+	c.Miss()
+	stats.Register("metrics-test", c)
+	defer stats.Unregister("metrics-test")
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, registry.Register(NewCacheCollector()))
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			assert.Equal(t, map[string]string{"cache": "metrics-test", "service": serviceName}, labels)
+
+			if m.GetCounter() != nil {
+				values[family.GetName()] = m.GetCounter().GetValue()
+			} else {
+				values[family.GetName()] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, float64(3), values["cache_hits_total"])
+	assert.Equal(t, float64(1), values["cache_misses_total"])
+	assert.Equal(t, 0.75, values["cache_hit_ratio"])
+	assert.Equal(t, float64(3), values["cache_items"])
+}
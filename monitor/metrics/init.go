@@ -29,12 +29,16 @@ var (
 	},
 		[]string{"method", "path", "status", "client_id"},
 	)
+
+	// CacheStats exports the statistics of the caches registered in cache/stats
+	CacheStats = NewCacheCollector()
 )
 
 func InitMetrics() {
 	callerInitMetricsOnce.Do(func() {
 		prometheus.MustRegister(RequestCount)
 		prometheus.MustRegister(RequestDuration)
+		prometheus.MustRegister(CacheStats)
 	})
 }
 
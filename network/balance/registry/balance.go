@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strconv"
+
+	"github.com/fengzhongzhu1621/xgo/collections/loadbalance"
+	"github.com/fengzhongzhu1621/xgo/db/zookeeper/registerdiscover"
+	"github.com/fengzhongzhu1621/xgo/logging"
+)
+
+// Address 返回节点地址 ip:port
+func (n ServiceNode) Address() string {
+	return net.JoinHostPort(n.ServerIp, strconv.Itoa(n.ServerPort))
+}
+
+// BalanceNodes 转换为负载均衡器的节点，忽略校验失败的节点
+func BalanceNodes(nodes []ServiceNode) []loadbalance.Node {
+	res := make([]loadbalance.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if err := n.Validate(); err != nil {
+			logging.Warnf("registry: ignore invalid service node %+v: %v", n, err)
+			continue
+		}
+		res = append(res, loadbalance.Node{Address: n.Address(), Weight: n.Weight})
+	}
+	return res
+}
+
+// ParseServiceNodes 解析 zookeeper 服务发现返回的节点信息，每个节点的数据为 ServiceNode 的 JSON
+func ParseServiceNodes(servers []string) []ServiceNode {
+	nodes := make([]ServiceNode, 0, len(servers))
+	for _, server := range servers {
+		var n ServiceNode
+		if err := json.Unmarshal([]byte(server), &n); err != nil {
+			logging.Warnf("registry: ignore service node %q: %v", server, err)
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// WatchDiscovery 将 zookeeper 服务发现的节点变化同步到负载均衡器，直到 ctx 结束或者 events 被关闭。
+// 服务发现出错或者节点信息都无法解析时保留原来的节点
+func WatchDiscovery(ctx context.Context, events <-chan *registerdiscover.DiscoverEvent, b loadbalance.Balancer) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Err != nil {
+				logging.Errorf("registry: discover %s fail: %v", event.Key, event.Err)
+				continue
+			}
+			nodes := BalanceNodes(ParseServiceNodes(event.Server))
+			if len(nodes) == 0 && len(event.Server) > 0 {
+				logging.Errorf("registry: no valid service node of %s, keep the current nodes", event.Key)
+				continue
+			}
+			b.Update(nodes)
+		}
+	}
+}
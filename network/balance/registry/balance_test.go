@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/collections/loadbalance"
+	"github.com/fengzhongzhu1621/xgo/db/zookeeper/registerdiscover"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalanceNodes(t *testing.T) {
+	nodes := BalanceNodes([]ServiceNode{
+		{ServerIp: "127.0.0.1", ServerPort: 8080, Weight: 10},
+		{ServerIp: "::1", ServerPort: 8081, Weight: 1},
+		{ServerIp: "127.0.0.1", ServerPort: 0, Weight: 1},
+	})
+	assert.Equal(t, []loadbalance.Node{
+		{Address: "127.0.0.1:8080", Weight: 10},
+		{Address: "[::1]:8081", Weight: 1},
+	}, nodes)
+}
+
+func TestWatchDiscovery(t *testing.T) {
+	b, err := loadbalance.New(loadbalance.P2C)
+	assert.NoError(t, err)
+	events := make(chan *registerdiscover.DiscoverEvent)
+	done := make(chan struct{})
+	go func() {
+		WatchDiscovery(context.Background(), events, b)
+		close(done)
+	}()
+
+	events <- &registerdiscover.DiscoverEvent{Server: []string{
+		`{"server_ip":"127.0.0.1","server_port":8080,"weight":1}`,
+		`{"server_ip":"127.0.0.1","server_port":8081,"weight":2}`,
+	}}
+	// 出错和无法解析的节点信息不影响当前的节点
+	events <- &registerdiscover.DiscoverEvent{Err: errors.New("zk is closed")}
+	events <- &registerdiscover.DiscoverEvent{Server: []string{"invalid"}}
+	close(events)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WatchDiscovery does not return after events closed")
+	}
+	assert.Equal(t, []loadbalance.Node{
+		{Address: "127.0.0.1:8080", Weight: 1},
+		{Address: "127.0.0.1:8081", Weight: 2},
+	}, b.Nodes())
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/fengzhongzhu1621/xgo/codec"
 	"github.com/fengzhongzhu1621/xgo/logging"
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
 )
 
 const (
@@ -54,6 +55,42 @@ type ConnectionPool struct {
 	used            int32         // size of connections used by user, atomic.
 	lastGetTime     int64         // last get connection millisecond timestamp, atomic. 上一次取出连接的时间
 	poolIdleTimeout time.Duration // pool idle timeout.
+
+	nodeKey   string             // 节点键，格式为 network_address_protocol
+	network   string             // 目标网络
+	address   string             // 目标地址
+	counters  poolstats.Counters // 连接事件计数
+	eventHook poolstats.Hook     // 连接事件回调
+}
+
+// Stats returns the state of the connection pool.
+func (p *ConnectionPool) Stats() poolstats.NodeStats {
+	p.mu.Lock()
+	idle := p.idleSize
+	p.mu.Unlock()
+	active := int(atomic.LoadInt32(&p.used))
+
+	s := poolstats.NodeStats{
+		Node:      p.nodeKey,
+		Network:   p.network,
+		Address:   p.address,
+		Conns:     idle + active,
+		Idle:      idle,
+		Active:    active,
+		MaxIdle:   p.MaxIdle,
+		MaxActive: p.MaxActive,
+	}
+	p.counters.Fill(&s)
+	return s
+}
+
+// emit 记录连接事件并通知回调
+func (p *ConnectionPool) emit(typ poolstats.EventType, err error) {
+	e := poolstats.NewEvent(typ, p.nodeKey, p.network, p.address, err)
+	p.counters.Record(e)
+	if p.eventHook != nil {
+		p.eventHook(e)
+	}
 }
 
 // keepMinIdles 保持最小空闲连接数
@@ -237,6 +274,7 @@ func (p *ConnectionPool) getToken(ctx context.Context) error {
 			return nil
 		default:
 			// 令牌桶满（连接已满）
+			p.emit(poolstats.EventDropFull, ErrPoolLimit)
 			return ErrPoolLimit
 		}
 	}
@@ -270,6 +308,7 @@ func (p *ConnectionPool) getIdleConn() *PoolConn {
 		// 如果检查失败，关闭连接并继续检查下一个空闲连接
 		pc.Conn.Close()
 		pc.closed = true
+		p.emit(poolstats.EventExpel, nil)
 		p.mu.Lock()
 	}
 	p.mu.Unlock()
@@ -341,6 +380,7 @@ func (p *ConnectionPool) checkHealthOnce() {
 		} else {
 			pc.Conn.Close()
 			pc.closed = true
+			p.emit(poolstats.EventExpel, nil)
 			p.mu.Lock()
 		}
 	}
@@ -460,7 +500,9 @@ func (p *ConnectionPool) defaultChecker(pc *PoolConn, isFast bool) bool {
 // dial establishes a connection.
 func (p *ConnectionPool) dial(ctx context.Context) (net.Conn, error) {
 	if p.Dial != nil {
-		return p.Dial(ctx)
+		c, err := p.Dial(ctx)
+		p.emit(poolstats.EventDial, err)
+		return c, err
 	}
 	return nil, errors.New("must pass Dial to pool")
 }
@@ -481,6 +523,7 @@ func (p *ConnectionPool) put(pc *PoolConn, forceClose bool) error {
 		return nil
 	}
 
+	var idleClose bool
 	p.mu.Lock()
 	// 检查连接池是否未关闭且不需要强制关闭连接
 	if !p.closed && !forceClose {
@@ -498,6 +541,7 @@ func (p *ConnectionPool) put(pc *PoolConn, forceClose bool) error {
 		if p.idleSize >= p.MaxIdle {
 			pc = p.idle.tail // 获取最旧的连接
 			p.idle.popTail() // 从尾部移除
+			idleClose = true
 		} else {
 			p.idleSize++ // 增加空闲连接计数
 			pc = nil     // 连接已成功放回池中，不需要关闭
@@ -509,6 +553,9 @@ func (p *ConnectionPool) put(pc *PoolConn, forceClose bool) error {
 	if pc != nil {
 		pc.closed = true
 		pc.Conn.Close() // 关闭底层连接
+		if idleClose {
+			p.emit(poolstats.EventIdleClose, nil)
+		}
 	}
 
 	// 释放令牌，允许其他请求获取连接
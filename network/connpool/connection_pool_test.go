@@ -5,6 +5,7 @@ import (
 	"io"
 	"net"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -12,6 +13,8 @@ import (
 	"github.com/fengzhongzhu1621/xgo/buildin/buffer"
 	"github.com/fengzhongzhu1621/xgo/codec"
 	"github.com/fengzhongzhu1621/xgo/network/dial"
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -133,3 +136,70 @@ func closePool(t *testing.T, p IPool) {
 		pool.(*ConnectionPool).Close()
 	}
 }
+
+func TestPoolSnapshotAndEvents(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		events  []poolstats.EventType
+		healthy int32 = 1
+	)
+	p := NewConnectionPool(
+		WithMaxActive(2),
+		WithMaxIdle(1),
+		WithDialFunc(func(*dial.DialOptions) (net.Conn, error) {
+			return &noopConn{closeFunc: func() {}, suc: true}, nil
+		}),
+		WithHealthChecker(func(*PoolConn, bool) bool { return atomic.LoadInt32(&healthy) == 1 }),
+		WithEventHook(func(e poolstats.Event) {
+			assert.Equal(t, t.Name(), e.Address)
+			mu.Lock()
+			events = append(events, e.Type)
+			mu.Unlock()
+		}),
+	)
+	defer closePool(t, p)
+
+	opts := GetOptions{CustomReader: buffer.NewReader, DialTimeout: time.Second}
+	c1, err := p.Get(t.Name(), t.Name(), opts)
+	require.Nil(t, err)
+	c2, err := p.Get(t.Name(), t.Name(), opts)
+	require.Nil(t, err)
+
+	// 连接数超过 MaxActive 时丢弃请求
+	_, err = p.Get(t.Name(), t.Name(), opts)
+	require.Equal(t, ErrPoolLimit, err)
+
+	nodes := p.(poolstats.ISnapshotter).Snapshot()
+	require.Len(t, nodes, 1)
+	assert.Equal(t, getNodeKey(t.Name(), t.Name(), ""), nodes[0].Node)
+	assert.Equal(t, 2, nodes[0].Conns)
+	assert.Equal(t, 2, nodes[0].Active)
+	assert.Equal(t, 0, nodes[0].Idle)
+	assert.Equal(t, 2, nodes[0].MaxActive)
+	assert.Equal(t, uint64(2), nodes[0].Dials)
+	assert.Equal(t, uint64(1), nodes[0].DropFulls)
+
+	// 空闲连接超过 MaxIdle 时关闭
+	require.Nil(t, c1.Close())
+	require.Nil(t, c2.Close())
+	nodes = p.(poolstats.ISnapshotter).Snapshot()
+	assert.Equal(t, 1, nodes[0].Idle)
+	assert.Equal(t, 0, nodes[0].Active)
+	assert.Equal(t, uint64(1), nodes[0].IdleCloses)
+
+	// 空闲连接未通过健康检查时被移除
+	atomic.StoreInt32(&healthy, 0)
+	c3, err := p.Get(t.Name(), t.Name(), opts)
+	require.Nil(t, err)
+	require.Nil(t, c3.Close())
+	nodes = p.(poolstats.ISnapshotter).Snapshot()
+	assert.Equal(t, uint64(1), nodes[0].Expels)
+	assert.Equal(t, uint64(3), nodes[0].Dials)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []poolstats.EventType{
+		poolstats.EventDial, poolstats.EventDial, poolstats.EventDropFull,
+		poolstats.EventIdleClose, poolstats.EventExpel, poolstats.EventDial,
+	}, events)
+}
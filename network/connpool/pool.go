@@ -8,11 +8,15 @@ import (
 	"time"
 
 	"github.com/fengzhongzhu1621/xgo/network/dial"
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
 )
 
 type dialFunc = func(ctx context.Context) (net.Conn, error)
 
-var _ IPool = (*pool)(nil)
+var (
+	_ IPool                  = (*pool)(nil)
+	_ poolstats.ISnapshotter = (*pool)(nil)
+)
 
 // pool connection pool factory, maintains connection pools corresponding to all addresses,
 // and connection pool option information.
@@ -27,6 +31,16 @@ type pool struct {
 // DefaultConnectionPool is the default connection pool, replaceable.
 var DefaultConnectionPool = NewConnectionPool()
 
+func init() {
+	// 默认连接池可能被替换，查询时再获取
+	poolstats.Register("connpool", poolstats.SnapshotterFunc(func() []poolstats.NodeStats {
+		if s, ok := DefaultConnectionPool.(poolstats.ISnapshotter); ok {
+			return s.Snapshot()
+		}
+		return nil
+	}))
+}
+
 // NewConnectionPool creates a connection pool.
 // NewConnectionPool 创建一个连接池，支持传入 Option 修改参数，不传则使用默认值初始化。
 // Dial 是默认的创建连接方式，每个 ConnectionPool 会根据自己的 GetOptions 生成 DialOptions, 来建立对应目标的连接。
@@ -81,6 +95,10 @@ func (p *pool) Get(network string, address string, opts GetOptions) (net.Conn, e
 		PushIdleConnToTail: p.opts.PushIdleConnToTail,
 		onCloseFunc:        func() { p.connectionPools.Delete(key) },
 		poolIdleTimeout:    p.opts.PoolIdleTimeout,
+		nodeKey:            key,
+		network:            network,
+		address:            address,
+		eventHook:          p.opts.EventHook,
 	}
 
 	// 如果设置了最大活跃连接数，创建令牌通道用于连接数控制
@@ -106,6 +124,17 @@ func (p *pool) Get(network string, address string, opts GetOptions) (net.Conn, e
 	return v.(*ConnectionPool).Get(ctx)
 }
 
+// Snapshot returns the state of all ConnectionPools, sorted by node key.
+func (p *pool) Snapshot() []poolstats.NodeStats {
+	nodes := make([]poolstats.NodeStats, 0)
+	p.connectionPools.Range(func(_, v interface{}) bool {
+		nodes = append(nodes, v.(*ConnectionPool).Stats())
+		return true
+	})
+	poolstats.SortNodes(nodes)
+	return nodes
+}
+
 // getDialFunc 创建拨号函数，用于建立网络连接
 // 参数:
 //
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/fengzhongzhu1621/xgo/network/dial"
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
 )
 
 // Option is the Options helper.
@@ -35,6 +36,8 @@ type Options struct {
 	PushIdleConnToTail bool // connection to ip will be push tail when ConnectionPool.put method is called
 	// 连接池空闲超时时间， 0 表示不做检查
 	PoolIdleTimeout time.Duration // ConnectionPool idle timeout
+	// 连接事件回调，包括建立连接、健康检查移除、空闲关闭和连接数超限
+	EventHook poolstats.Hook
 }
 
 // WithMinIdle returns an Option which sets the number of initialized connections.
@@ -123,3 +126,10 @@ func WithPoolIdleTimeout(t time.Duration) Option {
 		o.PoolIdleTimeout = t
 	}
 }
+
+// WithEventHook returns an Option which sets the hook called on connection events.
+func WithEventHook(h poolstats.Hook) Option {
+	return func(o *Options) {
+		o.EventHook = h
+	}
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/collections/flowctrl/ratelimiter"
+	"github.com/fengzhongzhu1621/xgo/ginx/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// 限流维度
+const (
+	RateLimitByIP    = "ip"
+	RateLimitByUser  = "user"
+	RateLimitByRoute = "route"
+)
+
+// 限流算法
+const (
+	RateLimitTokenBucket   = "token_bucket"
+	RateLimitFixedWindow   = "fixed_window"
+	RateLimitSlidingWindow = "sliding_window"
+)
+
+// RateLimitRule 限流规则
+type RateLimitRule struct {
+	// 限流维度：ip、user、route，按 user 限流时没有登录用户的请求不限流
+	By string
+	// 生效的路由（gin 注册的路由，如 /api/v1/users/:id），为空时对所有路由生效
+	Routes []string
+	// 限流算法：token_bucket、fixed_window、sliding_window，默认为 sliding_window
+	Algorithm string
+	// 窗口内允许的请求数，令牌桶算法为桶的容量
+	Limit int
+	// 窗口长度，令牌桶算法为填满令牌桶的时间
+	Window time.Duration
+}
+
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	// redis key 的前缀，默认为 ratelimit:
+	KeyPrefix string
+	// 限流规则，请求需要满足所有生效的规则
+	Rules []RateLimitRule
+}
+
+// rateLimitRule 限流规则及其限流器
+type rateLimitRule struct {
+	RateLimitRule
+	name    string
+	routes  map[string]struct{}
+	limiter ratelimiter.Limiter
+}
+
+// key 返回请求在规则下的限流 key，返回空时请求不受该规则限制
+func (r *rateLimitRule) key(c *gin.Context) string {
+	if len(r.routes) > 0 {
+		if _, ok := r.routes[c.FullPath()]; !ok {
+			return ""
+		}
+	}
+
+	var key string
+	switch r.By {
+	case RateLimitByIP:
+		key = c.ClientIP()
+	case RateLimitByUser:
+		key = utils.GetUserID(c)
+	case RateLimitByRoute:
+		key = c.Request.Method + " " + c.FullPath()
+	}
+	if key == "" {
+		return ""
+	}
+	return r.name + ":" + key
+}
+
+// RateLimitMiddleware 根据配置的规则按 IP、用户或者路由限流，超过限制时返回 429 和 Retry-After。
+// cli 不为空时限流状态保存在 redis 中由多个副本共享，redis 不可用时降级为本地限流；cli 为空时只使用本地限流
+func RateLimitMiddleware(cli redis.Scripter, cfg RateLimitConfig) (gin.HandlerFunc, error) {
+	var opts []ratelimiter.Option
+	if cfg.KeyPrefix != "" {
+		opts = append(opts, ratelimiter.WithKeyPrefix(cfg.KeyPrefix))
+	}
+
+	rules := make([]*rateLimitRule, 0, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		limiter, err := newRateLimiter(cli, rule, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit rule %d: %w", i, err)
+		}
+		r := &rateLimitRule{
+			RateLimitRule: rule,
+			name:          strconv.Itoa(i) + ":" + rule.By,
+			limiter:       limiter,
+		}
+		if len(rule.Routes) > 0 {
+			r.routes = make(map[string]struct{}, len(rule.Routes))
+			for _, route := range rule.Routes {
+				r.routes[route] = struct{}{}
+			}
+		}
+		rules = append(rules, r)
+	}
+
+	return func(c *gin.Context) {
+		for _, rule := range rules {
+			key := rule.key(c)
+			if key == "" {
+				continue
+			}
+			r := rule.limiter.Reserve(c.Request.Context(), key)
+			if r.OK() && r.Delay() == 0 {
+				continue
+			}
+			if r.OK() {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(r.Delay().Seconds()))))
+			}
+			c.AbortWithStatus(http.StatusTooManyRequests)
+			return
+		}
+		c.Next()
+	}, nil
+}
+
+// newRateLimiter 根据规则创建限流器
+func newRateLimiter(cli redis.Scripter, rule RateLimitRule, opts ...ratelimiter.Option) (ratelimiter.Limiter, error) {
+	switch rule.By {
+	case RateLimitByIP, RateLimitByUser, RateLimitByRoute:
+	default:
+		return nil, fmt.Errorf("unknown rate limit dimension %q", rule.By)
+	}
+	if rule.Limit <= 0 || rule.Window <= 0 {
+		return nil, fmt.Errorf("invalid limit %d or window %v", rule.Limit, rule.Window)
+	}
+
+	switch rule.Algorithm {
+	case RateLimitTokenBucket:
+		rate := rule.Window / time.Duration(rule.Limit)
+		if cli == nil {
+			return ratelimiter.NewLocalTokenBucket(rule.Limit, rate), nil
+		}
+		return ratelimiter.NewRedisTokenBucket(cli, rule.Limit, rate, opts...), nil
+	case RateLimitFixedWindow:
+		if cli == nil {
+			return ratelimiter.NewLocalFixedWindow(rule.Limit, rule.Window), nil
+		}
+		return ratelimiter.NewRedisFixedWindow(cli, rule.Limit, rule.Window, opts...), nil
+	case RateLimitSlidingWindow, "":
+		if cli == nil {
+			return ratelimiter.NewLocalSlidingWindow(rule.Limit, rule.Window), nil
+		}
+		return ratelimiter.NewRedisSlidingWindow(cli, rule.Limit, rule.Window, opts...), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit algorithm %q", rule.Algorithm)
+	}
+}
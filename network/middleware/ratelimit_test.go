@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fengzhongzhu1621/xgo/ginx/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitEngine(t *testing.T, cli redis.Scripter, cfg RateLimitConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	mw, err := RateLimitMiddleware(cli, cfg)
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			utils.SetUserID(c, user)
+		}
+	}, mw)
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	r.GET("/users/:id", ok)
+	r.GET("/health", ok)
+	return r
+}
+
+func doRequest(r http.Handler, path, ip, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = ip + ":12345"
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	cfg := RateLimitConfig{
+		KeyPrefix: "test:",
+		Rules: []RateLimitRule{
+			{By: RateLimitByIP, Algorithm: RateLimitFixedWindow, Limit: 2, Window: time.Minute},
+			{By: RateLimitByUser, Limit: 1, Window: time.Minute},
+			{By: RateLimitByRoute, Routes: []string{"/health"}, Algorithm: RateLimitTokenBucket,
+				Limit: 1, Window: time.Minute},
+		},
+	}
+	// 两个副本共享 redis 中的限流状态
+	a := newRateLimitEngine(t, cli, cfg)
+	b := newRateLimitEngine(t, cli, cfg)
+
+	// 按 IP 限流
+	assert.Equal(t, http.StatusOK, doRequest(a, "/users/1", "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusOK, doRequest(b, "/users/2", "10.0.0.1", "").Code)
+	w := doRequest(a, "/users/3", "10.0.0.1", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// 按用户限流
+	assert.Equal(t, http.StatusOK, doRequest(a, "/users/1", "10.0.0.2", "alice").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(b, "/users/1", "10.0.0.3", "alice").Code)
+	assert.Equal(t, http.StatusOK, doRequest(b, "/users/1", "10.0.0.3", "bob").Code)
+
+	// 按路由限流，只对配置的路由生效
+	assert.Equal(t, http.StatusOK, doRequest(a, "/health", "10.0.0.4", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(b, "/health", "10.0.0.5", "").Code)
+	assert.Equal(t, http.StatusOK, doRequest(b, "/users/1", "10.0.0.5", "").Code)
+}
+
+func TestRateLimitMiddlewareLocal(t *testing.T) {
+	r := newRateLimitEngine(t, nil, RateLimitConfig{
+		Rules: []RateLimitRule{{By: RateLimitByIP, Limit: 1, Window: time.Minute}},
+	})
+	assert.Equal(t, http.StatusOK, doRequest(r, "/health", "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(r, "/health", "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusOK, doRequest(r, "/health", "10.0.0.2", "").Code)
+}
+
+func TestRateLimitMiddlewareInvalidConfig(t *testing.T) {
+	for _, rule := range []RateLimitRule{
+		{By: "header", Limit: 1, Window: time.Second},
+		{By: RateLimitByIP, Limit: 0, Window: time.Second},
+		{By: RateLimitByIP, Limit: 1, Window: time.Second, Algorithm: "leaky_bucket"},
+	} {
+		_, err := RateLimitMiddleware(nil, RateLimitConfig{Rules: []RateLimitRule{rule}})
+		assert.Error(t, err)
+	}
+}
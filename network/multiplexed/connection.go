@@ -10,6 +10,7 @@ import (
 	queue "github.com/fengzhongzhu1621/xgo/collections/queue/listqueue"
 	"github.com/fengzhongzhu1621/xgo/logging"
 	"github.com/fengzhongzhu1621/xgo/network/dial"
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
 	"github.com/fengzhongzhu1621/xgo/pool/packetbuffer"
 )
 
@@ -29,14 +30,16 @@ var (
 
 // Connection 表示底层TCP/UDP连接，支持多路复用
 type Connection struct {
-	err                 error       // 连接错误状态
-	address             string      // 目标地址
-	network             string      // 网络协议类型
-	enableIdleRemove    bool        // 是否启用空闲连接移除
-	destroy             func()      // 连接销毁回调函数
-	connsSubIdle        func()      // 空闲连接数减少回调
-	connsAddIdle        func()      // 空闲连接数增加回调
-	connsNeedIdleRemove func() bool // 检查是否需要移除空闲连接的回调
+	err                 error                            // 连接错误状态
+	address             string                           // 目标地址
+	network             string                           // 网络协议类型
+	enableIdleRemove    bool                             // 是否启用空闲连接移除
+	destroy             func()                           // 连接销毁回调函数
+	connsSubIdle        func()                           // 空闲连接数减少回调
+	connsAddIdle        func()                           // 空闲连接数增加回调
+	connsNeedIdleRemove func() bool                      // 检查是否需要移除空闲连接的回调
+	emit                func(poolstats.EventType, error) // 连接事件回调
+	idleClosed          bool                             // 是否因为空闲连接超过上限被关闭
 
 	// 重连相关字段
 	reconnectCount    int       // 当前重连次数
@@ -141,13 +144,18 @@ func (c *Connection) send(b []byte) error {
 		case c.writeBuffer <- b:
 			return nil
 		default:
+			c.emit(poolstats.EventDropFull, ErrSendQueueFull)
 			return ErrSendQueueFull
 		}
 	}
+	// 重连时在锁内替换 c.done
+	c.mu.RLock()
+	done := c.done
+	c.mu.RUnlock()
 	select {
 	case c.writeBuffer <- b:
 		return nil
-	case <-c.done:
+	case <-done:
 		return c.err
 	}
 }
@@ -297,6 +305,7 @@ func (c *Connection) doClose(lastErr error, reconnect bool) (needDestroy bool) {
 func (c *Connection) reconnect() (success bool) {
 	for {
 		conn, err := dial.TryConnect(c.dialOpts)
+		c.emit(poolstats.EventReconnect, err)
 		if err != nil {
 			logging.Tracef("reconnect fail: %+v", err)
 			if !c.doReconnectBackoff() { // 如果当前重试次数大于最大重试次数，
@@ -306,7 +315,9 @@ func (c *Connection) reconnect() (success bool) {
 			continue
 		}
 		c.setRawConn(conn)
-		c.done = make(chan struct{})
+		// 读写协程使用各自的 done，重连时替换 c.done 不影响已经退出的旧协程
+		done := make(chan struct{})
+		c.done = done
 		if !c.isIdle {
 			c.isIdle = true
 			c.connsAddIdle()
@@ -314,8 +325,8 @@ func (c *Connection) reconnect() (success bool) {
 		// 成功重连，移除关闭标志并重置c.err
 		c.err = nil
 		c.closed = false
-		go c.reading()
-		go c.writing()
+		go c.reading(done)
+		go c.writing(done)
 		return true
 	}
 }
@@ -395,6 +406,7 @@ func (c *Connection) idleRemove() (needDestroy bool) {
 	}
 	// 关闭当前连接
 	c.closed = true
+	c.idleClosed = true
 	close(c.done)
 	if conn := c.getRawConn(); conn != nil {
 		conn.Close()
@@ -403,6 +415,17 @@ func (c *Connection) idleRemove() (needDestroy bool) {
 	return true
 }
 
+// state 返回虚拟连接数量和连接是否空闲
+// 返回:
+//
+//	int: 虚拟连接数量
+//	bool: 连接是否空闲
+func (c *Connection) state() (int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.virConns), c.isIdle
+}
+
 // canGetVirConn 检查是否可以获取新的虚拟连接
 // 返回:
 //
@@ -421,15 +444,17 @@ func (c *Connection) canGetVirConn() bool {
 //	dialTimeout: 拨号超时时间
 func (c *Connection) startConnect(opts *GetOptions, dialTimeout time.Duration) {
 	c.fp = opts.FP
-	if err := c.dial(dialTimeout, opts); err != nil {
+	err := c.dial(dialTimeout, opts)
+	c.emit(poolstats.EventDial, err)
+	if err != nil {
 		// 第一次连接建立失败直接失败，
 		// 让上层触发下一次重新建立连接
 		c.close(err, false)
 		return
 	}
 
-	go c.reading()
-	go c.writing()
+	go c.reading(c.done)
+	go c.writing(c.done)
 }
 
 // dial 执行拨号操作
@@ -462,12 +487,12 @@ func (c *Connection) dial(timeout time.Duration, opts *GetOptions) error {
 }
 
 // reading 读取数据的协程
-// 持续从连接读取数据并分发给对应的虚拟连接
-func (c *Connection) reading() {
+// 持续从连接读取数据并分发给对应的虚拟连接，done 关闭时退出
+func (c *Connection) reading(done <-chan struct{}) {
 	var lastErr error
 	for {
 		select {
-		case <-c.done:
+		case <-done:
 			return
 		default:
 		}
@@ -498,13 +523,13 @@ func (c *Connection) reading() {
 }
 
 // writing 写入数据的协程
-// 持续从写入缓冲区读取数据并写入到连接
-func (c *Connection) writing() {
+// 持续从写入缓冲区读取数据并写入到连接，done 关闭时退出
+func (c *Connection) writing(done <-chan struct{}) {
 	var lastErr error
 L:
 	for {
 		select {
-		case <-c.done:
+		case <-done:
 			return
 		case it := <-c.writeBuffer:
 			if err := c.writeAll(it); err != nil {
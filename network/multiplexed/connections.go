@@ -6,6 +6,7 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
 	// 是 HashiCorp 提供的一个 Go 语言库，它能将多个错误合并成一个标准 error，非常适合需要收集和统一管理多个错误的场景
 	"github.com/hashicorp/go-multierror"
 )
@@ -13,10 +14,14 @@ import (
 // Connections 表示特定目标地址的连接集合
 type Connections struct {
 	nodeKey    string       // 节点键，格式为"network_address"
+	network    string       // 目标网络
+	address    string       // 目标地址
 	maxIdle    int          // 最大空闲连接数
 	opts       *PoolOptions // 连接池配置选项
 	destructor func()       // 销毁回调函数，当连接集合被驱逐时调用
 
+	counters poolstats.Counters // 连接事件计数
+
 	// mu 保护以下字段的并发安全
 	mu              sync.Mutex
 	conns           []*Connection // 连接列表
@@ -58,6 +63,15 @@ func (cs *Connections) subIdle() {
 //
 //	c: 要驱逐的连接
 func (cs *Connections) expel(c *Connection) {
+	c.mu.RLock()
+	idleClosed := c.idleClosed
+	c.mu.RUnlock()
+	if idleClosed {
+		cs.emit(poolstats.EventIdleClose, nil)
+	} else {
+		cs.emit(poolstats.EventExpel, c.err)
+	}
+
 	cs.mu.Lock()
 	cs.subIdle()
 	cs.conns = filterOutConnection(cs.conns, c)
@@ -98,6 +112,7 @@ func (cs *Connections) newConn(opts *GetOptions) *Connection {
 		},
 	}
 	c.destroy = func() { cs.expel(c) }
+	c.emit = cs.emit
 	cs.conns = append(cs.conns, c)
 
 	// 增加空闲连接计数
@@ -147,3 +162,47 @@ func (cs *Connections) pickSingleConcrete(ctx context.Context, opts *GetOptions)
 	}
 	return cs.newConn(opts), nil
 }
+
+// emit 记录连接事件并通知回调
+// 参数:
+//
+//	typ: 事件类型
+//	err: 事件关联的错误
+func (cs *Connections) emit(typ poolstats.EventType, err error) {
+	e := poolstats.NewEvent(typ, cs.nodeKey, cs.network, cs.address, err)
+	cs.counters.Record(e)
+	if cs.opts.eventHook != nil {
+		cs.opts.eventHook(e)
+	}
+}
+
+// stats 返回连接集合的状态
+// 返回:
+//
+//	poolstats.NodeStats: 真实连接、虚拟连接、空闲连接数量和事件计数
+func (cs *Connections) stats() poolstats.NodeStats {
+	s := poolstats.NodeStats{
+		Node:    cs.nodeKey,
+		Network: cs.network,
+		Address: cs.address,
+		MaxIdle: cs.maxIdle,
+	}
+
+	cs.mu.Lock()
+	conns := make([]*Connection, len(cs.conns))
+	copy(conns, cs.conns)
+	cs.mu.Unlock()
+
+	s.Conns = len(conns)
+	for _, c := range conns {
+		virConns, isIdle := c.state()
+		s.VirtualConns += virConns
+		if isIdle {
+			s.Idle++
+		}
+		s.SendQueue += len(c.writeBuffer)
+	}
+	s.Active = s.Conns - s.Idle
+	cs.counters.Fill(&s)
+	return s
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
 )
 
 const (
@@ -25,9 +27,18 @@ type Multiplexed struct {
 	opts          *PoolOptions // 连接池配置选项
 }
 
+var _ poolstats.ISnapshotter = (*Multiplexed)(nil)
+
 // DefaultMultiplexedPool 是默认的多路复用连接池实现
 var DefaultMultiplexedPool = New()
 
+func init() {
+	// 默认连接池可能被替换，查询时再获取
+	poolstats.Register("multiplexed", poolstats.SnapshotterFunc(func() []poolstats.NodeStats {
+		return DefaultMultiplexedPool.Snapshot()
+	}))
+}
+
 // New 创建新的多路复用连接池实例
 // 参数:
 //
@@ -88,6 +99,19 @@ func (p *Multiplexed) GetMuxConn(
 	return p.get(ctx, &opts)
 }
 
+// Snapshot 返回所有节点的连接状态，按节点键排序
+func (p *Multiplexed) Snapshot() []poolstats.NodeStats {
+	nodes := make([]poolstats.NodeStats, 0)
+	p.concreteConns.Range(func(_, v interface{}) bool {
+		if conns, ok := v.(*Connections); ok {
+			nodes = append(nodes, conns.stats())
+		}
+		return true
+	})
+	poolstats.SortNodes(nodes)
+	return nodes
+}
+
 // get 内部方法，执行获取虚拟连接的三步流程
 // 参数:
 //
@@ -151,6 +175,8 @@ func (p *Multiplexed) initPoolForNode(opts *GetOptions) {
 func (p *Multiplexed) newConcreteConnections(opts *GetOptions) *Connections {
 	conns := &Connections{
 		nodeKey: opts.nodeKey,
+		network: opts.network,
+		address: opts.address,
 		opts:    p.opts,
 		conns:   make([]*Connection, 0, p.opts.connectNumberPerHost),
 		maxIdle: p.opts.maxIdleConnsPerHost,
@@ -0,0 +1,170 @@
+package multiplexed
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vidFrameParser 帧格式为 4 字节虚拟连接 ID + 4 字节长度 + 数据
+type vidFrameParser struct{}
+
+func (fp *vidFrameParser) Parse(r io.Reader) (uint32, []byte, error) {
+	var head [8]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	buf := make([]byte, 8+binary.BigEndian.Uint32(head[4:]))
+	copy(buf, head[:])
+	if _, err := io.ReadFull(r, buf[8:]); err != nil {
+		return 0, nil, err
+	}
+	return binary.BigEndian.Uint32(head[:4]), buf, nil
+}
+
+func encodeFrame(vid uint32, body string) []byte {
+	buf := make([]byte, 8+len(body))
+	binary.BigEndian.PutUint32(buf, vid)
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(body)))
+	copy(buf[8:], body)
+	return buf
+}
+
+// echoServer 回写收到的数据帧，返回已接受的连接
+type echoServer struct {
+	ln    net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newEchoServer(t *testing.T) *echoServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &echoServer{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go func() {
+				fp := &vidFrameParser{}
+				for {
+					_, buf, err := fp.Parse(conn)
+					if err != nil {
+						return
+					}
+					conn.Write(buf)
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+// closeConns 关闭服务端已接受的连接，触发客户端重连
+func (s *echoServer) closeConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func TestMultiplexedSnapshotAndEvents(t *testing.T) {
+	s := newEchoServer(t)
+	defer s.ln.Close()
+	address := s.ln.Addr().String()
+
+	var (
+		mu     sync.Mutex
+		events = map[poolstats.EventType]int{}
+	)
+	countOf := func(typ poolstats.EventType) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return events[typ]
+	}
+	p := New(WithConnectNumber(1), WithEventHook(func(e poolstats.Event) {
+		mu.Lock()
+		events[e.Type]++
+		mu.Unlock()
+	}))
+
+	opts := NewGetOptions()
+	opts.WithFrameParser(&vidFrameParser{})
+	for vid := uint32(1); vid <= 2; vid++ {
+		opts.WithVID(vid)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		mc, err := p.GetMuxConn(ctx, "tcp", address, opts)
+		require.NoError(t, err)
+		defer mc.Close()
+
+		req := encodeFrame(vid, "hello")
+		require.NoError(t, mc.Write(req))
+		rsp, err := mc.Read()
+		require.NoError(t, err)
+		assert.Equal(t, req, rsp)
+	}
+
+	nodes := p.Snapshot()
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "tcp_"+address, nodes[0].Node)
+	assert.Equal(t, address, nodes[0].Address)
+	assert.Equal(t, 1, nodes[0].Conns)
+	assert.Equal(t, 2, nodes[0].VirtualConns)
+	assert.Equal(t, 0, nodes[0].Idle)
+	assert.Equal(t, uint64(1), nodes[0].Dials)
+	assert.Equal(t, 1, countOf(poolstats.EventDial))
+
+	// 服务端关闭连接后客户端重连
+	s.closeConns()
+	require.Eventually(t, func() bool {
+		return countOf(poolstats.EventReconnect) > 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(countOf(poolstats.EventReconnect)), p.Snapshot()[0].Reconnects)
+
+	// 服务端不可用时重连失败，连接被驱逐，节点被删除
+	s.ln.Close()
+	s.closeConns()
+	require.Eventually(t, func() bool {
+		return countOf(poolstats.EventExpel) > 0
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Empty(t, p.Snapshot())
+}
+
+func TestMultiplexedDropFull(t *testing.T) {
+	var drops int
+	p := New(WithConnectNumber(1), WithQueueSize(1), WithDropFull(true),
+		WithEventHook(func(e poolstats.Event) {
+			if e.Type == poolstats.EventDropFull {
+				drops++
+			}
+		}))
+
+	// 连接一直在建立中，发送队列不会被消费
+	conns := &Connections{nodeKey: "tcp_127.0.0.1:0", opts: p.opts}
+	c := &Connection{writeBuffer: make(chan []byte, 1), dropFull: true, emit: conns.emit}
+	conns.conns = []*Connection{c}
+
+	require.NoError(t, c.send([]byte("1")))
+	assert.Equal(t, ErrSendQueueFull, c.send([]byte("2")))
+	assert.Equal(t, 1, drops)
+
+	s := conns.stats()
+	assert.Equal(t, uint64(1), s.DropFulls)
+	assert.Equal(t, 1, s.SendQueue)
+}
@@ -1,15 +1,20 @@
 package multiplexed
 
-import "time"
+import (
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
+)
 
 // PoolOptions 表示连接池的一些配置设置
 type PoolOptions struct {
-	connectNumberPerHost int           // 每个地址的连接数量
-	sendQueueSize        int           // 每个连接的发送队列长度
-	dropFull             bool          // 队列满时是否丢弃请求
-	dialTimeout          time.Duration // 连接超时时间，默认1秒
-	maxVirConnsPerConn   int           // 每个真实连接的最大虚拟连接数，0表示无限制
-	maxIdleConnsPerHost  int           // 每个对端ip:port的最大空闲连接数
+	connectNumberPerHost int            // 每个地址的连接数量
+	sendQueueSize        int            // 每个连接的发送队列长度
+	dropFull             bool           // 队列满时是否丢弃请求
+	dialTimeout          time.Duration  // 连接超时时间，默认1秒
+	maxVirConnsPerConn   int            // 每个真实连接的最大虚拟连接数，0表示无限制
+	maxIdleConnsPerHost  int            // 每个对端ip:port的最大空闲连接数
+	eventHook            poolstats.Hook // 连接事件回调
 }
 
 // PoolOption 是配置选项的辅助类型
@@ -76,3 +81,13 @@ func WithMaxIdleConnsPerHost(n int) PoolOption {
 		opts.maxIdleConnsPerHost = n
 	}
 }
+
+// WithEventHook 设置连接事件回调，包括建立连接、重连、驱逐、空闲关闭和发送队列满丢弃
+// 参数:
+//
+//	h: 事件回调，在连接池内部同步调用，不能阻塞
+func WithEventHook(h poolstats.Hook) PoolOption {
+	return func(opts *PoolOptions) {
+		opts.eventHook = h
+	}
+}
@@ -0,0 +1,45 @@
+package poolstats
+
+import "time"
+
+// EventType is the type of the connection event
+type EventType string
+
+const (
+	// EventDial 建立新的连接，Err 不为空表示建立连接失败
+	EventDial EventType = "dial"
+	// EventReconnect 连接异常后的一次重连尝试，Err 不为空表示重连失败
+	EventReconnect EventType = "reconnect"
+	// EventExpel 连接被移出连接池，例如未通过健康检查（包括空闲超时）、重连次数超过上限
+	EventExpel EventType = "expel"
+	// EventIdleClose 空闲连接超过上限后被关闭
+	EventIdleClose EventType = "idle_close"
+	// EventDropFull 队列已满（连接池令牌耗尽或者发送队列已满）导致请求被丢弃
+	EventDropFull EventType = "drop_full"
+)
+
+// Event is a connection event of a pool node
+type Event struct {
+	Type    EventType
+	Node    string // 节点键
+	Network string
+	Address string
+	Err     error
+	Time    time.Time
+}
+
+// Hook is called synchronously when an event occurs, it may be called while the pool holds its lock,
+// so it must be fast and must not call back into the pool
+type Hook func(Event)
+
+// NewEvent creates an event occurred now
+func NewEvent(typ EventType, node, network, address string, err error) Event {
+	return Event{
+		Type:    typ,
+		Node:    node,
+		Network: network,
+		Address: address,
+		Err:     err,
+		Time:    time.Now(),
+	}
+}
@@ -0,0 +1,76 @@
+package poolstats
+
+import (
+	"sort"
+	"sync"
+)
+
+// ISnapshotter is implemented by the connection pools which can be inspected by operators
+type ISnapshotter interface {
+	// Snapshot returns the state of all nodes of the pool, sorted by node key
+	Snapshot() []NodeStats
+}
+
+// SnapshotterFunc is an adapter to allow the use of ordinary functions as ISnapshotter
+type SnapshotterFunc func() []NodeStats
+
+// Snapshot calls f()
+func (f SnapshotterFunc) Snapshot() []NodeStats {
+	return f()
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]ISnapshotter{}
+)
+
+// Register registers a named pool, the pool with the same name will be replaced
+func Register(name string, s ISnapshotter) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = s
+}
+
+// Unregister removes a named pool
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, name)
+}
+
+// Get returns the named pool
+func Get(name string) (ISnapshotter, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	s, ok := registry[name]
+	return s, ok
+}
+
+// Names returns the sorted names of the registered pools
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Each calls fn for every registered pool in name order
+func Each(fn func(name string, s ISnapshotter)) {
+	for _, name := range Names() {
+		if s, ok := Get(name); ok {
+			fn(name, s)
+		}
+	}
+}
+
+// SortNodes sorts the snapshots by node key
+func SortNodes(nodes []NodeStats) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Node < nodes[j].Node
+	})
+}
@@ -0,0 +1,78 @@
+package poolstats
+
+import "sync/atomic"
+
+// NodeStats is a snapshot of the state of a pool node, i.e. the connections to one target
+type NodeStats struct {
+	// 节点键，同一个连接池内唯一
+	Node    string `json:"node"`
+	Network string `json:"network"`
+	Address string `json:"address"`
+	// 真实连接数量
+	Conns int `json:"conns"`
+	// 虚拟连接数量，只有多路复用连接池有效
+	VirtualConns int `json:"virtual_conns"`
+	// 空闲的真实连接数量
+	Idle int `json:"idle"`
+	// 正在使用的真实连接数量
+	Active int `json:"active"`
+	// 最大空闲连接数，0 表示不限制
+	MaxIdle int `json:"max_idle"`
+	// 最大活跃连接数，0 表示不限制
+	MaxActive int `json:"max_active"`
+	// 发送队列中等待发送的数据包数量，只有多路复用连接池有效
+	SendQueue int `json:"send_queue"`
+
+	// 以下是累计的事件次数
+	Dials           uint64 `json:"dials"`
+	DialErrors      uint64 `json:"dial_errors"`
+	Reconnects      uint64 `json:"reconnects"`
+	ReconnectErrors uint64 `json:"reconnect_errors"`
+	Expels          uint64 `json:"expels"`
+	IdleCloses      uint64 `json:"idle_closes"`
+	DropFulls       uint64 `json:"drop_fulls"`
+}
+
+// Counters counts the events of a pool node, it is safe for concurrent use
+type Counters struct {
+	dials           atomic.Uint64
+	dialErrors      atomic.Uint64
+	reconnects      atomic.Uint64
+	reconnectErrors atomic.Uint64
+	expels          atomic.Uint64
+	idleCloses      atomic.Uint64
+	dropFulls       atomic.Uint64
+}
+
+// Record counts the event
+func (c *Counters) Record(e Event) {
+	switch e.Type {
+	case EventDial:
+		c.dials.Add(1)
+		if e.Err != nil {
+			c.dialErrors.Add(1)
+		}
+	case EventReconnect:
+		c.reconnects.Add(1)
+		if e.Err != nil {
+			c.reconnectErrors.Add(1)
+		}
+	case EventExpel:
+		c.expels.Add(1)
+	case EventIdleClose:
+		c.idleCloses.Add(1)
+	case EventDropFull:
+		c.dropFulls.Add(1)
+	}
+}
+
+// Fill fills the event counts into the snapshot
+func (c *Counters) Fill(s *NodeStats) {
+	s.Dials = c.dials.Load()
+	s.DialErrors = c.dialErrors.Load()
+	s.Reconnects = c.reconnects.Load()
+	s.ReconnectErrors = c.reconnectErrors.Load()
+	s.Expels = c.expels.Load()
+	s.IdleCloses = c.idleCloses.Load()
+	s.DropFulls = c.dropFulls.Load()
+}
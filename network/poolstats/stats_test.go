@@ -0,0 +1,57 @@
+package poolstats
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounters(t *testing.T) {
+	var c Counters
+	c.Record(NewEvent(EventDial, "tcp_127.0.0.1:8000", "tcp", "127.0.0.1:8000", nil))
+	c.Record(NewEvent(EventDial, "tcp_127.0.0.1:8000", "tcp", "127.0.0.1:8000", errors.New("refused")))
+	c.Record(NewEvent(EventReconnect, "tcp_127.0.0.1:8000", "tcp", "127.0.0.1:8000", nil))
+	c.Record(NewEvent(EventReconnect, "tcp_127.0.0.1:8000", "tcp", "127.0.0.1:8000", errors.New("refused")))
+	c.Record(NewEvent(EventExpel, "tcp_127.0.0.1:8000", "tcp", "127.0.0.1:8000", nil))
+	c.Record(NewEvent(EventIdleClose, "tcp_127.0.0.1:8000", "tcp", "127.0.0.1:8000", nil))
+	c.Record(NewEvent(EventDropFull, "tcp_127.0.0.1:8000", "tcp", "127.0.0.1:8000", nil))
+	c.Record(NewEvent(EventDropFull, "tcp_127.0.0.1:8000", "tcp", "127.0.0.1:8000", nil))
+
+	s := NodeStats{Node: "tcp_127.0.0.1:8000"}
+	c.Fill(&s)
+	assert.Equal(t, NodeStats{
+		Node:            "tcp_127.0.0.1:8000",
+		Dials:           2,
+		DialErrors:      1,
+		Reconnects:      2,
+		ReconnectErrors: 1,
+		Expels:          1,
+		IdleCloses:      1,
+		DropFulls:       2,
+	}, s)
+}
+
+func TestRegistry(t *testing.T) {
+	nodes := []NodeStats{{Node: "tcp_b"}, {Node: "tcp_a"}}
+	SortNodes(nodes)
+	assert.Equal(t, "tcp_a", nodes[0].Node)
+
+	Register("test-b", SnapshotterFunc(func() []NodeStats { return nodes }))
+	Register("test-a", SnapshotterFunc(func() []NodeStats { return nil }))
+	defer Unregister("test-b")
+
+	var names []string
+	Each(func(name string, s ISnapshotter) {
+		names = append(names, name)
+	})
+	assert.Equal(t, []string{"test-a", "test-b"}, names)
+
+	s, ok := Get("test-b")
+	assert.True(t, ok)
+	assert.Equal(t, nodes, s.Snapshot())
+
+	Unregister("test-a")
+	_, ok = Get("test-a")
+	assert.False(t, ok)
+}
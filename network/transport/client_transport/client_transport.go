@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/fengzhongzhu1621/xgo/collections/flowctrl/concurrency"
+	"github.com/fengzhongzhu1621/xgo/collections/loadbalance"
 	"github.com/fengzhongzhu1621/xgo/network/connpool"
 	"github.com/fengzhongzhu1621/xgo/network/multiplexed"
 	"github.com/fengzhongzhu1621/xgo/network/transport/options"
@@ -58,6 +60,26 @@ func (c *clientTransport) RoundTrip(ctx context.Context, req []byte,
 		o(opts)
 	}
 
+	// 超过并发上限时快速失败
+	if opts.Limiter != nil {
+		token, ok := opts.Limiter.Acquire()
+		if !ok {
+			return nil, xerror.ErrClientLimited
+		}
+		defer func() { releaseToken(token, err) }()
+	}
+
+	// 未指定地址时由负载均衡器选择节点
+	if opts.Address == "" && opts.Balancer != nil {
+		addr, done, pickErr := opts.Balancer.Pick(opts.BalanceKey)
+		if pickErr != nil {
+			return nil, xerror.NewFrameError(xerror.RetClientConnectFail,
+				"client transport: pick node fail: "+pickErr.Error())
+		}
+		opts.Address = addr
+		defer func() { reportNode(done, err) }()
+	}
+
 	if opts.EnableMultiplexed {
 		return c.multiplexed(ctx, req, opts)
 	}
@@ -72,3 +94,31 @@ func (c *clientTransport) RoundTrip(ctx context.Context, req []byte,
 			fmt.Sprintf("client transport: network %s not support", opts.Network))
 	}
 }
+
+// releaseToken 根据请求结果释放并发许可，超时和过载的请求缩减并发上限，其他失败的请求不影响并发上限，
+// 不等待回包的请求没有耗时，同样不影响并发上限
+func releaseToken(token concurrency.IToken, err error) {
+	// ErrClientNoResponse 的错误码为 RetOK，需要在 switch 之前判断
+	if err == xerror.ErrClientNoResponse {
+		token.OnIgnore()
+		return
+	}
+	switch xerror.Code(err) {
+	case xerror.RetOK:
+		token.OnSuccess()
+	case xerror.RetClientTimeout, xerror.RetClientFullLinkTimeout, xerror.RetServerOverload:
+		token.OnDropped()
+	default:
+		token.OnIgnore()
+	}
+}
+
+// reportNode 将调用结果反馈给负载均衡器，调用方取消和全链路超时与节点无关，不影响节点的统计
+func reportNode(done loadbalance.DoneFunc, err error) {
+	switch xerror.Code(err) {
+	case xerror.RetClientCanceled, xerror.RetClientFullLinkTimeout:
+		done(context.Canceled)
+	default:
+		done(err)
+	}
+}
@@ -0,0 +1,355 @@
+package client_transport
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+
+	"github.com/fengzhongzhu1621/xgo/buildin/buffer"
+	"github.com/fengzhongzhu1621/xgo/codec"
+	"github.com/fengzhongzhu1621/xgo/network/ssl"
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/fengzhongzhu1621/xgo/xerror"
+	"github.com/quic-go/quic-go"
+	"golang.org/x/sync/singleflight"
+)
+
+// quicNoError 正常关闭 QUIC 连接或者流时使用的错误码
+const quicNoError = 0
+
+var (
+	_ IClientTransport       = (*QUICClientTransport)(nil)
+	_ IClientStreamTransport = (*QUICClientTransport)(nil)
+)
+
+// QUICClientTransport QUIC 客户端传输，同一个地址复用一个 QUIC 连接
+// 每次 RPC 调用或者每个流式 RPC 使用一个独立的 QUIC 流，数据帧由 FramerBuilder 切分
+type QUICClientTransport struct {
+	mu    sync.Mutex
+	conns map[string]*quic.Conn // 连接标识 => QUIC 连接
+	dials singleflight.Group    // 正在建立的连接，同一个连接标识只握手一次
+
+	streamMu sync.RWMutex
+	streams  map[uint32]*quicClientStream // 流 ID => QUIC 流
+}
+
+// quicClientStream 流式 RPC 使用的 QUIC 流
+type quicClientStream struct {
+	stream *quic.Stream
+	fr     codec.IFramer
+}
+
+// DefaultQUICClientTransport 默认的 QUIC 客户端传输
+var DefaultQUICClientTransport = NewQUICClientTransport()
+
+// NewQUICClientTransport 创建 QUIC 客户端传输，同时实现了 IClientTransport 和 IClientStreamTransport
+func NewQUICClientTransport() *QUICClientTransport {
+	return &QUICClientTransport{
+		conns:   make(map[string]*quic.Conn),
+		streams: make(map[uint32]*quicClientStream),
+	}
+}
+
+// RoundTrip 打开一个 QUIC 流发送请求，并读取一个响应帧
+func (c *QUICClientTransport) RoundTrip(ctx context.Context, req []byte,
+	roundTripOpts ...options.RoundTripOption) ([]byte, error) {
+	opts := &options.RoundTripOptions{}
+	for _, o := range roundTripOpts {
+		o(opts)
+	}
+
+	if opts.FramerBuilder == nil {
+		return nil, xerror.NewFrameError(xerror.RetClientConnectFail,
+			"quic client transport: framer builder empty")
+	}
+
+	stream, err := c.openStream(ctx, codec.Message(ctx), opts)
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { cancelQUICStream(stream) })
+	defer stop()
+
+	if _, err := stream.Write(req); err != nil {
+		cancelQUICStream(stream)
+		return nil, quicFrameError(ctx, xerror.RetClientNetErr, "quic client transport Write: ", err)
+	}
+	// 关闭流的发送方向，通知服务端请求已发送完成
+	stream.Close()
+
+	if opts.ReqType == codec.SendOnly {
+		stream.CancelRead(quicNoError)
+		return nil, xerror.ErrClientNoResponse
+	}
+
+	rsp, err := opts.FramerBuilder.New(buffer.NewReader(stream)).ReadFrame()
+	// 只读取一个响应帧，通知服务端停止发送
+	stream.CancelRead(quicNoError)
+	if err != nil {
+		return nil, quicFrameError(ctx, xerror.RetClientReadFrameErr, "quic client transport ReadFrame: ", err)
+	}
+	return rsp, nil
+}
+
+// Init 为流式 RPC 打开一个 QUIC 流
+func (c *QUICClientTransport) Init(ctx context.Context, roundTripOpts ...options.RoundTripOption) error {
+	opts := &options.RoundTripOptions{}
+	for _, o := range roundTripOpts {
+		o(opts)
+	}
+
+	if opts.FramerBuilder == nil {
+		return xerror.NewFrameError(xerror.RetClientConnectFail,
+			"quic client transport: framer builder empty")
+	}
+	if opts.Msg == nil {
+		return xerror.NewFrameError(xerror.RetClientConnectFail,
+			"quic client transport: message empty")
+	}
+
+	stream, err := c.openStream(ctx, opts.Msg, opts)
+	if err != nil {
+		return err
+	}
+
+	c.streamMu.Lock()
+	c.streams[opts.Msg.StreamID()] = &quicClientStream{
+		stream: stream,
+		fr:     opts.FramerBuilder.New(buffer.NewReader(stream)),
+	}
+	c.streamMu.Unlock()
+	return nil
+}
+
+// Send 向流式 RPC 的 QUIC 流发送数据
+func (c *QUICClientTransport) Send(ctx context.Context, req []byte, roundTripOpts ...options.RoundTripOption) error {
+	cs, err := c.getStream(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := cs.stream.Write(req); err != nil {
+		return quicFrameError(ctx, xerror.RetClientNetErr, "quic client transport Write: ", err)
+	}
+	return nil
+}
+
+// Recv 从流式 RPC 的 QUIC 流读取一个数据帧
+func (c *QUICClientTransport) Recv(ctx context.Context, roundTripOpts ...options.RoundTripOption) ([]byte, error) {
+	cs, err := c.getStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, quicFrameError(ctx, xerror.RetClientNetErr, "quic client transport before Recv: ", err)
+	}
+	if d, ok := ctx.Deadline(); ok {
+		cs.stream.SetReadDeadline(d)
+	}
+
+	rsp, err := cs.fr.ReadFrame()
+	if err != nil {
+		return nil, quicFrameError(ctx, xerror.RetClientReadFrameErr, "quic client transport ReadFrame: ", err)
+	}
+	// 数据帧可能复用缓冲区，复制后再返回
+	data := make([]byte, len(rsp))
+	copy(data, rsp)
+	return data, nil
+}
+
+// Close 关闭流式 RPC 的 QUIC 流，QUIC 连接继续复用
+func (c *QUICClientTransport) Close(ctx context.Context) {
+	streamID := codec.Message(ctx).StreamID()
+	c.streamMu.Lock()
+	cs, ok := c.streams[streamID]
+	delete(c.streams, streamID)
+	c.streamMu.Unlock()
+
+	if ok {
+		cs.stream.CancelRead(quicNoError)
+		cs.stream.Close()
+	}
+}
+
+// getStream 根据消息的流 ID 查询 QUIC 流
+func (c *QUICClientTransport) getStream(ctx context.Context) (*quicClientStream, error) {
+	c.streamMu.RLock()
+	cs := c.streams[codec.Message(ctx).StreamID()]
+	c.streamMu.RUnlock()
+	if cs == nil {
+		return nil, xerror.NewFrameError(xerror.RetServerSystemErr, "Stream is not inited yet")
+	}
+	return cs, nil
+}
+
+// openStream 获取 QUIC 连接并打开一个新的流，连接地址记录到消息中
+func (c *QUICClientTransport) openStream(ctx context.Context, msg codec.IMsg,
+	opts *options.RoundTripOptions) (*quic.Stream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, quicFrameError(ctx, xerror.RetClientConnectFail, "client before quic dial: ", err)
+	}
+
+	qc, err := c.getConn(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := qc.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, quicFrameError(ctx, xerror.RetClientConnectFail, "quic client transport OpenStream: ", err)
+	}
+	if d, ok := ctx.Deadline(); ok {
+		stream.SetDeadline(d)
+	}
+
+	msg.WithRemoteAddr(qc.RemoteAddr())
+	msg.WithLocalAddr(qc.LocalAddr())
+	return stream, nil
+}
+
+// getConn 返回可用的 QUIC 连接，连接不存在或者已关闭时重新建立连接
+// 连接标识包含 TLS 配置，因为 TLS 握手绑定在连接上；同一个连接标识同时只有一个握手，
+// 握手在锁外进行，不会阻塞其他地址的请求
+func (c *QUICClientTransport) getConn(ctx context.Context, opts *options.RoundTripOptions) (*quic.Conn, error) {
+	key := opts.Network + "|" + opts.Address + "|" + opts.LocalAddr + "|" + opts.TLSServerName + "|" +
+		opts.CACertFile + "|" + opts.TLSCertFile
+
+	if qc := c.loadConn(key); qc != nil {
+		return qc, nil
+	}
+
+	// 握手不随发起者取消，其他等待同一个连接的请求不受影响，但是仍然受发起者的截止时间限制
+	dialCtx := context.WithoutCancel(ctx)
+	if d, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithDeadline(dialCtx, d)
+		defer cancel()
+	}
+	ch := c.dials.DoChan(key, func() (interface{}, error) {
+		if qc := c.loadConn(key); qc != nil {
+			return qc, nil
+		}
+		qc, err := dialQUIC(dialCtx, opts)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.conns[key] = qc
+		c.mu.Unlock()
+		return qc, nil
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*quic.Conn), nil
+	case <-ctx.Done():
+		return nil, quicFrameError(ctx, xerror.RetClientConnectFail, "quic client transport dial: ", ctx.Err())
+	}
+}
+
+// loadConn 返回连接标识对应的可用连接，已关闭的连接被删除
+func (c *QUICClientTransport) loadConn(key string) *quic.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if qc, ok := c.conns[key]; ok {
+		if qc.Context().Err() == nil {
+			return qc
+		}
+		delete(c.conns, key)
+	}
+	return nil
+}
+
+// dialQUIC 建立 QUIC 连接，连接关闭后释放 UDP 连接
+func dialQUIC(ctx context.Context, opts *options.RoundTripOptions) (*quic.Conn, error) {
+	tlsConf, err := getQUICClientTLSConfig(opts)
+	if err != nil {
+		return nil, xerror.NewFrameError(xerror.RetClientConnectFail,
+			"quic client transport tls config: "+err.Error())
+	}
+
+	network := options.QUICPacketNetwork(opts.Network)
+	raddr, err := net.ResolveUDPAddr(network, opts.Address)
+	if err != nil {
+		return nil, xerror.NewFrameError(xerror.RetClientConnectFail,
+			"quic client transport ResolveUDPAddr: "+err.Error())
+	}
+	var laddr *net.UDPAddr
+	if opts.LocalAddr != "" {
+		if laddr, err = net.ResolveUDPAddr(network, opts.LocalAddr); err != nil {
+			return nil, xerror.NewFrameError(xerror.RetClientConnectFail,
+				"quic client transport ResolveUDPAddr: "+err.Error())
+		}
+	}
+
+	pc, err := net.ListenUDP(network, laddr)
+	if err != nil {
+		return nil, xerror.NewFrameError(xerror.RetClientConnectFail,
+			"quic client transport ListenUDP: "+err.Error())
+	}
+
+	// 连接使用 ctx 超时和拨号超时中较小的值
+	if opts.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.DialTimeout)
+		defer cancel()
+	}
+
+	tr := &quic.Transport{Conn: pc}
+	qc, err := tr.Dial(ctx, raddr, tlsConf, &quic.Config{})
+	if err != nil {
+		tr.Close()
+		pc.Close()
+		return nil, quicFrameError(ctx, xerror.RetClientConnectFail, "quic client transport dial: ", err)
+	}
+
+	go func() {
+		<-qc.Context().Done()
+		tr.Close()
+		pc.Close()
+	}()
+	return qc, nil
+}
+
+// getQUICClientTLSConfig 根据请求选项创建 TLS 配置，没有设置 CA 证书时使用系统根证书验证服务端
+func getQUICClientTLSConfig(opts *options.RoundTripOptions) (*tls.Config, error) {
+	serverName := opts.TLSServerName
+	if serverName == "" {
+		serverName, _, _ = net.SplitHostPort(opts.Address)
+	}
+
+	tlsConf := &tls.Config{ServerName: serverName}
+	if opts.CACertFile != "" {
+		var err error
+		tlsConf, err = ssl.GetClientConfig(serverName, opts.CACertFile, opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tlsConf.NextProtos = []string{options.QUICNextProto}
+	return tlsConf, nil
+}
+
+// cancelQUICStream 同时取消流的读写方向
+func cancelQUICStream(stream *quic.Stream) {
+	stream.CancelRead(quicNoError)
+	stream.CancelWrite(quicNoError)
+}
+
+// quicFrameError 将错误转换为框架错误，区分超时和取消
+func quicFrameError(ctx context.Context, code int, prefix string, err error) error {
+	switch {
+	case ctx.Err() == context.Canceled:
+		return xerror.NewFrameError(xerror.RetClientCanceled, prefix+err.Error())
+	case ctx.Err() == context.DeadlineExceeded:
+		return xerror.NewFrameError(xerror.RetClientTimeout, prefix+err.Error())
+	}
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		return xerror.NewFrameError(xerror.RetClientTimeout, prefix+err.Error())
+	}
+	return xerror.NewFrameError(code, prefix+err.Error())
+}
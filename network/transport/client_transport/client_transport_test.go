@@ -0,0 +1,35 @@
+package client_transport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fengzhongzhu1621/xgo/xerror"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordToken 记录许可的释放方式
+type recordToken struct {
+	released string
+}
+
+func (t *recordToken) OnSuccess() { t.released = "success" }
+func (t *recordToken) OnDropped() { t.released = "dropped" }
+func (t *recordToken) OnIgnore()  { t.released = "ignore" }
+
+func TestReleaseToken(t *testing.T) {
+	for _, tc := range []struct {
+		err      error
+		released string
+	}{
+		{nil, "success"},
+		{xerror.ErrClientNoResponse, "ignore"},
+		{xerror.NewFrameError(xerror.RetClientTimeout, "timeout"), "dropped"},
+		{xerror.NewFrameError(xerror.RetServerOverload, "overload"), "dropped"},
+		{errors.New("connect fail"), "ignore"},
+	} {
+		token := &recordToken{}
+		releaseToken(token, tc.err)
+		assert.Equal(t, tc.released, token.released, "err: %v", tc.err)
+	}
+}
@@ -11,3 +11,9 @@ type IHandler interface {
 type ICloseHandler interface {
 	HandleClose(ctx context.Context) error
 }
+
+// IRejectHandler builds the response of the request rejected by the server transport, such as exceeding
+// the concurrency limit. It is called instead of Handle, so it must not execute the business logic.
+type IRejectHandler interface {
+	HandleReject(ctx context.Context, req []byte, err error) (rsp []byte, e error)
+}
@@ -3,15 +3,32 @@ package transport
 import (
 	"github.com/fengzhongzhu1621/xgo/network/transport/client_transport"
 	"github.com/fengzhongzhu1621/xgo/network/transport/server_transport"
+	"github.com/fengzhongzhu1621/xgo/network/transport/ws"
 )
 
-const transportName = "go-net"
+const (
+	transportName     = "go-net"
+	quicTransportName = "quic"
+	wsTransportName   = "websocket"
+)
 
 func init() {
 	client_transport.RegisterClientTransport(transportName, client_transport.DefaultClientTransport)
 	client_transport.RegisterClientStreamTransport(transportName, client_transport.DefaultClientStreamTransport)
+
+	client_transport.RegisterClientTransport(quicTransportName, client_transport.DefaultQUICClientTransport)
+	client_transport.RegisterClientStreamTransport(quicTransportName, client_transport.DefaultQUICClientTransport)
+
+	client_transport.RegisterClientTransport(wsTransportName, ws.DefaultClientTransport)
+	client_transport.RegisterClientStreamTransport(wsTransportName, ws.DefaultClientTransport)
 }
 
 func init() {
 	server_transport.RegisterServerTransport(transportName, server_transport.DefaultServerStreamTransport)
+
+	server_transport.RegisterServerTransport(quicTransportName, server_transport.DefaultQUICServerTransport)
+	server_transport.RegisterServerStreamTransport(quicTransportName, server_transport.DefaultQUICServerTransport)
+
+	server_transport.RegisterServerTransport(wsTransportName, ws.DefaultServerTransport)
+	server_transport.RegisterServerStreamTransport(wsTransportName, ws.DefaultServerTransport)
 }
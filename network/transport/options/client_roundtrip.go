@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/fengzhongzhu1621/xgo/codec"
+	"github.com/fengzhongzhu1621/xgo/collections/flowctrl/concurrency"
+	"github.com/fengzhongzhu1621/xgo/collections/loadbalance"
 	"github.com/fengzhongzhu1621/xgo/network/connpool"
 	"github.com/fengzhongzhu1621/xgo/network/multiplexed"
 )
@@ -24,6 +26,9 @@ type RoundTripOptions struct {
 	Multiplexed           multiplexed.IPool    // 多路复用连接池
 	Msg                   codec.IMsg           // 消息对象
 	Protocol              string               // 协议类型
+	Limiter               concurrency.ILimiter // 自适应并发限制器
+	Balancer              loadbalance.Balancer // 负载均衡器，Address 为空时用于选择节点
+	BalanceKey            string               // 一致性哈希等负载均衡策略使用的请求标识
 
 	CACertFile    string // CA证书文件路径
 	TLSCertFile   string // 客户端证书文件路径
@@ -162,3 +167,21 @@ func WithProtocol(s string) RoundTripOption {
 		o.Protocol = s
 	}
 }
+
+// WithClientLimiter returns a RoundTripOption which sets the concurrency limiter, the requests exceeding
+// the limit fail fast with xerror.ErrClientLimited.
+func WithClientLimiter(limiter concurrency.ILimiter) RoundTripOption {
+	return func(o *RoundTripOptions) {
+		o.Limiter = limiter
+	}
+}
+
+// WithBalancer returns a RoundTripOption which sets the load balancer. When the dial address is empty,
+// a node is picked by the balancer for each call and the result is reported back for outlier ejection.
+// The key is used by the strategies such as consistent hash.
+func WithBalancer(b loadbalance.Balancer, key string) RoundTripOption {
+	return func(o *RoundTripOptions) {
+		o.Balancer = b
+		o.BalanceKey = key
+	}
+}
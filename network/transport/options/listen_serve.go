@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/fengzhongzhu1621/xgo/codec"
+	"github.com/fengzhongzhu1621/xgo/collections/flowctrl/concurrency"
 	"github.com/fengzhongzhu1621/xgo/network/transport/handler"
 )
 
@@ -35,6 +36,9 @@ type ListenServeOptions struct {
 
 	// StopListening 用于通知服务器传输停止监听
 	StopListening <-chan struct{}
+
+	// Limiter 自适应并发限制器，超过并发上限的请求不执行业务逻辑，直接返回过载错误
+	Limiter concurrency.ILimiter
 }
 
 // WithServiceName returns a ListenServeOption which sets the service name.
@@ -80,6 +84,15 @@ func WithHandler(handler handler.IHandler) ListenServeOption {
 	}
 }
 
+// WithServerLimiter returns a ListenServeOption which sets the concurrency limiter. The requests exceeding
+// the limit are answered by handler.IRejectHandler with xerror.ErrServerLimited set as the server response
+// error, without calling Handle. The connection is closed if the handler does not implement it.
+func WithServerLimiter(limiter concurrency.ILimiter) ListenServeOption {
+	return func(opts *ListenServeOptions) {
+		opts.Limiter = limiter
+	}
+}
+
 // WithServeTLS returns a ListenServeOption which sets TLS relatives.
 func WithServeTLS(certFile, keyFile, caFile string) ListenServeOption {
 	return func(opts *ListenServeOptions) {
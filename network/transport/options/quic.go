@@ -0,0 +1,18 @@
+package options
+
+import "strings"
+
+// QUICNextProto QUIC 传输默认使用的 ALPN 协议名称，服务端和客户端需要保持一致
+const QUICNextProto = "xgo-quic"
+
+// QUICPacketNetwork 返回 QUIC 底层使用的 UDP 网络类型，例如 quic => udp，quic4 => udp4
+func QUICPacketNetwork(network string) string {
+	switch network {
+	case "", "quic":
+		return "udp"
+	case "quic4", "quic6":
+		return "udp" + strings.TrimPrefix(network, "quic")
+	default:
+		return network
+	}
+}
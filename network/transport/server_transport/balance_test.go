@@ -0,0 +1,55 @@
+package server_transport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/collections/loadbalance"
+	"github.com/fengzhongzhu1621/xgo/network/transport/client_transport"
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/fengzhongzhu1621/xgo/xerror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientBalancer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := NewServerTransport()
+	err := st.ListenAndServe(ctx,
+		options.WithListenNetwork("tcp"),
+		options.WithListenAddress("127.0.0.1:12056"),
+		options.WithHandler(&overloadHandler{}),
+		options.WithServerFramerBuilder(&lengthFramerBuilder{}),
+	)
+	require.NoError(t, err)
+
+	// 127.0.0.1:12057 没有监听，连接失败后被摘除
+	b := loadbalance.NewBalancer(loadbalance.NewRoundRobinPicker(), loadbalance.WithConsecutiveFailures(1))
+	b.Update([]loadbalance.Node{{Address: "127.0.0.1:12057"}, {Address: "127.0.0.1:12056"}})
+
+	failures := 0
+	for i := 0; i < 4; i++ {
+		rsp, err := client_transport.DefaultClientTransport.RoundTrip(ctx, lengthFrame("hello"),
+			options.WithDialNetwork("tcp"),
+			options.WithDialTimeout(time.Second),
+			options.WithClientFramerBuilder(&lengthFramerBuilder{}),
+			options.WithBalancer(b, ""),
+		)
+		if err != nil {
+			failures++
+			continue
+		}
+		assert.Equal(t, "hello", string(rsp[4:]))
+	}
+	assert.Equal(t, 1, failures)
+
+	// 没有可用的节点
+	b.Update(nil)
+	_, err = client_transport.DefaultClientTransport.RoundTrip(ctx, lengthFrame("hello"),
+		options.WithDialNetwork("tcp"),
+		options.WithBalancer(b, ""),
+	)
+	assert.EqualValues(t, xerror.RetClientConnectFail, xerror.Code(err))
+}
@@ -0,0 +1,126 @@
+package server_transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/logging"
+)
+
+// drainCheckInterval 排空连接时检查正在处理的请求数量的间隔
+const drainCheckInterval = 10 * time.Millisecond
+
+// drainers records the server transports which have served stream connections.
+var drainers = &sync.Map{}
+
+// IDrainer is implemented by the server transports which can drain connections gracefully.
+type IDrainer interface {
+	// Drain waits for the in-flight requests to finish and then closes the connections,
+	// the connections are closed immediately when ctx is done.
+	Drain(ctx context.Context) error
+}
+
+var _ IDrainer = (*serverTransport)(nil)
+
+// Drain 排空连接：连接停止读取新的请求，已经读取的请求处理完成后关闭所有连接，ctx 结束时不再等待直接关闭连接
+// 调用前需要先停止监听，否则新的连接仍然会被接受
+func (s *serverTransport) Drain(ctx context.Context) error {
+	defer s.closeConns()
+
+	// 先停止读取，再等待请求处理完成，保证已经读取的请求都会回包
+	s.draining.Store(true)
+	s.interruptReads()
+	if err := waitZero(ctx, s.readers, "connections are still reading"); err != nil {
+		return err
+	}
+	return waitZero(ctx, s.inflight, "requests are still in-flight")
+}
+
+// interruptReads 唤醒阻塞在读取请求上的连接，连接读取超时后退出读循环
+func (s *serverTransport) interruptReads() {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	now := time.Now()
+	for _, tc := range s.addrToConn {
+		if err := tc.rwc.SetReadDeadline(now); err != nil {
+			logging.Tracef("transport: drain set read deadline error: %v", err)
+		}
+	}
+}
+
+// waitZero 等待 n 归零，ctx 结束时返回错误
+func waitZero(ctx context.Context, n *atomic.Int64, desc string) error {
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+	for n.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			logging.Errorf("transport: drain connections timeout, %d %s", n.Load(), desc)
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// closeConns 关闭所有已建立的连接，客户端重新建立连接到新的进程
+func (s *serverTransport) closeConns() {
+	s.m.RLock()
+	conns := make([]*tcpconn, 0, len(s.addrToConn))
+	for _, tc := range s.addrToConn {
+		conns = append(conns, tc)
+	}
+	s.m.RUnlock()
+
+	for _, tc := range conns {
+		tc.close()
+	}
+}
+
+// StopListening closes all the stream listeners of the current process so that no new connection will be
+// accepted, the fds passed to the child process are not affected. The QUIC listeners share the UDP socket
+// with their connections, they are closed by the Drain of the QUIC server transport.
+func StopListening() {
+	listenersMap.Range(func(key, _ interface{}) bool {
+		if ln, ok := key.(net.Listener); ok {
+			if err := ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+				logging.Errorf("transport: close listener %s error: %v", ln.Addr(), err)
+			}
+			listenersMap.Delete(key)
+		}
+		return true
+	})
+}
+
+// DrainAll stops listening and drains the connections of all the server transports concurrently,
+// returns the first error when ctx is done before all the in-flight requests finish.
+func DrainAll(ctx context.Context) error {
+	StopListening()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	drainers.Range(func(key, _ interface{}) bool {
+		d := key.(IDrainer)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.Drain(ctx); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+		return true
+	})
+	wg.Wait()
+	return firstErr
+}
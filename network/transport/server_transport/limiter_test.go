@@ -0,0 +1,156 @@
+package server_transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/codec"
+	"github.com/fengzhongzhu1621/xgo/collections/flowctrl/concurrency"
+	"github.com/fengzhongzhu1621/xgo/network/transport/client_transport"
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/fengzhongzhu1621/xgo/xerror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// overloadHandler 请求被拒绝时返回 overload，否则延迟返回请求内容
+type overloadHandler struct {
+	delay   time.Duration
+	handled atomic.Int64
+}
+
+func (h *overloadHandler) Handle(ctx context.Context, req []byte) ([]byte, error) {
+	h.handled.Add(1)
+	time.Sleep(h.delay)
+	return req, nil
+}
+
+func (h *overloadHandler) HandleReject(ctx context.Context, req []byte, err error) ([]byte, error) {
+	if codec.Message(ctx).ServerRspErr() == nil {
+		return nil, errors.New("server rsp err not set")
+	}
+	return lengthFrame("overload"), nil
+}
+
+func TestServerLimiter(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		address := "127.0.0.1:12053"
+		if async {
+			address = "127.0.0.1:12054"
+		}
+		limiter := concurrency.NewLimiter("server", concurrency.FixedLimit(1))
+		h := &overloadHandler{delay: 200 * time.Millisecond}
+		ctx, cancel := context.WithCancel(context.Background())
+		st := NewServerTransport()
+		err := st.ListenAndServe(ctx,
+			options.WithListenNetwork("tcp"),
+			options.WithListenAddress(address),
+			options.WithHandler(h),
+			options.WithServerFramerBuilder(&lengthFramerBuilder{}),
+			options.WithServerAsync(async),
+			options.WithServerLimiter(limiter),
+		)
+		require.NoError(t, err)
+
+		// 两个连接同时发送请求，超过并发上限的请求直接返回过载
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			rsps []string
+		)
+		for i, body := range []string{"first", "second"} {
+			wg.Add(1)
+			go func(delay time.Duration, body string) {
+				defer wg.Done()
+				time.Sleep(delay)
+				conn, err := net.Dial("tcp", address)
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer conn.Close()
+				_, err = conn.Write(lengthFrame(body))
+				assert.NoError(t, err)
+				rsp, err := (&lengthFramerBuilder{}).New(conn).ReadFrame()
+				assert.NoError(t, err)
+				mu.Lock()
+				rsps = append(rsps, string(rsp[4:]))
+				mu.Unlock()
+			}(time.Duration(i)*50*time.Millisecond, body)
+		}
+		wg.Wait()
+
+		// 被拒绝的请求不执行业务逻辑
+		assert.Equal(t, []string{"overload", "first"}, rsps)
+		assert.Equal(t, int64(1), h.handled.Load())
+		assert.Equal(t, concurrency.Stats{Name: "server", Limit: 1, Inflight: 0, Rejected: 1}, limiter.Stats())
+		cancel()
+	}
+}
+
+func TestClientLimiter(t *testing.T) {
+	limiter := concurrency.NewLimiter("client", concurrency.FixedLimit(0))
+	_, err := client_transport.DefaultClientTransport.RoundTrip(context.Background(), lengthFrame("limited"),
+		options.WithDialNetwork("tcp"),
+		options.WithDialAddress("127.0.0.1:12055"),
+		options.WithClientLimiter(limiter),
+	)
+	assert.Equal(t, xerror.ErrClientLimited, err)
+	assert.Equal(t, uint64(1), limiter.Stats().Rejected)
+}
+
+func TestServerLimiterCloseWithoutRejectHandler(t *testing.T) {
+	address := "127.0.0.1:12057"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := NewServerTransport()
+	err := st.ListenAndServe(ctx,
+		options.WithListenNetwork("tcp"),
+		options.WithListenAddress(address),
+		options.WithHandler(&slowHandler{}),
+		options.WithServerFramerBuilder(&lengthFramerBuilder{}),
+		options.WithServerLimiter(concurrency.NewLimiter("server", concurrency.FixedLimit(0))),
+	)
+	require.NoError(t, err)
+
+	// handler 不能构造过载回包时关闭连接
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write(lengthFrame("closed"))
+	require.NoError(t, err)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = (&lengthFramerBuilder{}).New(conn).ReadFrame()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+// recordToken 记录许可的释放方式
+type recordToken struct {
+	released string
+}
+
+func (t *recordToken) OnSuccess() { t.released = "success" }
+func (t *recordToken) OnDropped() { t.released = "dropped" }
+func (t *recordToken) OnIgnore()  { t.released = "ignore" }
+
+func TestReleaseToken(t *testing.T) {
+	for _, tc := range []struct {
+		rejected, err error
+		released      string
+	}{
+		{nil, nil, "success"},
+		{nil, xerror.ErrServerNoResponse, "ignore"},
+		{nil, xerror.NewFrameError(xerror.RetServerTimeout, "timeout"), "dropped"},
+		{nil, errors.New("business error"), "success"},
+		{xerror.ErrServerLimited, nil, "dropped"},
+	} {
+		token := &recordToken{}
+		releaseToken(token, tc.rejected, tc.err)
+		assert.Equal(t, tc.released, token.released, "err: %v", tc.err)
+	}
+}
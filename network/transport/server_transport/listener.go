@@ -57,6 +57,52 @@ func getPassedListener(network, address string) (interface{}, error) {
 	return ln, nil
 }
 
+// Listen returns a stream listener which can be passed to the child process during hot restart,
+// the listener is inherited from the parent process if the process is started by hot restart.
+// It is used by the servers outside the server transports, such as HTTP servers.
+func Listen(network, address string) (net.Listener, error) {
+	v, _ := os.LookupEnv(EnvGraceRestart)
+	if ok, _ := strconv.ParseBool(v); ok {
+		ln, err := getPassedStreamListener(network, address)
+		if err != nil {
+			return nil, err
+		}
+		listenersMap.Store(ln, struct{}{})
+		return ln, nil
+	}
+
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	listenersMap.Store(ln, struct{}{})
+	return ln, nil
+}
+
+// getPassedStreamListener 查找父进程传递的监听器，监听所有地址时监听器的地址为 [::]:port 或者 0.0.0.0:port
+func getPassedStreamListener(network, address string) (net.Listener, error) {
+	addrs := []string{address}
+	if host, port, err := net.SplitHostPort(address); err == nil && host == "" {
+		if p, err := net.LookupPort(network, port); err == nil {
+			port = strconv.Itoa(p)
+		}
+		addrs = append(addrs, net.JoinHostPort("::", port), net.JoinHostPort("0.0.0.0", port))
+	}
+
+	for _, addr := range addrs {
+		pln, err := getPassedListener(network, addr)
+		if err != nil {
+			continue
+		}
+		ln, ok := pln.(net.Listener)
+		if !ok {
+			return nil, errors.New("invalid net.Listener")
+		}
+		return ln, nil
+	}
+	return nil, errNotFound
+}
+
 // inheritListeners stores the listener according to start listenfd and number of listenfd passed
 // by environment variables.
 func inheritListeners() {
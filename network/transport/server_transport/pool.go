@@ -5,19 +5,23 @@ import (
 	"sync"
 	"time"
 
+	"github.com/fengzhongzhu1621/xgo/collections/flowctrl/concurrency"
 	"github.com/fengzhongzhu1621/xgo/logging"
+	"github.com/fengzhongzhu1621/xgo/xerror"
 	"github.com/panjf2000/ants/v2"
 )
 
 type handleParam struct {
 	req   []byte
 	c     *tcpconn
+	token concurrency.IToken
 	start time.Time
 }
 
 func (p *handleParam) reset() {
 	p.req = nil
 	p.c = nil
+	p.token = nil
 	p.start = time.Time{}
 }
 
@@ -40,7 +44,7 @@ func createRoutinePool(size int) *ants.PoolWithFunc {
 			logging.Tracef("routine pool tcpconn is nil, shouldn't happen!")
 			return
 		}
-		param.c.handleSync(param.req)
+		param.c.handleSync(param.req, param.token)
 		param.reset()
 		handleParamPool.Put(param)
 	})
@@ -50,3 +54,26 @@ func createRoutinePool(size int) *ants.PoolWithFunc {
 	}
 	return pool
 }
+
+// releaseToken 根据处理结果释放并发许可，被拒绝、超时和过载的请求缩减并发上限，
+// 流式请求没有回包，不影响并发上限
+func releaseToken(token concurrency.IToken, rejected, err error) {
+	if rejected != nil {
+		token.OnDropped()
+		return
+	}
+	// ErrServerNoResponse 的错误码为 RetOK，需要在 switch 之前判断
+	if err == xerror.ErrServerNoResponse {
+		token.OnIgnore()
+		return
+	}
+	switch xerror.Code(err) {
+	case xerror.RetOK:
+		token.OnSuccess()
+	case xerror.RetServerTimeout, xerror.RetServerFullLinkTimeout, xerror.RetServerOverload:
+		token.OnDropped()
+	default:
+		// 业务失败同样反映了处理耗时
+		token.OnSuccess()
+	}
+}
@@ -0,0 +1,301 @@
+package server_transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/logging"
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	defaultReadyTimeout = 30 * time.Second
+	defaultDrainTimeout = 30 * time.Second
+
+	// readyMessage 子进程就绪后写入管道的内容
+	readyMessage = "ready"
+)
+
+var (
+	errNotReady = errors.New("child process exited before ready")
+
+	notifyReadyOnce sync.Once
+)
+
+// RestartOptions is the options of the hot restart coordinator.
+type RestartOptions struct {
+	// 新进程的可执行文件，默认为当前进程的可执行文件
+	Binary string
+	// 新进程的参数，不包括 argv[0]，默认为当前进程的参数
+	Args []string
+	// 等待新进程就绪的超时时间，超时后杀死新进程并继续提供服务
+	ReadyTimeout time.Duration
+	// 排空连接的超时时间，超时后直接关闭连接
+	DrainTimeout time.Duration
+	// 触发热重启的信号，默认为 SIGUSR2
+	Signals []os.Signal
+}
+
+// RestartOption modifies the RestartOptions.
+type RestartOption func(*RestartOptions)
+
+// WithRestartBinary returns a RestartOption which sets the binary and arguments of the new process.
+func WithRestartBinary(binary string, args ...string) RestartOption {
+	return func(o *RestartOptions) {
+		o.Binary = binary
+		o.Args = args
+	}
+}
+
+// WithReadyTimeout returns a RestartOption which sets the timeout of waiting for the new process to be ready.
+func WithReadyTimeout(d time.Duration) RestartOption {
+	return func(o *RestartOptions) {
+		o.ReadyTimeout = d
+	}
+}
+
+// WithDrainTimeout returns a RestartOption which sets the timeout of draining connections.
+func WithDrainTimeout(d time.Duration) RestartOption {
+	return func(o *RestartOptions) {
+		o.DrainTimeout = d
+	}
+}
+
+// WithRestartSignals returns a RestartOption which sets the signals triggering hot restart.
+func WithRestartSignals(signals ...os.Signal) RestartOption {
+	return func(o *RestartOptions) {
+		o.Signals = signals
+	}
+}
+
+// Restarter 热重启协调器
+// 收到热重启信号后，启动新进程并通过文件描述符传递所有监听器，新进程所有服务开始监听后通过管道通知父进程，
+// 父进程随后停止监听，等待正在处理的请求完成后关闭连接并退出，整个过程中监听端口不会关闭
+type Restarter struct {
+	opts *RestartOptions
+
+	mu            sync.Mutex
+	shutdownFuncs []func(ctx context.Context) error
+}
+
+// NewRestarter creates a hot restart coordinator.
+func NewRestarter(opt ...RestartOption) *Restarter {
+	opts := &RestartOptions{
+		Binary:       os.Args[0],
+		Args:         os.Args[1:],
+		ReadyTimeout: defaultReadyTimeout,
+		DrainTimeout: defaultDrainTimeout,
+		Signals:      defaultRestartSignals,
+	}
+	if binary, err := os.Executable(); err == nil {
+		opts.Binary = binary
+	}
+	for _, o := range opt {
+		o(opts)
+	}
+	return &Restarter{opts: opts}
+}
+
+// OnShutdown registers the function called when the process stops serving, such as http.Server.Shutdown,
+// it is called concurrently with draining the connections of the server transports.
+func (r *Restarter) OnShutdown(fn func(ctx context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.shutdownFuncs = append(r.shutdownFuncs, fn)
+}
+
+// Run 通知父进程当前进程已就绪，然后等待热重启信号，需要在所有服务开始监听后调用
+// 收到信号后启动新进程，新进程就绪后停止服务并返回，调用方随后退出进程；新进程启动失败时继续提供服务
+// ctx 结束时同样停止服务后返回
+func (r *Restarter) Run(ctx context.Context) error {
+	if err := NotifyReady(); err != nil {
+		return fmt.Errorf("notify parent process ready err: %w", err)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	if len(r.opts.Signals) > 0 {
+		signal.Notify(sigCh, r.opts.Signals...)
+		defer signal.Stop(sigCh)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Infof("server is shutting down")
+			return r.Shutdown()
+		case sig := <-sigCh:
+			logging.Infof("receive signal %v, start hot restart", sig)
+			pid, err := r.Restart(ctx)
+			if err != nil {
+				logging.Errorf("hot restart fail, continue serving: %v", err)
+				continue
+			}
+			logging.Infof("new process %d is ready, draining connections", pid)
+			return r.Shutdown()
+		}
+	}
+}
+
+// Restart starts a new process which inherits all the listeners, and waits for the new process to report
+// readiness, returns the pid of the new process. The new process is killed if it is not ready in time.
+func (r *Restarter) Restart(ctx context.Context) (int, error) {
+	rp, wp, err := os.Pipe()
+	if err != nil {
+		return 0, fmt.Errorf("create ready pipe err: %w", err)
+	}
+	defer rp.Close()
+
+	// 文件描述符依次为标准输入输出、监听器和就绪管道
+	fds := GetListenersFds()
+	files := make([]uintptr, 0, len(fds)+4)
+	files = append(files, os.Stdin.Fd(), os.Stdout.Fd(), os.Stderr.Fd())
+	for _, fd := range fds {
+		files = append(files, fd.Fd)
+	}
+	files = append(files, wp.Fd())
+
+	args := append([]string{r.opts.Binary}, r.opts.Args...)
+	pid, err := forkExec(r.opts.Binary, args, restartEnv(len(fds)), files)
+	// 父进程关闭写端，子进程退出时读端返回 EOF
+	wp.Close()
+	if err != nil {
+		return 0, fmt.Errorf("fork exec %s err: %w", r.opts.Binary, err)
+	}
+	logging.Infof("start new process %d with %d listeners", pid, len(fds))
+
+	ctx, cancel := context.WithTimeout(ctx, r.opts.ReadyTimeout)
+	defer cancel()
+	if err := waitReady(ctx, rp); err != nil {
+		killProcess(pid)
+		return 0, fmt.Errorf("wait process %d ready err: %w", pid, err)
+	}
+	return pid, nil
+}
+
+// Shutdown stops listening, drains the connections of all the server transports and calls the
+// registered shutdown functions within DrainTimeout.
+func (r *Restarter) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.DrainTimeout)
+	defer cancel()
+
+	r.mu.Lock()
+	funcs := append([]func(ctx context.Context) error{DrainAll}, r.shutdownFuncs...)
+	r.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs error
+	)
+	for _, fn := range funcs {
+		wg.Add(1)
+		go func(fn func(ctx context.Context) error) {
+			defer wg.Done()
+			if err := fn(ctx); err != nil {
+				mu.Lock()
+				errs = multierror.Append(errs, err)
+				mu.Unlock()
+			}
+		}(fn)
+	}
+	wg.Wait()
+	return errs
+}
+
+// NotifyReady reports readiness to the parent process, it does nothing if the process is not started by
+// hot restart. Restarter.Run calls it automatically.
+func NotifyReady() error {
+	var err error
+	notifyReadyOnce.Do(func() {
+		v, ok := os.LookupEnv(EnvGraceReadyFd)
+		if !ok {
+			return
+		}
+		os.Unsetenv(EnvGraceReadyFd)
+
+		fd, perr := strconv.Atoi(v)
+		if perr != nil {
+			err = fmt.Errorf("invalid %s: %w", EnvGraceReadyFd, perr)
+			return
+		}
+		f := os.NewFile(uintptr(fd), "ready pipe")
+		defer f.Close()
+		_, err = f.Write([]byte(readyMessage))
+	})
+	return err
+}
+
+// waitReady 等待子进程写入就绪消息，子进程退出时管道返回 EOF
+func waitReady(ctx context.Context, rp *os.File) error {
+	errCh := make(chan error, 1)
+	go func() {
+		buf := make([]byte, len(readyMessage))
+		if _, err := io.ReadFull(rp, buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = errNotReady
+			}
+			errCh <- err
+			return
+		}
+		if string(buf) != readyMessage {
+			errCh <- fmt.Errorf("unexpected ready message: %q", buf)
+			return
+		}
+		errCh <- nil
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// restartEnv 返回新进程的环境变量，监听器的文件描述符从 3 开始，就绪管道紧随其后
+func restartEnv(fdNum int) []string {
+	keys := []string{EnvGraceRestart, EnvGraceFirstFd, EnvGraceRestartFdNum, EnvGraceRestartPPID, EnvGraceReadyFd}
+
+	env := make([]string, 0, len(os.Environ())+len(keys))
+	for _, kv := range os.Environ() {
+		inherited := true
+		for _, key := range keys {
+			if strings.HasPrefix(kv, key+"=") {
+				inherited = false
+				break
+			}
+		}
+		if inherited {
+			env = append(env, kv)
+		}
+	}
+
+	const firstFd = 3
+	return append(env,
+		EnvGraceRestart+"=1",
+		EnvGraceFirstFd+"="+strconv.Itoa(firstFd),
+		EnvGraceRestartFdNum+"="+strconv.Itoa(fdNum),
+		EnvGraceRestartPPID+"="+strconv.Itoa(os.Getpid()),
+		EnvGraceReadyFd+"="+strconv.Itoa(firstFd+fdNum),
+	)
+}
+
+// killProcess 杀死没有就绪的子进程并回收资源
+func killProcess(pid int) {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return
+	}
+	if err := p.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		logging.Errorf("kill process %d err: %v", pid, err)
+	}
+	p.Wait()
+}
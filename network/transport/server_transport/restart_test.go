@@ -0,0 +1,327 @@
+package server_transport
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/codec"
+	"github.com/fengzhongzhu1621/xgo/network/ssl"
+	"github.com/fengzhongzhu1621/xgo/network/transport/client_transport"
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	envRestartHelper = "XGO_TEST_RESTART_HELPER"
+	envRestartAddr   = "XGO_TEST_RESTART_ADDR"
+)
+
+// lengthFramerBuilder 使用 4 字节长度头分包
+type lengthFramerBuilder struct{}
+
+func (fb *lengthFramerBuilder) New(r io.Reader) codec.IFramer {
+	return &lengthFramer{r: r}
+}
+
+type lengthFramer struct {
+	r io.Reader
+}
+
+func (f *lengthFramer) ReadFrame() ([]byte, error) {
+	var head [4]byte
+	if _, err := io.ReadFull(f.r, head[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, 4+binary.BigEndian.Uint32(head[:]))
+	copy(data, head[:])
+	if _, err := io.ReadFull(f.r, data[4:]); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func lengthFrame(body string) []byte {
+	data := make([]byte, 4+len(body))
+	binary.BigEndian.PutUint32(data, uint32(len(body)))
+	copy(data[4:], body)
+	return data
+}
+
+// slowHandler 延迟返回请求内容
+type slowHandler struct {
+	delay time.Duration
+}
+
+func (h *slowHandler) Handle(ctx context.Context, req []byte) ([]byte, error) {
+	time.Sleep(h.delay)
+	return req, nil
+}
+
+func TestDrainAll(t *testing.T) {
+	address := "127.0.0.1:12051"
+	st := NewServerTransport()
+	err := st.ListenAndServe(context.Background(),
+		options.WithListenNetwork("tcp"),
+		options.WithListenAddress(address),
+		options.WithHandler(&slowHandler{delay: 200 * time.Millisecond}),
+		options.WithServerFramerBuilder(&lengthFramerBuilder{}),
+	)
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+	req := lengthFrame("draining")
+	_, err = conn.Write(req)
+	require.NoError(t, err)
+
+	// 等待请求开始处理
+	require.Eventually(t, func() bool {
+		return st.(*serverTransport).inflight.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, DrainAll(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	// 正在处理的请求正常返回，随后连接被关闭
+	rsp, err := (&lengthFramerBuilder{}).New(conn).ReadFrame()
+	require.NoError(t, err)
+	assert.Equal(t, req, rsp)
+	_, err = (&lengthFramerBuilder{}).New(conn).ReadFrame()
+	assert.Error(t, err)
+
+	// 停止监听后不再接受新的连接
+	_, err = net.DialTimeout("tcp", address, 100*time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestDrainTimeout(t *testing.T) {
+	address := "127.0.0.1:12052"
+	st := NewServerTransport()
+	err := st.ListenAndServe(context.Background(),
+		options.WithListenNetwork("tcp"),
+		options.WithListenAddress(address),
+		options.WithHandler(&slowHandler{delay: time.Second}),
+		options.WithServerFramerBuilder(&lengthFramerBuilder{}),
+	)
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write(lengthFrame("timeout"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return st.(*serverTransport).inflight.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// 超时后直接关闭连接
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, st.(IDrainer).Drain(ctx), context.DeadlineExceeded)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = (&lengthFramerBuilder{}).New(conn).ReadFrame()
+	assert.Error(t, err)
+}
+
+func TestDrainKeepAlive(t *testing.T) {
+	address := "127.0.0.1:12056"
+	st := NewServerTransport()
+	err := st.ListenAndServe(context.Background(),
+		options.WithListenNetwork("tcp"),
+		options.WithListenAddress(address),
+		options.WithHandler(&slowHandler{delay: 10 * time.Millisecond}),
+		options.WithServerFramerBuilder(&lengthFramerBuilder{}),
+	)
+	require.NoError(t, err)
+
+	// 空闲的长连接和持续发送请求的长连接
+	idle, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer idle.Close()
+	busy, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer busy.Close()
+
+	type result struct {
+		sent, replied int
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		fr := (&lengthFramerBuilder{}).New(busy)
+		for {
+			req := lengthFrame("keepalive")
+			if _, err := busy.Write(req); err != nil {
+				break
+			}
+			r.sent++
+			rsp, err := fr.ReadFrame()
+			if err != nil {
+				break
+			}
+			assert.Equal(t, req, rsp)
+			r.replied++
+		}
+		done <- r
+	}()
+	require.Eventually(t, func() bool {
+		return st.(*serverTransport).inflight.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	// 持续的请求不会阻止排空，已经读取的请求都会回包
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	require.NoError(t, st.(IDrainer).Drain(ctx))
+	assert.Less(t, time.Since(start), time.Second)
+
+	r := <-done
+	assert.Greater(t, r.replied, 0)
+	assert.LessOrEqual(t, r.sent-r.replied, 1)
+	idle.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = idle.Read(make([]byte, 1))
+	assert.Error(t, err)
+	assert.Zero(t, st.(*serverTransport).readers.Load())
+}
+
+func TestDrainQUIC(t *testing.T) {
+	certPEM, keyPEM, err := ssl.GenerateCACertificatePEM()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	address := "127.0.0.1:12058"
+	err = NewQUICServerTransport().ListenAndServe(context.Background(),
+		options.WithListenNetwork("quic"),
+		options.WithListenAddress(address),
+		options.WithHandler(&slowHandler{delay: 200 * time.Millisecond}),
+		options.WithServerFramerBuilder(&lengthFramerBuilder{}),
+		options.WithServeTLS(certFile, keyFile, ""),
+	)
+	require.NoError(t, err)
+	var qs *quicServer
+	drainers.Range(func(key, _ interface{}) bool {
+		if q, ok := key.(*quicServer); ok && q.ln.Addr().String() == address {
+			qs = q
+		}
+		return qs == nil
+	})
+	require.NotNil(t, qs)
+
+	roundTrip := func(ctx context.Context, body string) ([]byte, error) {
+		return client_transport.NewQUICClientTransport().RoundTrip(ctx, lengthFrame(body),
+			options.WithDialNetwork("quic"),
+			options.WithDialAddress(address),
+			options.WithClientFramerBuilder(&lengthFramerBuilder{}),
+			options.WithDialTLS("", "", "none", ""),
+		)
+	}
+	type result struct {
+		rsp []byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		rsp, err := roundTrip(ctx, "draining")
+		done <- result{rsp, err}
+	}()
+	require.Eventually(t, func() bool {
+		return qs.streams.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// 正在处理的流正常返回，随后关闭连接，不再接受新的连接
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, qs.Drain(ctx))
+	r := <-done
+	require.NoError(t, r.err)
+	assert.Equal(t, lengthFrame("draining"), r.rsp)
+	assert.Zero(t, qs.streams.Load())
+
+	reqCtx, reqCancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer reqCancel()
+	_, err = roundTrip(reqCtx, "closed")
+	assert.Error(t, err)
+}
+
+// TestRestartHelperProcess 作为热重启启动的新进程运行
+func TestRestartHelperProcess(t *testing.T) {
+	switch os.Getenv(envRestartHelper) {
+	case "ready":
+		// 检查继承的监听器后通知父进程
+		if _, err := Listen("tcp", os.Getenv(envRestartAddr)); err != nil {
+			os.Exit(1)
+		}
+		if err := NotifyReady(); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "exit":
+		os.Exit(1)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	ln, err := Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	t.Setenv(envRestartAddr, ln.Addr().String())
+	r := NewRestarter(
+		WithRestartBinary(os.Args[0], "-test.run=^TestRestartHelperProcess$"),
+		WithReadyTimeout(5*time.Second),
+	)
+
+	// 新进程继承监听器后就绪
+	t.Setenv(envRestartHelper, "ready")
+	pid, err := r.Restart(context.Background())
+	require.NoError(t, err)
+	assert.Greater(t, pid, 0)
+	p, err := os.FindProcess(pid)
+	require.NoError(t, err)
+	p.Wait()
+
+	// 新进程就绪前退出
+	t.Setenv(envRestartHelper, "exit")
+	_, err = r.Restart(context.Background())
+	assert.ErrorIs(t, err, errNotReady)
+
+	// 新进程就绪超时
+	r = NewRestarter(
+		WithRestartBinary("/bin/sleep", "10"),
+		WithReadyTimeout(100*time.Millisecond),
+	)
+	_, err = r.Restart(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRestarterShutdown(t *testing.T) {
+	var called bool
+	r := NewRestarter(WithDrainTimeout(time.Second))
+	r.OnShutdown(func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		called = ok
+		return nil
+	})
+	assert.NoError(t, r.Shutdown())
+	assert.True(t, called)
+
+	env := restartEnv(2)
+	assert.Contains(t, env, EnvGraceRestart+"=1")
+	assert.Contains(t, env, EnvGraceFirstFd+"=3")
+	assert.Contains(t, env, EnvGraceRestartFdNum+"=2")
+	assert.Contains(t, env, EnvGraceReadyFd+"=5")
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package server_transport
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultRestartSignals 默认使用 SIGUSR2 触发热重启
+var defaultRestartSignals = []os.Signal{syscall.SIGUSR2}
+
+// forkExec 启动新进程，直接使用原始文件描述符，避免 (*os.File).Fd 将监听器设置为阻塞模式
+func forkExec(binary string, args []string, env []string, files []uintptr) (int, error) {
+	return syscall.ForkExec(binary, args, &syscall.ProcAttr{
+		Env:   env,
+		Files: files,
+	})
+}
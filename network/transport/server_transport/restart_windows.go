@@ -0,0 +1,16 @@
+//go:build windows
+
+package server_transport
+
+import (
+	"errors"
+	"os"
+)
+
+// defaultRestartSignals windows 不支持热重启
+var defaultRestartSignals []os.Signal
+
+// forkExec windows 不支持传递监听器的文件描述符
+func forkExec(binary string, args []string, env []string, files []uintptr) (int, error) {
+	return 0, errors.New("hot restart is not supported on windows")
+}
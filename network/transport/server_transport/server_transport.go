@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fengzhongzhu1621/xgo/logging"
@@ -31,6 +32,9 @@ const (
 
 	// EnvGraceRestartPPID is the PPID of graceful restart.
 	EnvGraceRestartPPID = "XGO_RPC_GRACEFUL_PPID"
+
+	// EnvGraceReadyFd is the fd of the pipe which the child process reports readiness to.
+	EnvGraceReadyFd = "XGO_RPC_GRACEFUL_READY_FD"
 )
 
 var (
@@ -69,6 +73,9 @@ type serverTransport struct {
 	addrToConn map[string]*tcpconn             // 地址到TCP连接的映射
 	m          *sync.RWMutex                   // 读写锁，保护连接映射的并发安全
 	opts       *options.ServerTransportOptions // 服务器传输配置选项
+	inflight   *atomic.Int64                   // 正在处理的请求数量，排空连接时等待归零
+	readers    *atomic.Int64                   // 正在读取请求的连接数量，排空连接时等待归零
+	draining   *atomic.Bool                    // 是否正在排空连接，排空时连接不再读取新的请求
 }
 
 // DefaultServerTransport ServerStreamTransport的默认实现
@@ -88,7 +95,14 @@ func newServerTransport(opt ...options.ServerTransportOption) serverTransport {
 		o(opts) // 应用用户提供的选项
 	}
 	addrToConn := make(map[string]*tcpconn) // 初始化连接映射
-	return serverTransport{addrToConn: addrToConn, m: &sync.RWMutex{}, opts: opts}
+	return serverTransport{
+		addrToConn: addrToConn,
+		m:          &sync.RWMutex{},
+		opts:       opts,
+		inflight:   &atomic.Int64{},
+		readers:    &atomic.Int64{},
+		draining:   &atomic.Bool{},
+	}
 }
 
 // ListenAndServe 开始监听，失败时返回错误
@@ -148,6 +162,8 @@ func (s *serverTransport) listenAndServeStream(ctx context.Context, opts *option
 
 	// 必须保存原始TCP监听器（而不是TLS监听器）以确保热重启时可以成功检索底层文件描述符
 	listenersMap.Store(ln, struct{}{})
+	// 热重启或者退出时排空连接
+	drainers.Store(s, struct{}{})
 
 	// 可能升级为TLS监听器
 	ln, err = mayLiftToTLSListener(ln, opts)
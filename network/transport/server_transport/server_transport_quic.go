@@ -0,0 +1,419 @@
+package server_transport
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/buildin/buffer"
+	"github.com/fengzhongzhu1621/xgo/codec"
+	"github.com/fengzhongzhu1621/xgo/logging"
+	"github.com/fengzhongzhu1621/xgo/network/ssl"
+	"github.com/fengzhongzhu1621/xgo/network/transport/frame"
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/fengzhongzhu1621/xgo/xerror"
+	"github.com/quic-go/quic-go"
+)
+
+const (
+	// quicNoError 正常关闭 QUIC 连接或者流时使用的错误码
+	quicNoError = 0
+	// quicDrainLinger 排空连接时所有的流关闭后，等待回包发送到客户端的时间
+	quicDrainLinger = 100 * time.Millisecond
+)
+
+var _ IServerStreamTransport = (*quicServerTransport)(nil)
+
+// quicServerTransport QUIC 服务端传输，一个 QUIC 流对应一次 RPC 调用或者一个流式 RPC
+// 流内的数据帧仍然由 FramerBuilder 切分，因此可以复用已有的协议编解码
+type quicServerTransport struct {
+	opts *options.ServerTransportOptions
+}
+
+// DefaultQUICServerTransport 默认的 QUIC 服务端传输
+var DefaultQUICServerTransport = NewQUICServerTransport()
+
+// NewQUICServerTransport 创建 QUIC 服务端传输，支持 IdleTimeout 和 KeepAlivePeriod 选项
+func NewQUICServerTransport(opt ...options.ServerTransportOption) IServerStreamTransport {
+	opts := options.DefaultServerTransportOptions()
+	for _, o := range opt {
+		o(opts)
+	}
+	return &quicServerTransport{opts: opts}
+}
+
+// ListenAndServe 开始监听，QUIC 协议强制使用 TLS，必须设置服务端证书和私钥
+// Network 支持 quic、quic4、quic6，也可以直接使用 udp、udp4、udp6
+func (s *quicServerTransport) ListenAndServe(ctx context.Context, opts ...options.ListenServeOption) error {
+	lsopts := &options.ListenServeOptions{}
+	for _, opt := range opts {
+		opt(lsopts)
+	}
+
+	if lsopts.FramerBuilder == nil {
+		return errors.New("quic transport FramerBuilder empty")
+	}
+
+	tlsConf, err := getQUICServerTLSConfig(lsopts)
+	if err != nil {
+		return err
+	}
+
+	pc, err := s.getPacketConn(lsopts)
+	if err != nil {
+		return fmt.Errorf("get quic packet conn err: %w", err)
+	}
+
+	tr := &quic.Transport{Conn: pc}
+	ln, err := tr.Listen(tlsConf, s.quicConfig(lsopts))
+	if err != nil {
+		pc.Close()
+		return fmt.Errorf("quic listen err: %w", err)
+	}
+
+	// 保存原始的 UDP 连接，热重启时将文件描述符传递给子进程
+	listenersMap.Store(pc, struct{}{})
+
+	qs := newQUICServer(ctx, tr, ln, lsopts)
+	// 热重启或者退出时排空连接
+	drainers.Store(qs, struct{}{})
+	go qs.serve(ctx)
+	return nil
+}
+
+// getQUICServerTLSConfig 根据监听选项创建 TLS 配置
+func getQUICServerTLSConfig(opts *options.ListenServeOptions) (*tls.Config, error) {
+	if opts.TLSCertFile == "" || opts.TLSKeyFile == "" {
+		return nil, errors.New("quic transport requires TLSCertFile and TLSKeyFile")
+	}
+	tlsConf, err := ssl.GetServerConfig(opts.CACertFile, opts.TLSCertFile, opts.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls get server config err: %w", err)
+	}
+	tlsConf.NextProtos = []string{options.QUICNextProto}
+	return tlsConf, nil
+}
+
+// getPacketConn 获取 UDP 连接，热重启时使用父进程传递的连接
+// QUIC 连接需要固定在同一个 socket 上，因此不使用端口复用
+func (s *quicServerTransport) getPacketConn(opts *options.ListenServeOptions) (net.PacketConn, error) {
+	network := options.QUICPacketNetwork(opts.Network)
+
+	v, _ := os.LookupEnv(EnvGraceRestart)
+	ok, _ := strconv.ParseBool(v)
+	if ok {
+		ln, err := getPassedListener(network, opts.Address)
+		if err != nil {
+			return nil, err
+		}
+		pc, ok := ln.(net.PacketConn)
+		if !ok {
+			return nil, errors.New("invalid net.PacketConn")
+		}
+		return pc, nil
+	}
+
+	return net.ListenPacket(network, opts.Address)
+}
+
+// quicConfig 返回 QUIC 连接配置，服务端传输选项的空闲超时时间优先
+func (s *quicServerTransport) quicConfig(opts *options.ListenServeOptions) *quic.Config {
+	idleTimeout := opts.IdleTimeout
+	if s.opts.IdleTimeout > 0 {
+		idleTimeout = s.opts.IdleTimeout
+	}
+	return &quic.Config{
+		MaxIdleTimeout:  idleTimeout,
+		KeepAlivePeriod: s.opts.KeepAlivePeriod,
+	}
+}
+
+var _ IDrainer = (*quicServer)(nil)
+
+// quicServer 一个 QUIC 监听器上的服务，记录已经建立的连接和正在处理的流，用于排空连接
+type quicServer struct {
+	tr   *quic.Transport
+	ln   *quic.Listener
+	opts *options.ListenServeOptions
+
+	// acceptCtx 结束时停止接受新的连接和流，服务关闭或者排空连接时结束
+	acceptCtx   context.Context
+	stopAccept  context.CancelFunc
+	closeLnOnce sync.Once
+
+	mu      sync.Mutex
+	conns   map[*quic.Conn]struct{}
+	streams *atomic.Int64 // 没有关闭的流的数量，排空连接时等待归零
+}
+
+func newQUICServer(ctx context.Context, tr *quic.Transport, ln *quic.Listener,
+	opts *options.ListenServeOptions) *quicServer {
+	acceptCtx, stopAccept := context.WithCancel(ctx)
+	return &quicServer{
+		tr:         tr,
+		ln:         ln,
+		opts:       opts,
+		acceptCtx:  acceptCtx,
+		stopAccept: stopAccept,
+		conns:      make(map[*quic.Conn]struct{}),
+		streams:    &atomic.Int64{},
+	}
+}
+
+// closeListener 关闭监听器，正在进行的握手被拒绝，已经建立的连接不受影响
+func (q *quicServer) closeListener() {
+	q.closeLnOnce.Do(func() { q.ln.Close() })
+}
+
+// serve 接受 QUIC 连接，每个连接启动一个协程接受流
+func (q *quicServer) serve(ctx context.Context) {
+	defer q.closeListener()
+
+	// ctx.Done 停止监听并关闭所有连接，而 opts.StopListening 只停止监听
+	go func() {
+		select {
+		case <-q.acceptCtx.Done():
+		case <-q.opts.StopListening:
+		}
+		logging.Tracef("recv server close event")
+		q.closeListener()
+	}()
+
+	var wg sync.WaitGroup
+	for {
+		qc, err := q.ln.Accept(ctx)
+		if err != nil {
+			logging.Infof("quic listener with address %s is closed: %v", q.ln.Addr(), err)
+			break
+		}
+
+		q.mu.Lock()
+		q.conns[qc] = struct{}{}
+		q.mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.serveConn(ctx, qc)
+		}()
+	}
+
+	// 服务关闭时等待连接关闭后再关闭 UDP 连接，停止监听时已建立的连接继续服务
+	if ctx.Err() != nil {
+		wg.Wait()
+		q.tr.Close()
+		q.tr.Conn.Close()
+	}
+}
+
+// serveConn 接受 QUIC 连接上的流，每个流启动一个协程处理
+func (q *quicServer) serveConn(ctx context.Context, qc *quic.Conn) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			qc.CloseWithError(quicNoError, "server closed")
+		case <-qc.Context().Done():
+		}
+		q.mu.Lock()
+		delete(q.conns, qc)
+		q.mu.Unlock()
+	}()
+
+	for {
+		// 排空连接时不再接受新的流，已经接受的流继续处理
+		stream, err := qc.AcceptStream(q.acceptCtx)
+		if err != nil {
+			logging.Tracef("transport: quic conn AcceptStream fail: %v", err)
+			return
+		}
+
+		qs := &quicStream{
+			conn:       &conn{ctx: ctx, handler: q.opts.Handler},
+			stream:     stream,
+			fr:         q.opts.FramerBuilder.New(buffer.NewReader(stream)),
+			localAddr:  qc.LocalAddr(),
+			remoteAddr: qc.RemoteAddr(),
+			streams:    q.streams,
+		}
+		qs.copyFrame = frame.ShouldCopy(q.opts.CopyFrame, false, codec.IsSafeFramer(qs.fr))
+		q.streams.Add(1)
+		go qs.serve()
+	}
+}
+
+// Drain 排空连接：关闭监听器并停止接受新的流，已经接受的流处理完成后关闭所有连接和 UDP 连接，
+// ctx 结束时不再等待直接关闭。排空期间父子进程共享 UDP 连接，到达旧进程的新握手会被拒绝，客户端需要重试
+func (q *quicServer) Drain(ctx context.Context) error {
+	q.stopAccept()
+	q.closeListener()
+	defer q.close()
+	if err := waitZero(ctx, q.streams, "quic streams are still in-flight"); err != nil {
+		return err
+	}
+
+	// 流关闭后回包仍然可能在发送中，关闭连接会丢弃没有发送的数据
+	timer := time.NewTimer(quicDrainLinger)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// close 关闭所有连接和 UDP 连接，子进程继承的文件描述符不受影响
+func (q *quicServer) close() {
+	q.mu.Lock()
+	conns := make([]*quic.Conn, 0, len(q.conns))
+	for qc := range q.conns {
+		conns = append(conns, qc)
+	}
+	q.mu.Unlock()
+
+	for _, qc := range conns {
+		qc.CloseWithError(quicNoError, "server draining")
+	}
+	q.tr.Close()
+	q.tr.Conn.Close()
+	listenersMap.Delete(q.tr.Conn)
+	drainers.Delete(q)
+}
+
+// Send 向流式 RPC 所在的 QUIC 流发送数据，ctx 需要是 Handle 传入的 ctx 或者其子 ctx
+func (s *quicServerTransport) Send(ctx context.Context, req []byte) error {
+	qs, ok := ctx.Value(quicStreamContextKey{}).(*quicStream)
+	if !ok {
+		return xerror.NewFrameError(xerror.RetServerSystemErr, "Can't find quic stream in context")
+	}
+	if err := qs.write(req); err != nil {
+		qs.close()
+		return err
+	}
+	return nil
+}
+
+// Close 关闭流式 RPC 所在的 QUIC 流
+func (s *quicServerTransport) Close(ctx context.Context) {
+	if qs, ok := ctx.Value(quicStreamContextKey{}).(*quicStream); ok {
+		qs.close()
+	}
+}
+
+// ///////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// quicStreamContextKey 在 Handle 的 ctx 中保存 QUIC 流，用于流式 RPC 发送数据
+type quicStreamContextKey struct{}
+
+// quicStream 服务端接受的 QUIC 流
+type quicStream struct {
+	*conn
+	stream     *quic.Stream
+	fr         codec.IFramer
+	localAddr  net.Addr
+	remoteAddr net.Addr
+	copyFrame  bool
+
+	writeMu   sync.Mutex    // 流式 RPC 的 Send 可能与响应并发写入
+	streaming bool          // 流上是否有流式 RPC，由 Handle 返回 ErrServerNoResponse 判断
+	streams   *atomic.Int64 // 服务上没有关闭的流的数量，关闭时减少
+	closeOnce sync.Once
+}
+
+// serve 读取流上的请求帧并同步处理，保证同一个流上的消息有序
+func (qs *quicStream) serve() {
+	for {
+		select {
+		case <-qs.ctx.Done():
+			qs.close()
+			return
+		default:
+		}
+
+		req, err := qs.fr.ReadFrame()
+		if err != nil {
+			if err == io.EOF {
+				// 客户端发送完成，普通 RPC 关闭流，流式 RPC 由 Close 关闭流
+				if !qs.streaming {
+					qs.close()
+				}
+				return
+			}
+			logging.Trace("transport: quic stream ReadFrame fail ", err)
+			qs.close()
+			return
+		}
+
+		if qs.copyFrame {
+			reqCopy := make([]byte, len(req))
+			copy(reqCopy, req)
+			req = reqCopy
+		}
+
+		qs.handle(req)
+	}
+}
+
+// handle 处理业务逻辑并写回响应
+func (qs *quicStream) handle(req []byte) {
+	ctx, msg := codec.WithNewMessage(context.Background())
+	defer codec.PutBackMessage(msg)
+
+	msg.WithLocalAddr(qs.localAddr)
+	msg.WithRemoteAddr(qs.remoteAddr)
+	ctx = context.WithValue(ctx, quicStreamContextKey{}, qs)
+
+	rsp, err := qs.conn.handle(ctx, req)
+	if err != nil {
+		if err != xerror.ErrServerNoResponse {
+			logging.Trace("transport: quic stream serve handle fail ", err)
+			qs.close()
+			return
+		}
+		// On stream RPC, server does not need to write rsp, just returns.
+		qs.streaming = true
+		return
+	}
+
+	if err := qs.write(rsp); err != nil {
+		logging.Trace("transport: quic stream write fail ", err)
+		qs.close()
+	}
+}
+
+// write 向流写入数据
+func (qs *quicStream) write(p []byte) error {
+	qs.writeMu.Lock()
+	defer qs.writeMu.Unlock()
+	_, err := qs.stream.Write(p)
+	return err
+}
+
+// close 通知业务处理层并关闭流，只执行一次
+func (qs *quicStream) close() {
+	qs.closeOnce.Do(func() {
+		ctx, msg := codec.WithNewMessage(context.Background())
+		msg.WithLocalAddr(qs.localAddr)
+		msg.WithRemoteAddr(qs.remoteAddr)
+		msg.WithServerRspErr(&xerror.Error{
+			Type: xerror.ErrorTypeFramework,
+			Code: xerror.RetServerSystemErr,
+			Desc: "trpc",
+			Msg:  "Server quic stream closed",
+		})
+		if err := qs.conn.handleClose(ctx); err != nil {
+			logging.Trace("transport: notify quic stream close failed", err)
+		}
+
+		qs.stream.CancelRead(quicNoError)
+		qs.writeMu.Lock()
+		qs.stream.Close()
+		qs.writeMu.Unlock()
+		qs.streams.Add(-1)
+	})
+}
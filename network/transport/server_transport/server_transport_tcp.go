@@ -11,10 +11,12 @@ import (
 	"github.com/fengzhongzhu1621/xgo/buildin/buffer"
 	"github.com/fengzhongzhu1621/xgo/codec"
 	"github.com/fengzhongzhu1621/xgo/collections/backoff"
+	"github.com/fengzhongzhu1621/xgo/collections/flowctrl/concurrency"
 	"github.com/fengzhongzhu1621/xgo/collections/ring/writev"
 	"github.com/fengzhongzhu1621/xgo/logging"
 	"github.com/fengzhongzhu1621/xgo/network/ip"
 	"github.com/fengzhongzhu1621/xgo/network/transport/frame"
+	"github.com/fengzhongzhu1621/xgo/network/transport/handler"
 	"github.com/fengzhongzhu1621/xgo/network/transport/options"
 	"github.com/fengzhongzhu1621/xgo/xerror"
 	"github.com/panjf2000/ants/v2"
@@ -33,6 +35,7 @@ type tcpconn struct {
 	closeOnce   sync.Once
 	st          *serverTransport
 	pool        *ants.PoolWithFunc
+	limiter     concurrency.ILimiter
 	buffer      *writev.Buffer
 	closeNotify chan struct{}
 }
@@ -88,8 +91,15 @@ func (c *tcpconn) write(p []byte) (int, error) {
 	return c.rwc.Write(p)
 }
 
+// serve 循环读取连接上的请求并处理
+// 排空连接时不再读取新的请求，连接由 Drain 在正在处理的请求完成后关闭
 func (c *tcpconn) serve() {
-	defer c.close()
+	defer c.st.readers.Add(-1)
+	defer func() {
+		if !c.st.draining.Load() {
+			c.close()
+		}
+	}()
 	for {
 		// Check if upstream has closed.
 		select {
@@ -111,6 +121,11 @@ func (c *tcpconn) serve() {
 			}
 		}
 
+		// 在设置读超时之后检查，保证 Drain 设置的读超时不会被覆盖
+		if c.st.draining.Load() {
+			return
+		}
+
 		// 服务端从客户端监听的连接中读取一个完整的包
 		req, err := c.fr.ReadFrame()
 		if err != nil {
@@ -138,10 +153,23 @@ func (c *tcpconn) serve() {
 }
 
 // handle 处理业务逻辑
+// 超过并发上限的请求不执行业务逻辑，由 reject 直接回复过载错误；
 // 如果开启了异步处理，则将处理参数放入协程池中，否则直接调用handleSyncWithErr函数处理
 func (c *tcpconn) handle(req []byte) {
+	var token concurrency.IToken
+	if c.limiter != nil {
+		var ok bool
+		if token, ok = c.limiter.Acquire(); !ok {
+			c.reject(req, xerror.ErrServerLimited)
+			return
+		}
+	}
+
+	// 记录正在处理的请求，在 handleSyncWithErr 处理完成后减少
+	c.st.inflight.Add(1)
+
 	if !c.serverAsync || c.pool == nil {
-		c.handleSync(req)
+		c.handleSync(req, token)
 		return
 	}
 
@@ -150,20 +178,55 @@ func (c *tcpconn) handle(req []byte) {
 	args := handleParamPool.Get().(*handleParam)
 	args.req = req
 	args.c = c
+	args.token = token
 	args.start = time.Now()
 	if err := c.pool.Invoke(args); err != nil {
 		logging.Trace("transport: tcpconn serve routine pool put job queue fail ", err)
-		c.handleSyncWithErr(req, xerror.ErrServerRoutinePoolBusy)
+		c.handleSyncWithErr(req, token, xerror.ErrServerRoutinePoolBusy)
+	}
+}
+
+// reject 回复被拒绝的请求，不调用业务逻辑
+// handler 实现了 handler.IRejectHandler 时由它构造过载回包，否则传输层无法编码回包，关闭连接让客户端尽快重试
+func (c *tcpconn) reject(req []byte, e error) {
+	rh, ok := c.conn.handler.(handler.IRejectHandler)
+	if !ok {
+		logging.Tracef("transport: tcpconn reject request from %s: %v, close the connection", c.remoteAddr, e)
+		c.close()
+		return
+	}
+
+	ctx, msg := codec.WithNewMessage(context.Background())
+	defer codec.PutBackMessage(msg)
+	msg.WithServerRspErr(e)
+	msg.WithLocalAddr(c.localAddr)
+	msg.WithRemoteAddr(c.remoteAddr)
+
+	rsp, err := rh.HandleReject(ctx, req, e)
+	if err == nil {
+		_, err = c.write(rsp)
+	}
+	if err != nil {
+		logging.Trace("transport: tcpconn reject fail ", err)
+		c.close()
 	}
 }
 
-// handleSyncWithErr 同步处理业务逻辑
-func (c *tcpconn) handleSync(req []byte) {
-	c.handleSyncWithErr(req, nil)
+// handleSync 同步处理业务逻辑
+func (c *tcpconn) handleSync(req []byte, token concurrency.IToken) {
+	c.handleSyncWithErr(req, token, nil)
 }
 
-// handleSyncWithErr 处理业务逻辑
-func (c *tcpconn) handleSyncWithErr(req []byte, e error) {
+// handleSyncWithErr 处理业务逻辑，e 不为空时请求已经被拒绝，handler 根据 e 直接回包而不执行业务逻辑
+// token 为请求获取的并发许可，处理完成后释放
+func (c *tcpconn) handleSyncWithErr(req []byte, token concurrency.IToken, e error) {
+	defer c.st.inflight.Add(-1)
+
+	var err error
+	if token != nil {
+		defer func() { releaseToken(token, e, err) }()
+	}
+
 	// 创建一个新的消息，并传递给 ctx，覆盖 ctx 原来携带的消息
 	ctx, msg := codec.WithNewMessage(context.Background())
 	defer codec.PutBackMessage(msg)
@@ -175,7 +238,8 @@ func (c *tcpconn) handleSyncWithErr(req []byte, e error) {
 	msg.WithRemoteAddr(c.remoteAddr)
 
 	// 处理业务逻辑
-	rsp, err := c.conn.handle(ctx, req)
+	var rsp []byte
+	rsp, err = c.conn.handle(ctx, req)
 
 	if err != nil {
 		if err != xerror.ErrServerNoResponse {
@@ -271,6 +335,7 @@ func (s *serverTransport) serveTCP(ctx context.Context, ln net.Listener, opts *o
 			writev:      opts.Writev,                                   // 是否使用writev系统调用进行向量写操作
 			st:          s,                                             // 指向serverTransport的引用
 			pool:        pool,                                          // 协程池引用（如果启用）
+			limiter:     opts.Limiter,                                  // 并发限制器（如果启用）
 		}
 
 		// 如果启用writev优化，初始化写缓冲区
@@ -291,6 +356,7 @@ func (s *serverTransport) serveTCP(ctx context.Context, ln net.Listener, opts *o
 		s.m.Unlock()
 
 		// 启动新的goroutine处理该连接，实现并发处理
+		s.readers.Add(1)
 		go tc.serve()
 	}
 }
@@ -0,0 +1,275 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/codec"
+	"github.com/fengzhongzhu1621/xgo/network/ssl"
+	"github.com/fengzhongzhu1621/xgo/network/transport/client_transport"
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/fengzhongzhu1621/xgo/network/transport/server_transport"
+	"github.com/fengzhongzhu1621/xgo/xerror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeQUICCert 生成自签名证书，返回证书和私钥文件路径
+func writeQUICCert(t *testing.T) (string, string) {
+	certPEM, keyPEM, err := ssl.GenerateCACertificatePEM()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+	return certFile, keyFile
+}
+
+// quicFrame 使用 4 字节长度头封装数据
+func quicFrame(body string) []byte {
+	data := make([]byte, 4+len(body))
+	binary.BigEndian.PutUint32(data, uint32(len(body)))
+	copy(data[4:], body)
+	return data
+}
+
+// quicStreamHandler 模拟服务端流式业务处理逻辑，通过流式传输回写请求
+type quicStreamHandler struct {
+	st server_transport.IServerStreamTransport
+}
+
+func (h *quicStreamHandler) Handle(ctx context.Context, req []byte) ([]byte, error) {
+	if err := h.st.Send(ctx, req); err != nil {
+		return nil, err
+	}
+	return nil, xerror.ErrServerNoResponse
+}
+
+func TestQUICListenAndServe(t *testing.T) {
+	certFile, keyFile := writeQUICCert(t)
+	address := "127.0.0.1:12031"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st := server_transport.GetServerTransport("quic")
+	require.NotNil(t, st)
+	err := st.ListenAndServe(ctx,
+		options.WithListenNetwork("quic"),
+		options.WithListenAddress(address),
+		options.WithHandler(&echoHandler{}),
+		options.WithServerFramerBuilder(&framerBuilder{}),
+		options.WithServeTLS(certFile, keyFile, ""),
+	)
+	require.NoError(t, err)
+
+	// 热重启时可以获取 UDP 连接的文件描述符
+	var found bool
+	for _, fd := range server_transport.GetListenersFds() {
+		if fd.Network == "udp" && fd.Address == address {
+			found = true
+		}
+	}
+	assert.True(t, found)
+
+	ct := client_transport.GetClientTransport("quic")
+	require.NotNil(t, ct)
+	opts := []options.RoundTripOption{
+		options.WithDialNetwork("quic"),
+		options.WithDialAddress(address),
+		options.WithClientFramerBuilder(&framerBuilder{}),
+		options.WithDialTLS("", "", "none", ""),
+	}
+
+	// 多个请求复用同一个 QUIC 连接，每个请求使用一个 QUIC 流
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reqCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			reqCtx, msg := codec.WithNewMessage(reqCtx)
+
+			req := quicFrame(fmt.Sprintf("hello %d", i))
+			rsp, err := ct.RoundTrip(reqCtx, req, opts...)
+			assert.NoError(t, err)
+			assert.Equal(t, req, rsp)
+			assert.Equal(t, address, msg.RemoteAddr().String())
+		}(i)
+	}
+	wg.Wait()
+
+	// 只发送不接收响应
+	_, err = ct.RoundTrip(context.Background(), quicFrame("hello"),
+		append(opts, options.WithReqType(codec.SendOnly))...)
+	assert.Equal(t, xerror.ErrClientNoResponse, err)
+
+	// 服务端处理失败时关闭流
+	reqCtx, reqCancel := context.WithTimeout(context.Background(), time.Second)
+	defer reqCancel()
+	errAddress := "127.0.0.1:12032"
+	err = server_transport.NewQUICServerTransport().ListenAndServe(ctx,
+		options.WithListenNetwork("quic"),
+		options.WithListenAddress(errAddress),
+		options.WithHandler(&errorHandler{}),
+		options.WithServerFramerBuilder(&framerBuilder{}),
+		options.WithServeTLS(certFile, keyFile, ""),
+	)
+	require.NoError(t, err)
+	_, err = ct.RoundTrip(reqCtx, quicFrame("hello"),
+		options.WithDialNetwork("quic"),
+		options.WithDialAddress(errAddress),
+		options.WithClientFramerBuilder(&framerBuilder{}),
+		options.WithDialTLS("", "", "none", ""),
+	)
+	assert.Error(t, err)
+}
+
+func TestQUICDialNotBlocking(t *testing.T) {
+	certFile, keyFile := writeQUICCert(t)
+	address := "127.0.0.1:12035"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := server_transport.NewQUICServerTransport().ListenAndServe(ctx,
+		options.WithListenNetwork("quic"),
+		options.WithListenAddress(address),
+		options.WithHandler(&echoHandler{}),
+		options.WithServerFramerBuilder(&framerBuilder{}),
+		options.WithServeTLS(certFile, keyFile, ""),
+	)
+	require.NoError(t, err)
+
+	// 不回复任何数据包的地址，握手一直阻塞到超时
+	blackhole, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer blackhole.Close()
+
+	ct := client_transport.NewQUICClientTransport()
+	dial := func(ctx context.Context, address string) error {
+		_, err := ct.RoundTrip(ctx, quicFrame("hello"),
+			options.WithDialNetwork("quic"),
+			options.WithDialAddress(address),
+			options.WithClientFramerBuilder(&framerBuilder{}),
+			options.WithDialTLS("", "", "none", ""),
+		)
+		return err
+	}
+	blocked := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		blocked <- dial(ctx, blackhole.LocalAddr().String())
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// 其他地址的请求不会等待阻塞的握手
+	reqCtx, reqCancel := context.WithTimeout(context.Background(), time.Second)
+	defer reqCancel()
+	start := time.Now()
+	require.NoError(t, dial(reqCtx, address))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Error(t, <-blocked)
+}
+
+func TestQUICListenAndServeFail(t *testing.T) {
+	certFile, keyFile := writeQUICCert(t)
+	st := server_transport.NewQUICServerTransport()
+
+	// QUIC 协议必须使用 TLS
+	err := st.ListenAndServe(context.Background(),
+		options.WithListenNetwork("quic"),
+		options.WithListenAddress("127.0.0.1:12033"),
+		options.WithHandler(&echoHandler{}),
+		options.WithServerFramerBuilder(&framerBuilder{}),
+	)
+	assert.Error(t, err)
+
+	err = st.ListenAndServe(context.Background(),
+		options.WithListenNetwork("quic"),
+		options.WithListenAddress("127.0.0.1:12033"),
+		options.WithHandler(&echoHandler{}),
+		options.WithServeTLS(certFile, keyFile, ""),
+	)
+	assert.Error(t, err)
+
+	// 服务端证书不可信
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err = st.ListenAndServe(ctx,
+		options.WithListenNetwork("quic"),
+		options.WithListenAddress("127.0.0.1:12033"),
+		options.WithHandler(&echoHandler{}),
+		options.WithServerFramerBuilder(&framerBuilder{}),
+		options.WithServeTLS(certFile, keyFile, ""),
+	)
+	require.NoError(t, err)
+
+	reqCtx, reqCancel := context.WithTimeout(context.Background(), time.Second)
+	defer reqCancel()
+	_, err = client_transport.NewQUICClientTransport().RoundTrip(reqCtx, quicFrame("hello"),
+		options.WithDialNetwork("quic"),
+		options.WithDialAddress("127.0.0.1:12033"),
+		options.WithClientFramerBuilder(&framerBuilder{}),
+	)
+	assert.Error(t, err)
+}
+
+func TestQUICStreamListenAndServe(t *testing.T) {
+	certFile, keyFile := writeQUICCert(t)
+	address := "127.0.0.1:12034"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st := server_transport.NewQUICServerTransport()
+	err := st.ListenAndServe(ctx,
+		options.WithListenNetwork("quic"),
+		options.WithListenAddress(address),
+		options.WithHandler(&quicStreamHandler{st: st}),
+		options.WithServerFramerBuilder(&framerBuilder{}),
+		options.WithServeTLS(certFile, keyFile, ""),
+	)
+	require.NoError(t, err)
+
+	ct := client_transport.NewQUICClientTransport()
+	for _, streamID := range []uint32{1, 2} {
+		streamCtx, streamCancel := context.WithTimeout(context.Background(), 3*time.Second)
+		streamCtx, msg := codec.WithNewMessage(streamCtx)
+		msg.WithStreamID(streamID)
+
+		err = ct.Init(streamCtx,
+			options.WithDialNetwork("quic"),
+			options.WithDialAddress(address),
+			options.WithClientFramerBuilder(&framerBuilder{}),
+			options.WithDialTLS("", "", "none", ""),
+			options.WithMsg(msg),
+		)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			req := quicFrame(fmt.Sprintf("stream %d message %d", streamID, i))
+			require.NoError(t, ct.Send(streamCtx, req))
+			rsp, err := ct.Recv(streamCtx)
+			require.NoError(t, err)
+			assert.Equal(t, req, rsp)
+		}
+		ct.Close(streamCtx)
+		streamCancel()
+
+		// 关闭后不能继续发送
+		assert.Error(t, ct.Send(streamCtx, quicFrame("closed")))
+	}
+
+	// 没有 QUIC 流的 ctx 无法发送
+	assert.Error(t, st.Send(context.Background(), quicFrame("hello")))
+}
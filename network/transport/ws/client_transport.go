@@ -0,0 +1,297 @@
+package ws
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/buildin/buffer"
+	"github.com/fengzhongzhu1621/xgo/codec"
+	"github.com/fengzhongzhu1621/xgo/network/ssl"
+	"github.com/fengzhongzhu1621/xgo/network/transport/client_transport"
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/fengzhongzhu1621/xgo/xerror"
+)
+
+var (
+	_ client_transport.IClientTransport       = (*ClientTransport)(nil)
+	_ client_transport.IClientStreamTransport = (*ClientTransport)(nil)
+)
+
+// ClientTransport WebSocket 客户端传输
+// 普通 RPC 独占一个连接，调用完成后放回空闲连接池；每个流式 RPC 使用一个独立的连接
+type ClientTransport struct {
+	opts *wsOptions
+
+	mu   sync.Mutex
+	idle map[string][]*clientConn // 连接地址 => 空闲连接
+
+	streamMu sync.RWMutex
+	streams  map[uint32]*clientConn // 流 ID => 连接
+}
+
+// clientConn 客户端连接，帧读取器与连接绑定，避免缓冲区中的数据丢失
+type clientConn struct {
+	*Conn
+	url string
+	fr  codec.IFramer
+}
+
+// DefaultClientTransport 默认的 WebSocket 客户端传输
+var DefaultClientTransport = NewClientTransport()
+
+// NewClientTransport 创建 WebSocket 客户端传输，同时实现了 IClientTransport 和 IClientStreamTransport
+func NewClientTransport(opts ...Option) *ClientTransport {
+	return &ClientTransport{
+		opts:    newOptions(opts...),
+		idle:    make(map[string][]*clientConn),
+		streams: make(map[uint32]*clientConn),
+	}
+}
+
+// RoundTrip 发送请求并读取一个响应帧
+func (c *ClientTransport) RoundTrip(ctx context.Context, req []byte,
+	roundTripOpts ...options.RoundTripOption) ([]byte, error) {
+	opts := &options.RoundTripOptions{}
+	for _, o := range roundTripOpts {
+		o(opts)
+	}
+	if opts.FramerBuilder == nil {
+		return nil, xerror.NewFrameError(xerror.RetClientConnectFail,
+			"websocket client transport: framer builder empty")
+	}
+
+	cc, err := c.getConn(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := codec.Message(ctx)
+	msg.WithRemoteAddr(cc.RemoteAddr())
+	msg.WithLocalAddr(cc.LocalAddr())
+
+	if _, err := cc.Write(req); err != nil {
+		cc.Close()
+		return nil, wsFrameError(ctx, xerror.RetClientNetErr, "websocket client transport Write: ", err)
+	}
+
+	// 对端可能仍然返回响应，连接不再复用
+	if opts.ReqType == codec.SendOnly {
+		cc.Close()
+		return nil, xerror.ErrClientNoResponse
+	}
+
+	rsp, err := cc.fr.ReadFrame()
+	if err != nil {
+		cc.Close()
+		return nil, wsFrameError(ctx, xerror.RetClientReadFrameErr, "websocket client transport ReadFrame: ", err)
+	}
+	rspCopy := make([]byte, len(rsp))
+	copy(rspCopy, rsp)
+
+	c.putConn(cc)
+	return rspCopy, nil
+}
+
+// Init 为流式 RPC 建立一个独立的连接
+func (c *ClientTransport) Init(ctx context.Context, roundTripOpts ...options.RoundTripOption) error {
+	opts := &options.RoundTripOptions{}
+	for _, o := range roundTripOpts {
+		o(opts)
+	}
+	if opts.FramerBuilder == nil {
+		return xerror.NewFrameError(xerror.RetClientConnectFail,
+			"websocket client transport: framer builder empty")
+	}
+	if opts.Msg == nil {
+		return xerror.NewFrameError(xerror.RetClientConnectFail,
+			"websocket client transport: message empty")
+	}
+
+	cc, err := c.dial(ctx, opts)
+	if err != nil {
+		return err
+	}
+	opts.Msg.WithRemoteAddr(cc.RemoteAddr())
+	opts.Msg.WithLocalAddr(cc.LocalAddr())
+
+	c.streamMu.Lock()
+	c.streams[opts.Msg.StreamID()] = cc
+	c.streamMu.Unlock()
+	return nil
+}
+
+// Send 发送流式 RPC 数据
+func (c *ClientTransport) Send(ctx context.Context, req []byte, roundTripOpts ...options.RoundTripOption) error {
+	cc, err := c.getStream(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := cc.Write(req); err != nil {
+		return wsFrameError(ctx, xerror.RetClientNetErr, "websocket client transport Write: ", err)
+	}
+	return nil
+}
+
+// Recv 读取流式 RPC 的一个数据帧
+func (c *ClientTransport) Recv(ctx context.Context, roundTripOpts ...options.RoundTripOption) ([]byte, error) {
+	cc, err := c.getStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, wsFrameError(ctx, xerror.RetClientNetErr, "websocket client transport before Recv: ", err)
+	}
+	if d, ok := ctx.Deadline(); ok {
+		cc.SetReadDeadline(d)
+	}
+
+	rsp, err := cc.fr.ReadFrame()
+	if err != nil {
+		return nil, wsFrameError(ctx, xerror.RetClientReadFrameErr, "websocket client transport ReadFrame: ", err)
+	}
+	data := make([]byte, len(rsp))
+	copy(data, rsp)
+	return data, nil
+}
+
+// Close 关闭流式 RPC 的连接
+func (c *ClientTransport) Close(ctx context.Context) {
+	streamID := codec.Message(ctx).StreamID()
+	c.streamMu.Lock()
+	cc, ok := c.streams[streamID]
+	delete(c.streams, streamID)
+	c.streamMu.Unlock()
+
+	if ok {
+		cc.Close()
+	}
+}
+
+// getStream 根据消息的流 ID 查询连接
+func (c *ClientTransport) getStream(ctx context.Context) (*clientConn, error) {
+	c.streamMu.RLock()
+	cc := c.streams[codec.Message(ctx).StreamID()]
+	c.streamMu.RUnlock()
+	if cc == nil {
+		return nil, xerror.NewFrameError(xerror.RetServerSystemErr, "Stream is not inited yet")
+	}
+	return cc, nil
+}
+
+// getConn 优先使用空闲连接，没有空闲连接时建立新连接
+func (c *ClientTransport) getConn(ctx context.Context, opts *options.RoundTripOptions) (*clientConn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, wsFrameError(ctx, xerror.RetClientConnectFail, "client before websocket dial: ", err)
+	}
+
+	u := c.url(opts)
+	c.mu.Lock()
+	if conns := c.idle[u]; len(conns) > 0 {
+		cc := conns[len(conns)-1]
+		c.idle[u] = conns[:len(conns)-1]
+		c.mu.Unlock()
+
+		d, _ := ctx.Deadline()
+		cc.SetDeadline(d)
+		return cc, nil
+	}
+	c.mu.Unlock()
+
+	return c.dial(ctx, opts)
+}
+
+// putConn 将连接放回空闲连接池，超过最大空闲连接数时关闭连接
+func (c *ClientTransport) putConn(cc *clientConn) {
+	cc.SetDeadline(time.Time{})
+
+	c.mu.Lock()
+	if len(c.idle[cc.url]) < c.opts.maxIdleConnsPerHost {
+		c.idle[cc.url] = append(c.idle[cc.url], cc)
+		cc = nil
+	}
+	c.mu.Unlock()
+
+	if cc != nil {
+		cc.Close()
+	}
+}
+
+// dial 建立 WebSocket 连接
+func (c *ClientTransport) dial(ctx context.Context, opts *options.RoundTripOptions) (*clientConn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, wsFrameError(ctx, xerror.RetClientConnectFail, "client before websocket dial: ", err)
+	}
+
+	dialer := *c.opts.dialer
+	if opts.DialTimeout > 0 {
+		dialer.HandshakeTimeout = opts.DialTimeout
+	}
+	if opts.CACertFile != "" {
+		serverName := opts.TLSServerName
+		if serverName == "" {
+			serverName, _, _ = net.SplitHostPort(opts.Address)
+		}
+		tlsConf, err := ssl.GetClientConfig(serverName, opts.CACertFile, opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return nil, xerror.NewFrameError(xerror.RetClientConnectFail,
+				"websocket client transport tls config: "+err.Error())
+		}
+		dialer.TLSClientConfig = tlsConf
+	}
+	if opts.LocalAddr != "" {
+		localAddr, err := net.ResolveTCPAddr("tcp", opts.LocalAddr)
+		if err != nil {
+			return nil, xerror.NewFrameError(xerror.RetClientConnectFail,
+				"websocket client transport ResolveTCPAddr: "+err.Error())
+		}
+		netDialer := &net.Dialer{LocalAddr: localAddr}
+		dialer.NetDialContext = netDialer.DialContext
+	}
+
+	u := c.url(opts)
+	ws, _, err := dialer.DialContext(ctx, u, c.opts.header)
+	if err != nil {
+		return nil, wsFrameError(ctx, xerror.RetClientConnectFail, "websocket client transport dial: ", err)
+	}
+
+	conn := NewConn(ws)
+	if d, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(d)
+	}
+	return &clientConn{
+		Conn: conn,
+		url:  u,
+		fr:   opts.FramerBuilder.New(buffer.NewReader(conn)),
+	}, nil
+}
+
+// url 返回连接地址，Address 为完整的 URL 时直接使用，否则根据是否设置了 CA 证书选择 ws 或者 wss
+func (c *ClientTransport) url(opts *options.RoundTripOptions) string {
+	if strings.Contains(opts.Address, "://") {
+		return opts.Address
+	}
+	u := url.URL{Scheme: "ws", Host: opts.Address, Path: c.opts.path}
+	if opts.CACertFile != "" {
+		u.Scheme = "wss"
+	}
+	return u.String()
+}
+
+// wsFrameError 将错误转换为框架错误，区分超时和取消
+func wsFrameError(ctx context.Context, code int, prefix string, err error) error {
+	switch {
+	case ctx.Err() == context.Canceled:
+		return xerror.NewFrameError(xerror.RetClientCanceled, prefix+err.Error())
+	case ctx.Err() == context.DeadlineExceeded:
+		return xerror.NewFrameError(xerror.RetClientTimeout, prefix+err.Error())
+	}
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		return xerror.NewFrameError(xerror.RetClientTimeout, prefix+err.Error())
+	}
+	return xerror.NewFrameError(code, prefix+err.Error())
+}
@@ -0,0 +1,127 @@
+package ws
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// closeTimeout 发送关闭消息的超时时间
+const closeTimeout = time.Second
+
+var _ net.Conn = (*Conn)(nil)
+
+// Conn 将 WebSocket 连接适配为字节流，IFramer 可以直接运行在 WebSocket 连接上
+// 读取时依次读取每个数据消息的内容，消息边界与帧边界无关；每次写入作为一个二进制消息发送
+type Conn struct {
+	ws *websocket.Conn
+
+	readMu  sync.Mutex
+	reader  io.Reader // 当前正在读取的消息
+	writeMu sync.Mutex
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewConn 创建 WebSocket 连接适配器
+func NewConn(ws *websocket.Conn) *Conn {
+	return &Conn{ws: ws}
+}
+
+// WebSocket 返回原始的 WebSocket 连接
+func (c *Conn) WebSocket() *websocket.Conn {
+	return c.ws
+}
+
+// Read 读取消息内容，当前消息读取完成后继续读取下一个消息，对端正常关闭时返回 io.EOF
+func (c *Conn) Read(p []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	for {
+		if c.reader == nil {
+			_, r, err := c.ws.NextReader()
+			if err != nil {
+				return 0, convertCloseError(err)
+			}
+			c.reader = r
+		}
+
+		n, err := c.reader.Read(p)
+		if err == io.EOF {
+			c.reader = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Write 将数据作为一个二进制消息发送
+func (c *Conn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.ws.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close 发送关闭消息并关闭底层连接
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		// WriteControl 可以与其他方法并发调用，对端已经关闭时忽略错误
+		_ = c.ws.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(closeTimeout))
+		c.closeErr = c.ws.Close()
+	})
+	return c.closeErr
+}
+
+// LocalAddr 返回本地地址
+func (c *Conn) LocalAddr() net.Addr {
+	return c.ws.LocalAddr()
+}
+
+// RemoteAddr 返回对端地址
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.ws.RemoteAddr()
+}
+
+// SetDeadline 设置读写超时时间
+func (c *Conn) SetDeadline(t time.Time) error {
+	if err := c.ws.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.ws.SetWriteDeadline(t)
+}
+
+// SetReadDeadline 设置读超时时间
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.ws.SetReadDeadline(t)
+}
+
+// SetWriteDeadline 设置写超时时间
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.ws.SetWriteDeadline(t)
+}
+
+// convertCloseError 将对端正常关闭连接的错误转换为 io.EOF
+func convertCloseError(err error) error {
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway,
+		websocket.CloseNoStatusReceived) {
+		return io.EOF
+	}
+	if errors.Is(err, net.ErrClosed) {
+		return io.EOF
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package ws
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+
+	"github.com/fengzhongzhu1621/xgo/logging"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	_ net.Listener = (*Listener)(nil)
+	_ http.Handler = (*Listener)(nil)
+)
+
+var errNoRawListener = errors.New("websocket listener has no raw listener")
+
+// listenerAddr 挂载到网关路由上的监听器地址
+type listenerAddr struct{}
+
+func (listenerAddr) Network() string { return "websocket" }
+func (listenerAddr) String() string  { return "websocket" }
+
+// Listener 将 HTTP 请求升级为 WebSocket 连接，并作为 net.Listener 交给服务端传输处理
+// 可以挂载到任意 HTTP 路由上，例如 gin.WrapH(ln)，然后通过 options.WithListener(ln) 传给服务端传输
+type Listener struct {
+	upgrader *websocket.Upgrader
+	raw      net.Listener // 独立监听时的 TCP 监听器，用于热重启传递文件描述符
+	onClose  func()       // 关闭监听器时停止 HTTP 服务
+
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewListener 创建 WebSocket 监听器，支持 WithUpgrader 选项
+func NewListener(opts ...Option) *Listener {
+	return newListener(newOptions(opts...), nil)
+}
+
+func newListener(opts *wsOptions, raw net.Listener) *Listener {
+	return &Listener{
+		upgrader: opts.upgrader,
+		raw:      raw,
+		conns:    make(chan net.Conn),
+		done:     make(chan struct{}),
+	}
+}
+
+// ServeHTTP 升级连接并等待服务端传输接受连接
+func (l *Listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-l.done:
+		http.Error(w, "websocket listener closed", http.StatusServiceUnavailable)
+		return
+	default:
+	}
+
+	ws, err := l.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade 失败时已经写入了错误响应
+		logging.Tracef("transport: websocket upgrade fail: %v", err)
+		return
+	}
+
+	c := NewConn(ws)
+	select {
+	case l.conns <- c:
+	case <-l.done:
+		c.Close()
+	}
+}
+
+// Accept 返回升级后的 WebSocket 连接
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		// 与关闭的 TCP 监听器返回相同的错误，服务端传输据此退出
+		return nil, &net.OpError{Op: "accept", Net: l.Addr().Network(), Addr: l.Addr(), Err: net.ErrClosed}
+	}
+}
+
+// Close 停止接受新的连接，已经建立的连接不受影响
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+		if l.onClose != nil {
+			l.onClose()
+		}
+	})
+	return nil
+}
+
+// Addr 返回监听地址
+func (l *Listener) Addr() net.Addr {
+	if l.raw != nil {
+		return l.raw.Addr()
+	}
+	return listenerAddr{}
+}
+
+// SyscallConn 返回 TCP 监听器的原始连接，热重启时传递文件描述符
+func (l *Listener) SyscallConn() (syscall.RawConn, error) {
+	if sc, ok := l.raw.(syscall.Conn); ok {
+		return sc.SyscallConn()
+	}
+	return nil, errNoRawListener
+}
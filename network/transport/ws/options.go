@@ -0,0 +1,81 @@
+package ws
+
+import (
+	"net/http"
+
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultPath                = "/"
+	defaultMaxIdleConnsPerHost = 2
+)
+
+// wsOptions WebSocket 传输的配置选项
+type wsOptions struct {
+	path                string                          // WebSocket 请求路径
+	upgrader            *websocket.Upgrader             // 服务端升级连接的配置
+	dialer              *websocket.Dialer               // 客户端建立连接的配置
+	header              http.Header                     // 客户端握手请求头
+	maxIdleConnsPerHost int                             // 客户端每个地址的最大空闲连接数
+	serverTransportOpts []options.ServerTransportOption // 服务端传输选项
+}
+
+// Option WebSocket 传输选项修改函数类型
+type Option func(*wsOptions)
+
+func newOptions(opts ...Option) *wsOptions {
+	o := &wsOptions{
+		path:                defaultPath,
+		upgrader:            &websocket.Upgrader{},
+		dialer:              websocket.DefaultDialer,
+		maxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithPath 设置 WebSocket 请求路径，默认为 /
+func WithPath(path string) Option {
+	return func(o *wsOptions) {
+		o.path = path
+	}
+}
+
+// WithUpgrader 设置服务端升级连接的配置，例如 CheckOrigin 和 Subprotocols
+func WithUpgrader(upgrader *websocket.Upgrader) Option {
+	return func(o *wsOptions) {
+		o.upgrader = upgrader
+	}
+}
+
+// WithDialer 设置客户端建立连接的配置
+func WithDialer(dialer *websocket.Dialer) Option {
+	return func(o *wsOptions) {
+		o.dialer = dialer
+	}
+}
+
+// WithHeader 设置客户端握手请求头，例如 Origin 和鉴权信息
+func WithHeader(header http.Header) Option {
+	return func(o *wsOptions) {
+		o.header = header
+	}
+}
+
+// WithMaxIdleConnsPerHost 设置客户端每个地址的最大空闲连接数
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(o *wsOptions) {
+		o.maxIdleConnsPerHost = n
+	}
+}
+
+// WithServerTransportOptions 设置服务端传输选项，例如 IdleTimeout
+func WithServerTransportOptions(opts ...options.ServerTransportOption) Option {
+	return func(o *wsOptions) {
+		o.serverTransportOpts = append(o.serverTransportOpts, opts...)
+	}
+}
@@ -0,0 +1,117 @@
+package ws
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/fengzhongzhu1621/xgo/logging"
+	"github.com/fengzhongzhu1621/xgo/network/ssl"
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/fengzhongzhu1621/xgo/network/transport/server_transport"
+)
+
+var _ server_transport.IServerStreamTransport = (*serverTransport)(nil)
+
+// serverTransport WebSocket 服务端传输
+// 升级后的连接交给 server_transport 的流式传输处理，因此分包、异步处理和服务端推送与 tcp 相同
+type serverTransport struct {
+	server_transport.IServerStreamTransport
+	opts *wsOptions
+}
+
+// DefaultServerTransport 默认的 WebSocket 服务端传输
+var DefaultServerTransport = NewServerTransport()
+
+// NewServerTransport 创建 WebSocket 服务端传输
+func NewServerTransport(opts ...Option) server_transport.IServerStreamTransport {
+	o := newOptions(opts...)
+	return &serverTransport{
+		IServerStreamTransport: server_transport.NewServerStreamTransport(o.serverTransportOpts...),
+		opts:                   o,
+	}
+}
+
+// ListenAndServe 开始监听
+// 设置了 *Listener 类型的 Listener 时，连接由网关路由升级后交给服务端传输，不再启动 HTTP 服务；
+// 否则在 Address 上启动 HTTP 服务，设置证书时使用 wss
+func (s *serverTransport) ListenAndServe(ctx context.Context, opts ...options.ListenServeOption) error {
+	lsopts := &options.ListenServeOptions{}
+	for _, opt := range opts {
+		opt(lsopts)
+	}
+
+	if lsopts.FramerBuilder == nil {
+		return errors.New("websocket transport FramerBuilder empty")
+	}
+
+	// TLS 由 HTTP 服务处理，WebSocket 连接上不再使用 TLS
+	noTLS := options.WithServeTLS("", "", "")
+	if _, ok := lsopts.Listener.(*Listener); ok {
+		return s.IServerStreamTransport.ListenAndServe(ctx, append(opts, noTLS)...)
+	}
+
+	raw, err := getTCPListener(lsopts)
+	if err != nil {
+		return fmt.Errorf("get websocket tcp listener err: %w", err)
+	}
+	var httpLn net.Listener = raw
+	if lsopts.TLSCertFile != "" && lsopts.TLSKeyFile != "" {
+		tlsConf, err := ssl.GetServerConfig(lsopts.CACertFile, lsopts.TLSCertFile, lsopts.TLSKeyFile)
+		if err != nil {
+			raw.Close()
+			return fmt.Errorf("tls get server config err: %w", err)
+		}
+		httpLn = tls.NewListener(raw, tlsConf)
+	}
+
+	ln := newListener(s.opts, raw)
+	mux := http.NewServeMux()
+	mux.Handle(s.opts.path, ln)
+	srv := &http.Server{Handler: mux}
+	// 关闭监听器时只停止 HTTP 服务，升级后的连接已经被接管，不受影响
+	ln.onClose = func() { srv.Close() }
+
+	if err := s.IServerStreamTransport.ListenAndServe(ctx, append(opts, options.WithListener(ln), noTLS)...); err != nil {
+		raw.Close()
+		return err
+	}
+
+	go func() {
+		if err := srv.Serve(httpLn); err != nil && err != http.ErrServerClosed {
+			logging.Errorf("websocket server with address %s serve error: %v", raw.Addr(), err)
+		}
+	}()
+	return nil
+}
+
+// getTCPListener 获取 TCP 监听器，热重启时使用父进程传递的监听器
+func getTCPListener(opts *options.ListenServeOptions) (net.Listener, error) {
+	network := opts.Network
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		network = "tcp"
+	}
+
+	v, _ := os.LookupEnv(server_transport.EnvGraceRestart)
+	ok, _ := strconv.ParseBool(v)
+	if ok {
+		pln, err := server_transport.GetPassedListener(network, opts.Address)
+		if err != nil {
+			return nil, err
+		}
+		ln, ok := pln.(net.Listener)
+		if !ok {
+			return nil, errors.New("invalid net.Listener")
+		}
+		return ln, nil
+	}
+
+	return net.Listen(network, opts.Address)
+}
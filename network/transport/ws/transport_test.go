@@ -0,0 +1,221 @@
+package ws
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fengzhongzhu1621/xgo/codec"
+	"github.com/fengzhongzhu1621/xgo/network/transport/options"
+	"github.com/fengzhongzhu1621/xgo/network/transport/server_transport"
+	"github.com/fengzhongzhu1621/xgo/xerror"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// framerBuilder 使用 4 字节长度头分包
+type framerBuilder struct{}
+
+func (fb *framerBuilder) New(r io.Reader) codec.IFramer {
+	return &framer{r: r}
+}
+
+type framer struct {
+	r io.Reader
+}
+
+func (f *framer) ReadFrame() ([]byte, error) {
+	var head [4]byte
+	if _, err := io.ReadFull(f.r, head[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, 4+binary.BigEndian.Uint32(head[:]))
+	copy(data, head[:])
+	if _, err := io.ReadFull(f.r, data[4:]); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func newFrame(body string) []byte {
+	data := make([]byte, 4+len(body))
+	binary.BigEndian.PutUint32(data, uint32(len(body)))
+	copy(data[4:], body)
+	return data
+}
+
+type echoHandler struct{}
+
+func (h *echoHandler) Handle(ctx context.Context, req []byte) ([]byte, error) {
+	rsp := make([]byte, len(req))
+	copy(rsp, req)
+	return rsp, nil
+}
+
+// pushHandler 通过服务端推送返回两次请求内容
+type pushHandler struct {
+	st server_transport.IServerStreamTransport
+}
+
+func (h *pushHandler) Handle(ctx context.Context, req []byte) ([]byte, error) {
+	for i := 0; i < 2; i++ {
+		if err := h.st.Send(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	return nil, xerror.ErrServerNoResponse
+}
+
+func TestWebSocketTransport(t *testing.T) {
+	address := "127.0.0.1:12041"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st := NewServerTransport(WithPath("/rpc"))
+	err := st.ListenAndServe(ctx,
+		options.WithListenAddress(address),
+		options.WithHandler(&echoHandler{}),
+		options.WithServerFramerBuilder(&framerBuilder{}),
+	)
+	require.NoError(t, err)
+
+	// 热重启时传递 TCP 监听器的文件描述符
+	var found bool
+	for _, fd := range server_transport.GetListenersFds() {
+		if fd.Network == "tcp" && fd.Address == address {
+			found = true
+		}
+	}
+	assert.True(t, found)
+
+	ct := NewClientTransport(WithPath("/rpc"), WithMaxIdleConnsPerHost(1))
+	opts := []options.RoundTripOption{
+		options.WithDialAddress(address),
+		options.WithClientFramerBuilder(&framerBuilder{}),
+	}
+
+	var localAddr string
+	for i := 0; i < 3; i++ {
+		reqCtx, msg := codec.WithNewMessage(context.Background())
+		req := newFrame(fmt.Sprintf("hello %d", i))
+		rsp, err := ct.RoundTrip(reqCtx, req, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, req, rsp)
+
+		// 空闲连接被复用
+		if i == 0 {
+			localAddr = msg.LocalAddr().String()
+		}
+		assert.Equal(t, localAddr, msg.LocalAddr().String())
+	}
+
+	_, err = ct.RoundTrip(context.Background(), newFrame("hello"),
+		append(opts, options.WithReqType(codec.SendOnly))...)
+	assert.Equal(t, xerror.ErrClientNoResponse, err)
+
+	// 路径不存在时握手失败
+	_, err = NewClientTransport(WithPath("/none")).RoundTrip(context.Background(), newFrame("hello"), opts...)
+	assert.Error(t, err)
+
+	// 停止服务后不再接受新的连接
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	_, err = NewClientTransport(WithPath("/rpc")).RoundTrip(context.Background(), newFrame("hello"), opts...)
+	assert.Error(t, err)
+}
+
+func TestWebSocketStreamTransport(t *testing.T) {
+	address := "127.0.0.1:12042"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st := NewServerTransport()
+	err := st.ListenAndServe(ctx,
+		options.WithListenAddress(address),
+		options.WithHandler(&pushHandler{st: st}),
+		options.WithServerFramerBuilder(&framerBuilder{}),
+	)
+	require.NoError(t, err)
+
+	ct := NewClientTransport()
+	streamCtx, streamCancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer streamCancel()
+	streamCtx, msg := codec.WithNewMessage(streamCtx)
+	msg.WithStreamID(1)
+
+	err = ct.Init(streamCtx,
+		options.WithDialAddress(address),
+		options.WithClientFramerBuilder(&framerBuilder{}),
+		options.WithMsg(msg),
+	)
+	require.NoError(t, err)
+
+	req := newFrame("push")
+	require.NoError(t, ct.Send(streamCtx, req))
+	for i := 0; i < 2; i++ {
+		rsp, err := ct.Recv(streamCtx)
+		require.NoError(t, err)
+		assert.Equal(t, req, rsp)
+	}
+
+	ct.Close(streamCtx)
+	assert.Error(t, ct.Send(streamCtx, req))
+	_, err = ct.Recv(streamCtx)
+	assert.Error(t, err)
+}
+
+func TestWebSocketListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 监听器挂载到网关的路由上
+	ln := NewListener()
+	mux := http.NewServeMux()
+	mux.Handle("/gateway/ws", ln)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	st := NewServerTransport()
+	err := st.ListenAndServe(ctx,
+		options.WithListener(ln),
+		options.WithHandler(&echoHandler{}),
+		options.WithServerFramerBuilder(&framerBuilder{}),
+	)
+	require.NoError(t, err)
+
+	u := "ws" + strings.TrimPrefix(srv.URL, "http") + "/gateway/ws"
+	rsp, err := NewClientTransport().RoundTrip(context.Background(), newFrame("gateway"),
+		options.WithDialAddress(u),
+		options.WithClientFramerBuilder(&framerBuilder{}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, newFrame("gateway"), rsp)
+
+	// 一个数据帧拆分为多个消息发送
+	c, _, err := websocket.DefaultDialer.Dial(u, nil)
+	require.NoError(t, err)
+	defer c.Close()
+	req := newFrame("split")
+	require.NoError(t, c.WriteMessage(websocket.BinaryMessage, req[:3]))
+	require.NoError(t, c.WriteMessage(websocket.BinaryMessage, req[3:]))
+	fr := (&framerBuilder{}).New(NewConn(c))
+	rsp, err = fr.ReadFrame()
+	require.NoError(t, err)
+	assert.Equal(t, req, rsp)
+
+	// 监听器关闭后拒绝新的连接
+	require.NoError(t, ln.Close())
+	_, err = ln.Accept()
+	assert.Error(t, err)
+	_, resp, err := websocket.DefaultDialer.Dial(u, nil)
+	assert.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
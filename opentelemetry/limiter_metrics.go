@@ -0,0 +1,49 @@
+package opentelemetry
+
+import (
+	"context"
+
+	"github.com/fengzhongzhu1621/xgo/collections/flowctrl/concurrency"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// limiterMeterName is the instrumentation name of the concurrency limiter meter
+const limiterMeterName = "github.com/fengzhongzhu1621/xgo/collections/flowctrl/concurrency"
+
+// RegisterLimiterMeter registers observable instruments of all concurrency limiters registered in
+// collections/flowctrl/concurrency to the meter, if meter is nil, the global meter provider is used.
+// the returned Registration can be used to unregister the callback
+func RegisterLimiterMeter(meter metric.Meter) (metric.Registration, error) {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter(limiterMeterName)
+	}
+
+	limit, err := meter.Int64ObservableGauge("limiter.limit",
+		metric.WithDescription("current concurrency limit"))
+	if err != nil {
+		return nil, err
+	}
+	inflight, err := meter.Int64ObservableGauge("limiter.inflight",
+		metric.WithDescription("number of in-flight requests"))
+	if err != nil {
+		return nil, err
+	}
+	rejected, err := meter.Int64ObservableCounter("limiter.rejected",
+		metric.WithDescription("number of requests rejected because the limit is exceeded"))
+	if err != nil {
+		return nil, err
+	}
+
+	return meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		concurrency.Each(func(l *concurrency.Limiter) {
+			s := l.Stats()
+			attrs := metric.WithAttributes(attribute.String("limiter", s.Name))
+			o.ObserveInt64(limit, int64(s.Limit), attrs)
+			o.ObserveInt64(inflight, int64(s.Inflight), attrs)
+			o.ObserveInt64(rejected, int64(s.Rejected), attrs)
+		})
+		return nil
+	}, limit, inflight, rejected)
+}
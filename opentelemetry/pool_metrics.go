@@ -0,0 +1,101 @@
+package opentelemetry
+
+import (
+	"context"
+
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// poolMeterName is the instrumentation name of the connection pool meter
+const poolMeterName = "github.com/fengzhongzhu1621/xgo/network"
+
+// RegisterPoolMeter registers observable instruments of all connection pools registered in network/poolstats
+// to the meter, if meter is nil, the global meter provider is used.
+// the returned Registration can be used to unregister the callback
+func RegisterPoolMeter(meter metric.Meter) (metric.Registration, error) {
+	if meter == nil {
+		meter = otel.GetMeterProvider().Meter(poolMeterName)
+	}
+
+	type gauge struct {
+		name, desc string
+		value      func(s *poolstats.NodeStats) int
+		instrument metric.Int64ObservableGauge
+	}
+	type counter struct {
+		name, desc string
+		value      func(s *poolstats.NodeStats) uint64
+		instrument metric.Int64ObservableCounter
+	}
+
+	gauges := []*gauge{
+		{name: "pool.conns", desc: "number of concrete connections",
+			value: func(s *poolstats.NodeStats) int { return s.Conns }},
+		{name: "pool.virtual_conns", desc: "number of virtual connections on the concrete connections",
+			value: func(s *poolstats.NodeStats) int { return s.VirtualConns }},
+		{name: "pool.idle", desc: "number of idle concrete connections",
+			value: func(s *poolstats.NodeStats) int { return s.Idle }},
+		{name: "pool.active", desc: "number of concrete connections in use",
+			value: func(s *poolstats.NodeStats) int { return s.Active }},
+		{name: "pool.send_queue", desc: "number of packets waiting in the send queues",
+			value: func(s *poolstats.NodeStats) int { return s.SendQueue }},
+	}
+	counters := []*counter{
+		{name: "pool.dials", desc: "number of dials",
+			value: func(s *poolstats.NodeStats) uint64 { return s.Dials }},
+		{name: "pool.dial.errors", desc: "number of failed dials",
+			value: func(s *poolstats.NodeStats) uint64 { return s.DialErrors }},
+		{name: "pool.reconnects", desc: "number of reconnect attempts",
+			value: func(s *poolstats.NodeStats) uint64 { return s.Reconnects }},
+		{name: "pool.reconnect.errors", desc: "number of failed reconnect attempts",
+			value: func(s *poolstats.NodeStats) uint64 { return s.ReconnectErrors }},
+		{name: "pool.expels", desc: "number of expelled connections",
+			value: func(s *poolstats.NodeStats) uint64 { return s.Expels }},
+		{name: "pool.idle_closes", desc: "number of idle connections closed",
+			value: func(s *poolstats.NodeStats) uint64 { return s.IdleCloses }},
+		{name: "pool.drop_fulls", desc: "number of requests dropped because the queue is full",
+			value: func(s *poolstats.NodeStats) uint64 { return s.DropFulls }},
+	}
+
+	instruments := make([]metric.Observable, 0, len(gauges)+len(counters))
+	for _, g := range gauges {
+		instrument, err := meter.Int64ObservableGauge(g.name, metric.WithDescription(g.desc))
+		if err != nil {
+			return nil, err
+		}
+		g.instrument = instrument
+		instruments = append(instruments, instrument)
+	}
+	for _, c := range counters {
+		instrument, err := meter.Int64ObservableCounter(c.name, metric.WithDescription(c.desc))
+		if err != nil {
+			return nil, err
+		}
+		c.instrument = instrument
+		instruments = append(instruments, instrument)
+	}
+
+	return meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		poolstats.Each(func(name string, p poolstats.ISnapshotter) {
+			for _, s := range p.Snapshot() {
+				// 同一个地址可能有多个节点（节点键包含协议等信息），node 区分不同节点的序列
+				attrs := metric.WithAttributes(
+					attribute.String("pool", name),
+					attribute.String("node", s.Node),
+					attribute.String("network", s.Network),
+					attribute.String("address", s.Address),
+				)
+				for _, g := range gauges {
+					o.ObserveInt64(g.instrument, int64(g.value(&s)), attrs)
+				}
+				for _, c := range counters {
+					o.ObserveInt64(c.instrument, int64(c.value(&s)), attrs)
+				}
+			}
+		})
+		return nil
+	}, instruments...)
+}
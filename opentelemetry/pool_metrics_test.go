@@ -0,0 +1,66 @@
+package opentelemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fengzhongzhu1621/xgo/network/poolstats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/embedded"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// callbackMeter 保存注册的回调，由测试主动触发
+type callbackMeter struct {
+	noop.Meter
+	callback metric.Callback
+}
+
+func (m *callbackMeter) RegisterCallback(f metric.Callback, _ ...metric.Observable) (metric.Registration, error) {
+	m.callback = f
+	return noop.Meter{}.RegisterCallback(f)
+}
+
+// recordObserver 记录每次观测的属性
+type recordObserver struct {
+	embedded.Observer
+	attrs []attribute.Set
+}
+
+func (o *recordObserver) ObserveFloat64(_ metric.Float64Observable, _ float64, opts ...metric.ObserveOption) {
+	o.attrs = append(o.attrs, metric.NewObserveConfig(opts).Attributes())
+}
+
+func (o *recordObserver) ObserveInt64(_ metric.Int64Observable, _ int64, opts ...metric.ObserveOption) {
+	o.attrs = append(o.attrs, metric.NewObserveConfig(opts).Attributes())
+}
+
+func TestRegisterPoolMeter(t *testing.T) {
+	// 同一个地址的两个节点
+	poolstats.Register("test", poolstats.SnapshotterFunc(func() []poolstats.NodeStats {
+		return []poolstats.NodeStats{
+			{Node: "tcp_127.0.0.1:8000", Network: "tcp", Address: "127.0.0.1:8000", Conns: 1},
+			{Node: "tcp_127.0.0.1:8000_mux", Network: "tcp", Address: "127.0.0.1:8000", Conns: 2},
+		}
+	}))
+	defer poolstats.Unregister("test")
+
+	meter := &callbackMeter{}
+	_, err := RegisterPoolMeter(meter)
+	require.NoError(t, err)
+	o := &recordObserver{}
+	require.NoError(t, meter.callback(context.Background(), o))
+
+	series := make(map[attribute.Distinct]attribute.Set)
+	for _, attrs := range o.attrs {
+		series[attrs.Equivalent()] = attrs
+	}
+	require.Len(t, series, 2)
+	for _, attrs := range series {
+		node, _ := attrs.Value("node")
+		assert.Contains(t, []string{"tcp_127.0.0.1:8000", "tcp_127.0.0.1:8000_mux"}, node.AsString())
+	}
+}
@@ -6,4 +6,10 @@ var (
 	// -----------------------------log----------------------------- //
 	// log is dropped because the queue is full.
 	LogQueueDropNum = metrics.Counter("LogQueueDropNum")
+	// log is dropped by sampling.
+	LogSampledDropNum = metrics.Counter("LogSampledDropNum")
+	// log is dropped because the level exceeds its rate limit.
+	LogRateLimitDropNum = metrics.Counter("LogRateLimitDropNum")
+	// log is suppressed as a duplicate of the previous one.
+	LogDedupDropNum = metrics.Counter("LogDedupDropNum")
 )
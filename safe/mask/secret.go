@@ -0,0 +1,13 @@
+package mask
+
+// secretMask 密钥脱敏后的固定内容，不暴露原始内容的长度
+const secretMask = "******"
+
+// MaskSecret 密码、令牌等密钥脱敏，空字符串不处理
+func MaskSecret(secret string) string {
+	if secret == "" {
+		return secret
+	}
+
+	return secretMask
+}
@@ -29,6 +29,8 @@ const (
 	RetClientCanceled = 161
 	// RetClientReadFrameErr is the error code of the client read frame error.
 	RetClientReadFrameErr = 171
+	// RetClientLimited is the error code that the request is rejected by the client concurrency limiter.
+	RetClientLimited = 123
 
 	RetServerTimeout = 21 // 服务端超时错误码
 	// RetServerOverload is the error code that the request is overloaded on the server side.
@@ -55,6 +57,10 @@ var (
 
 	// ErrServerRoutinePoolBusy is an error that the request is overloaded on the server side.
 	ErrServerRoutinePoolBusy = NewFrameError(RetServerOverload, "server goroutine pool too small")
+	// ErrServerLimited is an error that the request is rejected by the server concurrency limiter.
+	ErrServerLimited = NewFrameError(RetServerOverload, "server concurrency limit exceeded")
+	// ErrClientLimited is an error that the request is rejected by the client concurrency limiter.
+	ErrClientLimited = NewFrameError(RetClientLimited, "client concurrency limit exceeded")
 	// ErrServerNoResponse is a server-side unresponsive error.
 	ErrServerNoResponse = NewFrameError(RetOK, "server no response")
 )